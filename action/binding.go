@@ -0,0 +1,161 @@
+package action
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/diakovliev/oak/v4/joystick"
+	"github.com/diakovliev/oak/v4/key"
+	"github.com/diakovliev/oak/v4/mouse"
+	"github.com/diakovliev/oak/v4/oakerr"
+)
+
+// A Device is a kind of hardware a Binding reads input from.
+type Device string
+
+// Device values
+const (
+	DeviceKey      Device = "key"
+	DeviceMouse    Device = "mouse"
+	DeviceJoystick Device = "joystick"
+)
+
+// An Axis is a component of an axis action's value.
+type Axis string
+
+// Axis values
+const (
+	AxisX Axis = "x"
+	AxisY Axis = "y"
+)
+
+// Analog joystick input names. Triggers read from 0 to 1, sticks from -1 to 1
+// on each axis.
+const (
+	TriggerL = "TriggerL"
+	TriggerR = "TriggerR"
+	StickL   = "StickL"
+	StickR   = "StickR"
+)
+
+// A Binding connects one physical input to an action.
+type Binding struct {
+	Device Device `json:"device"`
+	// Input names the input on its device: a name from key.AllKeys, a mouse
+	// button (Left, Middle or Right), a joystick button, or one of TriggerL,
+	// TriggerR, StickL and StickR.
+	Input string `json:"input"`
+	// Axis is the component of an axis action this binding moves, defaulting
+	// to AxisX. Sticks bound to 2D actions move both components.
+	Axis Axis `json:"axis,omitempty"`
+	// Scale multiplies this binding's value. Zero is treated as one, so a key
+	// bound with a Scale of -1 pushes an axis in its negative direction.
+	Scale float64 `json:"scale,omitempty"`
+	// Deadzone is the magnitude, from 0 to 1, below which an analog input
+	// reads as zero. Inputs beyond the deadzone are rescaled to start at zero.
+	Deadzone float64 `json:"deadzone,omitempty"`
+}
+
+// Key returns a binding to a keyboard key.
+func Key(code key.Code) Binding {
+	return Binding{Device: DeviceKey, Input: key.AllKeys[code]}
+}
+
+// MouseButton returns a binding to a mouse button.
+func MouseButton(b mouse.Button) Binding {
+	return Binding{Device: DeviceMouse, Input: mouseButtons[b]}
+}
+
+// JoystickButton returns a binding to a joystick button.
+func JoystickButton(in joystick.Input) Binding {
+	return Binding{Device: DeviceJoystick, Input: string(in)}
+}
+
+// JoystickAnalog returns a binding to one of TriggerL, TriggerR, StickL or
+// StickR, ignoring input within deadzone.
+func JoystickAnalog(input string, deadzone float64) Binding {
+	return Binding{Device: DeviceJoystick, Input: input, Deadzone: deadzone}
+}
+
+// On returns a copy of this binding directed at one axis of an action, scaled
+// by scale.
+func (b Binding) On(axis Axis, scale float64) Binding {
+	b.Axis = axis
+	b.Scale = scale
+	return b
+}
+
+func (b Binding) String() string {
+	if b.Axis == "" {
+		return fmt.Sprintf("%s %s", b.Device, b.Input)
+	}
+	return fmt.Sprintf("%s %s (%s)", b.Device, b.Input, b.Axis)
+}
+
+func (b Binding) scale() float64 {
+	if b.Scale == 0 {
+		return 1
+	}
+	return b.Scale
+}
+
+func (b Binding) analog() bool {
+	return b.Device == DeviceJoystick && (b.Input == TriggerL || b.Input == TriggerR || b.Input == StickL || b.Input == StickR)
+}
+
+func (b Binding) validate() error {
+	switch b.Device {
+	case DeviceKey:
+		if _, ok := keyCode(b.Input); !ok {
+			return oakerr.InvalidInput{InputName: "key " + b.Input}
+		}
+	case DeviceMouse:
+		if _, ok := mouseButton(b.Input); !ok {
+			return oakerr.InvalidInput{InputName: "mouse button " + b.Input}
+		}
+	case DeviceJoystick:
+		if b.Input == "" {
+			return oakerr.InvalidInput{InputName: "joystick input"}
+		}
+	default:
+		return oakerr.InvalidInput{InputName: "device " + string(b.Device)}
+	}
+	if b.Axis != "" && b.Axis != AxisX && b.Axis != AxisY {
+		return oakerr.InvalidInput{InputName: "axis " + string(b.Axis)}
+	}
+	if b.Deadzone < 0 || b.Deadzone >= 1 {
+		return oakerr.InvalidInput{InputName: "deadzone"}
+	}
+	return nil
+}
+
+var mouseButtons = map[mouse.Button]string{
+	mouse.ButtonLeft:   "Left",
+	mouse.ButtonMiddle: "Middle",
+	mouse.ButtonRight:  "Right",
+}
+
+func mouseButton(name string) (mouse.Button, bool) {
+	for b, n := range mouseButtons {
+		if n == name {
+			return b, true
+		}
+	}
+	return mouse.ButtonNone, false
+}
+
+var (
+	keyCodesOnce sync.Once
+	keyCodes     map[string]key.Code
+)
+
+func keyCode(name string) (key.Code, bool) {
+	keyCodesOnce.Do(func() {
+		keyCodes = make(map[string]key.Code, len(key.AllKeys))
+		for code, n := range key.AllKeys {
+			keyCodes[n] = code
+		}
+	})
+	code, ok := keyCodes[name]
+	return code, ok
+}
@@ -0,0 +1,4 @@
+// Package action maps keyboard, mouse and joystick inputs to named actions,
+// so gameplay code can react to "jump" or "move" instead of to particular
+// keys or buttons, and players can rebind their controls.
+package action
@@ -0,0 +1,77 @@
+package action
+
+import (
+	"sync"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/event"
+)
+
+var (
+	// AnyPress is triggered when any action is pressed. It is sent both as
+	// AnyPress, and as Press(action name).
+	AnyPress = event.RegisterEvent[Event]()
+	// AnyRelease is triggered when any action is released. It is sent both as
+	// AnyRelease, and as Release(action name).
+	AnyRelease = event.RegisterEvent[Event]()
+	// AnyChange is triggered when the value of any axis action changes. It is
+	// sent both as AnyChange, and as Change(action name).
+	AnyChange = event.RegisterEvent[Event]()
+)
+
+// An Event is sent as the payload for all action bindings.
+type Event struct {
+	Action string
+	Kind   Kind
+	// Pressed is whether the action is held. Axis actions are held while
+	// their value's magnitude is at least PressThreshold.
+	Pressed bool
+	// Value is the action's value. Buttons and 1D axes only use X.
+	Value floatgeom.Point2
+}
+
+var pressEventsLock sync.Mutex
+var pressEvents = map[string]event.EventID[Event]{}
+
+// Press returns the event triggered when the named action is pressed.
+func Press(name string) event.EventID[Event] {
+	pressEventsLock.Lock()
+	defer pressEventsLock.Unlock()
+	if ev, ok := pressEvents[name]; ok {
+		return ev
+	}
+	ev := event.RegisterEvent[Event]()
+	pressEvents[name] = ev
+	return ev
+}
+
+var releaseEventsLock sync.Mutex
+var releaseEvents = map[string]event.EventID[Event]{}
+
+// Release returns the event triggered when the named action is released.
+func Release(name string) event.EventID[Event] {
+	releaseEventsLock.Lock()
+	defer releaseEventsLock.Unlock()
+	if ev, ok := releaseEvents[name]; ok {
+		return ev
+	}
+	ev := event.RegisterEvent[Event]()
+	releaseEvents[name] = ev
+	return ev
+}
+
+var changeEventsLock sync.Mutex
+var changeEvents = map[string]event.EventID[Event]{}
+
+// Change returns the event triggered when the value of the named axis action
+// changes.
+func Change(name string) event.EventID[Event] {
+	changeEventsLock.Lock()
+	defer changeEventsLock.Unlock()
+	if ev, ok := changeEvents[name]; ok {
+		return ev
+	}
+	ev := event.RegisterEvent[Event]()
+	changeEvents[name] = ev
+	return ev
+}
@@ -0,0 +1,490 @@
+package action
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/joystick"
+	"github.com/diakovliev/oak/v4/key"
+	"github.com/diakovliev/oak/v4/mouse"
+	"github.com/diakovliev/oak/v4/oakerr"
+)
+
+// A Kind is the type of value an action produces.
+type Kind uint8
+
+// Kind values
+const (
+	// A Button is either pressed or released.
+	Button Kind = iota
+	// An Axis1D has a value from -1 to 1.
+	Axis1D
+	// An Axis2D has a value within the unit circle.
+	Axis2D
+)
+
+// PressThreshold is the magnitude at which an input or axis counts as pressed.
+const PressThreshold = 0.5
+
+// A Map holds a set of named actions and the inputs bound to them. Once a Map
+// is listening to an event handler, it tracks input events sent to that
+// handler and triggers action events on it.
+type Map struct {
+	mutex     sync.Mutex
+	actions   map[string]*actionState
+	keys      map[key.Code]bool
+	buttons   map[mouse.Button]bool
+	joysticks map[uint32]*joystick.State
+	handler   event.Handler
+	capture   func(Binding)
+}
+
+type actionState struct {
+	kind     Kind
+	bindings []Binding
+	pressed  bool
+	value    floatgeom.Point2
+}
+
+// NewMap creates a Map with no actions.
+func NewMap() *Map {
+	return &Map{
+		actions:   make(map[string]*actionState),
+		keys:      make(map[key.Code]bool),
+		buttons:   make(map[mouse.Button]bool),
+		joysticks: make(map[uint32]*joystick.State),
+	}
+}
+
+// Define adds an action to this map, replacing any existing action of the same
+// name.
+func (m *Map) Define(name string, kind Kind, bindings ...Binding) error {
+	if kind > Axis2D {
+		return oakerr.InvalidInput{InputName: "kind"}
+	}
+	if err := validateAll(bindings); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	m.actions[name] = &actionState{
+		kind:     kind,
+		bindings: append([]Binding{}, bindings...),
+	}
+	evs := m.update()
+	m.mutex.Unlock()
+	m.trigger(evs)
+	return nil
+}
+
+// Rebind replaces the bindings of a defined action.
+func (m *Map) Rebind(name string, bindings ...Binding) error {
+	if err := validateAll(bindings); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	act, ok := m.actions[name]
+	if !ok {
+		m.mutex.Unlock()
+		return oakerr.NotFound{InputName: name}
+	}
+	act.bindings = append([]Binding{}, bindings...)
+	evs := m.update()
+	m.mutex.Unlock()
+	m.trigger(evs)
+	return nil
+}
+
+// AddBinding adds a binding to a defined action.
+func (m *Map) AddBinding(name string, b Binding) error {
+	if err := b.validate(); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	act, ok := m.actions[name]
+	if !ok {
+		m.mutex.Unlock()
+		return oakerr.NotFound{InputName: name}
+	}
+	act.bindings = append(act.bindings, b)
+	evs := m.update()
+	m.mutex.Unlock()
+	m.trigger(evs)
+	return nil
+}
+
+// Bindings returns the bindings of an action.
+func (m *Map) Bindings(name string) []Binding {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	act, ok := m.actions[name]
+	if !ok {
+		return nil
+	}
+	return append([]Binding{}, act.bindings...)
+}
+
+// IsDown returns whether an action is pressed.
+func (m *Map) IsDown(name string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	act, ok := m.actions[name]
+	return ok && act.pressed
+}
+
+// Value returns the value of a button or 1D axis action.
+func (m *Map) Value(name string) float64 {
+	return m.Vector(name).X()
+}
+
+// Vector returns the value of an action.
+func (m *Map) Vector(name string) floatgeom.Point2 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	act, ok := m.actions[name]
+	if !ok {
+		return floatgeom.Point2{}
+	}
+	return act.value
+}
+
+// Capture calls fn with a binding for the next key, mouse button, joystick
+// button, trigger, or stick pressed past PressThreshold. This is intended for
+// letting players choose their own bindings; Capture does not change any
+// bindings itself.
+func (m *Map) Capture(fn func(Binding)) {
+	m.mutex.Lock()
+	m.capture = fn
+	m.mutex.Unlock()
+}
+
+// Listen binds this map to keyboard, mouse, and joystick events on h, and
+// triggers action events on h. As these are not persistent bindings, Listen
+// should be called again if h is reset, e.g. on scene change. Joystick
+// inputs are read from joystick.Change events.
+func (m *Map) Listen(h event.Handler) (cancel func()) {
+	m.mutex.Lock()
+	m.handler = h
+	m.mutex.Unlock()
+	bindings := []event.Binding{
+		event.GlobalBind(h, key.AnyDown, func(ev key.Event) event.Response {
+			m.setKey(ev.Code, true)
+			return 0
+		}),
+		event.GlobalBind(h, key.AnyUp, func(ev key.Event) event.Response {
+			m.setKey(ev.Code, false)
+			return 0
+		}),
+		event.GlobalBind(h, mouse.Press, func(ev *mouse.Event) event.Response {
+			m.setMouse(ev.Button, true)
+			return 0
+		}),
+		event.GlobalBind(h, mouse.Release, func(ev *mouse.Event) event.Response {
+			m.setMouse(ev.Button, false)
+			return 0
+		}),
+		event.GlobalBind(h, joystick.Change, func(st *joystick.State) event.Response {
+			m.setJoystick(st)
+			return 0
+		}),
+		event.GlobalBind(h, joystick.Disconnected, func(id uint32) event.Response {
+			m.removeJoystick(id)
+			return 0
+		}),
+	}
+	return func() {
+		for _, b := range bindings {
+			b.Unbind()
+		}
+		m.mutex.Lock()
+		if m.handler == h {
+			m.handler = nil
+		}
+		m.mutex.Unlock()
+	}
+}
+
+func (m *Map) setKey(code key.Code, down bool) {
+	m.mutex.Lock()
+	if m.keys[code] == down {
+		m.mutex.Unlock()
+		return
+	}
+	m.keys[code] = down
+	var captured *Binding
+	if name, ok := key.AllKeys[code]; ok && down {
+		captured = &Binding{Device: DeviceKey, Input: name}
+	}
+	m.finishInput(captured)
+}
+
+func (m *Map) setMouse(b mouse.Button, down bool) {
+	name, ok := mouseButtons[b]
+	if !ok {
+		return
+	}
+	m.mutex.Lock()
+	m.buttons[b] = down
+	var captured *Binding
+	if down {
+		captured = &Binding{Device: DeviceMouse, Input: name}
+	}
+	m.finishInput(captured)
+}
+
+func (m *Map) setJoystick(st *joystick.State) {
+	cp := *st
+	m.mutex.Lock()
+	last, ok := m.joysticks[st.ID]
+	if !ok {
+		last = &joystick.State{}
+	}
+	m.joysticks[st.ID] = &cp
+	var captured *Binding
+	for b, down := range cp.Buttons {
+		if down && !last.Buttons[b] {
+			captured = &Binding{Device: DeviceJoystick, Input: b}
+			break
+		}
+	}
+	if captured == nil {
+		for _, in := range []string{TriggerL, TriggerR, StickL, StickR} {
+			if analogMagnitude(&cp, in) >= PressThreshold && analogMagnitude(last, in) < PressThreshold {
+				captured = &Binding{Device: DeviceJoystick, Input: in}
+				break
+			}
+		}
+	}
+	m.finishInput(captured)
+}
+
+func (m *Map) removeJoystick(id uint32) {
+	m.mutex.Lock()
+	delete(m.joysticks, id)
+	m.finishInput(nil)
+}
+
+// finishInput must be called with the map's mutex held, and releases it.
+func (m *Map) finishInput(captured *Binding) {
+	var capture func(Binding)
+	if captured != nil && m.capture != nil {
+		capture = m.capture
+		m.capture = nil
+	}
+	evs := m.update()
+	m.mutex.Unlock()
+	if capture != nil {
+		capture(*captured)
+	}
+	m.trigger(evs)
+}
+
+// update recalculates each action's value, returning the events that should
+// be triggered for any changes.
+func (m *Map) update() []actionEvent {
+	var evs []actionEvent
+	for name, act := range m.actions {
+		var v floatgeom.Point2
+		pressed := false
+		for _, b := range act.bindings {
+			bv := m.read(b, act.kind)
+			switch act.kind {
+			case Button:
+				if math.Abs(bv.X()) >= PressThreshold {
+					pressed = true
+				}
+			default:
+				v = v.Add(bv)
+			}
+		}
+		switch act.kind {
+		case Button:
+			if pressed {
+				v = floatgeom.Point2{1, 0}
+			}
+		case Axis1D:
+			v[0] = math.Max(-1, math.Min(1, v[0]))
+			pressed = math.Abs(v[0]) >= PressThreshold
+		case Axis2D:
+			if v.Magnitude() > 1 {
+				v = v.Normalize()
+			}
+			pressed = v.Magnitude() >= PressThreshold
+		}
+		ev := Event{Action: name, Kind: act.kind, Pressed: pressed, Value: v}
+		if pressed != act.pressed {
+			if pressed {
+				evs = append(evs, actionEvent{AnyPress, Press(name), ev})
+			} else {
+				evs = append(evs, actionEvent{AnyRelease, Release(name), ev})
+			}
+		}
+		if act.kind != Button && v != act.value {
+			evs = append(evs, actionEvent{AnyChange, Change(name), ev})
+		}
+		act.pressed = pressed
+		act.value = v
+	}
+	return evs
+}
+
+type actionEvent struct {
+	any, named event.EventID[Event]
+	ev         Event
+}
+
+func (m *Map) trigger(evs []actionEvent) {
+	m.mutex.Lock()
+	h := m.handler
+	m.mutex.Unlock()
+	if h == nil {
+		return
+	}
+	for _, ae := range evs {
+		event.TriggerOn(h, ae.any, ae.ev)
+		event.TriggerOn(h, ae.named, ae.ev)
+	}
+}
+
+// read returns the value of one binding. For buttons and 1D axes only X is set.
+func (m *Map) read(b Binding, kind Kind) floatgeom.Point2 {
+	var v float64
+	switch b.Device {
+	case DeviceKey:
+		code, _ := keyCode(b.Input)
+		if m.keys[code] {
+			v = 1
+		}
+	case DeviceMouse:
+		btn, _ := mouseButton(b.Input)
+		if m.buttons[btn] {
+			v = 1
+		}
+	case DeviceJoystick:
+		switch b.Input {
+		case StickL, StickR:
+			stick := m.stick(b.Input, b.Deadzone)
+			if kind == Axis2D {
+				return stick.MulConst(b.scale())
+			}
+			if b.Axis == AxisY {
+				v = stick.Y()
+			} else {
+				v = stick.X()
+			}
+		case TriggerL, TriggerR:
+			for _, st := range m.joysticks {
+				v = math.Max(v, applyDeadzone(analogMagnitude(st, b.Input), b.Deadzone))
+			}
+		default:
+			for _, st := range m.joysticks {
+				if st.Buttons[b.Input] {
+					v = 1
+				}
+			}
+		}
+	}
+	v *= b.scale()
+	if kind == Axis2D && b.Axis == AxisY {
+		return floatgeom.Point2{0, v}
+	}
+	return floatgeom.Point2{v, 0}
+}
+
+// stick returns the most displaced of a stick across all joysticks.
+func (m *Map) stick(input string, deadzone float64) floatgeom.Point2 {
+	var out floatgeom.Point2
+	for _, st := range m.joysticks {
+		p := stickPoint(st, input)
+		mag := p.Magnitude()
+		scaled := applyDeadzone(mag, deadzone)
+		if scaled > out.Magnitude() {
+			out = p.MulConst(scaled / mag)
+		}
+	}
+	return out
+}
+
+func stickPoint(st *joystick.State, input string) floatgeom.Point2 {
+	var p floatgeom.Point2
+	if input == StickL {
+		p = floatgeom.Point2{float64(st.StickLX), float64(st.StickLY)}
+	} else {
+		p = floatgeom.Point2{float64(st.StickRX), float64(st.StickRY)}
+	}
+	p = p.DivConst(math.MaxInt16)
+	if p.Magnitude() > 1 {
+		p = p.Normalize()
+	}
+	return p
+}
+
+func analogMagnitude(st *joystick.State, input string) float64 {
+	switch input {
+	case TriggerL:
+		return float64(st.TriggerL) / math.MaxUint8
+	case TriggerR:
+		return float64(st.TriggerR) / math.MaxUint8
+	case StickL, StickR:
+		return stickPoint(st, input).Magnitude()
+	}
+	return 0
+}
+
+func applyDeadzone(mag, deadzone float64) float64 {
+	if mag <= deadzone {
+		return 0
+	}
+	return (mag - deadzone) / (1 - deadzone)
+}
+
+func validateAll(bindings []Binding) error {
+	for _, b := range bindings {
+		if err := b.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save writes the bindings of every action to w as JSON, keyed by action name.
+func (m *Map) Save(w io.Writer) error {
+	m.mutex.Lock()
+	out := make(map[string][]Binding, len(m.actions))
+	for name, act := range m.actions {
+		out[name] = act.bindings
+	}
+	m.mutex.Unlock()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(out)
+}
+
+// Load reads bindings written by Save from r, replacing the bindings of each
+// defined action found. Actions which are not defined in this map are ignored,
+// so bindings saved by older versions of a game can still be loaded. If any
+// binding is invalid, no bindings are changed.
+func (m *Map) Load(r io.Reader) error {
+	in := make(map[string][]Binding)
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return err
+	}
+	for _, bindings := range in {
+		if err := validateAll(bindings); err != nil {
+			return err
+		}
+	}
+	m.mutex.Lock()
+	for name, bindings := range in {
+		if act, ok := m.actions[name]; ok {
+			act.bindings = bindings
+		}
+	}
+	evs := m.update()
+	m.mutex.Unlock()
+	m.trigger(evs)
+	return nil
+}
@@ -0,0 +1,218 @@
+package action
+
+import (
+	"bytes"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/joystick"
+	"github.com/diakovliev/oak/v4/key"
+	"github.com/diakovliev/oak/v4/mouse"
+)
+
+func TestMap_Button(t *testing.T) {
+	m := NewMap()
+	if err := m.Define("jump", Button, Key(key.Spacebar), MouseButton(mouse.ButtonLeft), JoystickButton(joystick.InputA)); err != nil {
+		t.Fatalf("failed to define: %v", err)
+	}
+	m.setKey(key.Spacebar, true)
+	if !m.IsDown("jump") || m.Value("jump") != 1 {
+		t.Fatalf("expected jump to be down")
+	}
+	m.setKey(key.Spacebar, false)
+	if m.IsDown("jump") {
+		t.Fatalf("expected jump to be released")
+	}
+	m.setMouse(mouse.ButtonLeft, true)
+	if !m.IsDown("jump") {
+		t.Fatalf("expected mouse to press jump")
+	}
+	m.setMouse(mouse.ButtonLeft, false)
+	m.setJoystick(&joystick.State{ID: 1, Buttons: map[string]bool{"A": true}})
+	if !m.IsDown("jump") {
+		t.Fatalf("expected joystick to press jump")
+	}
+	m.removeJoystick(1)
+	if m.IsDown("jump") {
+		t.Fatalf("expected disconnect to release jump")
+	}
+}
+
+func TestMap_Axes(t *testing.T) {
+	m := NewMap()
+	err := m.Define("move", Axis2D,
+		Key(key.A).On(AxisX, -1),
+		Key(key.D).On(AxisX, 1),
+		Key(key.W).On(AxisY, -1),
+		JoystickAnalog(StickL, .2),
+	)
+	if err != nil {
+		t.Fatalf("failed to define: %v", err)
+	}
+	if err := m.Define("accelerate", Axis1D, JoystickAnalog(TriggerR, .5)); err != nil {
+		t.Fatalf("failed to define: %v", err)
+	}
+	m.setKey(key.D, true)
+	m.setKey(key.W, true)
+	v := m.Vector("move")
+	if math.Abs(v.Magnitude()-1) > .0001 || v.X() <= 0 || v.Y() >= 0 {
+		t.Fatalf("expected normalized up-right movement, got %v", v)
+	}
+	m.setKey(key.D, false)
+	m.setKey(key.W, false)
+
+	m.setJoystick(&joystick.State{ID: 1, StickLX: 3000, TriggerR: 100})
+	if v := m.Vector("move"); v.Magnitude() != 0 {
+		t.Fatalf("expected stick within deadzone to be ignored, got %v", v)
+	}
+	if m.Value("accelerate") != 0 {
+		t.Fatalf("expected trigger within deadzone to be ignored")
+	}
+	m.setJoystick(&joystick.State{ID: 1, StickLX: math.MaxInt16, TriggerR: math.MaxUint8})
+	if v := m.Vector("move"); v.X() != 1 {
+		t.Fatalf("expected full stick movement, got %v", v)
+	}
+	if m.Value("accelerate") != 1 || !m.IsDown("accelerate") {
+		t.Fatalf("expected full trigger to press accelerate")
+	}
+}
+
+func TestMap_Events(t *testing.T) {
+	b := event.NewBus(event.NewCallerMap())
+	m := NewMap()
+	m.Define("fire", Button, Key(key.F))
+	m.Define("steer", Axis1D, Key(key.Q).On(AxisX, -1))
+	cancel := m.Listen(b)
+	defer cancel()
+	// Listen's bindings are applied concurrently
+	time.Sleep(200 * time.Millisecond)
+	pressed := make(chan Event, 1)
+	changed := make(chan Event, 1)
+	<-event.GlobalBind(b, Press("fire"), func(ev Event) event.Response {
+		pressed <- ev
+		return 0
+	}).Bound
+	<-event.GlobalBind(b, Change("steer"), func(ev Event) event.Response {
+		changed <- ev
+		return 0
+	}).Bound
+	<-event.TriggerOn(b, key.AnyDown, key.Event{Code: key.F})
+	select {
+	case ev := <-pressed:
+		if ev.Action != "fire" || !ev.Pressed {
+			t.Fatalf("unexpected event %v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected fire to be pressed")
+	}
+	<-event.TriggerOn(b, key.AnyDown, key.Event{Code: key.Q})
+	select {
+	case ev := <-changed:
+		if ev.Value.X() != -1 {
+			t.Fatalf("unexpected steer value %v", ev.Value)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected steer to change")
+	}
+}
+
+func TestMap_AddBinding(t *testing.T) {
+	m := NewMap()
+	m.Define("fire", Button)
+	if err := m.AddBinding("missing", Key(key.G)); err == nil {
+		t.Fatalf("expected error adding to undefined action")
+	}
+	if err := m.AddBinding("fire", Binding{Device: DeviceKey, Input: "NotAKey"}); err == nil {
+		t.Fatalf("expected error adding invalid key")
+	}
+	keys := []key.Code{key.A, key.B, key.C, key.D, key.E, key.F, key.G, key.H}
+	var wg sync.WaitGroup
+	for _, k := range keys {
+		wg.Add(1)
+		go func(k key.Code) {
+			defer wg.Done()
+			if err := m.AddBinding("fire", Key(k)); err != nil {
+				t.Errorf("failed to add binding: %v", err)
+			}
+		}(k)
+	}
+	wg.Wait()
+	if got := len(m.Bindings("fire")); got != len(keys) {
+		t.Fatalf("expected %v bindings after concurrent adds, got %v", len(keys), got)
+	}
+	m.setKey(key.H, true)
+	if !m.IsDown("fire") {
+		t.Fatalf("expected added binding to press fire")
+	}
+}
+
+func TestMap_Rebind(t *testing.T) {
+	m := NewMap()
+	m.Define("fire", Button, Key(key.F))
+	if err := m.Rebind("missing", Key(key.G)); err == nil {
+		t.Fatalf("expected error rebinding undefined action")
+	}
+	if err := m.Rebind("fire", Binding{Device: DeviceKey, Input: "NotAKey"}); err == nil {
+		t.Fatalf("expected error binding invalid key")
+	}
+	var captured Binding
+	m.Capture(func(b Binding) {
+		captured = b
+	})
+	m.setKey(key.G, true)
+	m.setKey(key.G, false)
+	if captured != Key(key.G) {
+		t.Fatalf("expected G to be captured, got %v", captured)
+	}
+	if err := m.Rebind("fire", captured); err != nil {
+		t.Fatalf("failed to rebind: %v", err)
+	}
+	m.setKey(key.F, true)
+	if m.IsDown("fire") {
+		t.Fatalf("expected old binding to be removed")
+	}
+	m.setKey(key.G, true)
+	if !m.IsDown("fire") {
+		t.Fatalf("expected new binding to press fire")
+	}
+
+	m.Capture(func(b Binding) {
+		captured = b
+	})
+	m.setJoystick(&joystick.State{ID: 2, StickRY: -30000})
+	if captured != (Binding{Device: DeviceJoystick, Input: StickR}) {
+		t.Fatalf("expected stick to be captured, got %v", captured)
+	}
+}
+
+func TestMap_SaveLoad(t *testing.T) {
+	m := NewMap()
+	m.Define("fire", Button, Key(key.F), JoystickButton(joystick.InputX))
+	m.Define("move", Axis2D, JoystickAnalog(StickL, .25))
+	buf := &bytes.Buffer{}
+	if err := m.Save(buf); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	m2 := NewMap()
+	m2.Define("fire", Button)
+	m2.Define("move", Axis2D)
+	if err := m2.Load(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if bs := m2.Bindings("fire"); len(bs) != 2 || bs[0] != Key(key.F) {
+		t.Fatalf("unexpected loaded bindings %v", bs)
+	}
+	if bs := m2.Bindings("move"); len(bs) != 1 || bs[0].Deadzone != .25 {
+		t.Fatalf("unexpected loaded bindings %v", bs)
+	}
+	bad := `{"fire": [{"device": "keyboard", "input": "F"}]}`
+	if err := m2.Load(bytes.NewReader([]byte(bad))); err == nil {
+		t.Fatalf("expected error loading invalid device")
+	}
+	if err := m2.Load(bytes.NewReader([]byte("{"))); err == nil {
+		t.Fatalf("expected error loading invalid json")
+	}
+}
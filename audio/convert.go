@@ -0,0 +1,338 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/diakovliev/oak/v4/audio/internal/sample"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// A ResampleMethod determines how audio is interpolated when its sample rate is changed.
+type ResampleMethod int
+
+const (
+	// ResampleLinear interpolates linearly between neighboring samples. It is cheap, but will alias
+	// high frequencies.
+	ResampleLinear ResampleMethod = iota
+	// ResampleSinc interpolates with a windowed sinc filter. It is more expensive than ResampleLinear,
+	// but preserves quality much better, especially when downsampling.
+	ResampleSinc
+)
+
+// sincHalfWidth is how many source samples on either side of a resampled point are considered by
+// ResampleSinc.
+const sincHalfWidth = 16
+
+// Convert wraps a reader such that it will produce audio in the given format, changing its sample rate,
+// channel count, and bit depth as needed. If r already produces the given format, r is returned as is.
+// An error is returned if either format cannot be converted.
+func Convert(r pcm.Reader, f pcm.Format, method ResampleMethod) (pcm.Reader, error) {
+	in := r.PCMFormat()
+	if in == f {
+		return r, nil
+	}
+	if err := checkConvertible(in); err != nil {
+		return nil, err
+	}
+	if err := checkConvertible(f); err != nil {
+		return nil, err
+	}
+	// Mix down before resampling and mix up after so the resampler handles as few channels as possible.
+	if f.Channels < in.Channels {
+		r = MixChannels(r, f.Channels)
+	}
+	if f.SampleRate != in.SampleRate {
+		r = Resample(r, f.SampleRate, method)
+	}
+	if f.Channels > in.Channels {
+		r = MixChannels(r, f.Channels)
+	}
+	if f.Bits != in.Bits {
+		r = ConvertBits(r, f.Bits)
+	}
+	return r, nil
+}
+
+func checkConvertible(f pcm.Format) error {
+	switch f.Bits {
+	case 8, 16, 32:
+	default:
+		return pcm.ErrUnsupportedBits
+	}
+	if f.Channels == 0 || f.SampleRate == 0 {
+		return fmt.Errorf("invalid pcm format: %+v", f)
+	}
+	return nil
+}
+
+// ConvertBits wraps a reader such that its samples will be converted to the given bit depth.
+func ConvertBits(r pcm.Reader, bits uint16) pcm.Reader {
+	out := r.PCMFormat()
+	out.Bits = bits
+	return &convertReader{
+		Format: out,
+		converter: &bitsConverter{
+			src: frameSource{Reader: r},
+			out: out,
+		},
+	}
+}
+
+// MixChannels wraps a reader such that its audio will be up or down mixed to the given channel count.
+// Mixing down averages channels together; mono audio mixed up is duplicated across every channel.
+func MixChannels(r pcm.Reader, channels uint16) pcm.Reader {
+	out := r.PCMFormat()
+	out.Channels = channels
+	return &convertReader{
+		Format: out,
+		converter: &channelConverter{
+			src: frameSource{Reader: r},
+			out: out,
+		},
+	}
+}
+
+// Resample wraps a reader such that its audio will be resampled to the given sample rate.
+func Resample(r pcm.Reader, sampleRate uint32, method ResampleMethod) pcm.Reader {
+	in := r.PCMFormat()
+	out := in
+	out.SampleRate = sampleRate
+	rc := &resampleConverter{
+		src:    frameSource{Reader: r},
+		out:    out,
+		method: method,
+		step:   float64(in.SampleRate) / float64(sampleRate),
+		after:  1,
+	}
+	if method == ResampleSinc {
+		rc.before = sincHalfWidth - 1
+		rc.after = sincHalfWidth
+		rc.cutoff = math.Min(1, 1/rc.step)
+	}
+	// Prime the history with silence so the first output sample lines up with the first input sample.
+	rc.frames = make([]float64, rc.before*int(in.Channels))
+	rc.pos = float64(rc.before)
+	return &convertReader{
+		Format:    out,
+		converter: rc,
+	}
+}
+
+// A converter produces up to the requested number of frames of audio at a time.
+type converter interface {
+	convert(frames int) ([]byte, error)
+}
+
+// A convertReader buffers the whole frames produced by a converter so callers may read any number of bytes.
+type convertReader struct {
+	pcm.Format
+	converter converter
+	pending   []byte
+	err       error
+}
+
+func (cr *convertReader) ReadPCM(b []byte) (n int, err error) {
+	n = copy(b, cr.pending)
+	cr.pending = cr.pending[n:]
+	size := cr.SampleSize()
+	if size == 0 {
+		return n, pcm.ErrUnsupportedBits
+	}
+	for n < len(b) && cr.err == nil {
+		frames := (len(b) - n + size - 1) / size
+		var out []byte
+		out, cr.err = cr.converter.convert(frames)
+		copied := copy(b[n:], out)
+		n += copied
+		cr.pending = out[copied:]
+	}
+	if n == 0 && len(cr.pending) == 0 {
+		return 0, cr.err
+	}
+	return n, nil
+}
+
+// A frameSource reads whole, decoded frames from a reader.
+type frameSource struct {
+	pcm.Reader
+	raw    []byte
+	values []float64
+}
+
+// read reads up to frames frames from the source, returning their normalized, interleaved values.
+// io.EOF is returned alongside the final frames of the source.
+func (fs *frameSource) read(frames int) ([]float64, error) {
+	f := fs.PCMFormat()
+	size := f.SampleSize()
+	if size == 0 {
+		return nil, pcm.ErrUnsupportedBits
+	}
+	if cap(fs.raw) < frames*size {
+		fs.raw = make([]byte, frames*size)
+	}
+	raw := fs.raw[:frames*size]
+	n, err := ReadFull(fs.Reader, raw)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	n -= n % size
+	count := n / int(f.Bits/8)
+	if cap(fs.values) < count {
+		fs.values = make([]float64, count)
+	}
+	values := fs.values[:count]
+	sample.Decode(f, raw[:n], values)
+	return values, err
+}
+
+type bitsConverter struct {
+	src frameSource
+	out pcm.Format
+}
+
+func (bc *bitsConverter) convert(frames int) ([]byte, error) {
+	values, err := bc.src.read(frames)
+	b := make([]byte, len(values)*int(bc.out.Bits/8))
+	sample.Encode(bc.out, values, b)
+	return b, err
+}
+
+type channelConverter struct {
+	src frameSource
+	out pcm.Format
+}
+
+func (cc *channelConverter) convert(frames int) ([]byte, error) {
+	values, err := cc.src.read(frames)
+	inCh := int(cc.src.PCMFormat().Channels)
+	outCh := int(cc.out.Channels)
+	count := len(values) / inCh
+	mixed := make([]float64, count*outCh)
+	for i := 0; i < count; i++ {
+		in := values[i*inCh : (i+1)*inCh]
+		out := mixed[i*outCh : (i+1)*outCh]
+		if outCh >= inCh {
+			for c := range out {
+				out[c] = in[c%inCh]
+			}
+			continue
+		}
+		// Input channel c is folded into output channel c % outCh, and each output
+		// channel is the average of the input channels folded into it.
+		for c, v := range in {
+			out[c%outCh] += v
+		}
+		for c := range out {
+			folded := inCh / outCh
+			if c < inCh%outCh {
+				folded++
+			}
+			out[c] /= float64(folded)
+		}
+	}
+	b := make([]byte, len(mixed)*int(cc.out.Bits/8))
+	sample.Encode(cc.out, mixed, b)
+	return b, err
+}
+
+type resampleConverter struct {
+	src    frameSource
+	out    pcm.Format
+	method ResampleMethod
+
+	// step is how far pos advances in source frames per output frame.
+	step float64
+	// pos is the position of the next output frame, in source frames relative to frames.
+	pos float64
+	// before and after are how many source frames are needed before and after pos to interpolate.
+	before, after int
+	cutoff        float64
+	// frames holds buffered, decoded source frames.
+	frames []float64
+	eof    bool
+}
+
+func (rc *resampleConverter) convert(frames int) ([]byte, error) {
+	ch := int(rc.out.Channels)
+	values := make([]float64, 0, frames*ch)
+	for k := 0; k < frames; k++ {
+		i := int(rc.pos)
+		for !rc.eof && len(rc.frames)/ch <= i+rc.after {
+			want := int(float64(frames-k)*rc.step) + rc.after + 1
+			read, err := rc.src.read(want)
+			rc.frames = append(rc.frames, read...)
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					return rc.encode(values), err
+				}
+				rc.eof = true
+			}
+		}
+		if i >= len(rc.frames)/ch {
+			break
+		}
+		for c := 0; c < ch; c++ {
+			values = append(values, rc.interpolate(c, ch))
+		}
+		rc.pos += rc.step
+	}
+	// Discard frames which are no longer needed for interpolation.
+	if drop := int(rc.pos) - rc.before; drop > 0 {
+		if drop > len(rc.frames)/ch {
+			drop = len(rc.frames) / ch
+		}
+		rc.frames = append(rc.frames[:0], rc.frames[drop*ch:]...)
+		rc.pos -= float64(drop)
+	}
+	if len(values) == 0 && rc.eof {
+		return nil, io.EOF
+	}
+	return rc.encode(values), nil
+}
+
+func (rc *resampleConverter) frame(i, c, ch int) float64 {
+	if i < 0 || i >= len(rc.frames)/ch {
+		return 0
+	}
+	return rc.frames[i*ch+c]
+}
+
+func (rc *resampleConverter) interpolate(c, ch int) float64 {
+	i := int(rc.pos)
+	frac := rc.pos - float64(i)
+	if rc.method != ResampleSinc {
+		return rc.frame(i, c, ch)*(1-frac) + rc.frame(i+1, c, ch)*frac
+	}
+	var v float64
+	for j := i - rc.before; j <= i+rc.after; j++ {
+		d := rc.pos - float64(j)
+		v += rc.frame(j, c, ch) * rc.cutoff * sinc(rc.cutoff*d) * blackman(d, sincHalfWidth)
+	}
+	return v
+}
+
+func (rc *resampleConverter) encode(values []float64) []byte {
+	b := make([]byte, len(values)*int(rc.out.Bits/8))
+	sample.Encode(rc.out, values, b)
+	return b
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+// blackman returns the value of a blackman window of the given half width at distance d from its center.
+func blackman(d float64, halfWidth int) float64 {
+	if math.Abs(d) >= float64(halfWidth) {
+		return 0
+	}
+	x := math.Pi * d / float64(halfWidth)
+	return 0.42 + 0.5*math.Cos(x) + 0.08*math.Cos(2*x)
+}
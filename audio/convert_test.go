@@ -0,0 +1,93 @@
+package audio_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/audio"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+func TestConvertBits(t *testing.T) {
+	in := bytesReader(pcm.Format{SampleRate: 8000, Channels: 1, Bits: 8}, []byte{0, 64, 0x80})
+	out := audio.ReadAll(audio.ConvertBits(in, 16))
+	if out.Bits != 16 {
+		t.Fatalf("expected 16 bit output, got %v", out.Bits)
+	}
+	expected := []byte{0, 0, 0, 0x40, 0, 0x80}
+	if string(out.Buffer) != string(expected) {
+		t.Fatalf("expected %v, got %v", expected, out.Buffer)
+	}
+}
+
+func TestMixChannels(t *testing.T) {
+	stereo := bytesReader(pcm.Format{SampleRate: 8000, Channels: 2, Bits: 8}, []byte{10, 20, 30, 50})
+	mono := audio.ReadAll(audio.MixChannels(stereo, 1))
+	if string(mono.Buffer) != string([]byte{15, 40}) {
+		t.Fatalf("expected averaged channels, got %v", mono.Buffer)
+	}
+	up := audio.ReadAll(audio.MixChannels(bytesReader(mono.Format, mono.Buffer), 2))
+	if string(up.Buffer) != string([]byte{15, 15, 40, 40}) {
+		t.Fatalf("expected duplicated channels, got %v", up.Buffer)
+	}
+}
+
+func TestResample(t *testing.T) {
+	for _, method := range []audio.ResampleMethod{audio.ResampleLinear, audio.ResampleSinc} {
+		format := pcm.Format{SampleRate: 22050, Channels: 1, Bits: 16}
+		wave := make([]int16, 11025)
+		for i := range wave {
+			wave[i] = int16(8000 * math.Sin(float64(i)*2*math.Pi*440/22050))
+		}
+		in := audio.ReadAll(bytesReader(format, bytesFromInts(wave, 1)))
+		out := audio.ReadAll(audio.Resample(bytesReader(format, in.Buffer), 44100, method))
+		if out.SampleRate != 44100 {
+			t.Fatalf("expected 44100 sample rate, got %v", out.SampleRate)
+		}
+		// The resampled audio should be twice as long and still hold a sine at the same pitch.
+		if got, want := len(out.Buffer), len(in.Buffer)*2; math.Abs(float64(got-want)) > 4 {
+			t.Fatalf("method %v: expected %v bytes, got %v", method, want, got)
+		}
+		for i := 2000; i < 2100; i++ {
+			orig := sample16(in.Buffer, i)
+			re := sample16(out.Buffer, i*2)
+			if math.Abs(float64(orig-re)) > 200 {
+				t.Fatalf("method %v: sample %v: expected ~%v, got %v", method, i, orig, re)
+			}
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	in := bytesReader(pcm.Format{SampleRate: 22050, Channels: 1, Bits: 8}, make([]byte, 2205))
+	target := pcm.Format{SampleRate: 44100, Channels: 2, Bits: 16}
+	r, err := audio.Convert(in, target, audio.ResampleLinear)
+	if err != nil {
+		t.Fatalf("convert failed: %v", err)
+	}
+	if r.PCMFormat() != target {
+		t.Fatalf("expected %v, got %v", target, r.PCMFormat())
+	}
+	// Reads of partial frames must still make progress.
+	b := make([]byte, 3)
+	n, err := r.ReadPCM(b)
+	if n != 3 || err != nil {
+		t.Fatalf("expected 3 bytes, got %v %v", n, err)
+	}
+	_, err = audio.Convert(in, pcm.Format{SampleRate: 44100, Channels: 2, Bits: 24}, audio.ResampleLinear)
+	if err == nil {
+		t.Fatal("expected error converting to unsupported bits")
+	}
+}
+
+func sample16(b []byte, i int) int16 {
+	return int16(b[i*2]) | int16(b[i*2+1])<<8
+}
+
+func bytesReader(f pcm.Format, b []byte) pcm.Reader {
+	return &pcm.IOReader{
+		Format: f,
+		Reader: bytes.NewReader(b),
+	}
+}
@@ -0,0 +1,98 @@
+package effect
+
+import (
+	"math"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// A Compressor reduces the volume of audio louder than a threshold, narrowing the difference between
+// loud and quiet sounds. Each frame is compressed by the same amount across all channels.
+type Compressor struct {
+	// Threshold is the level, in decibels relative to full scale, above which audio is compressed.
+	Threshold float64
+	// Ratio is how many decibels audio must rise over the threshold for the output to rise one
+	// decibel. If Ratio is zero or infinite, the compressor is a limiter and audio will not rise over
+	// the threshold.
+	Ratio float64
+	// Attack is how quickly compression responds to rising levels.
+	Attack time.Duration
+	// Release is how quickly compression recovers from falling levels.
+	Release time.Duration
+	// MakeupGain, in decibels, is added to the compressed output.
+	MakeupGain float64
+}
+
+// Limiter creates a compressor which prevents audio from exceeding ceiling, in decibels relative to full
+// scale, such as to prevent clipping when many sounds play at once.
+func Limiter(ceiling float64, release time.Duration) Compressor {
+	return Compressor{
+		Threshold: ceiling,
+		Release:   release,
+	}
+}
+
+// Processor creates a processor applying this compressor to audio of the given format.
+func (c Compressor) Processor(f pcm.Format) Processor {
+	// slope is how much of the level over the threshold is removed.
+	var slope float64
+	switch {
+	case c.Ratio == 0, math.IsInf(c.Ratio, 1):
+		slope = 1
+	case c.Ratio > 1:
+		slope = 1 - 1/c.Ratio
+	}
+	return &compressorProcessor{
+		follower:  newFollower(c.Attack, c.Release, f.SampleRate),
+		threshold: c.Threshold,
+		slope:     slope,
+		makeup:    c.MakeupGain,
+	}
+}
+
+type compressorProcessor struct {
+	*follower
+	threshold float64
+	slope     float64
+	makeup    float64
+}
+
+func (cp *compressorProcessor) Process(frame []float64) {
+	level := GainToDecibels(cp.follow(frame))
+	reduction := 0.0
+	if over := level - cp.threshold; over > 0 {
+		reduction = over * cp.slope
+	}
+	gain := DecibelsToGain(cp.makeup - reduction)
+	for i := range frame {
+		frame[i] *= gain
+	}
+}
+
+// A follower tracks the peak level of audio, rising over an attack and falling over a release.
+type follower struct {
+	attack, release float64
+	level           float64
+}
+
+func newFollower(attack, release time.Duration, sampleRate uint32) *follower {
+	return &follower{
+		attack:  coefficient(attack, sampleRate),
+		release: coefficient(release, sampleRate),
+	}
+}
+
+// follow updates and returns the level from the peak of a frame.
+func (f *follower) follow(frame []float64) float64 {
+	var peak float64
+	for _, v := range frame {
+		peak = math.Max(peak, math.Abs(v))
+	}
+	coef := f.release
+	if peak > f.level {
+		coef = f.attack
+	}
+	f.level = coef*f.level + (1-coef)*peak
+	return f.level
+}
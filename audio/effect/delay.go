@@ -0,0 +1,66 @@
+package effect
+
+import (
+	"math"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// A Delay repeats audio after some time, each repeat quieter than the last, producing echoes.
+type Delay struct {
+	// Time is how long after a sound its first echo is heard.
+	Time time.Duration
+	// Feedback, between 0.0 -> 1.0, is how loud each echo is compared to the sound before it.
+	Feedback float64
+	// Mix, between 0.0 -> 1.0, is the proportion of output which is echoes rather than the
+	// original audio.
+	Mix float64
+}
+
+// Tail returns how long it takes for the delay's echoes to fall silent.
+func (d Delay) Tail() time.Duration {
+	feedback := clamp(d.Feedback, 0, .999)
+	if feedback == 0 {
+		return d.Time
+	}
+	repeats := math.Ceil(math.Log(silence) / math.Log(feedback))
+	return time.Duration(repeats+1) * d.Time
+}
+
+// Processor creates a processor applying this delay to audio of the given format.
+func (d Delay) Processor(f pcm.Format) Processor {
+	length := frames(d.Time, f.SampleRate)
+	dp := &delayProcessor{
+		buffers:  make([][]float64, f.Channels),
+		feedback: clamp(d.Feedback, 0, .999),
+		mix:      clamp(d.Mix, 0, 1),
+	}
+	for c := range dp.buffers {
+		dp.buffers[c] = make([]float64, length)
+	}
+	return dp
+}
+
+type delayProcessor struct {
+	buffers  [][]float64
+	pos      int
+	feedback float64
+	mix      float64
+}
+
+func (dp *delayProcessor) Process(frame []float64) {
+	for c, buf := range dp.buffers {
+		echo := buf[dp.pos]
+		buf[dp.pos] = frame[c] + echo*dp.feedback
+		frame[c] = frame[c]*(1-dp.mix) + echo*dp.mix
+	}
+	dp.pos++
+	if dp.pos == len(dp.buffers[0]) {
+		dp.pos = 0
+	}
+}
+
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
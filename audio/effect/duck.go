@@ -0,0 +1,87 @@
+package effect
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// A Meter measures the level of audio passing through it without changing it. A Meter is usually
+// applied to one stream so that its level can key a Duck on another.
+type Meter struct {
+	// Attack is how quickly the measured level responds to rising levels.
+	Attack time.Duration
+	// Release is how quickly the measured level responds to falling levels.
+	Release time.Duration
+
+	level uint64
+}
+
+// Level returns the most recently measured level of audio passing through the meter, between 0.0 -> 1.0.
+func (m *Meter) Level() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&m.level))
+}
+
+// Processor creates a processor measuring audio of the given format into this meter.
+func (m *Meter) Processor(f pcm.Format) Processor {
+	return &meterProcessor{
+		follower: newFollower(m.Attack, m.Release, f.SampleRate),
+		meter:    m,
+	}
+}
+
+type meterProcessor struct {
+	*follower
+	meter *Meter
+}
+
+func (mp *meterProcessor) Process(frame []float64) {
+	atomic.StoreUint64(&mp.meter.level, math.Float64bits(mp.follow(frame)))
+}
+
+// A Duck lowers the volume of audio while a sidechain is loud, such as lowering music while
+// dialogue plays. A Duck responds to its sidechain's level as last measured, so when both are
+// mixed by the same reader the duck may trail its sidechain by up to one read.
+type Duck struct {
+	// Sidechain measures the audio which causes ducking.
+	Sidechain *Meter
+	// Threshold is the level, in decibels relative to full scale, above which the sidechain causes ducking.
+	Threshold float64
+	// Depth is how many decibels audio is lowered while ducked.
+	Depth float64
+	// Attack is how quickly audio is lowered once the sidechain exceeds the threshold.
+	Attack time.Duration
+	// Release is how quickly audio recovers once the sidechain falls below the threshold.
+	Release time.Duration
+}
+
+// Processor creates a processor applying this duck to audio of the given format.
+func (d Duck) Processor(f pcm.Format) Processor {
+	return &duckProcessor{
+		duck:    d,
+		attack:  coefficient(d.Attack, f.SampleRate),
+		release: coefficient(d.Release, f.SampleRate),
+		ducked:  DecibelsToGain(-math.Abs(d.Depth)),
+		gain:    1,
+	}
+}
+
+type duckProcessor struct {
+	duck            Duck
+	attack, release float64
+	ducked          float64
+	gain            float64
+}
+
+func (dp *duckProcessor) Process(frame []float64) {
+	target, coef := 1.0, dp.release
+	if dp.duck.Sidechain != nil && GainToDecibels(dp.duck.Sidechain.Level()) > dp.duck.Threshold {
+		target, coef = dp.ducked, dp.attack
+	}
+	dp.gain = coef*dp.gain + (1-coef)*target
+	for i := range frame {
+		frame[i] *= dp.gain
+	}
+}
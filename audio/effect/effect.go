@@ -0,0 +1,112 @@
+// Package effect provides audio effects, such as echoes, reverb, and compression, which can wrap
+// pcm.Readers or be inserted on mixer buses.
+package effect
+
+import (
+	"math"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/internal/sample"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// An Effect describes how audio should be changed, and creates processors which change it.
+type Effect interface {
+	// Processor creates a processor applying this effect to audio of the given format.
+	Processor(f pcm.Format) Processor
+}
+
+// A Processor applies an effect to a stream of audio, one frame at a time.
+type Processor interface {
+	// Process modifies a frame of normalized samples, one per channel, in place.
+	Process(frame []float64)
+}
+
+type chain []Processor
+
+func (c chain) Process(frame []float64) {
+	for _, p := range c {
+		p.Process(frame)
+	}
+}
+
+// Chain creates a processor which applies each effect in order.
+func Chain(f pcm.Format, effects ...Effect) Processor {
+	c := make(chain, len(effects))
+	for i, e := range effects {
+		c[i] = e.Processor(f)
+	}
+	return c
+}
+
+// A Tailer is an Effect which continues to sound after its input ends, such as the echoes of a Delay.
+type Tailer interface {
+	// Tail returns how long the effect may continue to sound once its input is silent.
+	Tail() time.Duration
+}
+
+// MaxTail is the longest an applied effect will continue to sound after its underlying reader ends.
+const MaxTail = 30 * time.Second
+
+// silence is the level at which a tail is considered to have ended, -80 dB.
+const silence = 1e-4
+
+// Apply wraps a reader such that it passes through each effect in order. Once r ends, the returned
+// reader continues for the longest tail of its effects.
+func Apply(r pcm.Reader, effects ...Effect) pcm.Reader {
+	f := r.PCMFormat()
+	var tail time.Duration
+	for _, e := range effects {
+		if t, ok := e.(Tailer); ok && t.Tail() > tail {
+			tail = t.Tail()
+		}
+	}
+	if tail > MaxTail {
+		tail = MaxTail
+	}
+	process := Chain(f, effects...)
+	tailFrames := int(math.Round(tail.Seconds() * float64(f.SampleRate)))
+	return sample.NewReader(r, func(frame []float64) bool {
+		process.Process(frame)
+		return true
+	}, func(frame []float64) bool {
+		// Once r ends, the effects' response to silence is played.
+		if tailFrames <= 0 {
+			return false
+		}
+		tailFrames--
+		for i := range frame {
+			frame[i] = 0
+		}
+		process.Process(frame)
+		return true
+	})
+}
+
+// frames converts a duration to a number of frames at a sample rate, at least one.
+func frames(d time.Duration, sampleRate uint32) int {
+	n := int(math.Round(d.Seconds() * float64(sampleRate)))
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// coefficient returns the smoothing coefficient for a one pole filter reaching ~63% of a
+// change over d.
+func coefficient(d time.Duration, sampleRate uint32) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return math.Exp(-1 / (d.Seconds() * float64(sampleRate)))
+}
+
+// DecibelsToGain converts a level in decibels to a linear gain.
+func DecibelsToGain(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+// GainToDecibels converts a linear gain to a level in decibels.
+func GainToDecibels(g float64) float64 {
+	return 20 * math.Log10(g)
+}
@@ -0,0 +1,163 @@
+package effect
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/internal/audiotest"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+var mono = pcm.Format{
+	SampleRate: 1000,
+	Channels:   1,
+	Bits:       16,
+}
+
+// reader returns a reader of the given samples, scaled from -1.0 -> 1.0.
+func reader(f pcm.Format, vs ...float64) pcm.Reader {
+	b := make([]byte, len(vs)*2)
+	for i, v := range vs {
+		i16 := int16(v * math.MaxInt16)
+		b[i*2] = byte(i16)
+		b[i*2+1] = byte(i16 >> 8)
+	}
+	return &pcm.IOReader{
+		Format: f,
+		Reader: bytes.NewReader(b),
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < .001
+}
+
+func TestDelay(t *testing.T) {
+	d := Delay{Time: 10 * time.Millisecond, Feedback: .5, Mix: .5}
+	if d.Tail() != 150*time.Millisecond {
+		t.Fatalf("expected 150ms tail, got %v", d.Tail())
+	}
+	out := audiotest.Floats(audiotest.ReadAll(t, Apply(reader(mono, 1), d), 0))
+	if len(out) != 151 {
+		t.Fatalf("expected 151 samples, got %v", len(out))
+	}
+	for i, expected := range map[int]float64{0: .5, 5: 0, 10: .5, 20: .25, 30: .125} {
+		if !near(out[i], expected) {
+			t.Fatalf("sample %v: expected %v got %v", i, expected, out[i])
+		}
+	}
+}
+
+func TestReverb(t *testing.T) {
+	stereo := pcm.Format{SampleRate: 44100, Channels: 2, Bits: 16}
+	r := Reverb{RoomSize: .5, Damping: .5, Width: 1, Mix: .5}
+	pulse := make([]float64, 200)
+	pulse[0], pulse[1] = 1, 1
+	out := audiotest.Floats(audiotest.ReadAll(t, Apply(reader(stereo, pulse...), r), 0))
+	if frames := len(out) / 2; frames != 100+int(math.Round(r.Tail().Seconds()*44100)) {
+		t.Fatalf("expected reverb tail, got %v frames", frames)
+	}
+	if !near(out[0], .5) {
+		t.Fatalf("expected dry pulse, got %v", out[0])
+	}
+	var early, late float64
+	for i := 4000; i < 20000; i++ {
+		early = math.Max(early, math.Abs(out[i]))
+	}
+	for i := len(out) - 2000; i < len(out); i++ {
+		late = math.Max(late, math.Abs(out[i]))
+	}
+	if early < .01 {
+		t.Fatalf("expected audible reflections, got %v", early)
+	}
+	if late > early/100 {
+		t.Fatalf("expected reflections to fade, got %v", late)
+	}
+	// The first reflection reaches each channel at a different time.
+	first := [2]int{-1, -1}
+	for i := 2; i < len(out); i++ {
+		if c := i % 2; first[c] == -1 && out[i] != 0 {
+			first[c] = i / 2
+		}
+	}
+	if first[0] == first[1] {
+		t.Fatalf("expected stereo reflections, got %v", first)
+	}
+}
+
+func TestCompressor(t *testing.T) {
+	c := Compressor{Threshold: -20, Ratio: 4, MakeupGain: 6}
+	p := c.Processor(mono)
+	frame := []float64{1}
+	p.Process(frame)
+	// 20 dB over the threshold is compressed to 5 dB over, then raised by 6 dB.
+	if !near(frame[0], DecibelsToGain(-9)) {
+		t.Fatalf("expected -9 dB, got %v", GainToDecibels(frame[0]))
+	}
+	frame[0] = DecibelsToGain(-30)
+	p.Process(frame)
+	if !near(frame[0], DecibelsToGain(-24)) {
+		t.Fatalf("expected quiet audio to be uncompressed, got %v", GainToDecibels(frame[0]))
+	}
+
+	out := audiotest.Floats(audiotest.ReadAll(t, Apply(reader(mono, 1, -1, .9, .1), Limiter(-6, 10*time.Millisecond)), 0))
+	for i, v := range out[:3] {
+		if math.Abs(v) > DecibelsToGain(-6)+.001 {
+			t.Fatalf("sample %v: expected limited audio, got %v", i, v)
+		}
+	}
+	if out[3] > .06 {
+		t.Fatalf("expected limiter to still be releasing, got %v", out[3])
+	}
+
+	slow := Compressor{Threshold: -20, Ratio: 10, Attack: 100 * time.Millisecond}.Processor(mono)
+	frame[0] = 1
+	slow.Process(frame)
+	if frame[0] < .9 {
+		t.Fatalf("expected slow attack to let transients through, got %v", frame[0])
+	}
+}
+
+func TestDuck(t *testing.T) {
+	meter := &Meter{Release: 50 * time.Millisecond}
+	voice := Apply(reader(mono, 1, 1, 1, 0, 0, 0), meter)
+	duck := Duck{Sidechain: meter, Threshold: -20, Depth: 12}.Processor(mono)
+
+	frame := []float64{1}
+	duck.Process(frame)
+	if frame[0] != 1 {
+		t.Fatalf("expected no ducking without a sidechain level, got %v", frame[0])
+	}
+	audiotest.ReadAll(t, voice, 0)
+	if !near(meter.Level(), math.Pow(math.Exp(-1/50.0), 3)) {
+		t.Fatalf("expected meter to be releasing, got %v", meter.Level())
+	}
+	frame[0] = 1
+	duck.Process(frame)
+	if !near(frame[0], DecibelsToGain(-12)) {
+		t.Fatalf("expected ducked audio, got %v", GainToDecibels(frame[0]))
+	}
+	setLevel(meter, 0)
+	frame[0] = 1
+	duck.Process(frame)
+	if frame[0] != 1 {
+		t.Fatalf("expected audio to recover, got %v", frame[0])
+	}
+}
+
+// setLevel sets a meter's level by measuring a single frame with a new processor.
+func setLevel(m *Meter, v float64) {
+	m.Processor(mono).Process([]float64{v})
+}
+
+func TestChain(t *testing.T) {
+	out := audiotest.Floats(audiotest.ReadAll(t, Apply(reader(mono, .5, .5),
+		Compressor{Threshold: -100, Ratio: 1, MakeupGain: GainToDecibels(.5)},
+		Compressor{Threshold: -100, Ratio: 1, MakeupGain: GainToDecibels(.5)},
+	), 0))
+	if len(out) != 2 || !near(out[0], .125) {
+		t.Fatalf("expected effects to apply in order, got %v", out)
+	}
+}
@@ -0,0 +1,160 @@
+package effect
+
+import (
+	"math"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// Freeverb tunings, in samples at 44100hz.
+var (
+	combTunings    = []int{1116, 1188, 1277, 1356, 1422, 1491, 1557, 1617}
+	allpassTunings = []int{556, 441, 341, 225}
+)
+
+const (
+	// stereoSpread offsets the tunings of each channel after the first, decorrelating them.
+	stereoSpread    = 23
+	reverbInputGain = .015
+	reverbWetScale  = 3
+	allpassFeedback = .5
+)
+
+// A Reverb simulates the reflections of a room, after the Freeverb algorithm: a Schroeder reverberator
+// of parallel low passed comb filters followed by series allpass filters.
+type Reverb struct {
+	// RoomSize, between 0.0 -> 1.0, determines how long reflections take to fade.
+	RoomSize float64
+	// Damping, between 0.0 -> 1.0, determines how quickly high frequencies fade from reflections.
+	Damping float64
+	// Width, between 0.0 -> 1.0, is how separated the reflections in each stereo channel are.
+	// Width has no effect on non-stereo formats.
+	Width float64
+	// Mix, between 0.0 -> 1.0, is the proportion of output which is reflections rather than the
+	// original audio.
+	Mix float64
+}
+
+func (r Reverb) feedback() float64 {
+	return clamp(r.RoomSize, 0, 1)*.28 + .7
+}
+
+// Tail returns how long it takes for the reverb's reflections to fall silent.
+func (r Reverb) Tail() time.Duration {
+	longest := float64(combTunings[len(combTunings)-1]+stereoSpread) / 44100
+	loops := math.Ceil(math.Log(silence) / math.Log(r.feedback()))
+	return time.Duration(loops * longest * float64(time.Second))
+}
+
+// Processor creates a processor applying this reverb to audio of the given format.
+func (r Reverb) Processor(f pcm.Format) Processor {
+	scale := float64(f.SampleRate) / 44100
+	width := clamp(r.Width, 0, 1)
+	rp := &reverbProcessor{
+		channels: make([]reverbChannel, f.Channels),
+		mix:      clamp(r.Mix, 0, 1),
+		wet1:     width/2 + .5,
+		wet2:     (1 - width) / 2,
+		wet:      make([]float64, f.Channels),
+	}
+	feedback := r.feedback()
+	damp := clamp(r.Damping, 0, 1) * .4
+	for c := range rp.channels {
+		ch := &rp.channels[c]
+		for _, t := range combTunings {
+			ch.combs = append(ch.combs, &comb{
+				buf:      make([]float64, tuning(t+c*stereoSpread, scale)),
+				feedback: feedback,
+				damp:     damp,
+			})
+		}
+		for _, t := range allpassTunings {
+			ch.allpasses = append(ch.allpasses, &allpass{
+				buf: make([]float64, tuning(t+c*stereoSpread, scale)),
+			})
+		}
+	}
+	return rp
+}
+
+func tuning(samples int, scale float64) int {
+	n := int(math.Round(float64(samples) * scale))
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+type reverbProcessor struct {
+	channels   []reverbChannel
+	mix        float64
+	wet1, wet2 float64
+	wet        []float64
+}
+
+type reverbChannel struct {
+	combs     []*comb
+	allpasses []*allpass
+}
+
+func (rp *reverbProcessor) Process(frame []float64) {
+	var in float64
+	for _, v := range frame {
+		in += v
+	}
+	in *= reverbInputGain
+	for c := range rp.channels {
+		ch := &rp.channels[c]
+		var out float64
+		for _, cb := range ch.combs {
+			out += cb.process(in)
+		}
+		for _, ap := range ch.allpasses {
+			out = ap.process(out)
+		}
+		rp.wet[c] = out * reverbWetScale
+	}
+	if len(frame) == 2 {
+		l, r := rp.wet[0], rp.wet[1]
+		rp.wet[0] = l*rp.wet1 + r*rp.wet2
+		rp.wet[1] = r*rp.wet1 + l*rp.wet2
+	}
+	for c := range frame {
+		frame[c] = frame[c]*(1-rp.mix) + rp.wet[c]*rp.mix
+	}
+}
+
+type comb struct {
+	buf         []float64
+	pos         int
+	feedback    float64
+	damp        float64
+	filterStore float64
+}
+
+func (c *comb) process(in float64) float64 {
+	out := c.buf[c.pos]
+	c.filterStore = out*(1-c.damp) + c.filterStore*c.damp
+	c.buf[c.pos] = in + c.filterStore*c.feedback
+	c.pos++
+	if c.pos == len(c.buf) {
+		c.pos = 0
+	}
+	return out
+}
+
+type allpass struct {
+	buf []float64
+	pos int
+}
+
+func (a *allpass) process(in float64) float64 {
+	delayed := a.buf[a.pos]
+	a.buf[a.pos] = in + delayed*allpassFeedback
+	a.pos++
+	if a.pos == len(a.buf) {
+		a.pos = 0
+	}
+	return delayed - in
+}
@@ -47,6 +47,8 @@ func (c *Cache) Load(file string) (pcm.Reader, error) {
 			".mp3":  "github.com/diakovliev/oak/v4/audio/format/mp3",
 			".flac": "github.com/diakovliev/oak/v4/audio/format/flac",
 			".wav":  "github.com/diakovliev/oak/v4/audio/format/wav",
+			".ogg":  "github.com/diakovliev/oak/v4/audio/format/vorbis",
+			".oga":  "github.com/diakovliev/oak/v4/audio/format/vorbis",
 		}
 		if path, ok := knownFormats[ext]; ok {
 			dlog.Error("unable to parse audio format %v, did you mean to import %v?", ext, path)
@@ -0,0 +1,147 @@
+package ceol
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/synth"
+)
+
+// midiInstruments is the number of Bosca Ceoil instruments which mirror General MIDI programs.
+// Instruments past this are Bosca's own chiptune and drum kit instruments.
+const midiInstruments = 128
+
+// familyShapes approximate each General MIDI instrument family (8 programs each) with a waveform.
+var familyShapes = [16]synth.Shape{
+	synth.TriangleShape, // piano
+	synth.SinShape,      // chromatic percussion
+	synth.SquareShape,   // organ
+	synth.TriangleShape, // guitar
+	synth.TriangleShape, // bass
+	synth.SawShape,      // strings
+	synth.SawShape,      // ensemble
+	synth.SquareShape,   // brass
+	synth.PulseShape(4), // reed
+	synth.SinShape,      // pipe
+	synth.SawShape,      // synth lead
+	synth.TriangleShape, // synth pad
+	synth.PulseShape(8), // synth effects
+	synth.TriangleShape, // ethnic
+	synth.NoiseShape,    // percussive
+	synth.NoiseShape,    // sound effects
+}
+
+// chipShapes are cycled through for Bosca Ceoil's chiptune instruments.
+var chipShapes = []synth.Shape{
+	synth.SquareShape,
+	synth.PulseShape(4),
+	synth.PulseShape(8),
+	synth.TriangleShape,
+	synth.SawShape,
+	synth.SinShape,
+	synth.NoiseShape,
+}
+
+// instrumentFor chooses how to play a Ceol instrument. Bosca Ceoil's own instruments are
+// SiON presets, which are approximated here.
+func instrumentFor(ins Instrument, so SequencerOptions) synth.Instrument {
+	if so.Instrument != nil {
+		if si := so.Instrument(ins); si != nil {
+			return si
+		}
+	}
+	drums := ins.IsDrumkit != 0
+	if so.DLS != nil {
+		program := uint32(ins.Index)
+		if drums {
+			program = 0
+		}
+		if drums || ins.Index < midiInstruments {
+			if si, ok := so.DLS.Program(program, drums); ok {
+				return si
+			}
+		}
+	}
+	if drums {
+		return drumInstrument{}
+	}
+	if ins.Index < midiInstruments {
+		return synth.WaveInstrument{
+			Shape:  familyShapes[ins.Index/8],
+			Volume: .5,
+		}
+	}
+	return synth.WaveInstrument{
+		Shape:  chipShapes[(ins.Index-midiInstruments)%len(chipShapes)],
+		Volume: .5,
+	}
+}
+
+// drum kinds, selected by a drum note's key.
+const (
+	drumKick = iota
+	drumSnare
+	drumHat
+	drumKinds
+)
+
+// drumInstrument synthesizes simple kick, snare, and hat sounds, alternating between them by key.
+type drumInstrument struct{}
+
+func (drumInstrument) NoteOn(key, velocity int, sampleRate uint32) synth.Voice {
+	dv := &drumVoice{
+		kind:       key % drumKinds,
+		volume:     float64(velocity) / 127,
+		sampleRate: float64(sampleRate),
+	}
+	decay := 300 * time.Millisecond
+	switch dv.kind {
+	case drumSnare:
+		decay = 150 * time.Millisecond
+	case drumHat:
+		decay = 50 * time.Millisecond
+	}
+	// Decay to -60 dB over the decay time.
+	dv.decay = math.Pow(.001, 1/(decay.Seconds()*dv.sampleRate))
+	dv.level = 1
+	return dv
+}
+
+type drumVoice struct {
+	kind       int
+	volume     float64
+	sampleRate float64
+	level      float64
+	decay      float64
+	phase      float64
+	last       float64
+}
+
+func (dv *drumVoice) Next() (float64, bool) {
+	if dv.level < .001 {
+		return 0, false
+	}
+	var v float64
+	switch dv.kind {
+	case drumKick:
+		// A sine which falls in pitch as it decays
+		freq := 50 + 100*dv.level
+		dv.phase += freq / dv.sampleRate
+		v = math.Sin(dv.phase * 2 * math.Pi)
+	case drumSnare:
+		dv.phase += 200 / dv.sampleRate
+		v = .7*(rand.Float64()*2-1) + .3*math.Sin(dv.phase*2*math.Pi)
+	case drumHat:
+		// Differentiated noise, to favor high frequencies
+		n := rand.Float64()*2 - 1
+		v = (n - dv.last) / 2
+		dv.last = n
+	}
+	v *= dv.level * dv.volume
+	dv.level *= dv.decay
+	return v, true
+}
+
+// Release does nothing; drums always play out.
+func (dv *drumVoice) Release() {}
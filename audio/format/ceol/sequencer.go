@@ -0,0 +1,305 @@
+package ceol
+
+import (
+	"math"
+
+	"github.com/diakovliev/oak/v4/audio/format/dls"
+	"github.com/diakovliev/oak/v4/audio/internal/sample"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+	"github.com/diakovliev/oak/v4/audio/synth"
+	"github.com/diakovliev/oak/v4/oakerr"
+)
+
+var _ pcm.Reader = &Sequencer{}
+
+// channels is how many patterns may play at once in a Ceol arrangement.
+const channels = 8
+
+// maxVolume is the volume value at which Ceol instruments and filters play at full volume.
+const maxVolume = 256
+
+// openCutoff is the low pass cutoff value at which Ceol instruments are unfiltered.
+const openCutoff = 128
+
+// A SequencerOption sets some value on a SequencerOptions struct.
+type SequencerOption func(*SequencerOptions)
+
+// SequencerOptions define how a Ceol song is rendered.
+type SequencerOptions struct {
+	// Format is the format audio will be rendered in. Defaults to 44100 Hz, 16 bit stereo.
+	Format pcm.Format
+	// If Loop is true, the song will repeat the bars between its LoopStart and LoopEnd forever.
+	// Otherwise, the song will end after the bar before LoopEnd.
+	Loop bool
+	// Volume scales the output of the song, between 0.0 -> 1.0. Defaults to .5, to leave headroom
+	// for many notes playing at once.
+	Volume float64
+	// If DLS is provided, Ceol's MIDI instruments and drum kits will be played with instruments
+	// from it where it has them.
+	DLS *dls.DLS
+	// If Instrument is provided, it will be asked to play each of the song's instruments. If it
+	// returns nil, the default instrument will be used.
+	Instrument func(Instrument) synth.Instrument
+}
+
+func defaultSequencerOptions() SequencerOptions {
+	return SequencerOptions{
+		Format: pcm.Format{
+			SampleRate: 44100,
+			Channels:   2,
+			Bits:       16,
+		},
+		Volume: .5,
+	}
+}
+
+// WithFormat sets the format a song will be rendered in.
+func WithFormat(f pcm.Format) SequencerOption {
+	return func(so *SequencerOptions) {
+		so.Format = f
+	}
+}
+
+// Looping sets a song to loop forever.
+func Looping() SequencerOption {
+	return func(so *SequencerOptions) {
+		so.Loop = true
+	}
+}
+
+// WithVolume sets the volume a song will be rendered at.
+func WithVolume(v float64) SequencerOption {
+	return func(so *SequencerOptions) {
+		so.Volume = v
+	}
+}
+
+// WithDLS sets a DLS collection to play a song's instruments with.
+func WithDLS(d *dls.DLS) SequencerOption {
+	return func(so *SequencerOptions) {
+		so.DLS = d
+	}
+}
+
+// WithInstrument sets a function to choose how a song's instruments are played.
+func WithInstrument(fn func(Instrument) synth.Instrument) SequencerOption {
+	return func(so *SequencerOptions) {
+		so.Instrument = fn
+	}
+}
+
+// A Sequencer renders a Ceol song to PCM audio as it is read.
+type Sequencer struct {
+	pcm.Format
+	song        Ceol
+	loop        bool
+	volume      float64
+	instruments []synth.Instrument
+
+	samplesPerStep float64
+	// played is how many frames have been rendered, and nextStep is the frame at which the next
+	// step begins.
+	played, nextStep float64
+	bar, step        int
+	endBar           int
+	ended            bool
+
+	channels [channels]channel
+	voices   []activeVoice
+	renderer *sample.Renderer
+}
+
+type channel struct {
+	volume       float64
+	filter       *synth.Biquad
+	cutoff, res  int
+	instrument   int
+	sum          float64
+	hasFilterSet bool
+}
+
+type activeVoice struct {
+	voice   synth.Voice
+	channel int
+	// hold is how many more frames the voice is held before being released.
+	hold int
+}
+
+// NewSequencer creates a sequencer which plays the given song.
+func NewSequencer(c Ceol, opts ...SequencerOption) (*Sequencer, error) {
+	so := defaultSequencerOptions()
+	for _, o := range opts {
+		o(&so)
+	}
+	switch so.Format.Bits {
+	case 8, 16, 32:
+	default:
+		return nil, pcm.ErrUnsupportedBits
+	}
+	if so.Format.SampleRate == 0 || so.Format.Channels == 0 {
+		return nil, oakerr.InvalidInput{InputName: "Format"}
+	}
+	if c.Bpm <= 0 {
+		return nil, oakerr.InvalidInput{InputName: "Bpm"}
+	}
+	if c.PatternLength <= 0 {
+		return nil, oakerr.InvalidInput{InputName: "PatternLength"}
+	}
+	if c.LoopStart < 0 {
+		return nil, oakerr.InvalidInput{InputName: "LoopStart"}
+	}
+	for _, ins := range c.Instruments {
+		if ins.Index < 0 {
+			return nil, oakerr.InvalidInput{InputName: "Instruments.Index"}
+		}
+	}
+	s := &Sequencer{
+		Format:         so.Format,
+		song:           c,
+		loop:           so.Loop,
+		volume:         so.Volume,
+		samplesPerStep: float64(so.Format.SampleRate) * 60 / (float64(c.Bpm) * 4),
+	}
+	s.renderer = sample.NewRenderer(so.Format, s.render)
+	s.endBar = len(c.Arrangement)
+	if c.LoopEnd > 0 && c.LoopEnd < s.endBar {
+		s.endBar = c.LoopEnd
+	}
+	if s.endBar == 0 {
+		s.ended = true
+	}
+	for _, ins := range c.Instruments {
+		s.instruments = append(s.instruments, instrumentFor(ins, so))
+	}
+	return s, nil
+}
+
+// Position returns the bar of the song's arrangement and step within that bar which will play next.
+func (s *Sequencer) Position() (bar, step int) {
+	return s.bar, s.step
+}
+
+// ReadPCM renders the song into b.
+func (s *Sequencer) ReadPCM(b []byte) (n int, err error) {
+	return s.renderer.ReadPCM(b)
+}
+
+// render renders the next frame into frame, returning false once the song and its notes have
+// ended.
+func (s *Sequencer) render(frame []float64) bool {
+	if s.ended && len(s.voices) == 0 {
+		return false
+	}
+	for !s.ended && s.played >= s.nextStep {
+		if s.bar >= s.endBar {
+			s.end()
+			break
+		}
+		s.startStep()
+	}
+	for c := range s.channels {
+		s.channels[c].sum = 0
+	}
+	for i := 0; i < len(s.voices); i++ {
+		av := &s.voices[i]
+		if av.hold == 0 {
+			av.voice.Release()
+		}
+		av.hold--
+		v, ok := av.voice.Next()
+		if !ok {
+			s.voices = append(s.voices[:i], s.voices[i+1:]...)
+			i--
+			continue
+		}
+		s.channels[av.channel].sum += v
+	}
+	var out float64
+	for c := range s.channels {
+		ch := &s.channels[c]
+		v := ch.sum
+		if ch.filter != nil {
+			v = ch.filter.Process(v)
+		}
+		out += v * ch.volume
+	}
+	out *= s.volume
+	for i := range frame {
+		frame[i] = out
+	}
+	s.played++
+	return true
+}
+
+// startStep begins playing the notes at the current step and advances to the next step.
+func (s *Sequencer) startStep() {
+	for c, p := range s.song.Arrangement[s.bar] {
+		if p < 0 || p >= len(s.song.Patterns) {
+			continue
+		}
+		pattern := s.song.Patterns[p]
+		if pattern.Instrument < 0 || pattern.Instrument >= len(s.instruments) {
+			continue
+		}
+		ins := s.song.Instruments[pattern.Instrument]
+		volume, cutoff, res := ins.Volume, ins.LPFCutoff, ins.LPFResonance
+		if s.step < len(pattern.Filters) {
+			f := pattern.Filters[s.step]
+			volume, cutoff, res = f.Volume, f.LPFCutoff, f.LPFResonance
+		}
+		s.channels[c].set(float64(s.SampleRate), pattern.Instrument, volume, cutoff, res)
+		for _, note := range pattern.Notes {
+			if note.Offset != s.step {
+				continue
+			}
+			s.voices = append(s.voices, activeVoice{
+				voice:   s.instruments[pattern.Instrument].NoteOn(note.PitchIndex, 127, s.SampleRate),
+				channel: c,
+				hold:    int(float64(note.Length) * s.samplesPerStep),
+			})
+		}
+	}
+	s.nextStep += s.samplesPerStep
+	s.step++
+	if s.step < s.song.PatternLength {
+		return
+	}
+	s.step = 0
+	s.bar++
+	if s.bar >= s.endBar && s.loop && s.song.LoopStart < s.endBar {
+		s.bar = s.song.LoopStart
+	}
+}
+
+// end stops the song, releasing any notes still playing.
+func (s *Sequencer) end() {
+	s.ended = true
+	for i := range s.voices {
+		s.voices[i].voice.Release()
+	}
+}
+
+func (ch *channel) set(sampleRate float64, instrument, volume, cutoff, res int) {
+	ch.volume = float64(volume) / maxVolume
+	if ch.hasFilterSet && ch.instrument == instrument && ch.cutoff == cutoff && ch.res == res {
+		return
+	}
+	if ch.instrument != instrument {
+		ch.filter = nil
+	}
+	ch.instrument, ch.cutoff, ch.res = instrument, cutoff, res
+	ch.hasFilterSet = true
+	if cutoff >= openCutoff {
+		ch.filter = nil
+		return
+	}
+	// Map the cutoff exponentially from 20 Hz to 20 kHz, and the resonance from a flat
+	// response upwards.
+	freq := 20 * math.Pow(1000, float64(cutoff)/openCutoff)
+	q := math.Sqrt2/2 + float64(res)
+	if ch.filter == nil {
+		ch.filter = synth.NewLowPass(sampleRate, freq, q)
+		return
+	}
+	ch.filter.SetLowPass(sampleRate, freq, q)
+}
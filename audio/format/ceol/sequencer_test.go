@@ -0,0 +1,165 @@
+package ceol
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/audio/format/dls"
+	"github.com/diakovliev/oak/v4/audio/format/riff"
+	"github.com/diakovliev/oak/v4/audio/internal/audiotest"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+	"github.com/diakovliev/oak/v4/audio/synth"
+)
+
+func loadTestSong(t *testing.T) Ceol {
+	t.Helper()
+	f, err := os.Open("testdata/test.ceol")
+	if err != nil {
+		t.Fatalf("failed to open test file: %v", err)
+	}
+	defer f.Close()
+	c, err := Open(f)
+	if err != nil {
+		t.Fatalf("failed to parse test file: %v", err)
+	}
+	return c
+}
+
+func peak16(b []byte) int {
+	peak := 0
+	for i := 0; i+1 < len(b); i += 2 {
+		v := int(int16(uint16(b[i]) | uint16(b[i+1])<<8))
+		if v < 0 {
+			v = -v
+		}
+		if v > peak {
+			peak = v
+		}
+	}
+	return peak
+}
+
+func TestNewSequencer_Invalid(t *testing.T) {
+	c := loadTestSong(t)
+	if _, err := NewSequencer(c, WithFormat(pcm.Format{SampleRate: 44100, Channels: 2, Bits: 24})); err == nil {
+		t.Fatal("expected error for unsupported bits")
+	}
+	bad := c
+	bad.Bpm = 0
+	if _, err := NewSequencer(bad); err == nil {
+		t.Fatal("expected error for zero bpm")
+	}
+	bad = c
+	bad.LoopStart = -1
+	if _, err := NewSequencer(bad, Looping()); err == nil {
+		t.Fatal("expected error for negative loop start")
+	}
+	bad = c
+	bad.Instruments = append([]Instrument{}, c.Instruments...)
+	bad.Instruments[0].Index = -8
+	if _, err := NewSequencer(bad); err == nil {
+		t.Fatal("expected error for negative instrument index")
+	}
+}
+
+func TestSequencer(t *testing.T) {
+	c := loadTestSong(t)
+	f := pcm.Format{SampleRate: 22050, Channels: 2, Bits: 16}
+	s, err := NewSequencer(c, WithFormat(f))
+	if err != nil {
+		t.Fatalf("failed to create sequencer: %v", err)
+	}
+	if s.PCMFormat() != f {
+		t.Fatalf("format mismatch: got %v expected %v", s.PCMFormat(), f)
+	}
+	bars := len(c.Arrangement)
+	if c.LoopEnd > 0 && c.LoopEnd < bars {
+		bars = c.LoopEnd
+	}
+	songFrames := int(float64(bars*c.PatternLength) * float64(f.SampleRate) * 60 / float64(c.Bpm*4))
+	out := audiotest.ReadAll(t, s, songFrames*int(f.SampleSize())*2)
+	frames := len(out) / int(f.SampleSize())
+	if len(out)%int(f.SampleSize()) != 0 {
+		t.Fatalf("read a partial frame: %v bytes", len(out))
+	}
+	if frames < songFrames {
+		t.Fatalf("song ended early: got %v frames expected at least %v", frames, songFrames)
+	}
+	if frames > songFrames+int(f.SampleRate) {
+		t.Fatalf("song did not end: got %v frames expected about %v", frames, songFrames)
+	}
+	if peak16(out) == 0 {
+		t.Fatal("song was silent")
+	}
+	if _, err := s.ReadPCM(make([]byte, 4)); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF after song ended, got %v", err)
+	}
+}
+
+func TestSequencer_Looping(t *testing.T) {
+	c := loadTestSong(t)
+	s, err := NewSequencer(c, Looping())
+	if err != nil {
+		t.Fatalf("failed to create sequencer: %v", err)
+	}
+	bars := len(c.Arrangement)
+	if c.LoopEnd > 0 && c.LoopEnd < bars {
+		bars = c.LoopEnd
+	}
+	songFrames := int(float64(bars*c.PatternLength) * float64(s.SampleRate) * 60 / float64(c.Bpm*4))
+	want := 2 * songFrames * int(s.SampleSize())
+	out := audiotest.ReadAll(t, s, want)
+	if len(out) < want {
+		t.Fatalf("looping song ended: read %v bytes", len(out))
+	}
+	if peak16(out[len(out)/2:]) == 0 {
+		t.Fatal("looped song was silent")
+	}
+}
+
+func TestSequencer_Options(t *testing.T) {
+	c := loadTestSong(t)
+	var asked []int
+	s, err := NewSequencer(c, WithVolume(0), WithInstrument(func(ins Instrument) synth.Instrument {
+		asked = append(asked, ins.Index)
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("failed to create sequencer: %v", err)
+	}
+	if len(asked) != len(c.Instruments) {
+		t.Fatalf("expected instrument func to be called for %v instruments, got %v", len(c.Instruments), len(asked))
+	}
+	if peak16(audiotest.ReadAll(t, s, 44100*4)) != 0 {
+		t.Fatal("expected silence at zero volume")
+	}
+}
+
+func TestSequencer_DLS(t *testing.T) {
+	data, err := os.ReadFile("../dls/testdata/SanbikiSCC.dls")
+	if err != nil {
+		t.Fatalf("failed to open dls: %v", err)
+	}
+	d := &dls.DLS{}
+	// This file has trailing data the riff reader does not expect, but its instruments
+	// and waves are still read.
+	riff.Unmarshal(data, d)
+	if len(d.Lins) == 0 {
+		t.Fatal("failed to read dls instruments")
+	}
+	c := loadTestSong(t)
+	c.Instruments = append([]Instrument{}, c.Instruments...)
+	for i := range c.Instruments {
+		// Use MIDI instruments, which the DLS can provide
+		c.Instruments[i].Index = i
+	}
+	s, err := NewSequencer(c, WithDLS(d))
+	if err != nil {
+		t.Fatalf("failed to create sequencer: %v", err)
+	}
+	if peak16(audiotest.ReadAll(t, s, 44100*4*2)) == 0 {
+		t.Fatal("song was silent")
+	}
+}
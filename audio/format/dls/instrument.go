@@ -0,0 +1,139 @@
+package dls
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/synth"
+)
+
+// DrumBank is set in an instrument's UlBank when it is a drum kit.
+const DrumBank = 0x80000000
+
+// release is how long DLS notes take to fade out once released. Articulation data is not yet
+// parsed, so all instruments share it.
+const release = 150 * time.Millisecond
+
+// ParseWaveSample parses the content of a 'wsmp' chunk.
+func ParseWaveSample(b []byte) (WaveSample, bool) {
+	if len(b) < 20 {
+		return WaveSample{}, false
+	}
+	ws := WaveSample{
+		CbSize:       binary.LittleEndian.Uint32(b[0:4]),
+		UsUnityNote:  binary.LittleEndian.Uint16(b[4:6]),
+		SFineTune:    int16(binary.LittleEndian.Uint16(b[6:8])),
+		LGain:        int32(binary.LittleEndian.Uint32(b[8:12])),
+		FulOptions:   binary.LittleEndian.Uint32(b[12:16]),
+		CSampleLoops: binary.LittleEndian.Uint32(b[16:20]),
+	}
+	off := int(ws.CbSize)
+	if off < 20 {
+		off = 20
+	}
+	for i := 0; i < int(ws.CSampleLoops) && off+16 <= len(b); i++ {
+		ws.WaveSampleLoop = append(ws.WaveSampleLoop, WaveSampleLoop{
+			CbSize:       binary.LittleEndian.Uint32(b[off : off+4]),
+			UlLoopType:   binary.LittleEndian.Uint32(b[off+4 : off+8]),
+			UlLoopStart:  binary.LittleEndian.Uint32(b[off+8 : off+12]),
+			UlLoopLength: binary.LittleEndian.Uint32(b[off+12 : off+16]),
+		})
+		off += 16
+	}
+	return ws, true
+}
+
+// Instrument returns a playable instrument for the DLS instrument in the given bank with the given
+// program number. Drum kits are found by including DrumBank in bank.
+func (d *DLS) Instrument(bank, program uint32) (synth.Instrument, bool) {
+	for i := range d.Lins {
+		loc := d.Lins[i].Insh.Locale
+		if loc.UlBank == bank && loc.UlInstrument == program {
+			return d.instrument(&d.Lins[i]), true
+		}
+	}
+	return nil, false
+}
+
+// Program returns a playable instrument for the first DLS instrument in any bank with the given
+// program number, preferring lower banks.
+func (d *DLS) Program(program uint32, drums bool) (synth.Instrument, bool) {
+	var found *Ins
+	for i := range d.Lins {
+		loc := d.Lins[i].Insh.Locale
+		if loc.UlInstrument != program || (loc.UlBank&DrumBank != 0) != drums {
+			continue
+		}
+		if found == nil || loc.UlBank < found.Insh.Locale.UlBank {
+			found = &d.Lins[i]
+		}
+	}
+	if found == nil {
+		return nil, false
+	}
+	return d.instrument(found), true
+}
+
+func (d *DLS) instrument(ins *Ins) synth.Instrument {
+	si := synth.SampleInstrument{}
+	for _, rgn := range ins.Lrgn {
+		idx := int(rgn.Wlnk.UlTableIndex)
+		if idx >= len(d.Wvpl) {
+			continue
+		}
+		wave := &d.Wvpl[idx]
+		ws, ok := ParseWaveSample(rgn.Wsmp)
+		if !ok {
+			ws, ok = ParseWaveSample(wave.Wsmp)
+		}
+		if !ok {
+			ws = WaveSample{UsUnityNote: 60}
+		}
+		smp := &synth.Sample{
+			Data:       decodeWave(wave),
+			SampleRate: float64(wave.Fmt.SampleRate),
+			Unity:      float64(ws.UsUnityNote) - float64(ws.SFineTune)/100,
+			Gain:       math.Pow(10, float64(ws.LGain)/65536/20),
+			Release:    release,
+		}
+		if len(ws.WaveSampleLoop) != 0 {
+			l := ws.WaveSampleLoop[0]
+			smp.LoopStart = int(l.UlLoopStart)
+			smp.LoopEnd = int(l.UlLoopStart + l.UlLoopLength)
+		}
+		si.Regions = append(si.Regions, synth.Region{
+			KeyLow:  int(rgn.Rgnh.RangeKey.UsLow),
+			KeyHigh: int(rgn.Rgnh.RangeKey.UsHigh),
+			VelLow:  int(rgn.Rgnh.RangeVelocity.UsLow),
+			VelHigh: int(rgn.Rgnh.RangeVelocity.UsHigh),
+			Sample:  smp,
+		})
+	}
+	return si
+}
+
+// decodeWave converts a wave's first channel to values between -1.0 and 1.0.
+func decodeWave(w *Wave) []float64 {
+	channels := int(w.Fmt.NumChannels)
+	if channels == 0 {
+		channels = 1
+	}
+	switch w.Fmt.BitsPerSample {
+	case 8:
+		// 8 bit wave data is unsigned
+		out := make([]float64, len(w.Data)/channels)
+		for i := range out {
+			out[i] = (float64(w.Data[i*channels]) - 128) / 128
+		}
+		return out
+	case 16:
+		out := make([]float64, len(w.Data)/2/channels)
+		for i := range out {
+			off := i * 2 * channels
+			out[i] = float64(int16(binary.LittleEndian.Uint16(w.Data[off:off+2]))) / 32768
+		}
+		return out
+	}
+	return nil
+}
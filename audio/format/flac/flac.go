@@ -8,11 +8,14 @@
 package flac
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/diakovliev/oak/v4/audio/format"
 	"github.com/diakovliev/oak/v4/audio/pcm"
+	"github.com/diakovliev/oak/v4/oakerr"
 	"github.com/eaburns/flac"
 )
 
@@ -20,45 +23,117 @@ func init() {
 	format.Register(".flac", Load)
 }
 
+var _ pcm.ReadSeeker = &Reader{}
+
 // Load reads a FLAC header from a reader, parsing it's PCM format and returning
 // a pcm Reader for the data following the header. It will error if the reader
 // does not contain enough data to fill a FLAC header or if the header does not
-// look like a FLAC header.
+// look like a FLAC header. If r is an io.Seeker, the returned Reader can seek.
 func Load(r io.Reader) (pcm.Reader, error) {
 	d, err := flac.NewDecoder(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load flac: %w", err)
 	}
-
-	return &pcm.IOReader{
+	fr := &Reader{
 		Format: pcm.Format{
 			SampleRate: uint32(d.SampleRate),
 			Channels:   uint16(d.NChannels),
 			Bits:       uint16(d.BitsPerSample),
 		},
-		Reader: &reader{d: d},
-	}, nil
+		d: d,
+	}
+	if s, ok := r.(io.Seeker); ok {
+		if start, err := s.Seek(0, io.SeekCurrent); err == nil {
+			fr.seeker = s
+			fr.frames = []frameStart{{offset: start}}
+		}
+	}
+	return fr, nil
 }
 
-type reader struct {
+// A Reader decodes FLAC audio as it is read.
+type Reader struct {
+	pcm.Format
 	d         *flac.Decoder
 	readAhead []byte
+
+	seeker io.Seeker
+	// frames holds the start of every frame decoded so far, in order, so that
+	// seeking backwards does not require decoding from the start of the file.
+	frames []frameStart
+	// next is the sample frame at which the next decoded flac frame begins.
+	next int64
 }
 
-func (r *reader) Read(data []byte) (int, error) {
+type frameStart struct {
+	offset int64
+	sample int64
+}
+
+func (r *Reader) ReadPCM(data []byte) (int, error) {
 	if len(r.readAhead) == 0 {
-		read, err := r.d.Next()
+		read, err := r.nextFrame()
 		if err != nil {
 			return 0, err
 		}
 		r.readAhead = read
 	}
-	copy(data, r.readAhead)
-	if len(r.readAhead) < len(data) {
-		n := len(r.readAhead)
-		r.readAhead = []byte{}
-		return n, nil
+	n := copy(data, r.readAhead)
+	r.readAhead = r.readAhead[n:]
+	return n, nil
+}
+
+func (r *Reader) nextFrame() ([]byte, error) {
+	read, err := r.d.Next()
+	if err != nil {
+		return nil, err
+	}
+	r.next += int64(len(read) / r.SampleSize())
+	if r.seeker != nil && r.next > r.frames[len(r.frames)-1].sample {
+		offset, err := r.seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		r.frames = append(r.frames, frameStart{offset: offset, sample: r.next})
+	}
+	return read, nil
+}
+
+// SeekPCM moves the reader to the given sample frame. It will error if the reader was not loaded from an
+// io.Seeker, or if frame is past the end of the audio.
+func (r *Reader) SeekPCM(frame int64) error {
+	if r.seeker == nil {
+		return oakerr.UnsupportedFormat{Format: "unseekable flac"}
+	}
+	if frame < 0 || (r.d.TotalSamples != 0 && frame > r.d.TotalSamples) {
+		return oakerr.InvalidInput{InputName: "frame"}
+	}
+	// Resume from the last known frame starting at or before the target, then decode forward.
+	i := sort.Search(len(r.frames), func(i int) bool {
+		return r.frames[i].sample > frame
+	}) - 1
+	start := r.frames[i]
+	if _, err := r.seeker.Seek(start.offset, io.SeekStart); err != nil {
+		return err
+	}
+	r.next = start.sample
+	r.readAhead = nil
+	for {
+		from := r.next
+		read, err := r.nextFrame()
+		if errors.Is(err, io.EOF) {
+			if r.next < frame {
+				// The stream did not know its length, and ended before frame.
+				return oakerr.InvalidInput{InputName: "frame"}
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if r.next > frame {
+			r.readAhead = read[(frame-from)*int64(r.SampleSize()):]
+			return nil
+		}
 	}
-	r.readAhead = r.readAhead[len(data):]
-	return len(data), nil
 }
@@ -0,0 +1,314 @@
+// Package midi provides functionality to handle .mid files (Standard MIDI Files) and to play them
+// with synthesized instruments.
+package midi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Channel event kinds, as found in the high nibble of an event's Status.
+const (
+	NoteOff         byte = 0x80
+	NoteOn          byte = 0x90
+	KeyPressure     byte = 0xA0
+	ControlChange   byte = 0xB0
+	ProgramChange   byte = 0xC0
+	ChannelPressure byte = 0xD0
+	PitchBend       byte = 0xE0
+)
+
+// Statuses of events which are not channel events.
+const (
+	StatusSysEx       byte = 0xF0
+	StatusSysExEscape byte = 0xF7
+	StatusMeta        byte = 0xFF
+)
+
+// Meta event types.
+const (
+	MetaText       byte = 0x01
+	MetaTrackName  byte = 0x03
+	MetaEndOfTrack byte = 0x2F
+	MetaTempo      byte = 0x51
+	MetaTimeSig    byte = 0x58
+	MetaKeySig     byte = 0x59
+)
+
+// defaultTempo is the tempo of a file until it sets one, in microseconds per quarter note (120 bpm).
+const defaultTempo = 500000
+
+// A File is a parsed Standard MIDI File.
+type File struct {
+	// Format is 0 for a single track, 1 for simultaneous tracks, and 2 for independent tracks.
+	Format uint16
+	// Division is the number of ticks per quarter note, unless its high bit is set, in which
+	// case its high byte is a negative SMPTE frame rate and its low byte ticks per frame.
+	Division uint16
+	Tracks   []Track
+}
+
+// A Track is a sequence of events.
+type Track []Event
+
+// An Event is a single MIDI event within a track.
+type Event struct {
+	// Delta is how many ticks after the previous event in its track this event occurs.
+	Delta uint32
+	// Status is the event's status byte. Channel events hold their channel in its low nibble.
+	Status byte
+	// Meta is the type of a meta event, when Status is StatusMeta.
+	Meta byte
+	// Data holds the one or two parameters of channel events, or the content of meta and
+	// system exclusive events.
+	Data []byte
+}
+
+// Kind returns the kind of a channel event, such as NoteOn, or the Status of other events.
+func (e Event) Kind() byte {
+	if e.Status >= 0xF0 {
+		return e.Status
+	}
+	return e.Status & 0xF0
+}
+
+// Channel returns the channel, from 0 to 15, of a channel event.
+func (e Event) Channel() int {
+	return int(e.Status & 0x0F)
+}
+
+// Tempo returns the tempo set by a MetaTempo event, in microseconds per quarter note.
+func (e Event) Tempo() (uint32, bool) {
+	if e.Status != StatusMeta || e.Meta != MetaTempo || len(e.Data) < 3 {
+		return 0, false
+	}
+	return uint32(e.Data[0])<<16 | uint32(e.Data[1])<<8 | uint32(e.Data[2]), true
+}
+
+// Open parses a Standard MIDI File.
+func Open(r io.Reader) (File, error) {
+	var header [14]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return File{}, fmt.Errorf("failed to read midi header: %w", err)
+	}
+	if string(header[:4]) != "MThd" {
+		return File{}, errors.New("midi: missing MThd header")
+	}
+	hdrLen := binary.BigEndian.Uint32(header[4:8])
+	if hdrLen < 6 {
+		return File{}, errors.New("midi: MThd header is too short")
+	}
+	f := File{
+		Format:   binary.BigEndian.Uint16(header[8:10]),
+		Division: binary.BigEndian.Uint16(header[12:14]),
+	}
+	trackCount := int(binary.BigEndian.Uint16(header[10:12]))
+	if f.Division == 0 {
+		return File{}, errors.New("midi: division is zero")
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(hdrLen-6)); err != nil {
+		return File{}, fmt.Errorf("failed to read midi header: %w", err)
+	}
+	for len(f.Tracks) < trackCount {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return File{}, fmt.Errorf("failed to read midi track: %w", err)
+		}
+		size := int64(binary.BigEndian.Uint32(chunk[4:8]))
+		// Unknown chunk types should be skipped.
+		if string(chunk[:4]) != "MTrk" {
+			if _, err := io.CopyN(io.Discard, r, size); err != nil {
+				return File{}, fmt.Errorf("failed to read midi chunk: %w", unexpectedEOF(err))
+			}
+			continue
+		}
+		// The chunk's length is not trusted to size an allocation; the buffer only grows as
+		// data actually arrives.
+		var data bytes.Buffer
+		if _, err := io.CopyN(&data, r, size); err != nil {
+			return File{}, fmt.Errorf("failed to read midi track: %w", unexpectedEOF(err))
+		}
+		t, err := parseTrack(data.Bytes())
+		if err != nil {
+			return File{}, err
+		}
+		f.Tracks = append(f.Tracks, t)
+	}
+	return f, nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func readVarLen(b []byte) (uint32, int, error) {
+	var v uint32
+	for i := 0; i < 4 && i < len(b); i++ {
+		v = v<<7 | uint32(b[i]&0x7F)
+		if b[i]&0x80 == 0 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("midi: invalid variable length quantity")
+}
+
+func parseTrack(b []byte) (Track, error) {
+	var t Track
+	var running byte
+	for len(b) > 0 {
+		delta, n, err := readVarLen(b)
+		if err != nil {
+			return nil, err
+		}
+		b = b[n:]
+		if len(b) == 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		e := Event{Delta: delta}
+		status := b[0]
+		if status&0x80 != 0 {
+			b = b[1:]
+		} else if running == 0 {
+			return nil, errors.New("midi: data byte without running status")
+		} else {
+			status = running
+		}
+		e.Status = status
+		switch {
+		case status == StatusMeta:
+			if len(b) == 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			e.Meta = b[0]
+			ln, n, err := readVarLen(b[1:])
+			if err != nil {
+				return nil, err
+			}
+			b = b[1+n:]
+			if int(ln) > len(b) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			e.Data, b = b[:ln], b[ln:]
+		case status == StatusSysEx || status == StatusSysExEscape:
+			ln, n, err := readVarLen(b)
+			if err != nil {
+				return nil, err
+			}
+			b = b[n:]
+			if int(ln) > len(b) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			e.Data, b = b[:ln], b[ln:]
+		case status >= 0xF0:
+			return nil, fmt.Errorf("midi: unexpected status %#x in track", status)
+		default:
+			running = status
+			size := 2
+			if kind := status & 0xF0; kind == ProgramChange || kind == ChannelPressure {
+				size = 1
+			}
+			if len(b) < size {
+				return nil, io.ErrUnexpectedEOF
+			}
+			e.Data, b = b[:size], b[size:]
+		}
+		t = append(t, e)
+		if e.Status == StatusMeta && e.Meta == MetaEndOfTrack {
+			break
+		}
+	}
+	return t, nil
+}
+
+// Duration returns how long the file takes to play, following its tempo changes.
+func (f File) Duration() time.Duration {
+	var d time.Duration
+	for _, te := range f.timeline() {
+		d += te.wait
+	}
+	return d
+}
+
+// A timedEvent is an event merged from every track of a file, and the time between it and
+// the previous event.
+type timedEvent struct {
+	Event
+	wait time.Duration
+}
+
+// timeline merges the file's tracks into a single sequence of events, applying tempo changes.
+// Format 2 files have independent tracks, which are played one after another.
+func (f File) timeline() []timedEvent {
+	if f.Format == 2 {
+		var out []timedEvent
+		for _, t := range f.Tracks {
+			out = append(out, File{Division: f.Division, Tracks: []Track{t}}.timeline()...)
+		}
+		return out
+	}
+	type cursor struct {
+		idx  int
+		next uint64
+	}
+	cursors := make([]cursor, len(f.Tracks))
+	for i, t := range f.Tracks {
+		if len(t) != 0 {
+			cursors[i].next = uint64(t[0].Delta)
+		}
+	}
+	var out []timedEvent
+	var tick uint64
+	var tempo uint32 = defaultTempo
+	var carry time.Duration
+	for {
+		best := -1
+		for i, c := range cursors {
+			if c.idx >= len(f.Tracks[i]) {
+				continue
+			}
+			if best == -1 || c.next < cursors[best].next {
+				best = i
+			}
+		}
+		if best == -1 {
+			return out
+		}
+		c := &cursors[best]
+		e := f.Tracks[best][c.idx]
+		wait := f.tickDuration(c.next-tick, tempo) + carry
+		carry = 0
+		tick = c.next
+		c.idx++
+		if c.idx < len(f.Tracks[best]) {
+			c.next += uint64(f.Tracks[best][c.idx].Delta)
+		}
+		if t, ok := e.Tempo(); ok && t != 0 {
+			tempo = t
+		}
+		if e.Status == StatusMeta && e.Meta != MetaEndOfTrack {
+			// Other meta events have no effect on playback; their time carries to the next event.
+			carry = wait
+			continue
+		}
+		out = append(out, timedEvent{Event: e, wait: wait})
+	}
+}
+
+func (f File) tickDuration(ticks uint64, tempo uint32) time.Duration {
+	if f.Division&0x8000 != 0 {
+		fps := uint64(-int8(f.Division >> 8))
+		perFrame := uint64(f.Division & 0xFF)
+		if fps == 0 || perFrame == 0 {
+			return 0
+		}
+		return time.Duration(ticks * uint64(time.Second) / (fps * perFrame))
+	}
+	return time.Duration(ticks*uint64(tempo)/uint64(f.Division)) * time.Microsecond
+}
@@ -0,0 +1,244 @@
+package midi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/format/dls"
+	"github.com/diakovliev/oak/v4/audio/format/sf2"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+	"github.com/diakovliev/oak/v4/audio/synth"
+)
+
+var (
+	_ Bank = &dls.DLS{}
+	_ Bank = &sf2.SoundFont{}
+)
+
+func smf(format uint16, division uint16, tracks ...[]byte) []byte {
+	b := []byte("MThd\x00\x00\x00\x06\x00\x00\x00\x00\x00\x00")
+	binary.BigEndian.PutUint16(b[8:], format)
+	binary.BigEndian.PutUint16(b[10:], uint16(len(tracks)))
+	binary.BigEndian.PutUint16(b[12:], division)
+	for _, t := range tracks {
+		chunk := []byte("MTrk\x00\x00\x00\x00")
+		binary.BigEndian.PutUint32(chunk[4:], uint32(len(t)))
+		b = append(b, chunk...)
+		b = append(b, t...)
+	}
+	return b
+}
+
+var endOfTrack = []byte{0x00, 0xFF, 0x2F, 0x00}
+
+// testFile has a tempo track at 240 bpm and a track which plays middle C on channel 0 with
+// program 5 for one quarter note (a quarter second), using running status for the note off.
+func testFile() []byte {
+	tempo := append([]byte{0x00, 0xFF, 0x51, 0x03, 0x03, 0xD0, 0x90}, endOfTrack...)
+	notes := append([]byte{
+		0x00, 0xC0, 0x05,
+		0x00, 0x90, 60, 100,
+		0x60, 60, 0,
+	}, endOfTrack...)
+	return smf(1, 96, tempo, notes)
+}
+
+func TestOpen(t *testing.T) {
+	f, err := Open(bytes.NewReader(testFile()))
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	if f.Format != 1 || f.Division != 96 || len(f.Tracks) != 2 {
+		t.Fatalf("unexpected header: %+v", f)
+	}
+	if tempo, ok := f.Tracks[0][0].Tempo(); !ok || tempo != 250000 {
+		t.Fatalf("expected tempo 250000, got %v %v", tempo, ok)
+	}
+	notes := f.Tracks[1]
+	if len(notes) != 4 {
+		t.Fatalf("expected 4 events, got %v", len(notes))
+	}
+	if notes[0].Kind() != ProgramChange || notes[0].Data[0] != 5 {
+		t.Fatalf("expected program change, got %+v", notes[0])
+	}
+	off := notes[2]
+	if off.Kind() != NoteOn || off.Channel() != 0 || off.Delta != 96 || off.Data[1] != 0 {
+		t.Fatalf("expected running status note on with zero velocity, got %+v", off)
+	}
+	if d := f.Duration(); d != 250*time.Millisecond {
+		t.Fatalf("expected duration 250ms, got %v", d)
+	}
+}
+
+func TestOpen_Invalid(t *testing.T) {
+	if _, err := Open(bytes.NewReader([]byte("RIFF\x00\x00\x00\x06\x00\x00\x00\x01\x00\x60"))); err == nil {
+		t.Fatal("expected error for missing header")
+	}
+	b := testFile()
+	if _, err := Open(bytes.NewReader(b[:len(b)-3])); err == nil {
+		t.Fatal("expected error for truncated track")
+	}
+	if _, err := Open(bytes.NewReader(smf(0, 96, []byte{0x00, 60, 100}))); err == nil {
+		t.Fatal("expected error for data without running status")
+	}
+	huge := smf(0, 96, endOfTrack)
+	binary.BigEndian.PutUint32(huge[18:], 0xFFFFFFFF)
+	if _, err := Open(bytes.NewReader(huge)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected unexpected EOF for oversized track length, got %v", err)
+	}
+}
+
+type testBank struct {
+	requested []uint32
+}
+
+func (tb *testBank) Program(program uint32, drums bool) (synth.Instrument, bool) {
+	if drums {
+		return nil, false
+	}
+	tb.requested = append(tb.requested, program)
+	return &testInstrument{}, true
+}
+
+type testInstrument struct {
+	voices []*testVoice
+}
+
+func (ti *testInstrument) NoteOn(key, velocity int, sampleRate uint32) synth.Voice {
+	tv := &testVoice{key: key, velocity: velocity}
+	ti.voices = append(ti.voices, tv)
+	return tv
+}
+
+type testVoice struct {
+	key, velocity int
+	bend          float64
+	released      bool
+	fade          int
+}
+
+func (tv *testVoice) Next() (float64, bool) {
+	if tv.released {
+		tv.fade++
+		if tv.fade > 10 {
+			return 0, false
+		}
+	}
+	return float64(tv.velocity) / 127, true
+}
+
+func (tv *testVoice) Release()               { tv.released = true }
+func (tv *testVoice) Bend(semitones float64) { tv.bend = semitones }
+
+func TestSequencer(t *testing.T) {
+	f, err := Open(bytes.NewReader(testFile()))
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	bank := &testBank{}
+	format := pcm.Format{SampleRate: 8000, Channels: 1, Bits: 16}
+	s, err := NewSequencer(f, WithFormat(format), WithBank(bank))
+	if err != nil {
+		t.Fatalf("failed to create sequencer: %v", err)
+	}
+	var out []byte
+	buf := make([]byte, 333)
+	for {
+		n, err := s.ReadPCM(buf)
+		out = append(out, buf[:n]...)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if len(out) > 8000*2 {
+			t.Fatal("sequencer did not end")
+		}
+	}
+	frames := len(out) / 2
+	if frames < 2000 || frames > 2020 {
+		t.Fatalf("expected about 2000 frames, got %v", frames)
+	}
+	found := false
+	for _, p := range bank.requested {
+		if p == 5 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected program 5 to be requested, got %v", bank.requested)
+	}
+	if v := int16(binary.LittleEndian.Uint16(out[1000:])); v == 0 {
+		t.Fatal("expected note to sound")
+	}
+}
+
+func TestSequencer_Controls(t *testing.T) {
+	ins := &testInstrument{}
+	s, err := NewSequencer(File{Division: 96}, WithFormat(pcm.Format{SampleRate: 8000, Channels: 2, Bits: 16}))
+	if err != nil {
+		t.Fatalf("failed to create sequencer: %v", err)
+	}
+	s.channels[1].instrument = ins
+	s.handle(Event{Status: ControlChange | 1, Data: []byte{ccSustain, 127}})
+	s.handle(Event{Status: NoteOn | 1, Data: []byte{60, 127}})
+	s.handle(Event{Status: NoteOff | 1, Data: []byte{60, 0}})
+	if ins.voices[0].released {
+		t.Fatal("expected sustain to hold note")
+	}
+	// Bend up by the full default range of two semitones
+	s.handle(Event{Status: PitchBend | 1, Data: []byte{0x7F, 0x7F}})
+	if b := ins.voices[0].bend; b < 1.99 || b > 2 {
+		t.Fatalf("expected bend of 2 semitones, got %v", b)
+	}
+	// Set the bend range to 12 semitones
+	s.handle(Event{Status: ControlChange | 1, Data: []byte{ccRPNMSB, 0}})
+	s.handle(Event{Status: ControlChange | 1, Data: []byte{ccRPNLSB, 0}})
+	s.handle(Event{Status: ControlChange | 1, Data: []byte{ccDataEntry, 12}})
+	s.handle(Event{Status: NoteOn | 1, Data: []byte{64, 80}})
+	s.handle(Event{Status: PitchBend | 1, Data: []byte{0x00, 0x00}})
+	if b := ins.voices[1].bend; b != -12 {
+		t.Fatalf("expected bend of -12 semitones, got %v", b)
+	}
+	if ins.voices[1].velocity != 80 {
+		t.Fatalf("expected velocity 80, got %v", ins.voices[1].velocity)
+	}
+	s.handle(Event{Status: ControlChange | 1, Data: []byte{ccSustain, 0}})
+	if !ins.voices[0].released {
+		t.Fatal("expected releasing sustain to release note")
+	}
+	if ins.voices[1].released {
+		t.Fatal("expected held note to keep playing")
+	}
+	// Pan hard left
+	s.handle(Event{Status: ControlChange | 1, Data: []byte{ccPan, 0}})
+	frame := make([]float64, 2)
+	s.render(frame)
+	if frame[0] == 0 || frame[1] != 0 {
+		t.Fatalf("expected left panned output, got %v", frame)
+	}
+	s.handle(Event{Status: ControlChange | 1, Data: []byte{ccAllNotesOff, 0}})
+	if !ins.voices[1].released {
+		t.Fatal("expected all notes off to release note")
+	}
+}
+
+func TestSequencer_MaxVoices(t *testing.T) {
+	ins := &testInstrument{}
+	s, err := NewSequencer(File{Division: 96}, WithMaxVoices(2))
+	if err != nil {
+		t.Fatalf("failed to create sequencer: %v", err)
+	}
+	s.channels[0].instrument = ins
+	for key := 60; key < 64; key++ {
+		s.handle(Event{Status: NoteOn, Data: []byte{byte(key), 100}})
+	}
+	if len(s.voices) != 2 || s.voices[0].key != 62 || s.voices[1].key != 63 {
+		t.Fatalf("expected newest two voices to remain, got %+v", s.voices)
+	}
+}
@@ -0,0 +1,447 @@
+package midi
+
+import (
+	"math"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/internal/sample"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+	"github.com/diakovliev/oak/v4/audio/synth"
+	"github.com/diakovliev/oak/v4/oakerr"
+)
+
+var _ pcm.Reader = &Sequencer{}
+
+// channels is the number of MIDI channels.
+const channels = 16
+
+// drumChannel is the channel General MIDI reserves for percussion.
+const drumChannel = 9
+
+// Controllers the sequencer responds to.
+const (
+	ccDataEntry          = 6
+	ccVolume             = 7
+	ccPan                = 10
+	ccExpression         = 11
+	ccSustain            = 64
+	ccRPNLSB             = 100
+	ccRPNMSB             = 101
+	ccAllSoundOff        = 120
+	ccResetAllController = 121
+	ccAllNotesOff        = 123
+)
+
+// A Bank provides instruments by General MIDI program number. Both *dls.DLS and *sf2.SoundFont
+// are Banks.
+type Bank interface {
+	Program(program uint32, drums bool) (synth.Instrument, bool)
+}
+
+// A SequencerOption sets some value on a SequencerOptions struct.
+type SequencerOption func(*SequencerOptions)
+
+// SequencerOptions define how a MIDI file is rendered.
+type SequencerOptions struct {
+	// Format is the format audio will be rendered in. Defaults to 44100 Hz, 16 bit stereo.
+	Format pcm.Format
+	// If Loop is true, the file will repeat forever.
+	Loop bool
+	// Volume scales the output of the file, between 0.0 -> 1.0. Defaults to .5, to leave headroom
+	// for many notes playing at once.
+	Volume float64
+	// Banks are searched in order for each program the file uses. Melodic programs no bank
+	// provides are played with a triangle wave; missing drum kits are silent.
+	Banks []Bank
+	// MaxVoices is how many notes may sound at once. When exceeded, the oldest note is cut off.
+	// Defaults to 64.
+	MaxVoices int
+}
+
+func defaultSequencerOptions() SequencerOptions {
+	return SequencerOptions{
+		Format: pcm.Format{
+			SampleRate: 44100,
+			Channels:   2,
+			Bits:       16,
+		},
+		Volume:    .5,
+		MaxVoices: 64,
+	}
+}
+
+// WithFormat sets the format a file will be rendered in.
+func WithFormat(f pcm.Format) SequencerOption {
+	return func(so *SequencerOptions) {
+		so.Format = f
+	}
+}
+
+// Looping sets a file to loop forever.
+func Looping() SequencerOption {
+	return func(so *SequencerOptions) {
+		so.Loop = true
+	}
+}
+
+// WithVolume sets the volume a file will be rendered at.
+func WithVolume(v float64) SequencerOption {
+	return func(so *SequencerOptions) {
+		so.Volume = v
+	}
+}
+
+// WithBank adds a bank of instruments to play a file with.
+func WithBank(b Bank) SequencerOption {
+	return func(so *SequencerOptions) {
+		so.Banks = append(so.Banks, b)
+	}
+}
+
+// WithMaxVoices sets how many notes may sound at once.
+func WithMaxVoices(max int) SequencerOption {
+	return func(so *SequencerOptions) {
+		so.MaxVoices = max
+	}
+}
+
+// A Sequencer renders a MIDI file to PCM audio as it is read.
+type Sequencer struct {
+	pcm.Format
+	events    []timedEvent
+	loop      bool
+	volume    float64
+	banks     []Bank
+	maxVoices int
+
+	instruments map[programKey]synth.Instrument
+
+	next int
+	// elapsed is the time at which the next event occurs, and played is how many frames
+	// have been rendered.
+	elapsed time.Duration
+	played  int64
+	ended   bool
+
+	channels [channels]channel
+	voices   []activeVoice
+	renderer *sample.Renderer
+}
+
+type programKey struct {
+	program uint8
+	drums   bool
+}
+
+type channel struct {
+	instrument synth.Instrument
+	volume     uint8
+	expression uint8
+	pan        uint8
+	sustain    bool
+	rpn        uint16
+	// bendRange is how many semitones a full pitch bend moves, and bend is the current bend.
+	bendRange float64
+	bend      float64
+}
+
+type activeVoice struct {
+	voice   synth.Voice
+	channel int
+	key     int
+	// sustained voices have been released while the sustain pedal was held.
+	sustained bool
+	released  bool
+}
+
+// NewSequencer creates a sequencer which plays the given file.
+func NewSequencer(f File, opts ...SequencerOption) (*Sequencer, error) {
+	so := defaultSequencerOptions()
+	for _, o := range opts {
+		o(&so)
+	}
+	switch so.Format.Bits {
+	case 8, 16, 32:
+	default:
+		return nil, pcm.ErrUnsupportedBits
+	}
+	if so.Format.SampleRate == 0 || so.Format.Channels == 0 {
+		return nil, oakerr.InvalidInput{InputName: "Format"}
+	}
+	if so.MaxVoices <= 0 {
+		return nil, oakerr.InvalidInput{InputName: "MaxVoices"}
+	}
+	s := &Sequencer{
+		Format:      so.Format,
+		events:      f.timeline(),
+		loop:        so.Loop,
+		volume:      so.Volume,
+		banks:       so.Banks,
+		maxVoices:   so.MaxVoices,
+		instruments: map[programKey]synth.Instrument{},
+	}
+	s.renderer = sample.NewRenderer(so.Format, s.render)
+	if s.loop && f.Duration() == 0 {
+		// A file which takes no time to play cannot be looped.
+		s.loop = false
+	}
+	for i := range s.channels {
+		s.resetChannel(i)
+		s.channels[i].instrument = s.program(i, 0)
+	}
+	if len(s.events) != 0 {
+		s.elapsed = s.events[0].wait
+	}
+	return s, nil
+}
+
+func (s *Sequencer) resetChannel(ch int) {
+	c := &s.channels[ch]
+	c.volume = 100
+	c.expression = 127
+	c.pan = 64
+	c.sustain = false
+	c.rpn = 0x3FFF
+	c.bendRange = 2
+	c.bend = 0
+}
+
+// program returns the instrument a channel should play for a program.
+func (s *Sequencer) program(ch int, program uint8) synth.Instrument {
+	key := programKey{program: program, drums: ch == drumChannel}
+	if ins, ok := s.instruments[key]; ok {
+		return ins
+	}
+	var ins synth.Instrument
+	for _, b := range s.banks {
+		if found, ok := b.Program(uint32(program), key.drums); ok {
+			ins = found
+			break
+		}
+	}
+	if ins == nil && !key.drums {
+		ins = synth.WaveInstrument{
+			Shape:  synth.TriangleShape,
+			Volume: .5,
+		}
+	}
+	s.instruments[key] = ins
+	return ins
+}
+
+// ReadPCM renders the file into b.
+func (s *Sequencer) ReadPCM(b []byte) (n int, err error) {
+	return s.renderer.ReadPCM(b)
+}
+
+// render renders the next frame into frame, returning false once the file and its notes have
+// ended.
+func (s *Sequencer) render(frame []float64) bool {
+	if s.ended && len(s.voices) == 0 {
+		return false
+	}
+	for !s.ended && s.played >= int64(s.elapsed.Seconds()*float64(s.SampleRate)) {
+		if s.next >= len(s.events) {
+			if !s.loop {
+				s.end()
+				break
+			}
+			s.next = 0
+			s.elapsed += s.events[0].wait
+			continue
+		}
+		s.handle(s.events[s.next].Event)
+		s.next++
+		if s.next < len(s.events) {
+			s.elapsed += s.events[s.next].wait
+		}
+	}
+	var left, right float64
+	for i := 0; i < len(s.voices); i++ {
+		av := &s.voices[i]
+		v, ok := av.voice.Next()
+		if !ok {
+			s.voices = append(s.voices[:i], s.voices[i+1:]...)
+			i--
+			continue
+		}
+		c := &s.channels[av.channel]
+		v *= gain(c.volume) * gain(c.expression)
+		pan := math.Max(-1, float64(int(c.pan)-64)/63)
+		left += v * math.Min(1, 1-pan)
+		right += v * math.Min(1, 1+pan)
+	}
+	left *= s.volume
+	right *= s.volume
+	if len(frame) == 1 {
+		frame[0] = (left + right) / 2
+	} else {
+		for i := range frame {
+			if i%2 == 0 {
+				frame[i] = left
+			} else {
+				frame[i] = right
+			}
+		}
+	}
+	s.played++
+	return true
+}
+
+// gain converts a MIDI volume or expression to an amplitude, following General MIDI's curve.
+func gain(v uint8) float64 {
+	f := float64(v) / 127
+	return f * f
+}
+
+// end stops the file, releasing any notes still playing.
+func (s *Sequencer) end() {
+	s.ended = true
+	for i := range s.voices {
+		s.voices[i].voice.Release()
+	}
+}
+
+func (s *Sequencer) handle(e Event) {
+	if e.Status >= 0xF0 || len(e.Data) == 0 {
+		return
+	}
+	ch := e.Channel()
+	c := &s.channels[ch]
+	var d1, d2 uint8
+	d1 = e.Data[0] & 0x7F
+	if len(e.Data) > 1 {
+		d2 = e.Data[1] & 0x7F
+	}
+	switch e.Kind() {
+	case NoteOn:
+		if d2 == 0 {
+			s.noteOff(ch, int(d1))
+			return
+		}
+		s.noteOn(ch, int(d1), int(d2))
+	case NoteOff:
+		s.noteOff(ch, int(d1))
+	case ProgramChange:
+		c.instrument = s.program(ch, d1)
+	case PitchBend:
+		value := int(d2)<<7 | int(d1) - 8192
+		c.bend = float64(value) / 8192 * c.bendRange
+		for _, av := range s.voices {
+			if av.channel != ch {
+				continue
+			}
+			if b, ok := av.voice.(synth.Bender); ok {
+				b.Bend(c.bend)
+			}
+		}
+	case ControlChange:
+		s.control(ch, d1, d2)
+	}
+}
+
+func (s *Sequencer) noteOn(ch, key, velocity int) {
+	c := &s.channels[ch]
+	if c.instrument == nil {
+		return
+	}
+	// Restriking a key ends the note already playing it.
+	for i := range s.voices {
+		if s.voices[i].channel == ch && s.voices[i].key == key {
+			s.voices[i].voice.Release()
+			s.voices[i].released = true
+		}
+	}
+	v := c.instrument.NoteOn(key, velocity, s.SampleRate)
+	if b, ok := v.(synth.Bender); ok && c.bend != 0 {
+		b.Bend(c.bend)
+	}
+	if len(s.voices) >= s.maxVoices {
+		s.voices = append(s.voices[:0], s.voices[len(s.voices)-s.maxVoices+1:]...)
+	}
+	s.voices = append(s.voices, activeVoice{
+		voice:   v,
+		channel: ch,
+		key:     key,
+	})
+}
+
+func (s *Sequencer) noteOff(ch, key int) {
+	sustain := s.channels[ch].sustain
+	for i := range s.voices {
+		av := &s.voices[i]
+		if av.channel != ch || av.key != key || av.released || av.sustained {
+			continue
+		}
+		if sustain {
+			av.sustained = true
+			continue
+		}
+		av.voice.Release()
+		av.released = true
+	}
+}
+
+// releaseAll releases every note on a channel, including those held by the sustain pedal.
+func (s *Sequencer) releaseAll(ch int) {
+	for i := range s.voices {
+		av := &s.voices[i]
+		if av.channel != ch || av.released {
+			continue
+		}
+		av.voice.Release()
+		av.released = true
+	}
+}
+
+// releaseSustained releases every note on a channel held by the sustain pedal.
+func (s *Sequencer) releaseSustained(ch int) {
+	for i := range s.voices {
+		av := &s.voices[i]
+		if av.channel == ch && av.sustained && !av.released {
+			av.voice.Release()
+			av.released = true
+		}
+	}
+}
+
+func (s *Sequencer) control(ch int, controller, value uint8) {
+	c := &s.channels[ch]
+	switch controller {
+	case ccVolume:
+		c.volume = value
+	case ccExpression:
+		c.expression = value
+	case ccPan:
+		c.pan = value
+	case ccSustain:
+		c.sustain = value >= 64
+		if !c.sustain {
+			s.releaseSustained(ch)
+		}
+	case ccRPNMSB:
+		c.rpn = c.rpn&0x7F | uint16(value)<<7
+	case ccRPNLSB:
+		c.rpn = c.rpn&^0x7F | uint16(value)
+	case ccDataEntry:
+		// RPN 0 is the pitch bend range, in semitones.
+		if c.rpn == 0 {
+			c.bendRange = float64(value)
+		}
+	case ccAllSoundOff:
+		kept := s.voices[:0]
+		for _, av := range s.voices {
+			if av.channel != ch {
+				kept = append(kept, av)
+			}
+		}
+		s.voices = kept
+	case ccResetAllController:
+		instrument := c.instrument
+		s.resetChannel(ch)
+		c.instrument = instrument
+		s.releaseSustained(ch)
+	case ccAllNotesOff:
+		s.releaseAll(ch)
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/diakovliev/oak/v4/audio/format"
 	"github.com/diakovliev/oak/v4/audio/pcm"
+	"github.com/diakovliev/oak/v4/oakerr"
 
 	"github.com/hajimehoshi/go-mp3"
 )
@@ -20,21 +21,74 @@ func init() {
 	format.Register(".mp3", Load)
 }
 
+var _ pcm.ReadSeeker = &Reader{}
+
 // Load reads MP3 data from a reader, parsing it's PCM format and returning
 // a pcm Reader for the data contained within. It will error if the reader
 // does not contain enough data to fill a file header. The resulting format
-// will always be 16 bits and 2 channels.
+// will always be 16 bits and 2 channels. If r is an io.Seeker, the returned
+// Reader can seek.
 func Load(r io.Reader) (pcm.Reader, error) {
 	d, err := mp3.NewDecoder(r)
 	if err != nil {
 		return nil, err
 	}
-	return &pcm.IOReader{
+	_, seekable := r.(io.Seeker)
+	return &Reader{
 		Format: pcm.Format{
 			SampleRate: uint32(d.SampleRate()),
 			Bits:       16,
 			Channels:   2,
 		},
-		Reader: d,
+		d:        d,
+		seekable: seekable,
 	}, nil
 }
+
+// seekPreroll is how many sample frames are decoded and discarded ahead of a seek target, which is
+// enough for the decoder's bit reservoir, overlap and filter bank to match a sequential decode.
+const seekPreroll = 8 * 1152
+
+// A Reader decodes MP3 audio as it is read.
+type Reader struct {
+	pcm.Format
+	d        *mp3.Decoder
+	seekable bool
+	// atEnd is set when the reader was sought to the very end of its audio, which the decoder
+	// cannot seek to itself.
+	atEnd bool
+}
+
+func (r *Reader) ReadPCM(b []byte) (int, error) {
+	if r.atEnd {
+		return 0, io.EOF
+	}
+	return r.d.Read(b)
+}
+
+// SeekPCM moves the reader to the given sample frame. It will error if the reader was not loaded from an
+// io.Seeker, or if frame is past the end of the audio.
+func (r *Reader) SeekPCM(frame int64) error {
+	if !r.seekable {
+		return oakerr.UnsupportedFormat{Format: "unseekable mp3"}
+	}
+	offset := frame * int64(r.SampleSize())
+	if frame < 0 || offset > r.d.Length() {
+		return oakerr.InvalidInput{InputName: "frame"}
+	}
+	r.atEnd = offset == r.d.Length()
+	if r.atEnd {
+		return nil
+	}
+	// Decoding an MP3 frame depends on the frames before it, so decoding is resumed ahead of the
+	// target and the audio up to it is discarded.
+	start := frame - seekPreroll
+	if start < 0 {
+		start = 0
+	}
+	if _, err := r.d.Seek(start*int64(r.SampleSize()), io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.CopyN(io.Discard, r.d, offset-start*int64(r.SampleSize()))
+	return err
+}
@@ -0,0 +1,89 @@
+package mp3_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/audio/format/mp3"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// test.mp3 holds the first 40 mpeg frames, or 23040 sample frames, of the public domain
+// mpeg2.mp3 example from github.com/hajimehoshi/go-mp3.
+const testFrames = 23040
+
+func load(t *testing.T) pcm.ReadSeeker {
+	t.Helper()
+	data, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatalf("failed to open test file: %v", err)
+	}
+	r, err := mp3.Load(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	return r.(pcm.ReadSeeker)
+}
+
+func readAll(t *testing.T, r pcm.Reader) []byte {
+	t.Helper()
+	var out []byte
+	buf := make([]byte, 1000)
+	for {
+		n, err := r.ReadPCM(buf)
+		out = append(out, buf[:n]...)
+		if errors.Is(err, io.EOF) {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+	}
+}
+
+func TestReader_SeekPCM(t *testing.T) {
+	r := load(t)
+	size := int64(r.PCMFormat().SampleSize())
+	all := readAll(t, load(t))
+	if int64(len(all)) != testFrames*size {
+		t.Fatalf("expected %v bytes, got %v", testFrames*size, len(all))
+	}
+	// Seek forward, within and across frames, then back.
+	for _, frame := range []int64{0, testFrames / 2, 5000, testFrames - 1, 0} {
+		if err := r.SeekPCM(frame); err != nil {
+			t.Fatalf("failed to seek to %v: %v", frame, err)
+		}
+		if got := readAll(t, r); !bytes.Equal(got, all[frame*size:]) {
+			t.Fatalf("read after seeking to %v did not match a sequential read", frame)
+		}
+	}
+	if err := r.SeekPCM(testFrames); err != nil {
+		t.Fatalf("failed to seek to end: %v", err)
+	}
+	if n, err := r.ReadPCM(make([]byte, 4)); n != 0 || !errors.Is(err, io.EOF) {
+		t.Fatalf("expected end of audio, got %v %v", n, err)
+	}
+	if err := r.SeekPCM(testFrames + 1); err == nil {
+		t.Fatal("expected seek past end to fail")
+	}
+	if err := r.SeekPCM(-1); err == nil {
+		t.Fatal("expected negative seek to fail")
+	}
+}
+
+func TestReader_SeekPCM_Unseekable(t *testing.T) {
+	data, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatalf("failed to open test file: %v", err)
+	}
+	r, err := mp3.Load(io.MultiReader(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if err := r.(pcm.Seeker).SeekPCM(0); err == nil {
+		t.Fatal("expected seek without an io.Seeker to fail")
+	}
+}
@@ -0,0 +1,83 @@
+package riff
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Sample loop types, as stored in a SampleLoop's Type.
+const (
+	LoopForward     uint32 = 0
+	LoopAlternating uint32 = 1
+	LoopBackward    uint32 = 2
+)
+
+// Sampler is the content of a 'smpl' chunk, which describes how a sampler should play back a
+// sound, including any loop points within it.
+type Sampler struct {
+	Manufacturer uint32
+	Product      uint32
+	// SamplePeriod is the duration of one sample in nanoseconds.
+	SamplePeriod      uint32
+	MIDIUnityNote     uint32
+	MIDIPitchFraction uint32
+	SMPTEFormat       uint32
+	SMPTEOffset       uint32
+	Loops             []SampleLoop
+	// SamplerData is any manufacturer specific data following the loops.
+	SamplerData []byte
+}
+
+// A SampleLoop is a single loop within a Sampler. Start and End are sample frame offsets,
+// and both are inclusive: the frame at End is the last frame played before looping.
+type SampleLoop struct {
+	CuePointID uint32
+	Type       uint32
+	Start      uint32
+	End        uint32
+	Fraction   uint32
+	// PlayCount is how many times the loop should play. Zero means the loop is infinite.
+	PlayCount uint32
+}
+
+// ParseSampler parses the content of a 'smpl' chunk, not including its ID and length.
+func ParseSampler(data []byte) (Sampler, error) {
+	if len(data) < 36 {
+		return Sampler{}, errors.New("Insufficient data found in smpl chunk")
+	}
+	u32 := func(i int) uint32 {
+		return binary.LittleEndian.Uint32(data[i : i+4])
+	}
+	s := Sampler{
+		Manufacturer:      u32(0),
+		Product:           u32(4),
+		SamplePeriod:      u32(8),
+		MIDIUnityNote:     u32(12),
+		MIDIPitchFraction: u32(16),
+		SMPTEFormat:       u32(20),
+		SMPTEOffset:       u32(24),
+	}
+	loopCount := int(u32(28))
+	samplerDataLen := int(u32(32))
+	if len(data) < 36+loopCount*24 {
+		return Sampler{}, errors.New("Insufficient data found in smpl chunk loops")
+	}
+	s.Loops = make([]SampleLoop, loopCount)
+	for i := range s.Loops {
+		off := 36 + i*24
+		s.Loops[i] = SampleLoop{
+			CuePointID: u32(off),
+			Type:       u32(off + 4),
+			Start:      u32(off + 8),
+			End:        u32(off + 12),
+			Fraction:   u32(off + 16),
+			PlayCount:  u32(off + 20),
+		}
+	}
+	rest := data[36+loopCount*24:]
+	if samplerDataLen > len(rest) {
+		samplerDataLen = len(rest)
+	}
+	s.SamplerData = rest[:samplerDataLen]
+	return s, nil
+}
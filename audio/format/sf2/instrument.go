@@ -0,0 +1,190 @@
+package sf2
+
+import (
+	"math"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/synth"
+)
+
+// minRelease keeps notes from clicking when they are released with a near instant envelope.
+const minRelease = 10 * time.Millisecond
+
+// Instrument returns a playable instrument for the preset in the given bank with the given
+// program number. Drum kits are found in DrumBank. Modulators and envelopes other than
+// release are not applied.
+func (sf *SoundFont) Instrument(bank, program uint32) (synth.Instrument, bool) {
+	for i := range sf.Presets {
+		p := &sf.Presets[i]
+		if uint32(p.Bank) == bank && uint32(p.Program) == program {
+			return sf.preset(p), true
+		}
+	}
+	return nil, false
+}
+
+// Program returns a playable instrument for the first preset in any bank with the given
+// program number, preferring lower banks.
+func (sf *SoundFont) Program(program uint32, drums bool) (synth.Instrument, bool) {
+	var found *Preset
+	for i := range sf.Presets {
+		p := &sf.Presets[i]
+		if uint32(p.Program) != program || (p.Bank == DrumBank) != drums {
+			continue
+		}
+		if found == nil || p.Bank < found.Bank {
+			found = p
+		}
+	}
+	if found == nil {
+		return nil, false
+	}
+	return sf.preset(found), true
+}
+
+// splitGlobal separates a global zone, if there is one, from the zones which follow it.
+func splitGlobal(zones []Zone, terminal uint16) (Zone, []Zone) {
+	if len(zones) == 0 {
+		return Zone{}, nil
+	}
+	gens := zones[0].Generators
+	if len(gens) == 0 || gens[len(gens)-1].Oper != terminal {
+		return zones[0], zones[1:]
+	}
+	return Zone{}, zones
+}
+
+// zoneValues holds the generators which apply to one region, with local zones overriding
+// global ones.
+type zoneValues map[uint16]Generator
+
+func (zv zoneValues) add(z Zone) {
+	for _, g := range z.Generators {
+		zv[g.Oper] = g
+	}
+}
+
+func (zv zoneValues) amount(oper uint16, def int) int {
+	if g, ok := zv[oper]; ok {
+		return int(g.Amount)
+	}
+	return def
+}
+
+func (zv zoneValues) keyRange(oper uint16) (lo, hi int) {
+	if g, ok := zv[oper]; ok {
+		return g.Range()
+	}
+	return 0, 127
+}
+
+func (sf *SoundFont) preset(p *Preset) synth.Instrument {
+	si := synth.SampleInstrument{}
+	data := map[[2]int][]float64{}
+	pGlobal, pZones := splitGlobal(p.Zones, GenInstrument)
+	for _, pz := range pZones {
+		pv := zoneValues{}
+		pv.add(pGlobal)
+		pv.add(pz)
+		insIdx := pv.amount(GenInstrument, -1)
+		if insIdx < 0 || insIdx >= len(sf.Instruments) {
+			continue
+		}
+		pKeyLow, pKeyHigh := pv.keyRange(GenKeyRange)
+		pVelLow, pVelHigh := pv.keyRange(GenVelRange)
+		iGlobal, iZones := splitGlobal(sf.Instruments[insIdx].Zones, GenSampleID)
+		for _, iz := range iZones {
+			iv := zoneValues{}
+			iv.add(iGlobal)
+			iv.add(iz)
+			sampleIdx := iv.amount(GenSampleID, -1)
+			if sampleIdx < 0 || sampleIdx >= len(sf.Samples) {
+				continue
+			}
+			keyLow, keyHigh := iv.keyRange(GenKeyRange)
+			velLow, velHigh := iv.keyRange(GenVelRange)
+			r := synth.Region{
+				KeyLow:  maxInt(keyLow, pKeyLow),
+				KeyHigh: minInt(keyHigh, pKeyHigh),
+				VelLow:  maxInt(velLow, pVelLow),
+				VelHigh: minInt(velHigh, pVelHigh),
+			}
+			if r.KeyLow > r.KeyHigh || r.VelLow > r.VelHigh {
+				continue
+			}
+			r.Sample = sf.sample(sf.Samples[sampleIdx], iv, pv, data)
+			if r.Sample == nil {
+				continue
+			}
+			si.Regions = append(si.Regions, r)
+		}
+	}
+	return si
+}
+
+// sample builds a playable sample from a sample header and the instrument (iv) and preset (pv)
+// generators applied to it. Preset generators are offsets added to the instrument's.
+func (sf *SoundFont) sample(sh SampleHeader, iv, pv zoneValues, cache map[[2]int][]float64) *synth.Sample {
+	offset := func(fine, coarse uint16) int {
+		return iv.amount(fine, 0) + 32768*iv.amount(coarse, 0)
+	}
+	start := int(sh.Start) + offset(GenStartAddrsOffset, GenStartAddrsCoarseOffset)
+	end := int(sh.End) + offset(GenEndAddrsOffset, GenEndAddrsCoarseOffset)
+	if start < 0 || end > len(sf.Data) || start >= end {
+		return nil
+	}
+	data, ok := cache[[2]int{start, end}]
+	if !ok {
+		data = make([]float64, end-start)
+		for i, v := range sf.Data[start:end] {
+			data[i] = float64(v) / 32768
+		}
+		cache[[2]int{start, end}] = data
+	}
+	root := int(sh.OriginalPitch)
+	if root > 127 {
+		root = 60
+	}
+	root = iv.amount(GenOverridingRootKey, root)
+	if root < 0 {
+		root = int(sh.OriginalPitch)
+	}
+	tune := float64(iv.amount(GenCoarseTune, 0)+pv.amount(GenCoarseTune, 0)) +
+		float64(iv.amount(GenFineTune, 0)+pv.amount(GenFineTune, 0)+int(sh.PitchCorrection))/100
+	// Attenuation is in centibels
+	attenuation := float64(iv.amount(GenInitialAttenuation, 0) + pv.amount(GenInitialAttenuation, 0))
+	// Envelope times are in timecents
+	releaseCents := float64(iv.amount(GenReleaseVolEnv, -12000) + pv.amount(GenReleaseVolEnv, 0))
+	release := time.Duration(math.Pow(2, releaseCents/1200) * float64(time.Second))
+	if release < minRelease {
+		release = minRelease
+	}
+	smp := &synth.Sample{
+		Data:       data,
+		SampleRate: float64(sh.SampleRate),
+		Unity:      float64(root) - tune,
+		Gain:       math.Pow(10, -attenuation/200),
+		Release:    release,
+	}
+	// Modes 1 and 3 loop; 3 plays out the rest of the sample after release, which is
+	// approximated by looping until the release fades out.
+	if mode := iv.amount(GenSampleModes, 0); mode == 1 || mode == 3 {
+		smp.LoopStart = int(sh.StartLoop) + offset(GenStartloopAddrsOffset, GenStartloopAddrsCoarseOffset) - start
+		smp.LoopEnd = int(sh.EndLoop) + offset(GenEndloopAddrsOffset, GenEndloopAddrsCoarseOffset) - start
+	}
+	return smp
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,305 @@
+// Package sf2 contains data structures for SoundFont 2 (.sf2) instrument banks.
+package sf2
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Generator types which affect how samples are played. Generators not listed here are
+// parsed, but ignored during playback.
+const (
+	GenStartAddrsOffset           uint16 = 0
+	GenEndAddrsOffset             uint16 = 1
+	GenStartloopAddrsOffset       uint16 = 2
+	GenEndloopAddrsOffset         uint16 = 3
+	GenStartAddrsCoarseOffset     uint16 = 4
+	GenEndAddrsCoarseOffset       uint16 = 12
+	GenPan                        uint16 = 17
+	GenReleaseVolEnv              uint16 = 38
+	GenInstrument                 uint16 = 41
+	GenKeyRange                   uint16 = 43
+	GenVelRange                   uint16 = 44
+	GenStartloopAddrsCoarseOffset uint16 = 45
+	GenInitialAttenuation         uint16 = 48
+	GenEndloopAddrsCoarseOffset   uint16 = 50
+	GenCoarseTune                 uint16 = 51
+	GenFineTune                   uint16 = 52
+	GenSampleID                   uint16 = 53
+	GenSampleModes                uint16 = 54
+	GenOverridingRootKey          uint16 = 58
+)
+
+// DrumBank is the bank holding percussion presets.
+const DrumBank = 128
+
+// A SoundFont is a parsed SoundFont 2 bank.
+type SoundFont struct {
+	// Name is the name of the bank, from its INFO list.
+	Name        string
+	Presets     []Preset
+	Instruments []Instrument
+	Samples     []SampleHeader
+	// Data holds the audio of every sample, as 16 bit mono values.
+	Data []int16
+}
+
+// A Preset is a playable combination of instruments, selected by bank and program number.
+type Preset struct {
+	Name    string
+	Program uint16
+	Bank    uint16
+	Zones   []Zone
+}
+
+// An Instrument is a collection of samples mapped to ranges of keys and velocities.
+type Instrument struct {
+	Name  string
+	Zones []Zone
+}
+
+// A Zone is a list of generators applying to some range of a preset or instrument. A zone
+// which does not end in an instrument (for presets) or sample (for instruments) is a global
+// zone, providing defaults for every other zone.
+type Zone struct {
+	Generators []Generator
+}
+
+// A Generator sets a single parameter of sample playback.
+type Generator struct {
+	Oper   uint16
+	Amount int16
+}
+
+// Range returns the generator's amount as a low and high value, as used by GenKeyRange
+// and GenVelRange.
+func (g Generator) Range() (lo, hi int) {
+	return int(uint16(g.Amount) & 0xff), int(uint16(g.Amount) >> 8)
+}
+
+// Get returns the last generator in the zone with the given type.
+func (z Zone) Get(oper uint16) (Generator, bool) {
+	for i := len(z.Generators) - 1; i >= 0; i-- {
+		if z.Generators[i].Oper == oper {
+			return z.Generators[i], true
+		}
+	}
+	return Generator{}, false
+}
+
+// A SampleHeader locates a single sample within a SoundFont's Data.
+type SampleHeader struct {
+	Name string
+	// Start and End are offsets into Data; End is exclusive.
+	Start, End uint32
+	// StartLoop and EndLoop are offsets into Data; EndLoop is the first frame after the loop.
+	StartLoop, EndLoop uint32
+	SampleRate         uint32
+	OriginalPitch      uint8
+	// PitchCorrection is in cents.
+	PitchCorrection int8
+	SampleLink      uint16
+	SampleType      uint16
+}
+
+// Read parses a SoundFont 2 bank.
+func Read(r io.Reader) (*SoundFont, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "sfbk" {
+		return nil, errors.New("sf2: missing sfbk RIFF header")
+	}
+	body := data[12:]
+	if ln := int(binary.LittleEndian.Uint32(data[4:8])) - 4; ln >= 0 && ln < len(body) {
+		body = body[:ln]
+	}
+	sf := &SoundFont{}
+	var pdta map[string][]byte
+	err = walkChunks(body, func(id string, b []byte) error {
+		if id != "LIST" || len(b) < 4 {
+			return nil
+		}
+		list := b[4:]
+		switch string(b[:4]) {
+		case "INFO":
+			return walkChunks(list, func(id string, b []byte) error {
+				if id == "INAM" {
+					sf.Name = cString(b)
+				}
+				return nil
+			})
+		case "sdta":
+			return walkChunks(list, func(id string, b []byte) error {
+				if id == "smpl" {
+					sf.Data = make([]int16, len(b)/2)
+					for i := range sf.Data {
+						sf.Data[i] = int16(binary.LittleEndian.Uint16(b[i*2:]))
+					}
+				}
+				return nil
+			})
+		case "pdta":
+			pdta = map[string][]byte{}
+			return walkChunks(list, func(id string, b []byte) error {
+				pdta[id] = b
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if pdta == nil {
+		return nil, errors.New("sf2: missing pdta list")
+	}
+	if err := sf.readPresets(pdta); err != nil {
+		return nil, err
+	}
+	return sf, nil
+}
+
+func walkChunks(b []byte, fn func(id string, data []byte) error) error {
+	for len(b) >= 8 {
+		id := string(b[:4])
+		ln := int(binary.LittleEndian.Uint32(b[4:8]))
+		b = b[8:]
+		if ln > len(b) {
+			return fmt.Errorf("sf2: %q chunk is truncated", id)
+		}
+		if err := fn(id, b[:ln]); err != nil {
+			return err
+		}
+		// Chunks are padded to an even length
+		ln += ln % 2
+		if ln > len(b) {
+			ln = len(b)
+		}
+		b = b[ln:]
+	}
+	return nil
+}
+
+func cString(b []byte) string {
+	s := string(b)
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// records splits a pdta sub-chunk into fixed size records.
+func records(pdta map[string][]byte, id string, size int) ([][]byte, error) {
+	b := pdta[id]
+	if len(b)%size != 0 || len(b) < size {
+		return nil, fmt.Errorf("sf2: invalid %v chunk size %v", id, len(b))
+	}
+	recs := make([][]byte, len(b)/size)
+	for i := range recs {
+		recs[i] = b[i*size : (i+1)*size]
+	}
+	return recs, nil
+}
+
+func (sf *SoundFont) readPresets(pdta map[string][]byte) error {
+	phdr, err := records(pdta, "phdr", 38)
+	if err != nil {
+		return err
+	}
+	pbag, err := records(pdta, "pbag", 4)
+	if err != nil {
+		return err
+	}
+	pgen, err := records(pdta, "pgen", 4)
+	if err != nil {
+		return err
+	}
+	inst, err := records(pdta, "inst", 22)
+	if err != nil {
+		return err
+	}
+	ibag, err := records(pdta, "ibag", 4)
+	if err != nil {
+		return err
+	}
+	igen, err := records(pdta, "igen", 4)
+	if err != nil {
+		return err
+	}
+	shdr, err := records(pdta, "shdr", 46)
+	if err != nil {
+		return err
+	}
+	u16 := binary.LittleEndian.Uint16
+	u32 := binary.LittleEndian.Uint32
+	// Each record's bag index runs until the next record's; the final record of each list
+	// is a terminal which only marks where the previous record ends.
+	for i := 0; i+1 < len(phdr); i++ {
+		p := phdr[i]
+		zones, err := readZones(pbag, pgen, int(u16(p[24:])), int(u16(phdr[i+1][24:])))
+		if err != nil {
+			return err
+		}
+		sf.Presets = append(sf.Presets, Preset{
+			Name:    cString(p[:20]),
+			Program: u16(p[20:]),
+			Bank:    u16(p[22:]),
+			Zones:   zones,
+		})
+	}
+	for i := 0; i+1 < len(inst); i++ {
+		in := inst[i]
+		zones, err := readZones(ibag, igen, int(u16(in[20:])), int(u16(inst[i+1][20:])))
+		if err != nil {
+			return err
+		}
+		sf.Instruments = append(sf.Instruments, Instrument{
+			Name:  cString(in[:20]),
+			Zones: zones,
+		})
+	}
+	for i := 0; i+1 < len(shdr); i++ {
+		s := shdr[i]
+		sf.Samples = append(sf.Samples, SampleHeader{
+			Name:            cString(s[:20]),
+			Start:           u32(s[20:]),
+			End:             u32(s[24:]),
+			StartLoop:       u32(s[28:]),
+			EndLoop:         u32(s[32:]),
+			SampleRate:      u32(s[36:]),
+			OriginalPitch:   s[40],
+			PitchCorrection: int8(s[41]),
+			SampleLink:      u16(s[42:]),
+			SampleType:      u16(s[44:]),
+		})
+	}
+	return nil
+}
+
+func readZones(bags, gens [][]byte, from, to int) ([]Zone, error) {
+	if from > to || to >= len(bags) {
+		return nil, errors.New("sf2: invalid bag index")
+	}
+	zones := make([]Zone, 0, to-from)
+	for i := from; i < to; i++ {
+		genFrom := int(binary.LittleEndian.Uint16(bags[i]))
+		genTo := int(binary.LittleEndian.Uint16(bags[i+1]))
+		if genFrom > genTo || genTo > len(gens) {
+			return nil, errors.New("sf2: invalid generator index")
+		}
+		z := Zone{}
+		for _, g := range gens[genFrom:genTo] {
+			z.Generators = append(z.Generators, Generator{
+				Oper:   binary.LittleEndian.Uint16(g),
+				Amount: int16(binary.LittleEndian.Uint16(g[2:])),
+			})
+		}
+		zones = append(zones, z)
+	}
+	return zones, nil
+}
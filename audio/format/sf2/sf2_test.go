@@ -0,0 +1,153 @@
+package sf2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func chunk(id string, data []byte) []byte {
+	b := append([]byte(id), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(b[4:], uint32(len(data)))
+	b = append(b, data...)
+	if len(data)%2 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func list(typ string, chunks ...[]byte) []byte {
+	data := []byte(typ)
+	for _, c := range chunks {
+		data = append(data, c...)
+	}
+	return chunk("LIST", data)
+}
+
+func name(s string) []byte {
+	b := make([]byte, 20)
+	copy(b, s)
+	return b
+}
+
+func le(vs ...interface{}) []byte {
+	buf := &bytes.Buffer{}
+	for _, v := range vs {
+		binary.Write(buf, binary.LittleEndian, v)
+	}
+	return buf.Bytes()
+}
+
+// testSoundFont builds a bank with one sine sample, one instrument playing it from key 48 to 72,
+// and one preset at program 5 of bank 0 using that instrument.
+func testSoundFont() []byte {
+	const frames = 441
+	smpl := make([]int16, frames+46)
+	for i := 0; i < frames; i++ {
+		smpl[i] = int16(math.Sin(float64(i)/frames*2*math.Pi*4) * 16000)
+	}
+	phdr := append(name("Sine"), le(uint16(5), uint16(0), uint16(0), uint32(0), uint32(0), uint32(0))...)
+	phdr = append(phdr, append(name("EOP"), le(uint16(0), uint16(0), uint16(1), uint32(0), uint32(0), uint32(0))...)...)
+	pbag := le(uint16(0), uint16(0), uint16(1), uint16(0))
+	pgen := le(GenInstrument, int16(0), uint16(0), int16(0))
+	inst := append(name("SineInst"), le(uint16(0))...)
+	inst = append(inst, append(name("EOI"), le(uint16(1))...)...)
+	ibag := le(uint16(0), uint16(0), uint16(3), uint16(0))
+	igen := le(
+		GenKeyRange, uint8(48), uint8(72),
+		GenSampleModes, int16(1),
+		GenSampleID, int16(0),
+		uint16(0), int16(0),
+	)
+	shdr := append(name("Sine"), le(uint32(0), uint32(frames), uint32(0), uint32(frames), uint32(44100), uint8(69), int8(0), uint16(0), uint16(1))...)
+	shdr = append(shdr, append(name("EOS"), make([]byte, 26)...)...)
+	body := []byte("sfbk")
+	body = append(body, list("INFO", chunk("ifil", le(uint16(2), uint16(1))), chunk("INAM", []byte("Test\x00")))...)
+	body = append(body, list("sdta", chunk("smpl", le(smpl)))...)
+	body = append(body, list("pdta",
+		chunk("phdr", phdr), chunk("pbag", pbag), chunk("pmod", make([]byte, 10)), chunk("pgen", pgen),
+		chunk("inst", inst), chunk("ibag", ibag), chunk("imod", make([]byte, 10)), chunk("igen", igen),
+		chunk("shdr", shdr),
+	)...)
+	return chunk("RIFF", body)
+}
+
+func TestRead(t *testing.T) {
+	sf, err := Read(bytes.NewReader(testSoundFont()))
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if sf.Name != "Test" {
+		t.Fatalf("expected name Test, got %q", sf.Name)
+	}
+	if len(sf.Presets) != 1 || sf.Presets[0].Name != "Sine" || sf.Presets[0].Program != 5 {
+		t.Fatalf("unexpected presets: %+v", sf.Presets)
+	}
+	if len(sf.Instruments) != 1 || len(sf.Instruments[0].Zones) != 1 {
+		t.Fatalf("unexpected instruments: %+v", sf.Instruments)
+	}
+	g, ok := sf.Instruments[0].Zones[0].Get(GenKeyRange)
+	if !ok {
+		t.Fatal("expected key range generator")
+	}
+	if lo, hi := g.Range(); lo != 48 || hi != 72 {
+		t.Fatalf("expected key range 48-72, got %v-%v", lo, hi)
+	}
+	if len(sf.Samples) != 1 || sf.Samples[0].End != 441 || sf.Samples[0].OriginalPitch != 69 {
+		t.Fatalf("unexpected samples: %+v", sf.Samples)
+	}
+}
+
+func TestRead_Invalid(t *testing.T) {
+	if _, err := Read(bytes.NewReader([]byte("RIFF\x04\x00\x00\x00WAVE"))); err == nil {
+		t.Fatal("expected error reading non sf2 data")
+	}
+	b := testSoundFont()
+	if _, err := Read(bytes.NewReader(b[:len(b)-60])); err == nil {
+		t.Fatal("expected error reading truncated data")
+	}
+}
+
+func TestSoundFont_Program(t *testing.T) {
+	sf, err := Read(bytes.NewReader(testSoundFont()))
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if _, ok := sf.Program(5, true); ok {
+		t.Fatal("expected no drum preset")
+	}
+	if _, ok := sf.Instrument(1, 5); ok {
+		t.Fatal("expected no preset in bank 1")
+	}
+	ins, ok := sf.Program(5, false)
+	if !ok {
+		t.Fatal("expected preset for program 5")
+	}
+	v := ins.NoteOn(40, 127, 44100)
+	if _, ok := v.Next(); ok {
+		t.Fatal("expected key outside of range to be silent")
+	}
+	v = ins.NoteOn(69, 127, 44100)
+	var peak float64
+	// The sample loops, so it should play for longer than it is.
+	for i := 0; i < 44100; i++ {
+		s, ok := v.Next()
+		if !ok {
+			t.Fatalf("voice stopped early at %v", i)
+		}
+		peak = math.Max(peak, math.Abs(s))
+	}
+	if peak < .4 {
+		t.Fatalf("expected audible voice, got peak %v", peak)
+	}
+	v.Release()
+	for i := 0; ; i++ {
+		if _, ok := v.Next(); !ok {
+			break
+		}
+		if i > 44100 {
+			t.Fatal("voice did not stop after release")
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Package vorbis provides functionality to handle .ogg files and Ogg Vorbis encoded data.
+//
+// This package may be imported solely to register Ogg Vorbis files as a parseable file type within oak:
+//
+//	import (
+//	    _ "github.com/diakovliev/oak/v4/audio/format/vorbis"
+//	)
+package vorbis
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/diakovliev/oak/v4/audio/format"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+	"github.com/jfreymuth/oggvorbis"
+)
+
+func init() {
+	format.Register(".ogg", Load)
+	format.Register(".oga", Load)
+}
+
+var _ pcm.ReadSeeker = &Reader{}
+
+// Load reads Ogg Vorbis headers from a reader, parsing its PCM format and returning
+// a streaming pcm Reader for the audio following the headers. The resulting format
+// will always be 16 bits. If r is an io.Seeker, the returned Reader can seek.
+func Load(r io.Reader) (pcm.Reader, error) {
+	d, err := oggvorbis.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ogg vorbis: %w", err)
+	}
+	return &Reader{
+		Format: pcm.Format{
+			SampleRate: uint32(d.SampleRate()),
+			Channels:   uint16(d.Channels()),
+			Bits:       16,
+		},
+		d: d,
+	}, nil
+}
+
+// A Reader decodes Ogg Vorbis audio as it is read.
+type Reader struct {
+	pcm.Format
+	d       *oggvorbis.Reader
+	values  []float32
+	pending []byte
+}
+
+// ReadPCM decodes audio into b.
+func (r *Reader) ReadPCM(b []byte) (n int, err error) {
+	n = copy(b, r.pending)
+	r.pending = r.pending[n:]
+	if n == len(b) {
+		return n, nil
+	}
+	size := r.SampleSize()
+	frames := (len(b) - n + size - 1) / size
+	count := frames * int(r.Channels)
+	if cap(r.values) < count {
+		r.values = make([]float32, count)
+	}
+	read, err := r.d.Read(r.values[:count])
+	out := make([]byte, read*2)
+	for i, v := range r.values[:read] {
+		i16 := int16(math.Max(-1, math.Min(1, float64(v))) * math.MaxInt16)
+		out[i*2] = byte(i16)
+		out[i*2+1] = byte(i16 >> 8)
+	}
+	copied := copy(b[n:], out)
+	r.pending = out[copied:]
+	n += copied
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// SeekPCM moves the reader to the given sample frame, such that the next read will begin with
+// that frame. It will error if the reader was not loaded from an io.Seeker.
+func (r *Reader) SeekPCM(frame int64) error {
+	r.pending = nil
+	return r.d.SetPosition(frame)
+}
+
+// Length returns the length of the audio in sample frames, or zero if it is unknown because the
+// reader was not loaded from an io.Seeker.
+func (r *Reader) Length() int64 {
+	return r.d.Length()
+}
@@ -0,0 +1,46 @@
+package vorbis_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/audio"
+	"github.com/diakovliev/oak/v4/audio/format/vorbis"
+)
+
+func TestLoad_Seek(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "test.ogg"))
+	if err != nil {
+		t.Fatalf("failed to open test file: %v", err)
+	}
+	defer f.Close()
+	r, err := vorbis.Load(f)
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	format := r.PCMFormat()
+	if format.Bits != 16 || format.Channels == 0 || format.SampleRate == 0 {
+		t.Fatalf("unexpected format: %+v", format)
+	}
+	vr := r.(*vorbis.Reader)
+	all := audio.ReadAll(r).Buffer
+	if want := int(vr.Length()) * format.SampleSize(); len(all) != want {
+		t.Fatalf("expected %v bytes, got %v", want, len(all))
+	}
+
+	const frame = 1000
+	if err := vr.SeekPCM(frame); err != nil {
+		t.Fatalf("failed to seek: %v", err)
+	}
+	// Odd sized reads must not lose data.
+	got := make([]byte, 101)
+	if _, err := audio.ReadFull(r, got); err != nil {
+		t.Fatalf("failed to read after seek: %v", err)
+	}
+	offset := frame * format.SampleSize()
+	if !bytes.Equal(got, all[offset:offset+len(got)]) {
+		t.Fatal("data after seek did not match")
+	}
+}
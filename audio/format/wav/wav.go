@@ -8,36 +8,123 @@
 package wav
 
 import (
+	"bytes"
+	"errors"
 	"io"
+	"math"
 
 	"encoding/binary"
 
+	"github.com/diakovliev/oak/v4/audio"
 	"github.com/diakovliev/oak/v4/audio/format"
+	"github.com/diakovliev/oak/v4/audio/format/riff"
 	"github.com/diakovliev/oak/v4/audio/pcm"
+	"github.com/diakovliev/oak/v4/oakerr"
 )
 
 func init() {
 	format.Register(".wav", Load)
 }
 
+var _ pcm.ReadSeeker = &Reader{}
+
+// maxSamplerSize bounds how large a smpl chunk will be read. Larger chunks are skipped; each
+// loop takes 24 bytes, so this leaves room for thousands of loops.
+const maxSamplerSize = 1 << 16
+
 // Load reads a WAV header from a reader, parsing it's PCM format and returning
 // a pcm Reader for the data following the header. It will error if the reader
 // does not contain enough data to fill a WAV header. It does not validate that the
-// WAV header makes sense.
+// WAV header makes sense. If r is an io.Seeker, chunks following the audio data
+// will also be parsed, and the returned Reader can seek.
 func Load(r io.Reader) (pcm.Reader, error) {
-	data, err := readData(r)
+	wr := &Reader{
+		r: r,
+	}
+	if s, ok := r.(io.Seeker); ok {
+		if _, err := s.Seek(0, io.SeekCurrent); err == nil {
+			wr.seeker = s
+		}
+	}
+	data, err := readData(r, wr.seeker)
 	if err != nil {
 		return nil, err
 	}
+	wr.Format = pcm.Format{
+		SampleRate: data.SampleRate,
+		Channels:   data.NumChannels,
+		Bits:       data.BitsPerSample,
+	}
+	wr.dataStart = data.dataStart
+	wr.dataLen = int64(data.Subchunk2Size)
+	if data.Subchunk2Size == 0 || data.Subchunk2Size == math.MaxUint32 {
+		// Streamed wavs may not know their length; read until the reader ends.
+		wr.dataLen = -1
+	}
+	wr.sampler = data.Sampler
+	return wr, nil
+}
+
+// A Reader reads PCM data from the data chunk of a WAV file.
+type Reader struct {
+	pcm.Format
+	r         io.Reader
+	seeker    io.Seeker
+	dataStart int64
+	// dataLen is the length of the data chunk, or -1 if it is unknown.
+	dataLen int64
+	// pos is how many bytes of the data chunk have been read.
+	pos     int64
+	sampler *riff.Sampler
+}
+
+func (r *Reader) ReadPCM(p []byte) (n int, err error) {
+	if r.dataLen >= 0 {
+		if r.pos >= r.dataLen {
+			return 0, io.EOF
+		}
+		if int64(len(p)) > r.dataLen-r.pos {
+			p = p[:r.dataLen-r.pos]
+		}
+	}
+	n, err = r.r.Read(p)
+	r.pos += int64(n)
+	return n, err
+}
+
+// SeekPCM moves the reader to the given sample frame. It will error if the reader was not loaded from an
+// io.Seeker.
+func (r *Reader) SeekPCM(frame int64) error {
+	if r.seeker == nil {
+		return oakerr.UnsupportedFormat{Format: "unseekable wav"}
+	}
+	offset := frame * int64(r.SampleSize())
+	if frame < 0 || (r.dataLen >= 0 && offset > r.dataLen) {
+		return oakerr.InvalidInput{InputName: "frame"}
+	}
+	if _, err := r.seeker.Seek(r.dataStart+offset, io.SeekStart); err != nil {
+		return err
+	}
+	r.pos = offset
+	return nil
+}
+
+// Sampler returns the content of the WAV's smpl chunk, if it had one.
+func (r *Reader) Sampler() (riff.Sampler, bool) {
+	if r.sampler == nil {
+		return riff.Sampler{}, false
+	}
+	return *r.sampler, true
+}
 
-	return &pcm.IOReader{
-		Format: pcm.Format{
-			SampleRate: data.SampleRate,
-			Channels:   data.NumChannels,
-			Bits:       data.BitsPerSample,
-		},
-		Reader: r,
-	}, nil
+// Loop wraps this reader such that it will loop over the first loop in its smpl chunk, or over
+// all of its audio if it has no loops.
+func (r *Reader) Loop() (pcm.Reader, error) {
+	if r.sampler != nil && len(r.sampler.Loops) != 0 {
+		l := r.sampler.Loops[0]
+		return audio.LoopSection(r, int64(l.Start), int64(l.End)+1)
+	}
+	return audio.LoopSection(r, 0, 0)
 }
 
 // The following is a fork of verdverm's go-wav library
@@ -61,9 +148,13 @@ type data struct {
 	bSubchunk2ID  [4]byte // B
 	Subchunk2Size uint32  // L
 	Data          []byte  // L
+
+	// dataStart is the offset of the audio data, if the wav was read from an io.Seeker.
+	dataStart int64
+	Sampler   *riff.Sampler
 }
 
-func readData(r io.Reader) (data, error) {
+func readData(r io.Reader, seeker io.Seeker) (data, error) {
 	data := data{}
 
 	err := binary.Read(r, binary.BigEndian, &data.bChunkID)
@@ -112,13 +203,84 @@ func readData(r io.Reader) (data, error) {
 		return data, err
 	}
 
-	err = binary.Read(r, binary.BigEndian, &data.bSubchunk2ID)
-	if err != nil {
+	// Skip any extension to the fmt chunk
+	if err = skip(r, seeker, int64(data.Subchunk1Size)-16+int64(data.Subchunk1Size%2)); err != nil {
 		return data, err
 	}
-	err = binary.Read(r, binary.LittleEndian, &data.Subchunk2Size)
-	if err != nil {
-		return data, err
+
+	foundData := false
+chunks:
+	for {
+		var id [4]byte
+		var size uint32
+		err = binary.Read(r, binary.BigEndian, &id)
+		if err != nil {
+			if foundData && errors.Is(err, io.EOF) {
+				break chunks
+			}
+			return data, err
+		}
+		err = binary.Read(r, binary.LittleEndian, &size)
+		if err != nil {
+			return data, err
+		}
+		padded := int64(size) + int64(size%2)
+		switch string(id[:]) {
+		case "data":
+			data.bSubchunk2ID = id
+			data.Subchunk2Size = size
+			foundData = true
+			if seeker == nil {
+				// We cannot look past the audio data, so stop here.
+				return data, nil
+			}
+			data.dataStart, err = seeker.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return data, err
+			}
+			if size == 0 || size == math.MaxUint32 {
+				return data, nil
+			}
+			if _, err = seeker.Seek(padded, io.SeekCurrent); err != nil {
+				return data, err
+			}
+		case "smpl":
+			// Sampler chunks are metadata; a malformed one is ignored rather than failing the
+			// whole load.
+			if padded > maxSamplerSize {
+				if err = skip(r, seeker, padded); err != nil {
+					return data, err
+				}
+				continue
+			}
+			var chunk bytes.Buffer
+			if _, err = io.CopyN(&chunk, r, padded); err != nil {
+				if foundData && errors.Is(err, io.EOF) {
+					break chunks
+				}
+				return data, err
+			}
+			if sampler, err := riff.ParseSampler(chunk.Bytes()[:size]); err == nil {
+				data.Sampler = &sampler
+			}
+		default:
+			if err = skip(r, seeker, padded); err != nil {
+				return data, err
+			}
+		}
+	}
+	_, err = seeker.Seek(data.dataStart, io.SeekStart)
+	return data, err
+}
+
+func skip(r io.Reader, seeker io.Seeker, n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	if seeker != nil {
+		_, err := seeker.Seek(n, io.SeekCurrent)
+		return err
 	}
-	return data, nil
+	_, err := io.CopyN(io.Discard, r, n)
+	return err
 }
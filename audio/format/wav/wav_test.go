@@ -0,0 +1,144 @@
+package wav_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/audio"
+	"github.com/diakovliev/oak/v4/audio/format/wav"
+)
+
+// testWav builds a mono, 8 bit wav holding frames 0..frames-1, with a LIST chunk before
+// its data and a smpl chunk after it.
+func testWav(frames int, loopStart, loopEnd uint32) []byte {
+	buf := &bytes.Buffer{}
+	le := func(v interface{}) { binary.Write(buf, binary.LittleEndian, v) }
+	buf.WriteString("RIFF")
+	le(uint32(0))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	le(uint32(18))
+	le(uint16(1))    // pcm
+	le(uint16(1))    // channels
+	le(uint32(8000)) // sample rate
+	le(uint32(8000)) // byte rate
+	le(uint16(1))    // block align
+	le(uint16(8))    // bits
+	le(uint16(0))    // extension size
+	buf.WriteString("LIST")
+	le(uint32(3))
+	buf.Write([]byte{1, 2, 3, 0})
+	buf.WriteString("data")
+	le(uint32(frames))
+	for i := 0; i < frames; i++ {
+		buf.WriteByte(byte(i))
+	}
+	if frames%2 != 0 {
+		buf.WriteByte(0)
+	}
+	buf.WriteString("smpl")
+	le(uint32(36 + 24))
+	le([7]uint32{})
+	le(uint32(1)) // loops
+	le(uint32(0)) // sampler data
+	le([6]uint32{0, 0, loopStart, loopEnd, 0, 0})
+	return buf.Bytes()
+}
+
+func TestLoad_Sampler(t *testing.T) {
+	r, err := wav.Load(bytes.NewReader(testWav(51, 10, 19)))
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	wr := r.(*wav.Reader)
+	s, ok := wr.Sampler()
+	if !ok {
+		t.Fatal("expected smpl chunk")
+	}
+	if len(s.Loops) != 1 || s.Loops[0].Start != 10 || s.Loops[0].End != 19 {
+		t.Fatalf("unexpected loops: %+v", s.Loops)
+	}
+	// Only the data chunk should be read, not the chunks after it.
+	data := audio.ReadAll(r).Buffer
+	if len(data) != 51 || data[50] != 50 {
+		t.Fatalf("unexpected data: %v", data)
+	}
+	if err := wr.SeekPCM(25); err != nil {
+		t.Fatalf("failed to seek: %v", err)
+	}
+	b := make([]byte, 2)
+	if _, err := audio.ReadFull(r, b); err != nil || b[0] != 25 || b[1] != 26 {
+		t.Fatalf("expected [25 26] after seek, got %v %v", b, err)
+	}
+}
+
+func TestReader_Loop(t *testing.T) {
+	r, err := wav.Load(bytes.NewReader(testWav(51, 10, 19)))
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	loop, err := r.(*wav.Reader).Loop()
+	if err != nil {
+		t.Fatalf("failed to loop: %v", err)
+	}
+	b := make([]byte, 40)
+	if _, err := audio.ReadFull(loop, b); err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	for i, v := range b {
+		want := i
+		if i >= 20 {
+			want = 10 + (i-20)%10
+		}
+		if int(v) != want {
+			t.Fatalf("byte %v: expected %v, got %v", i, want, v)
+		}
+	}
+}
+
+func TestLoad_NotSeekable(t *testing.T) {
+	r, err := wav.Load(io.MultiReader(bytes.NewReader(testWav(4, 0, 0))))
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	wr := r.(*wav.Reader)
+	if _, ok := wr.Sampler(); ok {
+		t.Fatal("expected smpl chunk to be unreadable")
+	}
+	if data := audio.ReadAll(r).Buffer; len(data) != 4 {
+		t.Fatalf("expected 4 bytes, got %v", data)
+	}
+	if err := wr.SeekPCM(0); err == nil {
+		t.Fatal("expected seek to fail")
+	}
+}
+
+func TestLoad_BadSampler(t *testing.T) {
+	cases := map[string]func(b []byte) []byte{
+		"oversized": func(b []byte) []byte {
+			binary.LittleEndian.PutUint32(b[len(b)-64:], 1<<30)
+			return b
+		},
+		"truncated": func(b []byte) []byte {
+			return b[:len(b)-10]
+		},
+		"too many loops": func(b []byte) []byte {
+			binary.LittleEndian.PutUint32(b[len(b)-32:], 1000)
+			return b
+		},
+	}
+	for name, modify := range cases {
+		r, err := wav.Load(bytes.NewReader(modify(testWav(51, 10, 19))))
+		if err != nil {
+			t.Fatalf("%v: failed to load: %v", name, err)
+		}
+		if _, ok := r.(*wav.Reader).Sampler(); ok {
+			t.Fatalf("%v: expected smpl chunk to be skipped", name)
+		}
+		if data := audio.ReadAll(r).Buffer; len(data) != 51 {
+			t.Fatalf("%v: expected 51 bytes, got %v", name, len(data))
+		}
+	}
+}
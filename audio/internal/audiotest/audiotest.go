@@ -0,0 +1,49 @@
+// Package audiotest provides helpers for testing pcm readers.
+package audiotest
+
+import (
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// ReadAll reads r until it ends, failing the test on any error other than io.EOF. If max is
+// positive, reading also stops once at least max bytes have been read, for readers which may not
+// end. An odd buffer size checks that partial samples are not lost.
+func ReadAll(t testing.TB, r pcm.Reader, max int) []byte {
+	t.Helper()
+	var out []byte
+	buf := make([]byte, 1001)
+	for max <= 0 || len(out) < max {
+		n, err := r.ReadPCM(buf)
+		out = append(out, buf[:n]...)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+	}
+	return out
+}
+
+// Int16s decodes little endian 16 bit samples.
+func Int16s(b []byte) []int16 {
+	out := make([]int16, len(b)/2)
+	for i := range out {
+		out[i] = int16(b[i*2]) | int16(b[i*2+1])<<8
+	}
+	return out
+}
+
+// Floats decodes little endian 16 bit samples, scaled to -1.0 -> 1.0.
+func Floats(b []byte) []float64 {
+	out := make([]float64, len(b)/2)
+	for i, v := range Int16s(b) {
+		out[i] = float64(v) / math.MaxInt16
+	}
+	return out
+}
@@ -0,0 +1,158 @@
+package sample
+
+import (
+	"errors"
+	"io"
+
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// A Reader passes each frame of an underlying reader through a function as it is read.
+type Reader struct {
+	pcm.Reader
+	// process modifies a frame of normalized samples in place, returning false once the
+	// reader should end without including the frame.
+	process func(frame []float64) bool
+	// tail, if set, fills frames following the end of the underlying reader, returning false
+	// once there are none left.
+	tail func(frame []float64) bool
+
+	frame  []float64
+	frames frameBuffer
+	// partial holds bytes of an incomplete frame from the underlying reader.
+	partial []byte
+	// ended is set once the underlying reader has ended, and done once this reader has.
+	ended bool
+	done  bool
+}
+
+// NewReader creates a reader passing each frame of r through process. If tail is not nil, it is
+// called to fill frames once r ends.
+func NewReader(r pcm.Reader, process, tail func(frame []float64) bool) *Reader {
+	f := r.PCMFormat()
+	return &Reader{
+		Reader:  r,
+		process: process,
+		tail:    tail,
+		frame:   make([]float64, f.Channels),
+		frames:  frameBuffer{size: int(f.SampleSize())},
+	}
+}
+
+// ReadPCM reads processed frames into b.
+func (r *Reader) ReadPCM(b []byte) (n int, err error) {
+	if r.frames.size == 0 {
+		return 0, pcm.ErrUnsupportedBits
+	}
+	n, err = r.frames.read(b, r)
+	if n == 0 && err == nil && r.done {
+		return 0, io.EOF
+	}
+	return n, err
+}
+
+func (r *Reader) fill(dst []byte) (int, error) {
+	if r.done {
+		return 0, nil
+	}
+	if r.ended {
+		return r.fillTail(dst), nil
+	}
+	return r.fillSource(dst)
+}
+
+// fillSource fills dst with processed frames from the underlying reader.
+func (r *Reader) fillSource(dst []byte) (int, error) {
+	format := r.PCMFormat()
+	size := r.frames.size
+	total := copy(dst, r.partial)
+	var err error
+	for {
+		var read int
+		read, err = r.Reader.ReadPCM(dst[total:])
+		total += read
+		if total >= size || read == 0 || err != nil {
+			break
+		}
+	}
+	whole := total / size * size
+	r.partial = append(r.partial[:0], dst[whole:total]...)
+	for off := 0; off < whole; off += size {
+		Decode(format, dst[off:off+size], r.frame)
+		if !r.process(r.frame) {
+			r.done = true
+			return off, nil
+		}
+		Encode(format, r.frame, dst[off:off+size])
+	}
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			return whole, err
+		}
+		if r.tail != nil {
+			r.ended = true
+		} else {
+			r.done = true
+		}
+	}
+	return whole, nil
+}
+
+// fillTail fills dst with frames from tail.
+func (r *Reader) fillTail(dst []byte) int {
+	format := r.PCMFormat()
+	size := r.frames.size
+	off := 0
+	for ; off+size <= len(dst); off += size {
+		if !r.tail(r.frame) {
+			r.done = true
+			break
+		}
+		Encode(format, r.frame, dst[off:off+size])
+	}
+	return off
+}
+
+// A filler fills whole frames of dst, returning how many bytes were filled. It fills nothing
+// once it has ended or cannot currently make progress.
+type filler interface {
+	fill(dst []byte) (int, error)
+}
+
+// A frameBuffer lets frames be filled straight into reads of any length, buffering the rest of
+// a frame which did not fit in a read.
+type frameBuffer struct {
+	size int
+	// scratch holds one frame, for reads with less than a frame of space left.
+	scratch []byte
+	// pending holds bytes of scratch which did not fit in the last read.
+	pending []byte
+}
+
+func (fb *frameBuffer) read(b []byte, f filler) (n int, err error) {
+	n = copy(b, fb.pending)
+	fb.pending = fb.pending[n:]
+	for n < len(b) {
+		dst := b[n:]
+		dst = dst[:len(dst)/fb.size*fb.size]
+		if len(dst) != 0 {
+			filled, err := f.fill(dst)
+			n += filled
+			if filled == 0 || err != nil {
+				return n, err
+			}
+			continue
+		}
+		if fb.scratch == nil {
+			fb.scratch = make([]byte, fb.size)
+		}
+		filled, err := f.fill(fb.scratch)
+		copied := copy(b[n:], fb.scratch[:filled])
+		n += copied
+		fb.pending = fb.scratch[copied:filled]
+		if filled == 0 || err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
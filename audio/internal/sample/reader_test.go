@@ -0,0 +1,81 @@
+package sample
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+var format = pcm.Format{SampleRate: 100, Channels: 2, Bits: 16}
+
+func source(frames int) pcm.Reader {
+	b := make([]byte, frames*int(format.SampleSize()))
+	for i := range b {
+		b[i] = byte(i / 4)
+	}
+	return &pcm.IOReader{Format: format, Reader: bytes.NewReader(b)}
+}
+
+func TestReader(t *testing.T) {
+	ended := 0
+	r := NewReader(source(10), func(frame []float64) bool {
+		frame[0] = 0
+		return true
+	}, func(frame []float64) bool {
+		if ended == 3 {
+			return false
+		}
+		ended++
+		frame[0], frame[1] = 1, 1
+		return true
+	})
+	var out []byte
+	// An odd buffer size checks that partial frames are not lost.
+	buf := make([]byte, 3)
+	for {
+		n, err := r.ReadPCM(buf)
+		out = append(out, buf[:n]...)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+	}
+	if len(out) != 13*4 {
+		t.Fatalf("expected 13 frames, got %v bytes", len(out))
+	}
+	if out[36] != 0 || out[38] != 9 {
+		t.Fatalf("expected frames to pass through process, got %v", out)
+	}
+	if out[48] != 0xFF || out[49] != 0x7F {
+		t.Fatalf("expected tail frames after the source ended, got %v", out[40:])
+	}
+}
+
+func TestReader_Ends(t *testing.T) {
+	frames := 0
+	r := NewReader(source(10), func(frame []float64) bool {
+		frames++
+		return frames <= 4
+	}, nil)
+	out := make([]byte, 100)
+	n, err := r.ReadPCM(out)
+	if n != 16 || err != nil {
+		t.Fatalf("expected 4 frames, got %v bytes: %v", n, err)
+	}
+	if _, err := r.ReadPCM(out); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestReader_Allocations(t *testing.T) {
+	r := NewReader(source(10000), func(frame []float64) bool { return true }, nil)
+	buf := make([]byte, 7)
+	if allocs := testing.AllocsPerRun(100, func() { r.ReadPCM(buf) }); allocs != 0 {
+		t.Fatalf("expected reads not to allocate, got %v allocations", allocs)
+	}
+}
@@ -0,0 +1,54 @@
+package sample
+
+import (
+	"io"
+
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// A Renderer is a reader of frames rendered one at a time, such as by a synthesizer.
+type Renderer struct {
+	pcm.Format
+	// next fills a frame of normalized samples, returning false once there are no more.
+	next func(frame []float64) bool
+
+	frame  []float64
+	frames frameBuffer
+	done   bool
+}
+
+// NewRenderer creates a renderer of audio in format f, whose frames are filled by next until it
+// returns false.
+func NewRenderer(f pcm.Format, next func(frame []float64) bool) *Renderer {
+	return &Renderer{
+		Format: f,
+		next:   next,
+		frame:  make([]float64, f.Channels),
+		frames: frameBuffer{size: int(f.SampleSize())},
+	}
+}
+
+// ReadPCM renders frames into b.
+func (r *Renderer) ReadPCM(b []byte) (int, error) {
+	if r.frames.size == 0 {
+		return 0, pcm.ErrUnsupportedBits
+	}
+	n, _ := r.frames.read(b, r)
+	if n == 0 && r.done {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+func (r *Renderer) fill(dst []byte) (int, error) {
+	size := r.frames.size
+	off := 0
+	for ; !r.done && off+size <= len(dst); off += size {
+		if !r.next(r.frame) {
+			r.done = true
+			break
+		}
+		Encode(r.Format, r.frame, dst[off:off+size])
+	}
+	return off, nil
+}
@@ -0,0 +1,46 @@
+package sample
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestRenderer(t *testing.T) {
+	frames := 0
+	r := NewRenderer(format, func(frame []float64) bool {
+		if frames == 5 {
+			return false
+		}
+		frames++
+		frame[0], frame[1] = 1, -1
+		return true
+	})
+	var out []byte
+	// An odd buffer size checks that partial frames are not lost.
+	buf := make([]byte, 3)
+	for {
+		n, err := r.ReadPCM(buf)
+		out = append(out, buf[:n]...)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+	}
+	if len(out) != 5*4 {
+		t.Fatalf("expected 5 frames, got %v bytes", len(out))
+	}
+	if out[16] != 0xFF || out[17] != 0x7F || out[19] != 0x80 {
+		t.Fatalf("expected rendered frames, got %v", out)
+	}
+}
+
+func TestRenderer_Allocations(t *testing.T) {
+	r := NewRenderer(format, func(frame []float64) bool { return true })
+	buf := make([]byte, 7)
+	if allocs := testing.AllocsPerRun(100, func() { r.ReadPCM(buf) }); allocs != 0 {
+		t.Fatalf("expected reads not to allocate, got %v allocations", allocs)
+	}
+}
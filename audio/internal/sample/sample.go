@@ -0,0 +1,67 @@
+// Package sample converts between encoded PCM samples and normalized floating point values, and
+// reads audio one frame of normalized values at a time.
+package sample
+
+import (
+	"math"
+
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// Decode converts the samples in b into dst, normalized to the range -1.0 -> 1.0.
+func Decode(f pcm.Format, b []byte, dst []float64) {
+	switch f.Bits {
+	case 8:
+		for i, byt := range b {
+			dst[i] = float64(int8(byt)) / -math.MinInt8
+		}
+	case 16:
+		for i := 0; i+2 <= len(b); i += 2 {
+			i16 := int16(b[i]) | int16(b[i+1])<<8
+			dst[i/2] = float64(i16) / -math.MinInt16
+		}
+	case 32:
+		for i := 0; i+4 <= len(b); i += 4 {
+			i32 := int32(b[i]) |
+				int32(b[i+1])<<8 |
+				int32(b[i+2])<<16 |
+				int32(b[i+3])<<24
+			dst[i/4] = float64(i32) / -math.MinInt32
+		}
+	}
+}
+
+// Encode converts the normalized values in src into b, clipping values outside of -1.0 -> 1.0.
+func Encode(f pcm.Format, src []float64, b []byte) {
+	switch f.Bits {
+	case 8:
+		for i, v := range src {
+			b[i] = byte(int8(clip(v, math.MinInt8, math.MaxInt8)))
+		}
+	case 16:
+		for i, v := range src {
+			i16 := int16(clip(v, math.MinInt16, math.MaxInt16))
+			b[i*2] = byte(i16)
+			b[i*2+1] = byte(i16 >> 8)
+		}
+	case 32:
+		for i, v := range src {
+			i32 := int32(clip(v, math.MinInt32, math.MaxInt32))
+			b[i*4] = byte(i32)
+			b[i*4+1] = byte(i32 >> 8)
+			b[i*4+2] = byte(i32 >> 16)
+			b[i*4+3] = byte(i32 >> 24)
+		}
+	}
+}
+
+func clip(v, min, max float64) float64 {
+	v *= -min
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return math.Round(v)
+}
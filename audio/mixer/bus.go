@@ -0,0 +1,73 @@
+package mixer
+
+import "github.com/diakovliev/oak/v4/audio/effect"
+
+// A Bus groups voices played through a Mixer so they can be attenuated or muted together.
+type Bus struct {
+	name      string
+	mixer     *Mixer
+	volume    float64
+	muted     bool
+	maxVoices int
+	playing   int
+	effects   effect.Processor
+
+	buf []float64
+}
+
+// Name returns the name of this bus.
+func (b *Bus) Name() string {
+	return b.name
+}
+
+// SetVolume sets the volume of this bus, between 0.0 and 1.0.
+func (b *Bus) SetVolume(v float64) {
+	b.mixer.mu.Lock()
+	b.volume = clamp01(v)
+	b.mixer.mu.Unlock()
+}
+
+// Volume returns the volume of this bus.
+func (b *Bus) Volume() float64 {
+	b.mixer.mu.Lock()
+	defer b.mixer.mu.Unlock()
+	return b.volume
+}
+
+// SetMute mutes or unmutes this bus. Voices on a muted bus continue to advance, but are not heard.
+func (b *Bus) SetMute(muted bool) {
+	b.mixer.mu.Lock()
+	b.muted = muted
+	b.mixer.mu.Unlock()
+}
+
+// Muted reports whether this bus is muted.
+func (b *Bus) Muted() bool {
+	b.mixer.mu.Lock()
+	defer b.mixer.mu.Unlock()
+	return b.muted
+}
+
+// SetMaxVoices sets how many voices may play on this bus at once. If zero, the bus is only limited by
+// the mixer's voice limit. Lowering the limit does not stop voices which are already playing.
+func (b *Bus) SetMaxVoices(n int) {
+	b.mixer.mu.Lock()
+	b.maxVoices = n
+	b.mixer.mu.Unlock()
+}
+
+// Playing returns how many voices are currently playing on this bus.
+func (b *Bus) Playing() int {
+	b.mixer.mu.Lock()
+	defer b.mixer.mu.Unlock()
+	return b.playing
+}
+
+// SetEffects inserts effects on this bus, replacing any previous effects. The mixed audio of the bus's
+// voices passes through each effect in order before the bus's volume is applied. Effects on a muted bus
+// continue to run, so meters on a muted bus still measure its voices.
+func (b *Bus) SetEffects(effects ...effect.Effect) {
+	b.mixer.mu.Lock()
+	b.effects = b.mixer.chain(effects)
+	b.mixer.mu.Unlock()
+}
@@ -0,0 +1,414 @@
+// Package mixer provides a software mixer for combining many PCM streams into a single audio writer.
+package mixer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/diakovliev/oak/v4/audio"
+	"github.com/diakovliev/oak/v4/audio/effect"
+	"github.com/diakovliev/oak/v4/audio/internal/sample"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+var _ pcm.Reader = &Mixer{}
+
+// Default bus names. A Mixer always contains these buses.
+const (
+	BusMusic = "music"
+	BusSFX   = "sfx"
+	BusVoice = "voice"
+)
+
+// ErrVoiceLimit is returned when a voice could not be started because a voice limit was reached and
+// no playing voice had a low enough priority to be stolen.
+var ErrVoiceLimit = fmt.Errorf("voice limit reached")
+
+// Options configure a Mixer.
+type Options struct {
+	// MaxVoices limits how many voices may play at once across all buses. If zero, voices are unlimited.
+	MaxVoices int
+	// ResampleMethod determines how voices are resampled when they are converted to the mixer's format.
+	ResampleMethod audio.ResampleMethod
+	// PlayOptions are passed through to audio.Play when the mixer is run.
+	PlayOptions []audio.PlayOption
+}
+
+// A Mixer combines any number of voices into a single stream written to one pcm.Writer. Voices are grouped
+// into buses which can be collectively attenuated or muted. A Mixer is itself a pcm.Reader that never ends;
+// when no voices are playing it produces silence.
+type Mixer struct {
+	pcm.Format
+
+	mu        sync.Mutex
+	writer    pcm.Writer
+	volume    float64
+	maxVoices int
+	buses     map[string]*Bus
+	busOrder  []*Bus
+	voices    []*Voice
+	playOpts  []audio.PlayOption
+	resample  audio.ResampleMethod
+	nextOrder uint64
+	effects   effect.Processor
+
+	// readMu serializes ReadPCM, guarding the fields below and voice readers, which are read without
+	// mu held.
+	readMu    sync.Mutex
+	mixVoices []voiceMix
+	mixBuses  []busMix
+	ended     []endedVoice
+	raw       []byte
+	frame     []float64
+	master    []float64
+}
+
+// New creates a mixer which owns an OS level writer of the given format, obtained from audio.NewWriter.
+func New(f pcm.Format, opts Options) (*Mixer, error) {
+	w, err := audio.NewWriter(f)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithWriter(w, opts), nil
+}
+
+// NewWithWriter creates a mixer which will write to the given writer. The mixer's format is the writer's
+// format. The mixer takes ownership of the writer and will close it when the mixer is closed.
+func NewWithWriter(w pcm.Writer, opts Options) *Mixer {
+	m := &Mixer{
+		Format:    w.PCMFormat(),
+		writer:    w,
+		volume:    1,
+		maxVoices: opts.MaxVoices,
+		buses:     make(map[string]*Bus),
+		playOpts:  opts.PlayOptions,
+		resample:  opts.ResampleMethod,
+	}
+	m.Bus(BusMusic)
+	m.Bus(BusSFX)
+	m.Bus(BusVoice)
+	return m
+}
+
+// Run streams mixed audio to the mixer's writer until ctx is cancelled.
+func (m *Mixer) Run(ctx context.Context) error {
+	opts := append([]audio.PlayOption{func(po *audio.PlayOptions) {
+		po.Destination = m.writer
+	}}, m.playOpts...)
+	return audio.Play(ctx, m, opts...)
+}
+
+// Close stops all voices and closes the mixer's writer.
+func (m *Mixer) Close() error {
+	m.StopAll()
+	return m.writer.Close()
+}
+
+// SetVolume sets the master volume of the mixer, between 0.0 and 1.0.
+func (m *Mixer) SetVolume(v float64) {
+	m.mu.Lock()
+	m.volume = clamp01(v)
+	m.mu.Unlock()
+}
+
+// Volume returns the master volume of the mixer.
+func (m *Mixer) Volume() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.volume
+}
+
+// SetEffects inserts effects on the mixer's output, replacing any previous effects. The mix of all buses
+// passes through each effect in order after the master volume is applied, e.g. a limiter to prevent clipping.
+func (m *Mixer) SetEffects(effects ...effect.Effect) {
+	m.mu.Lock()
+	m.effects = m.chain(effects)
+	m.mu.Unlock()
+}
+
+func (m *Mixer) chain(effects []effect.Effect) effect.Processor {
+	if len(effects) == 0 {
+		return nil
+	}
+	return effect.Chain(m.Format, effects...)
+}
+
+// SetMaxVoices sets how many voices may play at once across all buses. If zero, voices are unlimited.
+// Lowering the limit does not stop voices which are already playing.
+func (m *Mixer) SetMaxVoices(n int) {
+	m.mu.Lock()
+	m.maxVoices = n
+	m.mu.Unlock()
+}
+
+// Bus returns the bus with the given name, creating it if it does not exist.
+func (m *Mixer) Bus(name string) *Bus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bus(name)
+}
+
+func (m *Mixer) bus(name string) *Bus {
+	if b, ok := m.buses[name]; ok {
+		return b
+	}
+	b := &Bus{name: name, mixer: m, volume: 1}
+	m.buses[name] = b
+	m.busOrder = append(m.busOrder, b)
+	return b
+}
+
+// Voices returns the voices which are currently playing.
+func (m *Mixer) Voices() []*Voice {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]*Voice, len(m.voices))
+	copy(out, m.voices)
+	return out
+}
+
+// StopAll stops every playing voice.
+func (m *Mixer) StopAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, v := range m.voices {
+		v.finish(nil)
+	}
+	m.voices = m.voices[:0]
+}
+
+// Play starts playing r as a new voice. The reader is converted to the mixer's format if needed. If a voice limit
+// would be exceeded, the lowest priority voice that is no more important than the new voice is stolen; ties
+// are broken by stealing the oldest voice. If no voice can be stolen, ErrVoiceLimit is returned.
+func (m *Mixer) Play(r pcm.Reader, options ...VoiceOption) (*Voice, error) {
+	opts := defaultVoiceOptions()
+	for _, o := range options {
+		o(&opts)
+	}
+	switch m.Bits {
+	case 8, 16, 32:
+	default:
+		return nil, pcm.ErrUnsupportedBits
+	}
+	r, err := audio.Convert(r, m.Format, m.resample)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", audio.ErrMismatchedPCMFormat, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := m.bus(opts.Bus)
+	if b.maxVoices > 0 && b.playing >= b.maxVoices {
+		if !m.steal(b, opts.Priority) {
+			return nil, ErrVoiceLimit
+		}
+	}
+	if m.maxVoices > 0 && len(m.voices) >= m.maxVoices {
+		if !m.steal(nil, opts.Priority) {
+			return nil, ErrVoiceLimit
+		}
+	}
+	m.nextOrder++
+	v := &Voice{
+		reader:   r,
+		bus:      b,
+		volume:   clamp01(opts.Volume),
+		pan:      clampPan(opts.Pan),
+		priority: opts.Priority,
+		order:    m.nextOrder,
+		done:     make(chan struct{}),
+	}
+	b.playing++
+	m.voices = append(m.voices, v)
+	return v, nil
+}
+
+// steal stops the lowest priority, oldest voice on the given bus (or on any bus, if b is nil) whose priority
+// does not exceed priority. It reports whether a voice was stopped.
+func (m *Mixer) steal(b *Bus, priority int) bool {
+	victim := -1
+	for i, v := range m.voices {
+		if b != nil && v.bus != b {
+			continue
+		}
+		if v.priority > priority {
+			continue
+		}
+		if victim == -1 {
+			victim = i
+			continue
+		}
+		cur := m.voices[victim]
+		if v.priority < cur.priority || (v.priority == cur.priority && v.order < cur.order) {
+			victim = i
+		}
+	}
+	if victim == -1 {
+		return false
+	}
+	m.removeVoice(victim, ErrStolen)
+	return true
+}
+
+func (m *Mixer) removeVoice(i int, err error) {
+	v := m.voices[i]
+	v.finish(err)
+	m.voices = append(m.voices[:i], m.voices[i+1:]...)
+}
+
+// stop removes a voice if it is still playing. It must be called with mu held.
+func (m *Mixer) stop(v *Voice, err error) {
+	for i, mv := range m.voices {
+		if mv == v {
+			m.removeVoice(i, err)
+			return
+		}
+	}
+}
+
+// ReadPCM mixes the next len(b) bytes of audio from all playing voices into b. It always fills b
+// completely, up to a whole number of samples.
+func (m *Mixer) ReadPCM(b []byte) (int, error) {
+	sampleSize := m.SampleSize()
+	if sampleSize == 0 {
+		return 0, pcm.ErrUnsupportedBits
+	}
+	n := len(b) - len(b)%sampleSize
+	b = b[:n]
+	values := n / int(m.Bits/8)
+	channels := int(m.Channels)
+
+	m.readMu.Lock()
+	defer m.readMu.Unlock()
+
+	// Voices are decoded without holding mu, so that slow readers do not block changes to the mixer;
+	// what is mixed is decided up front.
+	m.mu.Lock()
+	m.mixVoices = m.mixVoices[:0]
+	for _, v := range m.voices {
+		m.mixVoices = append(m.mixVoices, voiceMix{voice: v, volume: v.volume, pan: v.pan})
+	}
+	m.mixBuses = m.mixBuses[:0]
+	for _, bus := range m.busOrder {
+		m.mixBuses = append(m.mixBuses, busMix{bus: bus, volume: bus.volume, muted: bus.muted, effects: bus.effects})
+	}
+	volume, effects := m.volume, m.effects
+	m.mu.Unlock()
+
+	m.master = grow(m.master, values)
+	m.frame = grow(m.frame, values)
+	if cap(m.raw) < n {
+		m.raw = make([]byte, n)
+	}
+	raw := m.raw[:n]
+	for i := range m.master {
+		m.master[i] = 0
+	}
+	for _, bm := range m.mixBuses {
+		bm.bus.buf = grow(bm.bus.buf, values)
+		for i := range bm.bus.buf {
+			bm.bus.buf[i] = 0
+		}
+	}
+
+	m.ended = m.ended[:0]
+	for _, vm := range m.mixVoices {
+		// If a voice cannot fill b, the rest of its part of the mix is silent.
+		read, err := audio.ReadFull(vm.voice.reader, raw)
+		read -= read % sampleSize
+		if read > 0 {
+			sample.Decode(m.Format, raw[:read], m.frame)
+			mixInto(vm.voice.bus.buf, m.frame[:read/int(m.Bits/8)], channels, vm.volume, vm.pan)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				err = nil
+			}
+			m.ended = append(m.ended, endedVoice{voice: vm.voice, err: err})
+		}
+	}
+	if len(m.ended) != 0 {
+		m.mu.Lock()
+		for _, e := range m.ended {
+			m.stop(e.voice, e.err)
+		}
+		m.mu.Unlock()
+	}
+
+	for _, bm := range m.mixBuses {
+		if bm.effects != nil {
+			process(bm.effects, bm.bus.buf, channels)
+		}
+		if bm.muted {
+			continue
+		}
+		for i, f := range bm.bus.buf {
+			m.master[i] += f * bm.volume
+		}
+	}
+	for i := range m.master {
+		m.master[i] *= volume
+	}
+	if effects != nil {
+		process(effects, m.master, channels)
+	}
+	sample.Encode(m.Format, m.master, b)
+	return n, nil
+}
+
+// voiceMix and busMix hold the settings of voices and buses as of the start of a ReadPCM call.
+type voiceMix struct {
+	voice       *Voice
+	volume, pan float64
+}
+
+type busMix struct {
+	bus     *Bus
+	volume  float64
+	muted   bool
+	effects effect.Processor
+}
+
+// endedVoice is a voice which finished during a ReadPCM call, and why.
+type endedVoice struct {
+	voice *Voice
+	err   error
+}
+
+// process passes each frame of interleaved samples through p.
+func process(p effect.Processor, samples []float64, channels int) {
+	for off := 0; off+channels <= len(samples); off += channels {
+		p.Process(samples[off : off+channels])
+	}
+}
+
+func grow(f []float64, n int) []float64 {
+	if cap(f) < n {
+		return make([]float64, n)
+	}
+	return f[:n]
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+func clampPan(p float64) float64 {
+	if p < -1 {
+		return -1
+	}
+	if p > 1 {
+		return 1
+	}
+	return p
+}
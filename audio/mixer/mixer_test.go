@@ -0,0 +1,298 @@
+package mixer_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/audio"
+	"github.com/diakovliev/oak/v4/audio/effect"
+	"github.com/diakovliev/oak/v4/audio/mixer"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+var format = pcm.Format{
+	SampleRate: 44100,
+	Channels:   2,
+	Bits:       16,
+}
+
+type fakeWriter struct {
+	pcm.Format
+	closed bool
+}
+
+func (fw *fakeWriter) Close() error {
+	fw.closed = true
+	return nil
+}
+
+func (fw *fakeWriter) WritePCM(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// constant returns a reader of frames stereo samples, all equal to v.
+func constant(v int16, frames int) pcm.Reader {
+	b := make([]byte, frames*4)
+	for i := 0; i < len(b); i += 2 {
+		b[i] = byte(v)
+		b[i+1] = byte(v >> 8)
+	}
+	return &pcm.IOReader{
+		Format: format,
+		Reader: bytes.NewReader(b),
+	}
+}
+
+func samples(b []byte) []int16 {
+	out := make([]int16, len(b)/2)
+	for i := range out {
+		out[i] = int16(b[i*2]) | int16(b[i*2+1])<<8
+	}
+	return out
+}
+
+func newMixer(opts mixer.Options) (*mixer.Mixer, *fakeWriter) {
+	w := &fakeWriter{Format: format}
+	return mixer.NewWithWriter(w, opts), w
+}
+
+func TestMixer_Silence(t *testing.T) {
+	m, _ := newMixer(mixer.Options{})
+	b := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	n, err := m.ReadPCM(b)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if n != 8 {
+		t.Fatalf("expected 8 bytes read, got %v", n)
+	}
+	for _, s := range samples(b[:n]) {
+		if s != 0 {
+			t.Fatalf("expected silence, got %v", s)
+		}
+	}
+}
+
+func TestMixer_Sum(t *testing.T) {
+	m, _ := newMixer(mixer.Options{})
+	if _, err := m.Play(constant(1000, 4)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Play(constant(2000, 4), mixer.OnBus(mixer.BusMusic)); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 16)
+	m.ReadPCM(b)
+	for _, s := range samples(b) {
+		if s != 3000 {
+			t.Fatalf("expected 3000, got %v", s)
+		}
+	}
+}
+
+func TestMixer_Clipping(t *testing.T) {
+	m, _ := newMixer(mixer.Options{})
+	m.Play(constant(30000, 2))
+	m.Play(constant(30000, 2))
+	b := make([]byte, 8)
+	m.ReadPCM(b)
+	for _, s := range samples(b) {
+		if s != 32767 {
+			t.Fatalf("expected clipped sample, got %v", s)
+		}
+	}
+}
+
+func TestMixer_VolumeAndPan(t *testing.T) {
+	m, _ := newMixer(mixer.Options{})
+	v, _ := m.Play(constant(1000, 4), mixer.WithVolume(.5), mixer.WithPan(1))
+	b := make([]byte, 8)
+	m.ReadPCM(b)
+	got := samples(b)
+	if got[0] != 0 || got[1] != 500 {
+		t.Fatalf("expected [0 500], got %v", got[:2])
+	}
+	v.SetPan(-.5)
+	m.ReadPCM(b)
+	got = samples(b)
+	if got[0] != 500 || got[1] != 250 {
+		t.Fatalf("expected [500 250], got %v", got[:2])
+	}
+}
+
+func TestMixer_Buses(t *testing.T) {
+	m, _ := newMixer(mixer.Options{})
+	m.Play(constant(1000, 8), mixer.OnBus(mixer.BusMusic))
+	m.Play(constant(100, 8), mixer.OnBus(mixer.BusVoice))
+	m.Bus(mixer.BusMusic).SetVolume(.5)
+	b := make([]byte, 4)
+	m.ReadPCM(b)
+	if s := samples(b)[0]; s != 600 {
+		t.Fatalf("expected 600, got %v", s)
+	}
+	m.Bus(mixer.BusMusic).SetMute(true)
+	m.ReadPCM(b)
+	if s := samples(b)[0]; s != 100 {
+		t.Fatalf("expected 100, got %v", s)
+	}
+	m.SetVolume(.5)
+	m.ReadPCM(b)
+	if s := samples(b)[0]; s != 50 {
+		t.Fatalf("expected 50, got %v", s)
+	}
+}
+
+func TestMixer_VoiceFinishes(t *testing.T) {
+	m, _ := newMixer(mixer.Options{})
+	v, _ := m.Play(constant(1000, 1))
+	b := make([]byte, 8)
+	m.ReadPCM(b)
+	got := samples(b)
+	if got[0] != 1000 || got[2] != 0 {
+		t.Fatalf("expected voice to end after one frame, got %v", got)
+	}
+	select {
+	case <-v.Done():
+	default:
+		t.Fatal("expected voice to be done")
+	}
+	if v.Playing() || v.Err() != nil {
+		t.Fatalf("expected finished voice without error, got %v", v.Err())
+	}
+	if n := len(m.Voices()); n != 0 {
+		t.Fatalf("expected no voices, got %v", n)
+	}
+}
+
+// stallingReader returns no data on its first read, calling onRead while the mixer reads it.
+type stallingReader struct {
+	pcm.Reader
+	stalled bool
+	onRead  func()
+}
+
+func (sr *stallingReader) ReadPCM(b []byte) (int, error) {
+	if sr.onRead != nil {
+		sr.onRead()
+	}
+	if !sr.stalled {
+		sr.stalled = true
+		return 0, nil
+	}
+	return sr.Reader.ReadPCM(b)
+}
+
+func TestMixer_StalledVoice(t *testing.T) {
+	m, _ := newMixer(mixer.Options{})
+	sr := &stallingReader{Reader: constant(1000, 100)}
+	v, _ := m.Play(sr)
+	// Voices are read without the mixer locked, so readers may control their own voice.
+	sr.onRead = func() { v.Volume() }
+	b := make([]byte, 8)
+	m.ReadPCM(b)
+	if got := samples(b); got[0] != 1000 || got[2] != 1000 {
+		t.Fatalf("expected stalled voice to keep playing, got %v", got)
+	}
+	if !v.Playing() {
+		t.Fatal("expected stalled voice to still be playing")
+	}
+}
+
+func TestMixer_Stealing(t *testing.T) {
+	m, _ := newMixer(mixer.Options{MaxVoices: 2})
+	low, _ := m.Play(constant(1, 100), mixer.WithPriority(1))
+	high, _ := m.Play(constant(1, 100), mixer.WithPriority(5))
+	if _, err := m.Play(constant(1, 100), mixer.WithPriority(0)); !errors.Is(err, mixer.ErrVoiceLimit) {
+		t.Fatalf("expected voice limit error, got %v", err)
+	}
+	v, err := m.Play(constant(1, 100), mixer.WithPriority(1))
+	if err != nil {
+		t.Fatalf("expected low priority voice to be stolen: %v", err)
+	}
+	if low.Playing() || !errors.Is(low.Err(), mixer.ErrStolen) {
+		t.Fatal("expected low priority voice to be stolen")
+	}
+	if !high.Playing() || !v.Playing() {
+		t.Fatal("expected other voices to keep playing")
+	}
+}
+
+func TestMixer_BusLimit(t *testing.T) {
+	m, _ := newMixer(mixer.Options{})
+	m.Bus(mixer.BusVoice).SetMaxVoices(1)
+	first, _ := m.Play(constant(1, 100), mixer.OnBus(mixer.BusVoice))
+	sfx, _ := m.Play(constant(1, 100))
+	second, err := m.Play(constant(1, 100), mixer.OnBus(mixer.BusVoice))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Playing() || !second.Playing() || !sfx.Playing() {
+		t.Fatal("expected only the older voice on the bus to be stolen")
+	}
+	if n := m.Bus(mixer.BusVoice).Playing(); n != 1 {
+		t.Fatalf("expected one voice on bus, got %v", n)
+	}
+}
+
+func TestMixer_ConvertsFormat(t *testing.T) {
+	m, _ := newMixer(mixer.Options{})
+	mono := &pcm.IOReader{
+		Format: pcm.Format{SampleRate: 44100, Channels: 1, Bits: 8},
+		Reader: bytes.NewReader([]byte{64, 64}),
+	}
+	if _, err := m.Play(mono); err != nil {
+		t.Fatalf("expected mono voice to be converted: %v", err)
+	}
+	b := make([]byte, 8)
+	m.ReadPCM(b)
+	for _, s := range samples(b) {
+		if s != 16384 {
+			t.Fatalf("expected 16384, got %v", s)
+		}
+	}
+	_, err := m.Play(&pcm.IOReader{Format: pcm.Format{SampleRate: 44100, Channels: 1, Bits: 24}})
+	if !errors.Is(err, audio.ErrMismatchedPCMFormat) {
+		t.Fatalf("expected mismatched format error, got %v", err)
+	}
+}
+
+func TestMixer_Close(t *testing.T) {
+	m, w := newMixer(mixer.Options{})
+	v, _ := m.Play(constant(1, 100))
+	m.Close()
+	if !w.closed {
+		t.Fatal("expected writer to be closed")
+	}
+	if v.Playing() {
+		t.Fatal("expected voices to be stopped")
+	}
+}
+
+func TestMixer_Effects(t *testing.T) {
+	m, _ := newMixer(mixer.Options{})
+	meter := &effect.Meter{}
+	m.Bus(mixer.BusVoice).SetEffects(meter)
+	m.Bus(mixer.BusMusic).SetEffects(effect.Duck{Sidechain: meter, Threshold: -40, Depth: 20})
+	m.SetEffects(effect.Limiter(-6, 0))
+	m.Play(constant(30000, 8), mixer.OnBus(mixer.BusMusic))
+	b := make([]byte, 8)
+	m.ReadPCM(b)
+	// The limiter holds the music to -6 dB.
+	if s := samples(b)[0]; s != 16423 {
+		t.Fatalf("expected limited sample, got %v", s)
+	}
+	m.Play(constant(1000, 8), mixer.OnBus(mixer.BusVoice))
+	m.ReadPCM(b)
+	m.ReadPCM(b)
+	// Once the voice has been measured, the music is ducked by 20 dB under it.
+	if s := samples(b)[0]; s != 4000 {
+		t.Fatalf("expected ducked music, got %v", s)
+	}
+	m.SetEffects()
+	m.Bus(mixer.BusMusic).SetEffects()
+	m.ReadPCM(b)
+	if s := samples(b)[0]; s != 31000 {
+		t.Fatalf("expected effects to be removed, got %v", s)
+	}
+}
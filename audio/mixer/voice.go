@@ -0,0 +1,178 @@
+package mixer
+
+import (
+	"fmt"
+
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// ErrStolen is reported by a Voice's Err method when the voice was stopped to make room for another voice.
+var ErrStolen = fmt.Errorf("voice stolen")
+
+// A VoiceOption sets some value on a VoiceOptions struct.
+type VoiceOption func(*VoiceOptions)
+
+// VoiceOptions define how a voice should be played by a Mixer.
+type VoiceOptions struct {
+	// Bus is the name of the bus the voice plays on. Defaults to BusSFX.
+	Bus string
+	// Volume, between 0.0 -> 1.0. Defaults to 1.
+	Volume float64
+	// Pan, between -1.0 (left) -> 1.0 (right). Defaults to 0. Pan has no effect on non-stereo formats.
+	Pan float64
+	// Priority determines which voices may be stolen when a voice limit is reached; voices with
+	// higher priorities are kept over voices with lower priorities.
+	Priority int
+}
+
+func defaultVoiceOptions() VoiceOptions {
+	return VoiceOptions{
+		Bus:    BusSFX,
+		Volume: 1,
+	}
+}
+
+// OnBus sets the bus a voice will play on.
+func OnBus(name string) VoiceOption {
+	return func(vo *VoiceOptions) {
+		vo.Bus = name
+	}
+}
+
+// WithVolume sets the initial volume of a voice.
+func WithVolume(v float64) VoiceOption {
+	return func(vo *VoiceOptions) {
+		vo.Volume = v
+	}
+}
+
+// WithPan sets the initial pan of a voice.
+func WithPan(p float64) VoiceOption {
+	return func(vo *VoiceOptions) {
+		vo.Pan = p
+	}
+}
+
+// WithPriority sets the priority of a voice.
+func WithPriority(p int) VoiceOption {
+	return func(vo *VoiceOptions) {
+		vo.Priority = p
+	}
+}
+
+// A Voice is a single stream playing through a Mixer.
+type Voice struct {
+	reader   pcm.Reader
+	bus      *Bus
+	volume   float64
+	pan      float64
+	priority int
+	order    uint64
+
+	finished bool
+	err      error
+	done     chan struct{}
+}
+
+// Bus returns the bus this voice is playing on.
+func (v *Voice) Bus() *Bus {
+	return v.bus
+}
+
+// Priority returns the priority this voice was started with.
+func (v *Voice) Priority() int {
+	return v.priority
+}
+
+// SetVolume sets the volume of this voice, between 0.0 and 1.0.
+func (v *Voice) SetVolume(vol float64) {
+	m := v.bus.mixer
+	m.mu.Lock()
+	v.volume = clamp01(vol)
+	m.mu.Unlock()
+}
+
+// Volume returns the volume of this voice.
+func (v *Voice) Volume() float64 {
+	m := v.bus.mixer
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return v.volume
+}
+
+// SetPan sets the pan of this voice, between -1.0 (left) and 1.0 (right).
+func (v *Voice) SetPan(p float64) {
+	m := v.bus.mixer
+	m.mu.Lock()
+	v.pan = clampPan(p)
+	m.mu.Unlock()
+}
+
+// Pan returns the pan of this voice.
+func (v *Voice) Pan() float64 {
+	m := v.bus.mixer
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return v.pan
+}
+
+// Stop stops this voice. Stopping a voice which has already finished does nothing.
+func (v *Voice) Stop() {
+	m := v.bus.mixer
+	m.mu.Lock()
+	m.stop(v, nil)
+	m.mu.Unlock()
+}
+
+// Done returns a channel which is closed once this voice has finished playing, been stopped, or been stolen.
+func (v *Voice) Done() <-chan struct{} {
+	return v.done
+}
+
+// Playing reports whether this voice is still playing.
+func (v *Voice) Playing() bool {
+	m := v.bus.mixer
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !v.finished
+}
+
+// Err returns the reason this voice finished early, if any. It returns ErrStolen for stolen voices and the
+// underlying read error for voices whose reader failed. It is only meaningful once Done is closed.
+func (v *Voice) Err() error {
+	m := v.bus.mixer
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return v.err
+}
+
+// finish must be called with the mixer lock held.
+func (v *Voice) finish(err error) {
+	if v.finished {
+		return
+	}
+	v.finished = true
+	v.err = err
+	v.bus.playing--
+	close(v.done)
+}
+
+// mixInto adds samples, scaled by volume and pan, into dst.
+func mixInto(dst, src []float64, channels int, volume, pan float64) {
+	if channels != 2 {
+		for i, f := range src {
+			dst[i] += f * volume
+		}
+		return
+	}
+	left, right := volume, volume
+	if pan > 0 {
+		left *= 1 - pan
+	} else if pan < 0 {
+		right *= 1 + pan
+	}
+	for i := 0; i+1 < len(src); i += 2 {
+		dst[i] += src[i] * left
+		dst[i+1] += src[i+1] * right
+	}
+}
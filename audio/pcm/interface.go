@@ -24,6 +24,19 @@ func (ior *IOReader) ReadPCM(p []byte) (n int, err error) {
 	return ior.Read(p)
 }
 
+// A Seeker can move the position of a Reader to a specific sample frame, where a frame is one sample
+// for every channel. Seeking should be sample accurate: the next read following a call to SeekPCM(n)
+// should begin with frame n. Readers that can seek should implement this interface.
+type Seeker interface {
+	SeekPCM(frame int64) error
+}
+
+// A ReadSeeker is a Reader that can also Seek.
+type ReadSeeker interface {
+	Reader
+	Seeker
+}
+
 // A Writer can have PCM formatted audio data written to it. It mimics io.Writer.
 type Writer interface {
 	io.Closer
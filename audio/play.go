@@ -50,9 +50,13 @@ type PlayOptions struct {
 	// This must be at least 2 to avoid the read and write buffers clipping.
 	// Defaults to 2.
 	ChaseIncrements int
-	// If AllowMismatchedFormats is false, Play will error when a reader's PCM format
-	// disagrees with a writer's expected PCM format. Defaults to false.
+	// If AllowMismatchedFormats is false, Play will convert a reader whose PCM format
+	// disagrees with a writer's expected PCM format, or error if it cannot be converted.
+	// If true, the reader's data is written as is. Defaults to false.
 	AllowMismatchedFormats bool
+	// ResampleMethod determines how readers are resampled when they are converted to
+	// a writer's sample rate. Defaults to ResampleLinear.
+	ResampleMethod ResampleMethod
 
 	ClearBufferOnStop bool
 }
@@ -67,7 +71,7 @@ func defaultPlayOptions() PlayOptions {
 }
 
 // ErrMismatchedPCMFormat will be returned by operations streaming from Readers to Writers where the PCM formats
-// of those Readers and Writers are not equivalent.
+// of those Readers and Writers are not equivalent and cannot be converted.
 var ErrMismatchedPCMFormat = fmt.Errorf("source and destination have differing PCM formats")
 
 // Play will copy data from the provided src to the provided dst until ctx is cancelled. This copy is not constant.
@@ -91,7 +95,11 @@ func Play(ctx context.Context, src pcm.Reader, options ...PlayOption) error {
 	format := opts.Destination.PCMFormat()
 	if !opts.AllowMismatchedFormats {
 		if srcFormat := src.PCMFormat(); srcFormat != format {
-			return ErrMismatchedPCMFormat
+			var err error
+			src, err = Convert(src, format, opts.ResampleMethod)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrMismatchedPCMFormat, err)
+			}
 		}
 	}
 	buf := make([]byte, format.BytesPerSecond()/uint32(time.Second/opts.CopyIncrement))
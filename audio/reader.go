@@ -5,10 +5,12 @@ import (
 	"io"
 
 	"github.com/diakovliev/oak/v4/audio/pcm"
+	"github.com/diakovliev/oak/v4/oakerr"
 )
 
 var _ pcm.Reader = &LoopingReader{}
-var _ pcm.Reader = &BytesReader{}
+var _ pcm.Reader = &sectionLoopReader{}
+var _ pcm.ReadSeeker = &BytesReader{}
 
 // LoopReader will cache read bytes as they are read and resend them after the reader returns EOF.
 func LoopReader(r pcm.Reader) pcm.Reader {
@@ -52,6 +54,75 @@ func (l *LoopingReader) ReadPCM(p []byte) (n int, err error) {
 	return
 }
 
+// LoopSection wraps a seekable reader such that, once it reaches the sample frame end, it seeks back to the
+// sample frame start and continues playing, forever. Audio before start plays once, as an intro. If end is
+// zero, the section loops when the reader is exhausted. Unlike LoopReader, no audio data is cached.
+// The reader is expected to be positioned at its beginning.
+func LoopSection(r pcm.Reader, start, end int64) (pcm.Reader, error) {
+	seeker, ok := r.(pcm.Seeker)
+	if !ok {
+		return nil, oakerr.UnsupportedFormat{Format: "unseekable pcm.Reader"}
+	}
+	if start < 0 || (end != 0 && end <= start) {
+		return nil, oakerr.InvalidInput{InputName: "start, end"}
+	}
+	size := int64(r.PCMFormat().SampleSize())
+	return &sectionLoopReader{
+		Reader: r,
+		seeker: seeker,
+		start:  start * size,
+		end:    end * size,
+		size:   size,
+	}, nil
+}
+
+type sectionLoopReader struct {
+	pcm.Reader
+	seeker pcm.Seeker
+	// start, end, and pos are in bytes
+	start, end, pos int64
+	size            int64
+}
+
+func (sl *sectionLoopReader) ReadPCM(p []byte) (n int, err error) {
+	emptyLoops := 0
+	for n == 0 {
+		buf := p
+		if sl.end != 0 && int64(len(buf)) > sl.end-sl.pos {
+			buf = buf[:sl.end-sl.pos]
+		}
+		var read int
+		if len(buf) != 0 {
+			read, err = sl.Reader.ReadPCM(buf)
+			n += read
+			sl.pos += int64(read)
+		}
+		atEnd := sl.end != 0 && sl.pos >= sl.end
+		if errors.Is(err, io.EOF) {
+			atEnd = true
+			err = nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if !atEnd {
+			continue
+		}
+		if read == 0 {
+			emptyLoops++
+			if emptyLoops > 1 {
+				// The loop section holds no audio.
+				return 0, io.EOF
+			}
+		}
+		if err := sl.seeker.SeekPCM(sl.start / sl.size); err != nil {
+			return n, err
+		}
+		sl.pos = sl.start
+	}
+	return n, nil
+}
+
 // A BytesReader acts like a bytes.Buffer for converting raw []bytes into pcm Readers.
 type BytesReader struct {
 	pcm.Format
@@ -60,18 +131,30 @@ type BytesReader struct {
 }
 
 func (b *BytesReader) ReadPCM(p []byte) (n int, err error) {
-	copy(p, b.Buffer)
-	if len(p) >= len(b.Buffer[b.Offset:]) {
-		b.Offset = len(b.Buffer)
-		return len(p), io.EOF
+	if b.Offset >= len(b.Buffer) {
+		return 0, io.EOF
+	}
+	n = copy(p, b.Buffer[b.Offset:])
+	b.Offset += n
+	if b.Offset >= len(b.Buffer) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// SeekPCM moves the reader's offset to the given sample frame.
+func (b *BytesReader) SeekPCM(frame int64) error {
+	offset := frame * int64(b.SampleSize())
+	if frame < 0 || offset > int64(len(b.Buffer)) {
+		return oakerr.InvalidInput{InputName: "frame"}
 	}
-	b.Offset += len(p)
-	return len(p), nil
+	b.Offset = int(offset)
+	return nil
 }
 
+// Copy returns a reader at the same offset as b which shares b's buffer. Reading or seeking either
+// reader does not move the other, but the buffer itself should not be modified while both are in use.
 func (b *BytesReader) Copy() *BytesReader {
-	copyBuff := make([]byte, len(b.Buffer))
-	copy(b.Buffer, copyBuff)
 	return &BytesReader{
 		Format: b.Format,
 		Buffer: b.Buffer,
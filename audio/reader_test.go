@@ -0,0 +1,92 @@
+package audio_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/audio"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+func TestBytesReader(t *testing.T) {
+	r := &audio.BytesReader{
+		Format: pcm.Format{SampleRate: 8000, Channels: 1, Bits: 8},
+		Buffer: []byte{0, 1, 2, 3, 4},
+	}
+	b := make([]byte, 3)
+	if n, err := r.ReadPCM(b); n != 3 || err != nil || b[0] != 0 {
+		t.Fatalf("unexpected read: %v %v", b[:n], err)
+	}
+	cp := r.Copy()
+	if n, err := r.ReadPCM(b); n != 2 || !errors.Is(err, io.EOF) || b[0] != 3 || b[1] != 4 {
+		t.Fatalf("expected read to continue from offset: %v %v", b[:n], err)
+	}
+	if n, err := r.ReadPCM(b); n != 0 || !errors.Is(err, io.EOF) {
+		t.Fatalf("expected exhausted reader to end: %v %v", n, err)
+	}
+	if n, _ := cp.ReadPCM(b); n != 2 || b[0] != 3 || b[1] != 4 {
+		t.Fatalf("expected copy to keep offset and buffer: %v", b[:n])
+	}
+}
+
+func TestBytesReader_SeekPCM(t *testing.T) {
+	r := &audio.BytesReader{
+		Format: pcm.Format{SampleRate: 8000, Channels: 2, Bits: 8},
+		Buffer: []byte{0, 1, 2, 3, 4, 5, 6, 7},
+	}
+	b := make([]byte, 3)
+	if n, _ := r.ReadPCM(b); n != 3 || b[2] != 2 {
+		t.Fatalf("unexpected read: %v", b[:n])
+	}
+	if err := r.SeekPCM(3); err != nil {
+		t.Fatalf("failed to seek: %v", err)
+	}
+	n, _ := r.ReadPCM(b)
+	if n != 2 || b[0] != 6 || b[1] != 7 {
+		t.Fatalf("unexpected read after seek: %v", b[:n])
+	}
+	if err := r.SeekPCM(5); err == nil {
+		t.Fatal("expected seek past end to fail")
+	}
+}
+
+func TestLoopSection(t *testing.T) {
+	buf := make([]byte, 10)
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+	r := &audio.BytesReader{
+		Format: pcm.Format{SampleRate: 8000, Channels: 1, Bits: 8},
+		Buffer: buf,
+	}
+	loop, err := audio.LoopSection(r, 4, 7)
+	if err != nil {
+		t.Fatalf("failed to create loop: %v", err)
+	}
+	b := make([]byte, 13)
+	if _, err := audio.ReadFull(loop, b); err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	expected := []byte{0, 1, 2, 3, 4, 5, 6, 4, 5, 6, 4, 5, 6}
+	if string(b) != string(expected) {
+		t.Fatalf("expected %v, got %v", expected, b)
+	}
+
+	r.SeekPCM(0)
+	loop, _ = audio.LoopSection(r, 8, 0)
+	if _, err := audio.ReadFull(loop, b); err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	expected = []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 8, 9, 8}
+	if string(b) != string(expected) {
+		t.Fatalf("expected %v, got %v", expected, b)
+	}
+
+	if _, err := audio.LoopSection(&pcm.IOReader{}, 0, 0); err == nil {
+		t.Fatal("expected unseekable reader to fail")
+	}
+	if _, err := audio.LoopSection(r, 5, 5); err == nil {
+		t.Fatal("expected empty section to fail")
+	}
+}
@@ -0,0 +1,42 @@
+package spatial
+
+import "math"
+
+// A Rolloff returns how loud, between 0.0 -> 1.0, a sound is at some distance from a listener, where
+// the sound is at full volume within min and silent beyond max. Rolloffs are only called with
+// distances between min and max.
+type Rolloff func(distance, min, max float64) float64
+
+// LinearRolloff fades sounds out evenly between min and max.
+func LinearRolloff(distance, min, max float64) float64 {
+	return 1 - (distance-min)/(max-min)
+}
+
+// InverseRolloff fades sounds out in proportion to min/distance, as sounds naturally do, adjusted to
+// reach silence at max.
+func InverseRolloff(distance, min, max float64) float64 {
+	return inverse(distance, min, max, 1)
+}
+
+// InverseSquareRolloff fades sounds out in proportion to (min/distance)², falling off quicker than
+// InverseRolloff, adjusted to reach silence at max.
+func InverseSquareRolloff(distance, min, max float64) float64 {
+	return inverse(distance, min, max, 2)
+}
+
+func inverse(distance, min, max, pow float64) float64 {
+	if min <= 0 {
+		return LinearRolloff(distance, min, max)
+	}
+	floor := math.Pow(min/max, pow)
+	return (math.Pow(min/distance, pow) - floor) / (1 - floor)
+}
+
+// ExponentialRolloff returns a rolloff which fades sounds out along (1-t)^exp, where t is how far a
+// sound is between min and max. Exponents above 1 fade quickly near the listener; exponents below 1
+// keep sounds loud until they are near max.
+func ExponentialRolloff(exp float64) Rolloff {
+	return func(distance, min, max float64) float64 {
+		return math.Pow(LinearRolloff(distance, min, max), exp)
+	}
+}
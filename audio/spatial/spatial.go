@@ -0,0 +1,323 @@
+// Package spatial provides positional audio, panning and attenuating mixer voices based on where
+// their sources are relative to a listener.
+package spatial
+
+import (
+	"math"
+	"sync"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/audio/mixer"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+	"github.com/diakovliev/oak/v4/entities"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/scene"
+)
+
+// A Position is anywhere a sound can be heard from or listened at. physics.Vector is a Position.
+type Position interface {
+	X() float64
+	Y() float64
+}
+
+// Point is a fixed Position.
+type Point floatgeom.Point2
+
+// X returns the x coordinate of this point.
+func (p Point) X() float64 {
+	return p[0]
+}
+
+// Y returns the y coordinate of this point.
+func (p Point) Y() float64 {
+	return p[1]
+}
+
+type entityPosition struct {
+	e *entities.Entity
+}
+
+func (ep entityPosition) X() float64 {
+	return ep.e.Rect.Center().X()
+}
+
+func (ep entityPosition) Y() float64 {
+	return ep.e.Rect.Center().Y()
+}
+
+// EntityCenter returns a Position following the center of an entity.
+func EntityCenter(e *entities.Entity) Position {
+	return entityPosition{e: e}
+}
+
+type viewportCenter struct {
+	w scene.Window
+}
+
+func (vc viewportCenter) X() float64 {
+	return float64(vc.w.Viewport().X()) + float64(vc.w.Bounds().X())/2
+}
+
+func (vc viewportCenter) Y() float64 {
+	return float64(vc.w.Viewport().Y()) + float64(vc.w.Bounds().Y())/2
+}
+
+// ViewportCenter returns a Position following the center of a window's viewport.
+func ViewportCenter(w scene.Window) Position {
+	return viewportCenter{w: w}
+}
+
+// An Option sets some value on an Options struct.
+type Option func(*Options)
+
+// Options configure how a Listener hears voices.
+type Options struct {
+	// Listener is where voices are heard from. Defaults to the center of the scene's viewport.
+	Listener Position
+	// Rolloff determines how voices attenuate with distance. Defaults to LinearRolloff.
+	Rolloff Rolloff
+	// Voices closer than MinDistance are heard at full volume. Defaults to 32.
+	MinDistance float64
+	// Voices further than MaxDistance are silent. Defaults to 640.
+	MaxDistance float64
+	// PanDistance is how far to either side of the listener a voice must be to be panned entirely
+	// to that side. If zero, voices are not panned. Defaults to 320.
+	PanDistance float64
+}
+
+func defaultOptions() Options {
+	return Options{
+		Rolloff:     LinearRolloff,
+		MinDistance: 32,
+		MaxDistance: 640,
+		PanDistance: 320,
+	}
+}
+
+// ListenAt sets where voices are heard from.
+func ListenAt(p Position) Option {
+	return func(o *Options) {
+		o.Listener = p
+	}
+}
+
+// WithRolloff sets how voices attenuate with distance.
+func WithRolloff(r Rolloff) Option {
+	return func(o *Options) {
+		o.Rolloff = r
+	}
+}
+
+// WithDistances sets the distances where voices begin to attenuate and become silent.
+func WithDistances(min, max float64) Option {
+	return func(o *Options) {
+		o.MinDistance = min
+		o.MaxDistance = max
+	}
+}
+
+// WithPanDistance sets how far to the side of the listener voices are panned entirely to that side.
+func WithPanDistance(d float64) Option {
+	return func(o *Options) {
+		o.PanDistance = d
+	}
+}
+
+// A Listener positions voices played through a mixer. Each frame, the volume and pan of every voice
+// it tracks is recomputed from where that voice's source is relative to the listener. Calling
+// SetVolume or SetPan on a tracked voice is overwritten on the next frame; change the volume a
+// tracked voice is attenuated from with Listener.SetVolume instead.
+type Listener struct {
+	mixer *mixer.Mixer
+
+	mu      sync.Mutex
+	opts    Options
+	sources []*source
+	// detached holds voices which were positioned by this listener, so they can be attached again
+	// without attenuating them twice.
+	detached map[*mixer.Voice]detachedVoice
+	binding  event.Binding
+}
+
+type source struct {
+	voice *mixer.Voice
+	at    Position
+	// volume is the voice's volume before it is attenuated by distance.
+	volume float64
+}
+
+type detachedVoice struct {
+	// volume is the voice's unattenuated volume, and heard the volume it was left at.
+	volume, heard float64
+}
+
+// NewListener creates a listener for voices played through m, which will update on every frame
+// of the given scene.
+func NewListener(ctx *scene.Context, m *mixer.Mixer, opts ...Option) *Listener {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.Listener == nil && ctx.Window != nil {
+		o.Listener = ViewportCenter(ctx.Window)
+	}
+	l := &Listener{
+		mixer:    m,
+		opts:     o,
+		detached: make(map[*mixer.Voice]detachedVoice),
+	}
+	l.binding = event.GlobalBind(ctx, event.Enter, func(event.EnterPayload) event.Response {
+		l.Update()
+		return 0
+	})
+	return l
+}
+
+// SetPosition changes where voices are heard from.
+func (l *Listener) SetPosition(p Position) {
+	l.mu.Lock()
+	l.opts.Listener = p
+	l.mu.Unlock()
+}
+
+// Play starts playing r through the listener's mixer, positioned at the given source. The voice's
+// volume, as set by options, is attenuated by its distance from the listener.
+func (l *Listener) Play(r pcm.Reader, at Position, options ...mixer.VoiceOption) (*mixer.Voice, error) {
+	vo := mixer.VoiceOptions{Volume: 1}
+	for _, o := range options {
+		o(&vo)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := &source{at: at, volume: vo.Volume}
+	volume, pan := l.place(s)
+	v, err := l.mixer.Play(r, append(options, mixer.WithVolume(volume), mixer.WithPan(pan))...)
+	if err != nil {
+		return nil, err
+	}
+	s.voice = v
+	l.sources = append(l.sources, s)
+	return v, nil
+}
+
+// Attach positions a voice which is already playing at the given source. The voice's current volume
+// is attenuated by its distance from the listener. If the voice was detached from this listener and
+// its volume has not changed since, the volume it had before it was attenuated is used instead.
+func (l *Listener) Attach(v *mixer.Voice, at Position) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.sources {
+		if s.voice == v {
+			s.at = at
+			l.apply(s)
+			return
+		}
+	}
+	volume := v.Volume()
+	if d, ok := l.detached[v]; ok {
+		delete(l.detached, v)
+		if volume == d.heard {
+			volume = d.volume
+		}
+	}
+	s := &source{voice: v, at: at, volume: volume}
+	l.sources = append(l.sources, s)
+	l.apply(s)
+}
+
+// Detach stops positioning a voice, leaving it at its last volume and pan.
+func (l *Listener) Detach(v *mixer.Voice) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, s := range l.sources {
+		if s.voice == v {
+			l.sources = append(l.sources[:i], l.sources[i+1:]...)
+			l.detached[v] = detachedVoice{volume: s.volume, heard: v.Volume()}
+			return
+		}
+	}
+}
+
+// SetVolume sets the volume a voice is heard at before it is attenuated by distance. If the voice is
+// positioned by this listener, its attenuated volume is applied at once; otherwise, its volume is
+// set directly.
+func (l *Listener) SetVolume(v *mixer.Voice, volume float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.sources {
+		if s.voice == v {
+			s.volume = volume
+			l.apply(s)
+			return
+		}
+	}
+	delete(l.detached, v)
+	v.SetVolume(volume)
+}
+
+// Update recomputes the volume and pan of every positioned voice. It is called on every frame, and
+// voices which have finished playing are forgotten.
+func (l *Listener) Update() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := 0; i < len(l.sources); i++ {
+		s := l.sources[i]
+		if !s.voice.Playing() {
+			l.sources = append(l.sources[:i], l.sources[i+1:]...)
+			i--
+			continue
+		}
+		l.apply(s)
+	}
+	for v := range l.detached {
+		if !v.Playing() {
+			delete(l.detached, v)
+		}
+	}
+}
+
+// Close stops updating voices.
+func (l *Listener) Close() {
+	l.binding.Unbind()
+	l.mu.Lock()
+	l.sources = nil
+	l.detached = make(map[*mixer.Voice]detachedVoice)
+	l.mu.Unlock()
+}
+
+// apply must be called with the listener lock held.
+func (l *Listener) apply(s *source) {
+	volume, pan := l.place(s)
+	s.voice.SetVolume(volume)
+	s.voice.SetPan(pan)
+}
+
+// place returns the volume and pan a source should be heard at.
+func (l *Listener) place(s *source) (volume, pan float64) {
+	if l.opts.Listener == nil {
+		return s.volume, 0
+	}
+	dx := s.at.X() - l.opts.Listener.X()
+	dy := s.at.Y() - l.opts.Listener.Y()
+	volume = s.volume * l.gain(math.Hypot(dx, dy))
+	if l.opts.PanDistance > 0 {
+		pan = math.Max(-1, math.Min(1, dx/l.opts.PanDistance))
+	}
+	return volume, pan
+}
+
+// gain returns the attenuation of a source at the given distance from the listener.
+func (l *Listener) gain(distance float64) float64 {
+	min, max := l.opts.MinDistance, l.opts.MaxDistance
+	if distance <= min {
+		return 1
+	}
+	if distance >= max {
+		return 0
+	}
+	rolloff := l.opts.Rolloff
+	if rolloff == nil {
+		rolloff = LinearRolloff
+	}
+	return math.Max(0, math.Min(1, rolloff(distance, min, max)))
+}
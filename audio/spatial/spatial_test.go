@@ -0,0 +1,175 @@
+package spatial
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/alg/intgeom"
+	"github.com/diakovliev/oak/v4/audio/mixer"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+	"github.com/diakovliev/oak/v4/entities"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/internal/scenetest"
+	"github.com/diakovliev/oak/v4/physics"
+	"github.com/diakovliev/oak/v4/scene"
+)
+
+var format = pcm.Format{
+	SampleRate: 44100,
+	Channels:   2,
+	Bits:       16,
+}
+
+type fakeWriter struct {
+	pcm.Format
+}
+
+func (fw *fakeWriter) Close() error {
+	return nil
+}
+
+func (fw *fakeWriter) WritePCM(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func silence() pcm.Reader {
+	return &pcm.IOReader{
+		Format: format,
+		Reader: bytes.NewReader(make([]byte, 44100*4)),
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestListener(t *testing.T) {
+	ctx := scenetest.NewContext()
+	m := mixer.NewWithWriter(&fakeWriter{Format: format}, mixer.Options{})
+	l := NewListener(ctx, m, ListenAt(Point{0, 0}), WithDistances(10, 110), WithPanDistance(100))
+	<-l.binding.Bound
+
+	at := physics.NewVector(5, 0)
+	v, err := l.Play(silence(), at, mixer.WithVolume(.5))
+	if err != nil {
+		t.Fatalf("play failed: %v", err)
+	}
+	if !near(v.Volume(), .5) || !near(v.Pan(), .05) {
+		t.Fatalf("expected volume .5 pan .05, got %v %v", v.Volume(), v.Pan())
+	}
+
+	// Moving the source is heard on the next frame.
+	at.SetPos(-60, 0)
+	<-ctx.Trigger(event.Enter.UnsafeEventID, event.EnterPayload{})
+	if !near(v.Volume(), .25) || !near(v.Pan(), -.6) {
+		t.Fatalf("expected volume .25 pan -.6, got %v %v", v.Volume(), v.Pan())
+	}
+	at.SetPos(0, 200)
+	l.Update()
+	if v.Volume() != 0 || v.Pan() != 0 {
+		t.Fatalf("expected distant voice to be silent, got %v %v", v.Volume(), v.Pan())
+	}
+
+	l.SetPosition(Point{0, 150})
+	l.Update()
+	if !near(v.Volume(), .3) {
+		t.Fatalf("expected moving the listener to change volume, got %v", v.Volume())
+	}
+
+	l.Detach(v)
+	at.SetPos(500, 500)
+	l.Update()
+	if !near(v.Volume(), .3) {
+		t.Fatalf("expected detached voice to keep its volume, got %v", v.Volume())
+	}
+
+	e := &entities.Entity{Rect: floatgeom.NewRect2(20, 140, 40, 160)}
+	l.Attach(v, EntityCenter(e))
+	if !near(v.Volume(), .5*.8) || !near(v.Pan(), .3) {
+		t.Fatalf("expected reattached voice to follow entity from its unattenuated volume, got %v %v", v.Volume(), v.Pan())
+	}
+	// Attaching a tracked voice again moves it immediately.
+	l.Attach(v, Point{0, 150})
+	if !near(v.Volume(), .5) || v.Pan() != 0 {
+		t.Fatalf("expected reattached voice to be placed at once, got %v %v", v.Volume(), v.Pan())
+	}
+	// Setting a tracked voice's volume through the listener outlasts the next frame.
+	l.SetVolume(v, .4)
+	l.Update()
+	if !near(v.Volume(), .4) {
+		t.Fatalf("expected listener volume to be kept, got %v", v.Volume())
+	}
+	v.SetVolume(.1)
+	l.Update()
+	if !near(v.Volume(), .4) {
+		t.Fatalf("expected voice volume to be overwritten while attached, got %v", v.Volume())
+	}
+	// A voice whose volume changed while detached is attenuated from its new volume.
+	l.Detach(v)
+	v.SetVolume(.2)
+	l.Attach(v, EntityCenter(e))
+	if !near(v.Volume(), .2*.8) {
+		t.Fatalf("expected voice to be attenuated from its new volume, got %v", v.Volume())
+	}
+
+	v.Stop()
+	l.Update()
+	if len(l.sources) != 0 {
+		t.Fatalf("expected finished voice to be forgotten")
+	}
+	l.Close()
+}
+
+type fakeWindow struct {
+	scene.Window
+}
+
+func (fakeWindow) Viewport() intgeom.Point2 {
+	return intgeom.Point2{100, 50}
+}
+
+func (fakeWindow) Bounds() intgeom.Point2 {
+	return intgeom.Point2{640, 480}
+}
+
+func TestNewListener_Viewport(t *testing.T) {
+	ctx := scenetest.NewContext()
+	ctx.Window = fakeWindow{}
+	m := mixer.NewWithWriter(&fakeWriter{Format: format}, mixer.Options{})
+	l := NewListener(ctx, m)
+	defer l.Close()
+	v, err := l.Play(silence(), Point{420, 290})
+	if err != nil {
+		t.Fatalf("play failed: %v", err)
+	}
+	if v.Volume() != 1 || v.Pan() != 0 {
+		t.Fatalf("expected voice at viewport center to be centered, got %v %v", v.Volume(), v.Pan())
+	}
+}
+
+func TestRolloffs(t *testing.T) {
+	cases := []struct {
+		name     string
+		rolloff  Rolloff
+		at       float64
+		expected float64
+	}{
+		{"linear", LinearRolloff, 60, .5},
+		{"inverse", InverseRolloff, 20, .45},
+		{"inverse square", InverseSquareRolloff, 20, .24375},
+		{"exponential", ExponentialRolloff(2), 60, .25},
+	}
+	for _, c := range cases {
+		if got := c.rolloff(c.at, 10, 110); !near(got, c.expected) {
+			t.Errorf("%v: expected %v got %v", c.name, c.expected, got)
+		}
+		if got := c.rolloff(110, 10, 110); !near(got, 0) {
+			t.Errorf("%v: expected silence at max, got %v", c.name, got)
+		}
+		if got := c.rolloff(10, 10, 110); !near(got, 1) {
+			t.Errorf("%v: expected full volume at min, got %v", c.name, got)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package synth
+
+import "math"
+
+// A Biquad is a second order IIR filter. Its zero value passes audio through unchanged.
+type Biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+	set                bool
+}
+
+// NewLowPass returns a filter which attenuates frequencies above cutoff, in hertz. Q controls
+// the resonance at the cutoff; 0.707 gives a flat response.
+func NewLowPass(sampleRate, cutoff, q float64) *Biquad {
+	bq := &Biquad{}
+	bq.SetLowPass(sampleRate, cutoff, q)
+	return bq
+}
+
+// SetLowPass changes this filter into a low pass filter, keeping its state so the change is smooth.
+func (bq *Biquad) SetLowPass(sampleRate, cutoff, q float64) {
+	w0, alpha := biquadParams(sampleRate, cutoff, q)
+	cos := math.Cos(w0)
+	bq.setCoefficients(
+		(1-cos)/2, 1-cos, (1-cos)/2,
+		1+alpha, -2*cos, 1-alpha,
+	)
+}
+
+// NewHighPass returns a filter which attenuates frequencies below cutoff, in hertz. Q controls
+// the resonance at the cutoff; 0.707 gives a flat response.
+func NewHighPass(sampleRate, cutoff, q float64) *Biquad {
+	bq := &Biquad{}
+	bq.SetHighPass(sampleRate, cutoff, q)
+	return bq
+}
+
+// SetHighPass changes this filter into a high pass filter, keeping its state so the change is smooth.
+func (bq *Biquad) SetHighPass(sampleRate, cutoff, q float64) {
+	w0, alpha := biquadParams(sampleRate, cutoff, q)
+	cos := math.Cos(w0)
+	bq.setCoefficients(
+		(1+cos)/2, -(1 + cos), (1+cos)/2,
+		1+alpha, -2*cos, 1-alpha,
+	)
+}
+
+// NewBandPass returns a filter which attenuates frequencies away from center, in hertz. Higher
+// values of Q narrow the band which passes through.
+func NewBandPass(sampleRate, center, q float64) *Biquad {
+	bq := &Biquad{}
+	bq.SetBandPass(sampleRate, center, q)
+	return bq
+}
+
+// SetBandPass changes this filter into a band pass filter, keeping its state so the change is smooth.
+func (bq *Biquad) SetBandPass(sampleRate, center, q float64) {
+	w0, alpha := biquadParams(sampleRate, center, q)
+	cos := math.Cos(w0)
+	bq.setCoefficients(
+		alpha, 0, -alpha,
+		1+alpha, -2*cos, 1-alpha,
+	)
+}
+
+func biquadParams(sampleRate, cutoff, q float64) (w0, alpha float64) {
+	// Keep the cutoff safely below nyquist
+	cutoff = math.Max(1, math.Min(cutoff, sampleRate*.49))
+	if q <= 0 {
+		q = math.Sqrt2 / 2
+	}
+	w0 = 2 * math.Pi * cutoff / sampleRate
+	return w0, math.Sin(w0) / (2 * q)
+}
+
+func (bq *Biquad) setCoefficients(b0, b1, b2, a0, a1, a2 float64) {
+	bq.b0 = b0 / a0
+	bq.b1 = b1 / a0
+	bq.b2 = b2 / a0
+	bq.a1 = a1 / a0
+	bq.a2 = a2 / a0
+	bq.set = true
+}
+
+// Process filters a single sample.
+func (bq *Biquad) Process(x float64) float64 {
+	if !bq.set {
+		return x
+	}
+	y := bq.b0*x + bq.b1*bq.x1 + bq.b2*bq.x2 - bq.a1*bq.y1 - bq.a2*bq.y2
+	bq.x2, bq.x1 = bq.x1, x
+	bq.y2, bq.y1 = bq.y1, y
+	return y
+}
+
+// Reset clears the filter's memory of previous samples.
+func (bq *Biquad) Reset() {
+	bq.x1, bq.x2, bq.y1, bq.y2 = 0, 0, 0, 0
+}
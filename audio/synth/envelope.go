@@ -0,0 +1,88 @@
+package synth
+
+import (
+	"math"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/internal/sample"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// An Envelope shapes the volume of a note over time. A note rises to full volume over Attack,
+// falls to Sustain over Decay, holds there until it is released, and then fades to silence
+// over Release.
+type Envelope struct {
+	Attack time.Duration
+	Decay  time.Duration
+	// Sustain, between 0.0 -> 1.0
+	Sustain float64
+	Release time.Duration
+}
+
+// Level returns the volume of the envelope, between 0.0 -> 1.0, at some time into a note which
+// is released after gate.
+func (e Envelope) Level(t, gate time.Duration) float64 {
+	return e.level(t.Seconds(), gate.Seconds())
+}
+
+// Length returns how long a note released after gate will sound for.
+func (e Envelope) Length(gate time.Duration) time.Duration {
+	return gate + e.Release
+}
+
+func (e Envelope) level(t, gate float64) float64 {
+	if t >= gate {
+		release := t - gate
+		if release >= e.Release.Seconds() {
+			return 0
+		}
+		return e.held(gate) * (1 - release/e.Release.Seconds())
+	}
+	return e.held(t)
+}
+
+// held returns the level of the envelope at t before it is released.
+func (e Envelope) held(t float64) float64 {
+	attack, decay := e.Attack.Seconds(), e.Decay.Seconds()
+	if t < attack {
+		return t / attack
+	}
+	if t < attack+decay {
+		return 1 - (1-e.Sustain)*(t-attack)/decay
+	}
+	return e.Sustain
+}
+
+// ADSR sets an envelope on a generated waveform. The waveform's duration is how long it is held
+// before being released, after which it will end once the release completes.
+func ADSR(attack, decay time.Duration, sustain float64, release time.Duration) Option {
+	return func(s Source) Source {
+		s.Envelope = Envelope{
+			Attack:  attack,
+			Decay:   decay,
+			Sustain: sustain,
+			Release: release,
+		}
+		return s
+	}
+}
+
+// ApplyEnvelope wraps a reader such that its volume follows an envelope, released after gate.
+// The returned reader will end once the envelope completes.
+func ApplyEnvelope(r pcm.Reader, e Envelope, gate time.Duration) pcm.Reader {
+	rate := float64(r.PCMFormat().SampleRate)
+	gateSecs := gate.Seconds()
+	end := math.Round(e.Length(gate).Seconds() * rate)
+	var played float64
+	return sample.NewReader(r, func(frame []float64) bool {
+		if played >= end {
+			return false
+		}
+		level := e.level(played/rate, gateSecs)
+		for i := range frame {
+			frame[i] *= level
+		}
+		played++
+		return true
+	}, nil)
+}
@@ -0,0 +1,161 @@
+package synth
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/internal/audiotest"
+)
+
+func peak(vs []int16) float64 {
+	var p float64
+	for _, v := range vs {
+		p = math.Max(p, math.Abs(float64(v)))
+	}
+	return p
+}
+
+func TestEnvelope_Level(t *testing.T) {
+	e := Envelope{
+		Attack:  100 * time.Millisecond,
+		Decay:   100 * time.Millisecond,
+		Sustain: .5,
+		Release: 200 * time.Millisecond,
+	}
+	gate := 500 * time.Millisecond
+	cases := []struct {
+		at       time.Duration
+		expected float64
+	}{
+		{0, 0},
+		{50 * time.Millisecond, .5},
+		{100 * time.Millisecond, 1},
+		{150 * time.Millisecond, .75},
+		{300 * time.Millisecond, .5},
+		{600 * time.Millisecond, .25},
+		{700 * time.Millisecond, 0},
+		{time.Second, 0},
+	}
+	for _, c := range cases {
+		if got := e.Level(c.at, gate); math.Abs(got-c.expected) > 1e-9 {
+			t.Errorf("level at %v: expected %v got %v", c.at, c.expected, got)
+		}
+	}
+	// Releasing during the attack fades from where the attack had reached.
+	if got := e.Level(75*time.Millisecond, 50*time.Millisecond); math.Abs(got-.4375) > 1e-9 {
+		t.Errorf("early release: expected .4375 got %v", got)
+	}
+	if e.Length(gate) != 700*time.Millisecond {
+		t.Errorf("expected length 700ms got %v", e.Length(gate))
+	}
+}
+
+func TestSource_ADSR(t *testing.T) {
+	src := Int16
+	src.Channels = 1
+	src.SampleRate = 10000
+	r := src.Sin(Duration(100*time.Millisecond), ADSR(10*time.Millisecond, 10*time.Millisecond, .5, 50*time.Millisecond))
+	out := audiotest.Int16s(audiotest.ReadAll(t, r, 20000))
+	if len(out) != 1500 {
+		t.Fatalf("expected 1500 samples, got %v", len(out))
+	}
+	full := src.Volume * math.MaxInt16
+	if p := peak(out[:200]); p < full*.9 {
+		t.Fatalf("expected attack to reach full volume, got %v", p)
+	}
+	if p := peak(out[500:1000]); p > full*.55 || p < full*.45 {
+		t.Fatalf("expected sustain at half volume, got %v", p)
+	}
+	if p := peak(out[1400:]); p > full*.15 {
+		t.Fatalf("expected release to fade out, got %v", p)
+	}
+}
+
+func TestSource_LFOs(t *testing.T) {
+	src := Int16
+	src.Channels = 1
+	src.SampleRate = 10000
+	full := src.Volume * math.MaxInt16
+	out := audiotest.Int16s(audiotest.ReadAll(t, src.Sin(Tremolo(5, 1)), 4000))
+	// The tremolo is silent at the bottom of its cycle, 150ms in.
+	if p := peak(out[1480:1520]); p > full*.05 {
+		t.Fatalf("expected tremolo to silence wave, got %v", p)
+	}
+	if p := peak(out[400:600]); p < full*.9 {
+		t.Fatalf("expected tremolo at full volume, got %v", p)
+	}
+	plain := audiotest.Int16s(audiotest.ReadAll(t, src.Square(), 4000))
+	bent := audiotest.Int16s(audiotest.ReadAll(t, src.Square(Vibrato(5, 2)), 4000))
+	if crossings(bent) == crossings(plain) {
+		t.Fatalf("expected vibrato to change the wave's pitch")
+	}
+	pwm := audiotest.Int16s(audiotest.ReadAll(t, src.Pulse(2)(WithLFO(LFO{Target: LFOPulseWidth, Rate: 5, Depth: .4})), 4000))
+	if up(pwm[:200]) == up(pwm[400:600]) {
+		t.Fatalf("expected pulse width to change")
+	}
+}
+
+func crossings(vs []int16) int {
+	n := 0
+	for i := 1; i < len(vs); i++ {
+		if (vs[i-1] < 0) != (vs[i] < 0) {
+			n++
+		}
+	}
+	return n
+}
+
+func up(vs []int16) int {
+	n := 0
+	for _, v := range vs {
+		if v > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSource_Filters(t *testing.T) {
+	src := Int16
+	src.Channels = 1
+	src.SampleRate = 40000
+	src.Pitch = A5
+	full := src.Volume * math.MaxInt16
+	if p := peak(audiotest.Int16s(audiotest.ReadAll(t, src.Sin(LowPass(100, 0)), 80000))[20000:]); p > full*.1 {
+		t.Fatalf("expected low pass to attenuate, got %v", p)
+	}
+	if p := peak(audiotest.Int16s(audiotest.ReadAll(t, src.Sin(HighPass(100, 0)), 80000))[20000:]); p < full*.9 {
+		t.Fatalf("expected high pass to pass, got %v", p)
+	}
+	if p := peak(audiotest.Int16s(audiotest.ReadAll(t, src.Sin(BandPass(float64(A5), 1)), 80000))[20000:]); p < full*.9 {
+		t.Fatalf("expected band pass to pass, got %v", p)
+	}
+	if p := peak(audiotest.Int16s(audiotest.ReadAll(t, src.Sin(BandPass(10000, 5)), 80000))[20000:]); p > full*.1 {
+		t.Fatalf("expected band pass to attenuate, got %v", p)
+	}
+}
+
+func TestApply(t *testing.T) {
+	src := Int16
+	src.SampleRate = 10000
+	full := src.Volume * math.MaxInt16
+	e := Envelope{Sustain: 1, Release: 100 * time.Millisecond}
+	out := audiotest.Int16s(audiotest.ReadAll(t, ApplyEnvelope(src.Sin(), e, 100*time.Millisecond), 200000))
+	// Two channels for 200 milliseconds
+	if len(out) != 4000 {
+		t.Fatalf("expected 4000 samples, got %v", len(out))
+	}
+	if p := peak(out[3800:]); p > full*.15 {
+		t.Fatalf("expected envelope to release, got %v", p)
+	}
+	out = audiotest.Int16s(audiotest.ReadAll(t, ApplyTremolo(src.Sin(), LFO{Rate: 5, Depth: 1}), 8000))
+	if p := peak(out[2960:3040]); p > full*.05 {
+		t.Fatalf("expected tremolo to silence wave, got %v", p)
+	}
+	src.Pitch = A5
+	out = audiotest.Int16s(audiotest.ReadAll(t, ApplyFilters(src.Sin(), Filter{Type: FilterLowPass, Cutoff: 50}, Filter{Type: FilterLowPass, Cutoff: 50}), 40000))
+	if p := peak(out[10000:]); p > full*.05 {
+		t.Fatalf("expected filters to attenuate, got %v", p)
+	}
+}
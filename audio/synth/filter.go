@@ -0,0 +1,80 @@
+package synth
+
+import (
+	"github.com/diakovliev/oak/v4/audio/internal/sample"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// A FilterType is a kind of biquad filter.
+type FilterType int
+
+// Filter types.
+const (
+	FilterLowPass FilterType = iota
+	FilterHighPass
+	FilterBandPass
+)
+
+// A Filter describes a biquad filter to apply to audio.
+type Filter struct {
+	Type FilterType
+	// Cutoff is the cutoff frequency in hertz, or for band pass filters, the center frequency.
+	Cutoff float64
+	// Q is the resonance of the filter. Defaults to 0.707, a flat response.
+	Q float64
+}
+
+// Biquad creates a filter for audio at the given sample rate.
+func (f Filter) Biquad(sampleRate float64) *Biquad {
+	switch f.Type {
+	case FilterHighPass:
+		return NewHighPass(sampleRate, f.Cutoff, f.Q)
+	case FilterBandPass:
+		return NewBandPass(sampleRate, f.Cutoff, f.Q)
+	default:
+		return NewLowPass(sampleRate, f.Cutoff, f.Q)
+	}
+}
+
+// WithFilter adds a filter to a generated waveform. Filters are applied in the order they are added.
+func WithFilter(f Filter) Option {
+	return func(s Source) Source {
+		s.Filters = append(append([]Filter{}, s.Filters...), f)
+		return s
+	}
+}
+
+// LowPass filters frequencies above cutoff out of a generated waveform.
+func LowPass(cutoff, q float64) Option {
+	return WithFilter(Filter{Type: FilterLowPass, Cutoff: cutoff, Q: q})
+}
+
+// HighPass filters frequencies below cutoff out of a generated waveform.
+func HighPass(cutoff, q float64) Option {
+	return WithFilter(Filter{Type: FilterHighPass, Cutoff: cutoff, Q: q})
+}
+
+// BandPass filters frequencies away from center out of a generated waveform.
+func BandPass(center, q float64) Option {
+	return WithFilter(Filter{Type: FilterBandPass, Cutoff: center, Q: q})
+}
+
+// ApplyFilters wraps a reader such that it passes through each filter in order. Each channel
+// is filtered separately.
+func ApplyFilters(r pcm.Reader, filters ...Filter) pcm.Reader {
+	f := r.PCMFormat()
+	chains := make([][]*Biquad, f.Channels)
+	for c := range chains {
+		for _, filter := range filters {
+			chains[c] = append(chains[c], filter.Biquad(float64(f.SampleRate)))
+		}
+	}
+	return sample.NewReader(r, func(frame []float64) bool {
+		for c, chain := range chains {
+			for _, bq := range chain {
+				frame[c] = bq.Process(frame[c])
+			}
+		}
+		return true
+	}, nil)
+}
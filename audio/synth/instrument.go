@@ -0,0 +1,157 @@
+package synth
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// An Instrument creates voices which play notes.
+type Instrument interface {
+	// NoteOn begins playing a note. Key is a MIDI note number, where 60 is middle C, and velocity
+	// is between 0 and 127.
+	NoteOn(key, velocity int, sampleRate uint32) Voice
+}
+
+// A Voice is a single note being played by an Instrument.
+type Voice interface {
+	// Next returns the next sample of the voice, between -1.0 and 1.0, and whether the voice is
+	// still sounding. Once a voice stops sounding it will not sound again.
+	Next() (float64, bool)
+	// Release begins the end of the note. The voice may continue to sound for some time after
+	// being released.
+	Release()
+}
+
+// A Bender is a Voice whose pitch can be changed while it plays.
+type Bender interface {
+	// Bend offsets the pitch of the voice from its key by some number of semitones.
+	Bend(semitones float64)
+}
+
+// KeyFrequency returns the frequency in hertz of a MIDI note number, where 69 is A4 at 440 hertz.
+// Fractional keys are detuned between semitones.
+func KeyFrequency(key float64) float64 {
+	return 440 * math.Pow(2, (key-69)/12)
+}
+
+// A Shape is a single cycle of a periodic waveform, given a phase from 0.0 to 1.0 and returning
+// a value from -1.0 to 1.0.
+type Shape func(phase float64) float64
+
+// SinShape is a sine wave.
+func SinShape(phase float64) float64 {
+	return math.Sin(phase * 2 * math.Pi)
+}
+
+// SquareShape is a square wave.
+func SquareShape(phase float64) float64 {
+	if phase < .5 {
+		return 1
+	}
+	return -1
+}
+
+// SawShape is a saw wave.
+func SawShape(phase float64) float64 {
+	return 1 - 2*phase
+}
+
+// TriangleShape is a triangle wave.
+func TriangleShape(phase float64) float64 {
+	if phase < .5 {
+		return -1 + 4*phase
+	}
+	return 3 - 4*phase
+}
+
+// NoiseShape ignores its phase and produces random values.
+func NoiseShape(phase float64) float64 {
+	return rand.Float64()*2 - 1
+}
+
+// PulseShape acts like SquareShape when given a pulse of 2; when given any greater pulse the wave
+// will be up for 1/pulse of each cycle.
+func PulseShape(pulse float64) Shape {
+	return func(phase float64) float64 {
+		if phase < 1/pulse {
+			return 1
+		}
+		return -1
+	}
+}
+
+// defaultRelease is used by WaveInstruments without a Release.
+const defaultRelease = 50 * time.Millisecond
+
+// A WaveInstrument plays notes by oscillating a Shape.
+type WaveInstrument struct {
+	Shape Shape
+	// Volume, between 0.0 -> 1.0
+	Volume float64
+	// Release is how long notes take to fade out after they are released. Defaults to 50 milliseconds.
+	Release time.Duration
+}
+
+// NoteOn begins playing a note.
+func (wi WaveInstrument) NoteOn(key, velocity int, sampleRate uint32) Voice {
+	release := wi.Release
+	if release == 0 {
+		release = defaultRelease
+	}
+	shape := wi.Shape
+	if shape == nil {
+		shape = SinShape
+	}
+	step := KeyFrequency(float64(key)) / float64(sampleRate)
+	return &waveVoice{
+		shape:        shape,
+		baseStep:     step,
+		step:         step,
+		volume:       wi.Volume * float64(velocity) / 127,
+		attack:       float64(sampleRate) / 500,
+		releaseTotal: release.Seconds() * float64(sampleRate),
+	}
+}
+
+type waveVoice struct {
+	shape    Shape
+	phase    float64
+	baseStep float64
+	step     float64
+	volume   float64
+
+	// attack is a very short fade in, in samples, to prevent clicks.
+	attack       float64
+	played       float64
+	releasing    bool
+	released     float64
+	releaseTotal float64
+}
+
+func (wv *waveVoice) Next() (float64, bool) {
+	level := wv.volume
+	if wv.played < wv.attack {
+		level *= wv.played / wv.attack
+	}
+	if wv.releasing {
+		if wv.released >= wv.releaseTotal {
+			return 0, false
+		}
+		level *= 1 - wv.released/wv.releaseTotal
+		wv.released++
+	}
+	v := wv.shape(wv.phase) * level
+	wv.played++
+	wv.phase += wv.step
+	wv.phase -= math.Floor(wv.phase)
+	return v, true
+}
+
+func (wv *waveVoice) Release() {
+	wv.releasing = true
+}
+
+func (wv *waveVoice) Bend(semitones float64) {
+	wv.step = wv.baseStep * math.Pow(2, semitones/12)
+}
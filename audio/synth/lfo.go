@@ -0,0 +1,80 @@
+package synth
+
+import (
+	"math"
+
+	"github.com/diakovliev/oak/v4/audio/internal/sample"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+)
+
+// An LFOTarget is a property of a waveform that an LFO can modulate.
+type LFOTarget int
+
+// LFO targets.
+const (
+	// LFOPitch modulates pitch by up to Depth semitones, for vibrato.
+	LFOPitch LFOTarget = iota
+	// LFOVolume lowers volume by up to Depth, between 0.0 -> 1.0, for tremolo.
+	LFOVolume
+	// LFOPulseWidth modulates how much of each cycle a pulse wave is up for by up to Depth,
+	// between 0.0 -> 1.0. It only affects pulse and square waves.
+	LFOPulseWidth
+)
+
+// An LFO is a low frequency oscillator which modulates a waveform as it is generated.
+type LFO struct {
+	Target LFOTarget
+	// Rate is how many times per second the LFO cycles.
+	Rate float64
+	// Depth is how far the LFO modulates its target, depending on the target.
+	Depth float64
+	// Shape is the waveform of the LFO. Defaults to SinShape.
+	Shape Shape
+}
+
+// value returns the LFO's output t seconds in, between -1.0 and 1.0.
+func (l LFO) value(t float64) float64 {
+	shape := l.Shape
+	if shape == nil {
+		shape = SinShape
+	}
+	phase := t * l.Rate
+	return shape(phase - math.Floor(phase))
+}
+
+// gain returns the volume multiplier of a volume LFO t seconds in.
+func (l LFO) gain(t float64) float64 {
+	return 1 - l.Depth*(1-l.value(t))/2
+}
+
+// WithLFO adds an LFO to a generated waveform.
+func WithLFO(l LFO) Option {
+	return func(s Source) Source {
+		s.LFOs = append(append([]LFO{}, s.LFOs...), l)
+		return s
+	}
+}
+
+// Vibrato modulates the pitch of a generated waveform by up to some semitones, rate times a second.
+func Vibrato(rate, semitones float64) Option {
+	return WithLFO(LFO{Target: LFOPitch, Rate: rate, Depth: semitones})
+}
+
+// Tremolo modulates the volume of a generated waveform down by up to depth, rate times a second.
+func Tremolo(rate, depth float64) Option {
+	return WithLFO(LFO{Target: LFOVolume, Rate: rate, Depth: depth})
+}
+
+// ApplyTremolo wraps a reader such that its volume is modulated by an LFO. The LFO's Target is ignored.
+func ApplyTremolo(r pcm.Reader, l LFO) pcm.Reader {
+	rate := float64(r.PCMFormat().SampleRate)
+	var played float64
+	return sample.NewReader(r, func(frame []float64) bool {
+		gain := l.gain(played / rate)
+		for i := range frame {
+			frame[i] *= gain
+		}
+		played++
+		return true
+	}, nil)
+}
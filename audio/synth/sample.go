@@ -0,0 +1,117 @@
+package synth
+
+import (
+	"math"
+	"time"
+)
+
+// A Sample is a recording which can be played back as an instrument at any key.
+type Sample struct {
+	// Data is the recording, in mono, as values between -1.0 and 1.0.
+	Data []float64
+	// SampleRate is the rate Data was recorded at.
+	SampleRate float64
+	// Unity is the key at which Data plays back at its recorded pitch. Fractional keys
+	// are detuned between semitones.
+	Unity float64
+	// Gain scales the volume of the recording.
+	Gain float64
+	// If LoopEnd is greater than LoopStart, the frames from LoopStart up to but not including
+	// LoopEnd will repeat until the note is released.
+	LoopStart, LoopEnd int
+	// Release is how long notes take to fade out after they are released.
+	Release time.Duration
+}
+
+// NoteOn begins playing a sample at the given key.
+func (s *Sample) NoteOn(key, velocity int, sampleRate uint32) Voice {
+	ratio := math.Pow(2, (float64(key)-s.Unity)/12) * s.SampleRate / float64(sampleRate)
+	return &sampleVoice{
+		Sample:       s,
+		baseRatio:    ratio,
+		ratio:        ratio,
+		volume:       s.Gain * float64(velocity) / 127,
+		releaseTotal: s.Release.Seconds() * float64(sampleRate),
+		looping:      s.LoopEnd > s.LoopStart && s.LoopEnd <= len(s.Data) && s.LoopStart >= 0,
+	}
+}
+
+type sampleVoice struct {
+	*Sample
+	// ratio is how many recorded frames to advance per output frame.
+	baseRatio float64
+	ratio     float64
+	volume    float64
+	pos       float64
+	looping   bool
+
+	releasing    bool
+	released     float64
+	releaseTotal float64
+}
+
+func (sv *sampleVoice) Next() (float64, bool) {
+	if sv.releasing && sv.released >= sv.releaseTotal {
+		return 0, false
+	}
+	i := int(sv.pos)
+	if i >= len(sv.Data) {
+		return 0, false
+	}
+	frac := sv.pos - float64(i)
+	next := 0.0
+	if sv.looping && i+1 == sv.LoopEnd {
+		next = sv.Data[sv.LoopStart]
+	} else if i+1 < len(sv.Data) {
+		next = sv.Data[i+1]
+	}
+	out := (sv.Data[i]*(1-frac) + next*frac) * sv.volume
+	if sv.releasing {
+		out *= 1 - sv.released/sv.releaseTotal
+		sv.released++
+	}
+	sv.pos += sv.ratio
+	if sv.looping {
+		for sv.pos >= float64(sv.LoopEnd) {
+			sv.pos -= float64(sv.LoopEnd - sv.LoopStart)
+		}
+	}
+	return out, true
+}
+
+func (sv *sampleVoice) Release() {
+	sv.releasing = true
+}
+
+func (sv *sampleVoice) Bend(semitones float64) {
+	sv.ratio = sv.baseRatio * math.Pow(2, semitones/12)
+}
+
+// A Region is a Sample played by an Instrument for a range of keys and velocities.
+type Region struct {
+	KeyLow, KeyHigh int
+	VelLow, VelHigh int
+	Sample          *Sample
+}
+
+// A SampleInstrument plays notes with the first of its Regions which covers the note's key
+// and velocity. Notes outside of every region are silent.
+type SampleInstrument struct {
+	Regions []Region
+}
+
+// NoteOn begins playing a note.
+func (si SampleInstrument) NoteOn(key, velocity int, sampleRate uint32) Voice {
+	for _, r := range si.Regions {
+		if key < r.KeyLow || key > r.KeyHigh || velocity < r.VelLow || velocity > r.VelHigh {
+			continue
+		}
+		return r.Sample.NoteOn(key, velocity, sampleRate)
+	}
+	return silentVoice{}
+}
+
+type silentVoice struct{}
+
+func (silentVoice) Next() (float64, bool) { return 0, false }
+func (silentVoice) Release()              {}
@@ -0,0 +1,293 @@
+package sfxr
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/diakovliev/oak/v4/audio/internal/sample"
+	"github.com/diakovliev/oak/v4/audio/pcm"
+	"github.com/diakovliev/oak/v4/oakerr"
+)
+
+// baseSampleRate is the rate sfxr synthesizes at; lower rates average its output.
+const baseSampleRate = 44100
+
+// oversampling is how many sub samples are synthesized for each sample.
+const oversampling = 8
+
+// NewReader creates a reader which generates a sound effect. The reader will produce mono audio
+// in the sample rate and size of the parameters, and ends when the effect does.
+func NewReader(p Params) (pcm.Reader, error) {
+	g, err := newGenerator(p)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func newGenerator(p Params) (*generator, error) {
+	switch p.SampleSize {
+	case 8, 16:
+	default:
+		return nil, oakerr.InvalidInput{InputName: "SampleSize"}
+	}
+	if p.SampleRate == 0 || p.SampleRate > baseSampleRate {
+		return nil, oakerr.InvalidInput{InputName: "SampleRate"}
+	}
+	if p.WaveType < Square || p.WaveType > Noise {
+		return nil, oakerr.InvalidInput{InputName: "WaveType"}
+	}
+	g := &generator{
+		Format: pcm.Format{
+			SampleRate: p.SampleRate,
+			Channels:   1,
+			Bits:       p.SampleSize,
+		},
+		p:        p,
+		summands: baseSampleRate / int(p.SampleRate),
+	}
+	g.renderer = sample.NewRenderer(g.Format, g.render)
+	g.init()
+	return g, nil
+}
+
+// generator follows the synthesis of jsfxr, which in turn follows the original sfxr.
+type generator struct {
+	pcm.Format
+	p Params
+
+	// Frequency
+	period, periodMax, periodMult, periodMultSlide float64
+	enableFrequencyCutoff                          bool
+	dutyCycle, dutyCycleSlide                      float64
+	arpeggioMultiplier                             float64
+	arpeggioTime                                   int
+	elapsedSinceRepeat, repeatTime                 int
+
+	// Filters
+	fltw, fltwD, fltdmp, flthp, flthpD float64
+	enableLowPassFilter                bool
+	fltp, fltdp, fltphp                float64
+
+	// Vibrato
+	vibratoSpeed, vibratoAmplitude, vibratoPhase float64
+
+	// Envelope
+	envelopeLength  [3]int
+	envelopePunch   float64
+	envelopeStage   int
+	envelopeElapsed int
+
+	// Flanger
+	flangerOffset, flangerOffsetSlide float64
+	flangerBuffer                     [1024]float64
+	ipp                               int
+
+	gain        float64
+	phase       int
+	noiseBuffer [32]float64
+	t           int
+	summands    int
+	renderer    *sample.Renderer
+}
+
+func (g *generator) init() {
+	p := g.p
+	g.initForRepeat()
+
+	g.fltw = math.Pow(p.LPFFreq, 3) * .1
+	g.enableLowPassFilter = p.LPFFreq != 1
+	g.fltwD = 1 + p.LPFRamp*.0001
+	g.fltdmp = math.Min(.8, 5/(1+math.Pow(p.LPFResonance, 2)*20)*(.01+g.fltw))
+	g.flthp = math.Pow(p.HPFFreq, 2) * .1
+	g.flthpD = 1 + p.HPFRamp*.0003
+
+	g.vibratoSpeed = math.Pow(p.VibSpeed, 2) * .01
+	g.vibratoAmplitude = p.VibStrength * .5
+
+	g.envelopeLength = [3]int{
+		int(p.EnvAttack * p.EnvAttack * 100000),
+		int(p.EnvSustain * p.EnvSustain * 100000),
+		int(p.EnvDecay * p.EnvDecay * 100000),
+	}
+	g.envelopePunch = p.EnvPunch
+
+	g.flangerOffset = math.Copysign(math.Pow(p.PhaserOffset, 2)*1020, p.PhaserOffset)
+	g.flangerOffsetSlide = math.Copysign(math.Pow(p.PhaserRamp, 2), p.PhaserRamp)
+
+	g.repeatTime = int(math.Pow(1-p.RepeatSpeed, 2)*20000 + 32)
+	if p.RepeatSpeed == 0 {
+		g.repeatTime = 0
+	}
+	g.gain = math.Exp(p.SoundVolume) - 1
+	for i := range g.noiseBuffer {
+		g.noiseBuffer[i] = rand.Float64()*2 - 1
+	}
+}
+
+// initForRepeat resets the parameters which restart when an effect repeats.
+func (g *generator) initForRepeat() {
+	p := g.p
+	g.elapsedSinceRepeat = 0
+
+	g.period = 100 / (p.BaseFreq*p.BaseFreq + .001)
+	g.periodMax = 100 / (p.FreqLimit*p.FreqLimit + .001)
+	g.enableFrequencyCutoff = p.FreqLimit > 0
+	g.periodMult = 1 - math.Pow(p.FreqRamp, 3)*.01
+	g.periodMultSlide = -math.Pow(p.FreqDeltaRamp, 3) * .000001
+
+	g.dutyCycle = .5 - p.Duty*.5
+	g.dutyCycleSlide = -p.DutyRamp * .00005
+
+	if p.ArpMod >= 0 {
+		g.arpeggioMultiplier = 1 - math.Pow(p.ArpMod, 2)*.9
+	} else {
+		g.arpeggioMultiplier = 1 + math.Pow(p.ArpMod, 2)*10
+	}
+	g.arpeggioTime = int(math.Pow(1-p.ArpSpeed, 2)*20000 + 32)
+	if p.ArpSpeed == 1 {
+		g.arpeggioTime = 0
+	}
+}
+
+// next synthesizes the next sample at the base sample rate, or returns false once the effect ends.
+func (g *generator) next() (float64, bool) {
+	g.elapsedSinceRepeat++
+	if g.repeatTime != 0 && g.elapsedSinceRepeat >= g.repeatTime {
+		g.initForRepeat()
+	}
+	if g.arpeggioTime != 0 && g.t >= g.arpeggioTime {
+		g.arpeggioTime = 0
+		g.period *= g.arpeggioMultiplier
+	}
+	g.t++
+
+	g.periodMult += g.periodMultSlide
+	g.period *= g.periodMult
+	if g.period > g.periodMax {
+		g.period = g.periodMax
+		if g.enableFrequencyCutoff {
+			return 0, false
+		}
+	}
+
+	rfperiod := g.period
+	if g.vibratoAmplitude > 0 {
+		g.vibratoPhase += g.vibratoSpeed
+		rfperiod = g.period * (1 + math.Sin(g.vibratoPhase)*g.vibratoAmplitude)
+	}
+	iperiod := int(rfperiod)
+	if iperiod < oversampling {
+		iperiod = oversampling
+	}
+
+	g.dutyCycle = math.Max(0, math.Min(.5, g.dutyCycle+g.dutyCycleSlide))
+
+	g.envelopeElapsed++
+	if g.envelopeElapsed > g.envelopeLength[g.envelopeStage] {
+		g.envelopeElapsed = 0
+		g.envelopeStage++
+		if g.envelopeStage > 2 {
+			return 0, false
+		}
+	}
+	var envf float64
+	if length := g.envelopeLength[g.envelopeStage]; length > 0 {
+		envf = float64(g.envelopeElapsed) / float64(length)
+	}
+	var envVol float64
+	switch g.envelopeStage {
+	case 0:
+		envVol = envf
+	case 1:
+		envVol = 1 + (1-envf)*2*g.envelopePunch
+	default:
+		envVol = 1 - envf
+	}
+
+	g.flangerOffset += g.flangerOffsetSlide
+	iphase := int(math.Abs(math.Floor(g.flangerOffset)))
+	if iphase > 1023 {
+		iphase = 1023
+	}
+
+	if g.flthpD != 0 {
+		g.flthp = math.Max(.00001, math.Min(.1, g.flthp*g.flthpD))
+	}
+
+	var out float64
+	for si := 0; si < oversampling; si++ {
+		var sub float64
+		g.phase++
+		if g.phase >= iperiod {
+			g.phase %= iperiod
+			if g.p.WaveType == Noise {
+				for i := range g.noiseBuffer {
+					g.noiseBuffer[i] = rand.Float64()*2 - 1
+				}
+			}
+		}
+		fp := float64(g.phase) / float64(iperiod)
+		switch g.p.WaveType {
+		case Square:
+			if fp < g.dutyCycle {
+				sub = .5
+			} else {
+				sub = -.5
+			}
+		case Sawtooth:
+			if fp < g.dutyCycle {
+				sub = -1 + 2*fp/g.dutyCycle
+			} else {
+				sub = 1 - 2*(fp-g.dutyCycle)/(1-g.dutyCycle)
+			}
+		case Sine:
+			sub = math.Sin(fp * 2 * math.Pi)
+		case Noise:
+			sub = g.noiseBuffer[g.phase*32/iperiod]
+		}
+
+		// Low pass filter
+		pp := g.fltp
+		g.fltw = math.Max(0, math.Min(.1, g.fltw*g.fltwD))
+		if g.enableLowPassFilter {
+			g.fltdp += (sub - g.fltp) * g.fltw
+			g.fltdp -= g.fltdp * g.fltdmp
+		} else {
+			g.fltp = sub
+			g.fltdp = 0
+		}
+		g.fltp += g.fltdp
+
+		// High pass filter
+		g.fltphp += g.fltp - pp
+		g.fltphp -= g.fltphp * g.flthp
+		sub = g.fltphp
+
+		// Flanger
+		g.flangerBuffer[g.ipp&1023] = sub
+		sub += g.flangerBuffer[(g.ipp-iphase+1024)&1023]
+		g.ipp = (g.ipp + 1) & 1023
+
+		out += sub * envVol
+	}
+	return out / oversampling * g.gain, true
+}
+
+func (g *generator) ReadPCM(b []byte) (n int, err error) {
+	return g.renderer.ReadPCM(b)
+}
+
+// render averages the next summands of the effect into frame, returning false once it ends.
+func (g *generator) render(frame []float64) bool {
+	var sum float64
+	for i := 0; i < g.summands; i++ {
+		v, ok := g.next()
+		if !ok {
+			return false
+		}
+		sum += v
+	}
+	frame[0] = sum / float64(g.summands)
+	return true
+}
@@ -0,0 +1,189 @@
+// Package sfxr generates retro sound effects from sfxr parameters, compatible with the
+// parameter sets, JSON, and base58 formats of jsfxr (https://sfxr.me).
+//
+// Effects are synthesized with sfxr's own oscillators, envelope and filters rather than those of
+// package synth, as matching jsfxr's output depends on their exact, per sample math. Waveform
+// exposes an effect to synth, so it can be played and further shaped by a synth.Source.
+package sfxr
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// A WaveType is the base waveform of a sound effect.
+type WaveType int
+
+// Wave types.
+const (
+	Square WaveType = iota
+	Sawtooth
+	Sine
+	Noise
+)
+
+// Params describe a sound effect. Parameters range from 0.0 -> 1.0, or -1.0 -> 1.0 where noted as signed.
+// Field names in JSON match those of jsfxr.
+type Params struct {
+	WaveType WaveType `json:"wave_type"`
+
+	// Envelope
+	EnvAttack  float64 `json:"p_env_attack"`
+	EnvSustain float64 `json:"p_env_sustain"`
+	EnvPunch   float64 `json:"p_env_punch"`
+	EnvDecay   float64 `json:"p_env_decay"`
+
+	// Tone
+	BaseFreq float64 `json:"p_base_freq"`
+	// FreqLimit is a minimum frequency; the sound ends if a slide falls below it.
+	FreqLimit float64 `json:"p_freq_limit"`
+	// FreqRamp slides the frequency up or down. Signed.
+	FreqRamp float64 `json:"p_freq_ramp"`
+	// FreqDeltaRamp accelerates the frequency slide. Signed.
+	FreqDeltaRamp float64 `json:"p_freq_dramp"`
+
+	// Vibrato
+	VibStrength float64 `json:"p_vib_strength"`
+	VibSpeed    float64 `json:"p_vib_speed"`
+
+	// Arpeggio, a single jump in pitch
+	// ArpMod is the size of the jump. Signed.
+	ArpMod   float64 `json:"p_arp_mod"`
+	ArpSpeed float64 `json:"p_arp_speed"`
+
+	// Duty is the proportion of square waves spent high, and shapes sawtooth waves.
+	Duty float64 `json:"p_duty"`
+	// DutyRamp sweeps the duty. Signed.
+	DutyRamp float64 `json:"p_duty_ramp"`
+
+	// RepeatSpeed restarts the frequency and duty sweeps and arpeggio periodically.
+	RepeatSpeed float64 `json:"p_repeat_speed"`
+
+	// Flanger
+	// PhaserOffset is signed.
+	PhaserOffset float64 `json:"p_pha_offset"`
+	// PhaserRamp is signed.
+	PhaserRamp float64 `json:"p_pha_ramp"`
+
+	// Low pass filter
+	LPFFreq float64 `json:"p_lpf_freq"`
+	// LPFRamp is signed.
+	LPFRamp      float64 `json:"p_lpf_ramp"`
+	LPFResonance float64 `json:"p_lpf_resonance"`
+
+	// High pass filter
+	HPFFreq float64 `json:"p_hpf_freq"`
+	// HPFRamp is signed.
+	HPFRamp float64 `json:"p_hpf_ramp"`
+
+	// SoundVolume is the volume of the effect.
+	SoundVolume float64 `json:"sound_vol"`
+	// SampleRate is the rate of generated audio: one of 44100, 22050, 11025, or 5512.
+	SampleRate uint32 `json:"sample_rate"`
+	// SampleSize is the bits per sample of generated audio: 8 or 16.
+	SampleSize uint16 `json:"sample_size"`
+}
+
+// DefaultParams returns the parameters jsfxr starts from.
+func DefaultParams() Params {
+	return Params{
+		WaveType:    Square,
+		EnvSustain:  .3,
+		EnvDecay:    .4,
+		BaseFreq:    .3,
+		LPFFreq:     1,
+		SoundVolume: .5,
+		SampleRate:  44100,
+		SampleSize:  8,
+	}
+}
+
+// ParseJSON parses parameters from jsfxr's JSON format. Fields not present keep their default values.
+func ParseJSON(data []byte) (Params, error) {
+	p := DefaultParams()
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Params{}, err
+	}
+	return p, nil
+}
+
+// floats returns pointers to the parameters encoded in jsfxr's base58 format, in order.
+func (p *Params) floats() []*float64 {
+	return []*float64{
+		&p.EnvAttack, &p.EnvSustain, &p.EnvPunch, &p.EnvDecay,
+		&p.BaseFreq, &p.FreqLimit, &p.FreqRamp, &p.FreqDeltaRamp,
+		&p.VibStrength, &p.VibSpeed,
+		&p.ArpMod, &p.ArpSpeed,
+		&p.Duty, &p.DutyRamp,
+		&p.RepeatSpeed,
+		&p.PhaserOffset, &p.PhaserRamp,
+		&p.LPFFreq, &p.LPFRamp, &p.LPFResonance,
+		&p.HPFFreq, &p.HPFRamp,
+	}
+}
+
+const b58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// B58 encodes the parameters in jsfxr's base58 format, as found in sfxr.me links. The format
+// stores each parameter as a float32, and does not include volume, sample rate, or sample size.
+func (p Params) B58() string {
+	data := []byte{byte(p.WaveType)}
+	for _, f := range p.floats() {
+		bits := math.Float32bits(float32(*f))
+		data = append(data, byte(bits), byte(bits>>8), byte(bits>>16), byte(bits>>24))
+	}
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, b58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, b58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// ParseB58 parses parameters from jsfxr's base58 format. Volume, sample rate, and sample size
+// keep their default values.
+func ParseB58(s string) (Params, error) {
+	p := DefaultParams()
+	floats := p.floats()
+	size := 1 + 4*len(floats)
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	zeros := 0
+	for i, c := range s {
+		d := strings.IndexRune(b58Alphabet, c)
+		if d < 0 {
+			return Params{}, errors.New("sfxr: invalid base58 character")
+		}
+		if d == 0 && i == zeros {
+			zeros++
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(d)))
+	}
+	data := append(make([]byte, zeros), n.Bytes()...)
+	if len(data) != size {
+		return Params{}, errors.New("sfxr: invalid base58 parameter length")
+	}
+	p.WaveType = WaveType(data[0])
+	for i, f := range floats {
+		off := 1 + i*4
+		bits := uint32(data[off]) | uint32(data[off+1])<<8 | uint32(data[off+2])<<16 | uint32(data[off+3])<<24
+		*f = float64(math.Float32frombits(bits))
+	}
+	return p, nil
+}
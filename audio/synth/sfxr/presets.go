@@ -0,0 +1,278 @@
+package sfxr
+
+import (
+	"math"
+	"math/rand"
+)
+
+// A Category is a kind of sound effect which parameters can be randomly generated for.
+type Category int
+
+// Categories, matching the generator buttons of sfxr.
+const (
+	PickupCoin Category = iota
+	LaserShoot
+	Explosion
+	PowerUp
+	HitHurt
+	Jump
+	BlipSelect
+	Random
+)
+
+// Preset randomly generates parameters for a category of sound effect, as sfxr does. If rng
+// is nil, the global source of math/rand will be used.
+func Preset(c Category, rng *rand.Rand) Params {
+	g := presetGen{rng: rng}
+	p := DefaultParams()
+	switch c {
+	case PickupCoin:
+		g.pickupCoin(&p)
+	case LaserShoot:
+		g.laserShoot(&p)
+	case Explosion:
+		g.explosion(&p)
+	case PowerUp:
+		g.powerUp(&p)
+	case HitHurt:
+		g.hitHurt(&p)
+	case Jump:
+		g.jump(&p)
+	case BlipSelect:
+		g.blipSelect(&p)
+	default:
+		g.random(&p)
+	}
+	return p
+}
+
+type presetGen struct {
+	rng *rand.Rand
+}
+
+func (g presetGen) float() float64 {
+	if g.rng == nil {
+		return rand.Float64()
+	}
+	return g.rng.Float64()
+}
+
+// frnd returns a random value in [0, max).
+func (g presetGen) frnd(max float64) float64 {
+	return g.float() * max
+}
+
+// rnd returns a random integer in [0, max].
+func (g presetGen) rnd(max int) int {
+	return int(g.float() * float64(max+1))
+}
+
+// chance returns true half of the time.
+func (g presetGen) chance() bool {
+	return g.rnd(1) == 1
+}
+
+func (g presetGen) pickupCoin(p *Params) {
+	p.WaveType = Sawtooth
+	p.BaseFreq = .4 + g.frnd(.5)
+	p.EnvAttack = 0
+	p.EnvSustain = g.frnd(.1)
+	p.EnvDecay = .1 + g.frnd(.4)
+	p.EnvPunch = .3 + g.frnd(.3)
+	if g.chance() {
+		p.ArpSpeed = .5 + g.frnd(.2)
+		p.ArpMod = .2 + g.frnd(.4)
+	}
+}
+
+func (g presetGen) laserShoot(p *Params) {
+	p.WaveType = WaveType(g.rnd(2))
+	if p.WaveType == Sine && g.chance() {
+		p.WaveType = WaveType(g.rnd(1))
+	}
+	if g.rnd(2) == 0 {
+		p.BaseFreq = .3 + g.frnd(.6)
+		p.FreqLimit = g.frnd(.1)
+		p.FreqRamp = -.35 - g.frnd(.3)
+	} else {
+		p.BaseFreq = .5 + g.frnd(.5)
+		p.FreqLimit = math.Max(.2, p.BaseFreq-.2-g.frnd(.6))
+		p.FreqRamp = -.15 - g.frnd(.2)
+	}
+	if p.WaveType == Sawtooth {
+		p.Duty = 1
+	}
+	if g.chance() {
+		p.Duty = g.frnd(.5)
+		p.DutyRamp = g.frnd(.2)
+	} else {
+		p.Duty = .4 + g.frnd(.5)
+		p.DutyRamp = -g.frnd(.7)
+	}
+	p.EnvAttack = 0
+	p.EnvSustain = .1 + g.frnd(.2)
+	p.EnvDecay = g.frnd(.4)
+	if g.chance() {
+		p.EnvPunch = g.frnd(.3)
+	}
+	if g.rnd(2) == 0 {
+		p.PhaserOffset = g.frnd(.2)
+		p.PhaserRamp = -g.frnd(.2)
+	}
+	p.HPFFreq = g.frnd(.3)
+}
+
+func (g presetGen) explosion(p *Params) {
+	p.WaveType = Noise
+	if g.chance() {
+		p.BaseFreq = math.Pow(.1+g.frnd(.4), 2)
+		p.FreqRamp = -.1 + g.frnd(.4)
+	} else {
+		p.BaseFreq = math.Pow(.2+g.frnd(.7), 2)
+		p.FreqRamp = -.2 - g.frnd(.2)
+	}
+	if g.rnd(4) == 0 {
+		p.FreqRamp = 0
+	}
+	if g.rnd(2) == 0 {
+		p.RepeatSpeed = .3 + g.frnd(.5)
+	}
+	p.EnvAttack = 0
+	p.EnvSustain = .1 + g.frnd(.3)
+	p.EnvDecay = g.frnd(.5)
+	if g.chance() {
+		p.PhaserOffset = -.3 + g.frnd(.9)
+		p.PhaserRamp = -g.frnd(.3)
+	}
+	p.EnvPunch = .2 + g.frnd(.6)
+	if g.chance() {
+		p.VibStrength = g.frnd(.7)
+		p.VibSpeed = g.frnd(.6)
+	}
+	if g.rnd(2) == 0 {
+		p.ArpSpeed = .6 + g.frnd(.3)
+		p.ArpMod = .8 - g.frnd(1.6)
+	}
+}
+
+func (g presetGen) powerUp(p *Params) {
+	if g.chance() {
+		p.WaveType = Sawtooth
+		p.Duty = 1
+	} else {
+		p.Duty = g.frnd(.6)
+	}
+	p.BaseFreq = .2 + g.frnd(.3)
+	if g.chance() {
+		p.FreqRamp = .1 + g.frnd(.4)
+		p.RepeatSpeed = .4 + g.frnd(.4)
+	} else {
+		p.FreqRamp = .05 + g.frnd(.2)
+		if g.chance() {
+			p.VibStrength = g.frnd(.7)
+			p.VibSpeed = g.frnd(.6)
+		}
+	}
+	p.EnvAttack = 0
+	p.EnvSustain = g.frnd(.4)
+	p.EnvDecay = .1 + g.frnd(.4)
+}
+
+func (g presetGen) hitHurt(p *Params) {
+	p.WaveType = WaveType(g.rnd(2))
+	switch p.WaveType {
+	case Sine:
+		p.WaveType = Noise
+	case Square:
+		p.Duty = g.frnd(.6)
+	case Sawtooth:
+		p.Duty = 1
+	}
+	p.BaseFreq = .2 + g.frnd(.6)
+	p.FreqRamp = -.3 - g.frnd(.4)
+	p.EnvAttack = 0
+	p.EnvSustain = g.frnd(.1)
+	p.EnvDecay = .1 + g.frnd(.2)
+	if g.chance() {
+		p.HPFFreq = g.frnd(.3)
+	}
+}
+
+func (g presetGen) jump(p *Params) {
+	p.WaveType = Square
+	p.Duty = g.frnd(.6)
+	p.BaseFreq = .3 + g.frnd(.3)
+	p.FreqRamp = .1 + g.frnd(.2)
+	p.EnvAttack = 0
+	p.EnvSustain = .1 + g.frnd(.3)
+	p.EnvDecay = .1 + g.frnd(.2)
+	if g.chance() {
+		p.HPFFreq = g.frnd(.3)
+	}
+	if g.chance() {
+		p.LPFFreq = 1 - g.frnd(.6)
+	}
+}
+
+func (g presetGen) blipSelect(p *Params) {
+	p.WaveType = WaveType(g.rnd(1))
+	if p.WaveType == Square {
+		p.Duty = g.frnd(.6)
+	} else {
+		p.Duty = 1
+	}
+	p.BaseFreq = .2 + g.frnd(.4)
+	p.EnvAttack = 0
+	p.EnvSustain = .1 + g.frnd(.1)
+	p.EnvDecay = g.frnd(.2)
+	p.HPFFreq = .1
+}
+
+// signed returns a random value in [-1, 1) raised to a power.
+func (g presetGen) signed(pow float64) float64 {
+	v := g.frnd(2) - 1
+	return math.Copysign(math.Pow(math.Abs(v), pow), v)
+}
+
+func (g presetGen) random(p *Params) {
+	p.WaveType = WaveType(g.rnd(3))
+	if g.chance() {
+		p.BaseFreq = g.signed(3) + .5
+	} else {
+		p.BaseFreq = math.Pow(g.frnd(1), 2)
+	}
+	p.FreqLimit = 0
+	p.FreqRamp = g.signed(5)
+	if p.BaseFreq > .7 && p.FreqRamp > .2 {
+		p.FreqRamp = -p.FreqRamp
+	}
+	if p.BaseFreq < .2 && p.FreqRamp < -.05 {
+		p.FreqRamp = -p.FreqRamp
+	}
+	p.FreqDeltaRamp = g.signed(3)
+	p.Duty = g.signed(1)
+	p.DutyRamp = g.signed(3)
+	p.VibStrength = g.signed(3)
+	p.VibSpeed = g.signed(1)
+	p.EnvAttack = g.signed(3)
+	p.EnvSustain = math.Pow(g.signed(1), 2)
+	p.EnvDecay = g.signed(1)
+	p.EnvPunch = math.Pow(g.frnd(.8), 2)
+	if p.EnvAttack+p.EnvSustain+p.EnvDecay < .2 {
+		p.EnvSustain += .2 + g.frnd(.3)
+		p.EnvDecay += .2 + g.frnd(.3)
+	}
+	p.LPFResonance = g.signed(1)
+	p.LPFFreq = 1 - math.Pow(g.frnd(1), 3)
+	p.LPFRamp = g.signed(3)
+	if p.LPFFreq < .1 && p.LPFRamp < -.05 {
+		p.LPFRamp = -p.LPFRamp
+	}
+	p.HPFFreq = math.Pow(g.frnd(1), 5)
+	p.HPFRamp = g.signed(5)
+	p.PhaserOffset = g.signed(3)
+	p.PhaserRamp = g.signed(3)
+	p.RepeatSpeed = g.signed(1)
+	p.ArpSpeed = g.signed(1)
+	p.ArpMod = g.signed(1)
+}
@@ -0,0 +1,160 @@
+package sfxr
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/internal/audiotest"
+	"github.com/diakovliev/oak/v4/audio/synth"
+)
+
+// maxEffect is more bytes than any effect should take to end.
+const maxEffect = 44100 * 2 * 60
+
+func TestParams_B58(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for c := PickupCoin; c <= Random; c++ {
+		p := Preset(c, rng)
+		got, err := ParseB58(p.B58())
+		if err != nil {
+			t.Fatalf("parse failed: %v", err)
+		}
+		// Base58 stores float32s.
+		for i, f := range p.floats() {
+			*f = float64(float32(*f))
+			if *f != *got.floats()[i] {
+				t.Fatalf("category %v: parameter %d: expected %v got %v", c, i, *f, *got.floats()[i])
+			}
+		}
+		if got.WaveType != p.WaveType {
+			t.Fatalf("category %v: expected wave %v got %v", c, p.WaveType, got.WaveType)
+		}
+	}
+	if _, err := ParseB58("0OIl"); err == nil {
+		t.Fatalf("expected invalid characters to fail")
+	}
+	if _, err := ParseB58("abc"); err == nil {
+		t.Fatalf("expected short input to fail")
+	}
+	zero := DefaultParams()
+	zero.EnvSustain, zero.EnvDecay, zero.BaseFreq, zero.LPFFreq = 0, 0, 0, 0
+	if got, err := ParseB58(zero.B58()); err != nil || got.BaseFreq != 0 {
+		t.Fatalf("expected zero parameters to round trip: %v", err)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	p, err := ParseJSON([]byte(`{"wave_type": 3, "p_base_freq": 0.5, "sample_rate": 22050, "sample_size": 16}`))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if p.WaveType != Noise || p.BaseFreq != .5 || p.SampleRate != 22050 || p.SampleSize != 16 {
+		t.Fatalf("unexpected params: %+v", p)
+	}
+	if p.EnvDecay != DefaultParams().EnvDecay {
+		t.Fatalf("expected missing fields to keep defaults")
+	}
+	if _, err := ParseJSON([]byte(`{`)); err == nil {
+		t.Fatalf("expected invalid json to fail")
+	}
+}
+
+func TestNewReader_Invalid(t *testing.T) {
+	p := DefaultParams()
+	p.SampleSize = 24
+	if _, err := NewReader(p); err == nil {
+		t.Fatalf("expected invalid sample size to fail")
+	}
+	p = DefaultParams()
+	p.SampleRate = 96000
+	if _, err := NewReader(p); err == nil {
+		t.Fatalf("expected invalid sample rate to fail")
+	}
+	p = DefaultParams()
+	p.WaveType = 7
+	if _, err := NewReader(p); err == nil {
+		t.Fatalf("expected invalid wave type to fail")
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for c := PickupCoin; c <= Random; c++ {
+		for i := 0; i < 5; i++ {
+			p := Preset(c, rng)
+			r, err := NewReader(p)
+			if err != nil {
+				t.Fatalf("new reader failed: %v", err)
+			}
+			out := audiotest.ReadAll(t, r, maxEffect)
+			if len(out) == 0 {
+				t.Fatalf("category %v: expected audio", c)
+			}
+			if len(out) >= maxEffect {
+				t.Fatalf("category %v: effect did not end", c)
+			}
+		}
+	}
+	p := DefaultParams()
+	p.SampleSize = 16
+	p.SampleRate = 22050
+	r, err := NewReader(p)
+	if err != nil {
+		t.Fatalf("new reader failed: %v", err)
+	}
+	if f := r.PCMFormat(); f.Channels != 1 || f.SampleRate != 22050 || f.Bits != 16 {
+		t.Fatalf("unexpected format: %+v", f)
+	}
+	out := audiotest.ReadAll(t, r, maxEffect)
+	// The default envelope lasts 25002 samples at 44100hz, one more than its length per stage.
+	if len(out) != 12501*2 {
+		t.Fatalf("expected 25002 bytes, got %v", len(out))
+	}
+	var loud bool
+	for i := 0; i < len(out); i += 2 {
+		v := int16(out[i]) | int16(out[i+1])<<8
+		if v > 1000 || v < -1000 {
+			loud = true
+		}
+	}
+	if !loud {
+		t.Fatalf("expected effect to be audible")
+	}
+}
+
+func TestWaveform(t *testing.T) {
+	wave, length, err := Waveform(DefaultParams())
+	if err != nil {
+		t.Fatalf("waveform failed: %v", err)
+	}
+	// The default envelope lasts 25002 samples at 44100hz.
+	if length < 566*time.Millisecond || length > 567*time.Millisecond {
+		t.Fatalf("expected length of about 567ms, got %v", length)
+	}
+	src := synth.Int16.Update(synth.Mono(), synth.Volume(1))
+	if v := wave(src, 0); v == 0 {
+		t.Fatalf("expected effect to start without an attack")
+	}
+	if v := wave(src, 25002); v != 0 {
+		t.Fatalf("expected silence after the effect, got %v", v)
+	}
+	r := src.Wave(wave, synth.Duration(length), synth.ADSR(0, 0, 1, 0), synth.LowPass(4000, .7))
+	out := audiotest.ReadAll(t, r, maxEffect)
+	if len(out) != 25002*2 {
+		t.Fatalf("expected 50004 bytes, got %v", len(out))
+	}
+	var loud bool
+	for i := 0; i < len(out); i += 2 {
+		v := int16(out[i]) | int16(out[i+1])<<8
+		if v > 1000 || v < -1000 {
+			loud = true
+		}
+	}
+	if !loud {
+		t.Fatalf("expected effect to be audible through synth")
+	}
+	if _, _, err := Waveform(Params{SampleSize: 16}); err == nil {
+		t.Fatalf("expected invalid params to fail")
+	}
+}
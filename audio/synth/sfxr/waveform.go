@@ -0,0 +1,35 @@
+package sfxr
+
+import (
+	"time"
+
+	"github.com/diakovliev/oak/v4/audio/synth"
+)
+
+// Waveform renders the effect described by p as a synth.Waveform, so it can be played through a
+// synth.Source and shaped by its envelope, LFOs and filters. The waveform is scaled by the source's
+// volume, resampled to the source's sample rate, and silent once the effect ends. The returned
+// duration is how long the effect lasts, suitable for synth.Duration.
+//
+//	wave, length, err := sfxr.Waveform(p)
+//	r := synth.Int16.Wave(wave, synth.Duration(length), synth.LowPass(2000, .7))
+func Waveform(p Params) (synth.Waveform, time.Duration, error) {
+	g, err := newGenerator(p)
+	if err != nil {
+		return nil, 0, err
+	}
+	var samples []float64
+	frame := make([]float64, 1)
+	for g.render(frame) {
+		samples = append(samples, frame[0])
+	}
+	rate := float64(p.SampleRate)
+	length := time.Duration(float64(len(samples)) / rate * float64(time.Second))
+	return func(s synth.Source, idx int) float64 {
+		i := int(float64(idx) * rate / float64(s.SampleRate))
+		if i < 0 || i >= len(samples) {
+			return 0
+		}
+		return samples[i] * s.Volume
+	}, length, nil
+}
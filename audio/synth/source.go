@@ -13,6 +13,16 @@ type Source struct {
 	// Volume, between 0.0 -> 1.0
 	Volume  float64
 	Seconds float64
+	// Envelope shapes the volume of the waveform over time, releasing it after Seconds.
+	// The zero Envelope leaves volume unchanged and lets the waveform play forever.
+	Envelope Envelope
+	// LFOs modulate the waveform as it is generated.
+	LFOs []LFO
+	// Filters are applied to the waveform in order.
+	Filters []Filter
+
+	// pulseMod is set by pulse width LFOs for pulse waveforms to read.
+	pulseMod float64
 }
 
 // PlayLength returns the time it will take before audio generated from this
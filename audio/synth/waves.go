@@ -2,6 +2,7 @@
 package synth
 
 import (
+	"io"
 	"math"
 	"math/rand"
 
@@ -50,7 +51,13 @@ func (s Source) Pulse(pulse float64) func(opts ...Option) pcm.Reader {
 func PulseWave(pulse float64) Waveform {
 	pulseSwitch := 1 - 2/pulse
 	return func(s Source, idx int) float64 {
-		if math.Sin(s.Phase(idx)) > pulseSwitch {
+		threshold := pulseSwitch
+		if s.pulseMod != 0 {
+			// Keep at least a sliver of the wave up and down
+			duty := math.Max(.01, math.Min(.99, 1/pulse+s.pulseMod))
+			threshold = 1 - 2*duty
+		}
+		if math.Sin(s.Phase(idx)) > threshold {
 			return s.Volume
 		}
 		return -s.Volume
@@ -108,34 +115,29 @@ func (s Source) modPhase(idx int) float64 {
 // and an index of where in the generated waveform the requested point lies
 type Waveform func(s Source, idx int) float64
 
-// Wave converts a waveform function into a pcm.Reader
+// Wave converts a waveform function into a pcm.Reader. The reader will end once the source's
+// Envelope, if it has one, completes.
 func (s Source) Wave(waveFn Waveform, opts ...Option) pcm.Reader {
 	switch s.Bits {
 	case 8:
 		s.Volume *= math.MaxInt8
+		s = s.Update(opts...)
 		return &wave8Reader{
-			Source: s.Update(opts...),
-			waveFunc: func(s Source, idx int) int8 {
-				return int8(waveFn(s, idx))
-			},
+			modulator: newModulator(s, waveFn),
 		}
 	case 32:
 		s.Volume *= math.MaxInt32
+		s = s.Update(opts...)
 		return &wave32Reader{
-			Source: s.Update(opts...),
-			waveFunc: func(s Source, idx int) int32 {
-				return int32(waveFn(s, idx))
-			},
+			modulator: newModulator(s, waveFn),
 		}
 	case 16:
 		fallthrough
 	default:
 		s.Volume *= math.MaxInt16
+		s = s.Update(opts...)
 		return &wave16Reader{
-			Source: s.Update(opts...),
-			waveFunc: func(s Source, idx int) int16 {
-				return int16(waveFn(s, idx))
-			},
+			modulator: newModulator(s, waveFn),
 		}
 	}
 }
@@ -153,56 +155,132 @@ func (s Source) MultiWave(waveFns []Waveform, opts ...Option) pcm.Reader {
 	}, opts...)
 }
 
-type wave8Reader struct {
+// A modulator generates a waveform, applying a source's LFOs, Filters, and Envelope.
+type modulator struct {
 	Source
-	lastIndex int
-	waveFunc  func(s Source, idx int) int8
+	waveFn Waveform
+	// pos is the index into the waveform of the next value. Pitch LFOs move through the
+	// waveform faster or slower, and fractional positions are interpolated.
+	pos     float64
+	played  float64
+	filters []*Biquad
+	// gate is how long the envelope is held, and end is the frame at which the envelope completes.
+	gate, end   float64
+	hasEnvelope bool
+}
+
+func newModulator(s Source, waveFn Waveform) *modulator {
+	m := &modulator{
+		Source:      s,
+		waveFn:      waveFn,
+		hasEnvelope: s.Envelope != Envelope{},
+		gate:        s.Seconds,
+		end:         math.Round((s.Seconds + s.Envelope.Release.Seconds()) * float64(s.SampleRate)),
+	}
+	for _, f := range s.Filters {
+		m.filters = append(m.filters, f.Biquad(float64(s.SampleRate)))
+	}
+	return m
+}
+
+// next returns the next value of the waveform, or false if its envelope has completed.
+func (m *modulator) next() (float64, bool) {
+	if m.hasEnvelope && m.played >= m.end {
+		return 0, false
+	}
+	t := m.played / float64(m.SampleRate)
+	step, gain := 1.0, 1.0
+	m.pulseMod = 0
+	for _, l := range m.LFOs {
+		switch l.Target {
+		case LFOPitch:
+			step *= math.Pow(2, l.Depth*l.value(t)/12)
+		case LFOVolume:
+			gain *= l.gain(t)
+		case LFOPulseWidth:
+			m.pulseMod += l.Depth * l.value(t)
+		}
+	}
+	idx := int(m.pos)
+	v := m.waveFn(m.Source, idx)
+	if frac := m.pos - float64(idx); frac != 0 {
+		v += (m.waveFn(m.Source, idx+1) - v) * frac
+	}
+	m.pos += step
+	for _, f := range m.filters {
+		v = f.Process(v)
+	}
+	if m.hasEnvelope {
+		gain *= m.Envelope.level(t, m.gate)
+	}
+	m.played++
+	return v * gain, true
+}
+
+// clamp keeps resonant filters from overflowing the sample range.
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
+
+type wave8Reader struct {
+	*modulator
 }
 
 func (pr *wave8Reader) ReadPCM(b []byte) (n int, err error) {
 	bytesPerI8 := int(pr.Channels)
 	for i := 0; i+bytesPerI8 <= len(b); i += bytesPerI8 {
-		i8 := pr.waveFunc(pr.Source, pr.lastIndex)
-		pr.lastIndex++
+		v, ok := pr.next()
+		if !ok {
+			break
+		}
+		i8 := int8(clamp(v, math.MinInt8, math.MaxInt8))
 		for c := 0; c < int(pr.Channels); c++ {
 			b[i+c] = byte(i8)
 		}
 		n += bytesPerI8
 	}
+	if n == 0 && len(b) >= bytesPerI8 {
+		return 0, io.EOF
+	}
 	return
 }
 
 type wave16Reader struct {
-	Source
-	lastIndex int
-	waveFunc  func(s Source, idx int) int16
+	*modulator
 }
 
 func (pr *wave16Reader) ReadPCM(b []byte) (n int, err error) {
 	bytesPerI16 := int(pr.Channels) * 2
 	for i := 0; i+bytesPerI16 <= len(b); i += bytesPerI16 {
-		i16 := pr.waveFunc(pr.Source, pr.lastIndex)
-		pr.lastIndex++
+		v, ok := pr.next()
+		if !ok {
+			break
+		}
+		i16 := int16(clamp(v, math.MinInt16, math.MaxInt16))
 		for c := 0; c < int(pr.Channels); c++ {
 			b[i+(2*c)] = byte(i16)
 			b[i+(2*c)+1] = byte(i16 >> 8)
 		}
 		n += bytesPerI16
 	}
+	if n == 0 && len(b) >= bytesPerI16 {
+		return 0, io.EOF
+	}
 	return
 }
 
 type wave32Reader struct {
-	Source
-	lastIndex int
-	waveFunc  func(s Source, idx int) int32
+	*modulator
 }
 
 func (pr *wave32Reader) ReadPCM(b []byte) (n int, err error) {
 	bytesPerF32 := int(pr.Channels) * 4
 	for i := 0; i+bytesPerF32 <= len(b); i += bytesPerF32 {
-		i32 := pr.waveFunc(pr.Source, pr.lastIndex)
-		pr.lastIndex++
+		v, ok := pr.next()
+		if !ok {
+			break
+		}
+		i32 := int32(clamp(v, math.MinInt32, math.MaxInt32))
 		for c := 0; c < int(pr.Channels); c++ {
 			b[i+(4*c)] = byte(i32)
 			b[i+(4*c)+1] = byte(i32 >> 8)
@@ -211,5 +289,8 @@ func (pr *wave32Reader) ReadPCM(b []byte) (n int, err error) {
 		}
 		n += bytesPerF32
 	}
+	if n == 0 && len(b) >= bytesPerF32 {
+		return 0, io.EOF
+	}
 	return
 }
@@ -0,0 +1,442 @@
+// Package combo detects sequences of key and joystick inputs, like the special
+// moves of fighting games.
+package combo
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/diakovliev/oak/v4/action"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/joystick"
+	"github.com/diakovliev/oak/v4/key"
+	"github.com/diakovliev/oak/v4/oakerr"
+)
+
+// Matched is triggered when a combo is performed.
+var Matched = event.RegisterEvent[Match]()
+
+// A Match describes a performed combo.
+type Match struct {
+	Name     string
+	Priority int
+	// Start and End are when the combo's first and last steps were input.
+	Start, End time.Time
+}
+
+// DefaultStepDelay is the most time allowed between the steps of a combo
+// which does not set its own StepDelay.
+const DefaultStepDelay = 250 * time.Millisecond
+
+// StickThreshold is how far, from 0 to 1, a joystick's left stick must be
+// pushed along an axis for Listen to count its direction as held.
+const StickThreshold = 0.5
+
+// A Step is a set of inputs which must be held together.
+type Step struct {
+	Inputs []action.Binding
+	// MaxDelay is the most time allowed between the previous step and this
+	// one. If zero, the combo's StepDelay is used.
+	MaxDelay time.Duration
+}
+
+// Inputs returns a step of inputs held together, with the default delay.
+func Inputs(bs ...action.Binding) Step {
+	return Step{Inputs: bs}
+}
+
+// A Combo is a named sequence of steps.
+//
+// A step is performed when exactly its inputs, among the inputs used anywhere
+// in the combo, become held. Other inputs may be pressed between steps. For
+// example, with steps down, down+right and right+punch, releasing down after
+// the second step does not break the combo.
+type Combo struct {
+	Name  string
+	Steps []Step
+	// When multiple combos are completed by the same input, only the one with
+	// the highest Priority, or the most steps if tied, is matched.
+	Priority int
+	// StepDelay is the default time allowed between steps, DefaultStepDelay
+	// if zero.
+	StepDelay time.Duration
+	// MaxDuration, if set, limits the time from the first step to the last.
+	MaxDuration time.Duration
+}
+
+type input struct {
+	device action.Device
+	name   string
+}
+
+func toInput(b action.Binding) input {
+	return input{device: b.Device, name: b.Input}
+}
+
+// snapshot is the set of held inputs after an input changed.
+type snapshot struct {
+	held map[input]bool
+	at   time.Time
+}
+
+type compiled struct {
+	Combo
+	order int
+	steps []map[input]bool
+	// relevant holds every input used by the combo.
+	relevant map[input]bool
+}
+
+// A Matcher watches inputs for registered combos.
+type Matcher struct {
+	mutex     sync.Mutex
+	combos    map[string]*compiled
+	nextOrder int
+	held      map[input]bool
+	joysticks map[uint32]map[string]bool
+	history   []snapshot
+	// keep is how long snapshots are remembered.
+	keep    time.Duration
+	handler event.Handler
+}
+
+// NewMatcher creates a Matcher with no combos.
+func NewMatcher() *Matcher {
+	return &Matcher{
+		combos:    make(map[string]*compiled),
+		held:      make(map[input]bool),
+		joysticks: make(map[uint32]map[string]bool),
+		history:   []snapshot{{}},
+	}
+}
+
+// Add registers a combo, replacing any with the same name. Combos must have
+// a name and at least one step, and every step at least one key or joystick
+// input.
+func (m *Matcher) Add(c Combo) error {
+	if c.Name == "" {
+		return oakerr.InvalidInput{InputName: "Name"}
+	}
+	if len(c.Steps) == 0 {
+		return oakerr.InvalidInput{InputName: "Steps"}
+	}
+	if c.StepDelay == 0 {
+		c.StepDelay = DefaultStepDelay
+	}
+	cc := &compiled{
+		Combo:    c,
+		steps:    make([]map[input]bool, len(c.Steps)),
+		relevant: make(map[input]bool),
+	}
+	for i, st := range c.Steps {
+		if len(st.Inputs) == 0 {
+			return oakerr.InvalidInput{InputName: "Steps"}
+		}
+		cc.steps[i] = make(map[input]bool, len(st.Inputs))
+		for _, b := range st.Inputs {
+			if b.Device != action.DeviceKey && b.Device != action.DeviceJoystick {
+				return oakerr.UnsupportedFormat{Format: string(b.Device)}
+			}
+			cc.steps[i][toInput(b)] = true
+			cc.relevant[toInput(b)] = true
+		}
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if old, ok := m.combos[c.Name]; ok {
+		cc.order = old.order
+	} else {
+		cc.order = m.nextOrder
+		m.nextOrder++
+	}
+	m.combos[c.Name] = cc
+	m.keep = 0
+	for _, cc := range m.combos {
+		if d := cc.duration(); d > m.keep {
+			m.keep = d
+		}
+	}
+	return nil
+}
+
+// duration returns the most time the combo can take.
+func (c *compiled) duration() time.Duration {
+	if c.MaxDuration != 0 {
+		return c.MaxDuration
+	}
+	var d time.Duration
+	for i, st := range c.Steps {
+		if i == 0 {
+			continue
+		}
+		if st.MaxDelay != 0 {
+			d += st.MaxDelay
+		} else {
+			d += c.StepDelay
+		}
+	}
+	return d
+}
+
+// Remove unregisters a combo.
+func (m *Matcher) Remove(name string) {
+	m.mutex.Lock()
+	delete(m.combos, name)
+	m.mutex.Unlock()
+}
+
+// Reset forgets input history, so no combo can be completed using inputs
+// from before it was called.
+func (m *Matcher) Reset() {
+	m.mutex.Lock()
+	held := make(map[input]bool, len(m.held))
+	for in := range m.held {
+		held[in] = true
+	}
+	m.history = []snapshot{{held: held}}
+	m.mutex.Unlock()
+}
+
+// Listen starts matching key and joystick input from h, triggering Matched
+// on h. The returned function stops listening.
+//
+// A joystick's left stick is matched as its d-pad: pushing the stick past
+// StickThreshold holds the joystick input Up, Down, Left or Right, and a
+// diagonal holds both of its directions, so a step of Down and Right is
+// performed by either control.
+func (m *Matcher) Listen(h event.Handler) (cancel func()) {
+	m.mutex.Lock()
+	m.handler = h
+	m.mutex.Unlock()
+	bindings := []event.Binding{
+		event.GlobalBind(h, key.AnyDown, func(ev key.Event) event.Response {
+			m.setKey(ev.Code, true)
+			return 0
+		}),
+		event.GlobalBind(h, key.AnyUp, func(ev key.Event) event.Response {
+			m.setKey(ev.Code, false)
+			return 0
+		}),
+		event.GlobalBind(h, joystick.Change, func(st *joystick.State) event.Response {
+			m.setJoystick(st.ID, joystickInputs(st))
+			return 0
+		}),
+		event.GlobalBind(h, joystick.Disconnected, func(id uint32) event.Response {
+			m.setJoystick(id, nil)
+			return 0
+		}),
+	}
+	return func() {
+		for _, b := range bindings {
+			b.Unbind()
+		}
+		m.mutex.Lock()
+		if m.handler == h {
+			m.handler = nil
+		}
+		m.mutex.Unlock()
+	}
+}
+
+func (m *Matcher) setKey(code key.Code, down bool) {
+	name, ok := key.AllKeys[code]
+	if !ok {
+		return
+	}
+	if down {
+		m.Press(action.Binding{Device: action.DeviceKey, Input: name}, time.Now())
+	} else {
+		m.Release(action.Binding{Device: action.DeviceKey, Input: name}, time.Now())
+	}
+}
+
+// joystickInputs returns the inputs held on a joystick, with the left stick
+// holding the d-pad directions it is pushed toward.
+func joystickInputs(st *joystick.State) map[string]bool {
+	held := make(map[string]bool, len(st.Buttons)+2)
+	for b, down := range st.Buttons {
+		if down {
+			held[b] = true
+		}
+	}
+	x := float64(st.StickLX) / math.MaxInt16
+	y := float64(st.StickLY) / math.MaxInt16
+	if x >= StickThreshold {
+		held[string(joystick.InputRight)] = true
+	} else if x <= -StickThreshold {
+		held[string(joystick.InputLeft)] = true
+	}
+	// Positive Y is up
+	if y >= StickThreshold {
+		held[string(joystick.InputUp)] = true
+	} else if y <= -StickThreshold {
+		held[string(joystick.InputDown)] = true
+	}
+	return held
+}
+
+// setJoystick updates the inputs held on a joystick, or releases all of them
+// if held is nil.
+func (m *Matcher) setJoystick(id uint32, held map[string]bool) {
+	m.mutex.Lock()
+	last := m.joysticks[id]
+	if held == nil {
+		delete(m.joysticks, id)
+	} else {
+		m.joysticks[id] = held
+	}
+	m.mutex.Unlock()
+	now := time.Now()
+	for b, down := range last {
+		if down && !held[b] {
+			m.Release(action.JoystickButton(joystick.Input(b)), now)
+		}
+	}
+	// Process presses in a consistent order, so simultaneous presses are
+	// seen the same way every time.
+	var pressed []string
+	for b, down := range held {
+		if down && !last[b] {
+			pressed = append(pressed, b)
+		}
+	}
+	sort.Strings(pressed)
+	for _, b := range pressed {
+		m.Press(action.JoystickButton(joystick.Input(b)), now)
+	}
+}
+
+// Press records an input being pressed at a time, returning the combo it
+// completed, if any. Matched is triggered for the combo if the matcher is
+// listening to a handler.
+func (m *Matcher) Press(b action.Binding, at time.Time) (Match, bool) {
+	return m.set(toInput(b), true, at)
+}
+
+// Release records an input being released at a time, returning the combo it
+// completed, if any.
+func (m *Matcher) Release(b action.Binding, at time.Time) (Match, bool) {
+	return m.set(toInput(b), false, at)
+}
+
+func (m *Matcher) set(in input, down bool, at time.Time) (Match, bool) {
+	m.mutex.Lock()
+	if m.held[in] == down {
+		m.mutex.Unlock()
+		return Match{}, false
+	}
+	if down {
+		m.held[in] = true
+	} else {
+		delete(m.held, in)
+	}
+	held := make(map[input]bool, len(m.held))
+	for in := range m.held {
+		held[in] = true
+	}
+	m.history = append(m.history, snapshot{held: held, at: at})
+	// Forget snapshots too old to be part of any combo, keeping one extra
+	// to tell whether the oldest kept snapshot changed anything.
+	for len(m.history) > 2 && at.Sub(m.history[1].at) > m.keep {
+		m.history = m.history[1:]
+	}
+
+	var best *compiled
+	var bestStart time.Time
+	for _, c := range m.combos {
+		start, ok := m.match(c)
+		if !ok {
+			continue
+		}
+		if best == nil || better(c, best) {
+			best, bestStart = c, start
+		}
+	}
+	if best == nil {
+		m.mutex.Unlock()
+		return Match{}, false
+	}
+	// Inputs used by a combo cannot be used again
+	m.history = m.history[len(m.history)-1:]
+	match := Match{
+		Name:     best.Name,
+		Priority: best.Priority,
+		Start:    bestStart,
+		End:      at,
+	}
+	h := m.handler
+	m.mutex.Unlock()
+	if h != nil {
+		event.TriggerOn(h, Matched, match)
+	}
+	return match, true
+}
+
+func better(a, b *compiled) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if len(a.steps) != len(b.steps) {
+		return len(a.steps) > len(b.steps)
+	}
+	return a.order < b.order
+}
+
+// performs reports whether snapshot i of history performs step s of c: the
+// step's inputs are exactly the relevant inputs held, and were not before.
+func (m *Matcher) performs(c *compiled, s, i int) bool {
+	if i == 0 {
+		// The first snapshot is only kept to compare the next one to
+		return false
+	}
+	return c.holds(s, m.history[i].held) && !c.holds(s, m.history[i-1].held)
+}
+
+func (c *compiled) holds(s int, held map[input]bool) bool {
+	step := c.steps[s]
+	for in := range c.relevant {
+		if held[in] != step[in] {
+			return false
+		}
+	}
+	return true
+}
+
+// match reports whether the latest snapshot completes c, and when c's
+// first step was performed.
+func (m *Matcher) match(c *compiled) (time.Time, bool) {
+	last := len(m.history) - 1
+	if !m.performs(c, len(c.steps)-1, last) {
+		return time.Time{}, false
+	}
+	end := m.history[last].at
+	var search func(s, next int) (time.Time, bool)
+	search = func(s, next int) (time.Time, bool) {
+		if s < 0 {
+			return m.history[next].at, true
+		}
+		delay := c.Steps[s+1].MaxDelay
+		if delay == 0 {
+			delay = c.StepDelay
+		}
+		for i := next - 1; i > 0; i-- {
+			at := m.history[i].at
+			if m.history[next].at.Sub(at) > delay {
+				break
+			}
+			if c.MaxDuration != 0 && end.Sub(at) > c.MaxDuration {
+				break
+			}
+			if !m.performs(c, s, i) {
+				continue
+			}
+			if start, ok := search(s-1, i); ok {
+				return start, true
+			}
+		}
+		return time.Time{}, false
+	}
+	return search(len(c.steps)-2, last)
+}
@@ -0,0 +1,195 @@
+package combo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/diakovliev/oak/v4/action"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/joystick"
+	"github.com/diakovliev/oak/v4/key"
+)
+
+var (
+	down    = action.Key(key.DownArrow)
+	forward = action.Key(key.RightArrow)
+	punch   = action.Key(key.Z)
+)
+
+func fireball() Combo {
+	return Combo{
+		Name: "fireball",
+		Steps: []Step{
+			Inputs(down),
+			Inputs(down, forward),
+			Inputs(forward, punch),
+		},
+	}
+}
+
+func TestMatcher_Sequence(t *testing.T) {
+	m := NewMatcher()
+	if err := m.Add(fireball()); err != nil {
+		t.Fatalf("failed to add combo: %v", err)
+	}
+	now := time.Now()
+	ms := time.Millisecond
+	m.Press(down, now)
+	m.Press(forward, now.Add(50*ms))
+	m.Release(down, now.Add(100*ms))
+	match, ok := m.Press(punch, now.Add(150*ms))
+	if !ok {
+		t.Fatalf("expected fireball to match")
+	}
+	if match.Name != "fireball" || !match.Start.Equal(now) || !match.End.Equal(now.Add(150*ms)) {
+		t.Fatalf("unexpected match %+v", match)
+	}
+	m.Release(punch, now.Add(160*ms))
+	if _, ok := m.Press(punch, now.Add(170*ms)); ok {
+		t.Fatalf("expected inputs of a matched combo not to be reused")
+	}
+}
+
+func TestMatcher_Timing(t *testing.T) {
+	m := NewMatcher()
+	c := fireball()
+	c.Steps[2].MaxDelay = 20 * time.Millisecond
+	if err := m.Add(c); err != nil {
+		t.Fatalf("failed to add combo: %v", err)
+	}
+	now := time.Now()
+	ms := time.Millisecond
+	m.Press(down, now)
+	m.Press(forward, now.Add(50*ms))
+	m.Release(down, now.Add(60*ms))
+	if _, ok := m.Press(punch, now.Add(100*ms)); ok {
+		t.Fatalf("expected late final step to fail")
+	}
+
+	m.Reset()
+	m.Release(punch, now)
+	m.Release(forward, now)
+	m.Press(down, now)
+	m.Press(forward, now.Add(time.Second))
+	m.Release(down, now.Add(time.Second))
+	if _, ok := m.Press(punch, now.Add(time.Second+10*ms)); ok {
+		t.Fatalf("expected late second step to fail")
+	}
+}
+
+func TestMatcher_Priority(t *testing.T) {
+	m := NewMatcher()
+	if err := m.Add(Combo{Name: "punch", Steps: []Step{Inputs(punch)}}); err != nil {
+		t.Fatalf("failed to add combo: %v", err)
+	}
+	if err := m.Add(Combo{Name: "low punch", Steps: []Step{Inputs(down), Inputs(punch)}}); err != nil {
+		t.Fatalf("failed to add combo: %v", err)
+	}
+	now := time.Now()
+	ms := time.Millisecond
+	if match, ok := m.Press(punch, now); !ok || match.Name != "punch" {
+		t.Fatalf("expected punch, got %v", match.Name)
+	}
+	m.Release(punch, now.Add(10*ms))
+	m.Press(down, now.Add(20*ms))
+	m.Release(down, now.Add(30*ms))
+	if match, _ := m.Press(punch, now.Add(40*ms)); match.Name != "low punch" {
+		t.Fatalf("expected longer combo to win ties, got %v", match.Name)
+	}
+	m.Release(punch, now.Add(50*ms))
+	m.Add(Combo{Name: "punch", Steps: []Step{Inputs(punch)}, Priority: 1})
+	m.Press(down, now.Add(60*ms))
+	m.Release(down, now.Add(70*ms))
+	if match, _ := m.Press(punch, now.Add(80*ms)); match.Name != "punch" {
+		t.Fatalf("expected higher priority combo to win, got %v", match.Name)
+	}
+	if err := m.Add(Combo{Name: "bad", Steps: []Step{Inputs(action.MouseButton(0))}}); err == nil {
+		t.Fatalf("expected error for mouse input")
+	}
+	if err := m.Add(Combo{Name: "empty"}); err == nil {
+		t.Fatalf("expected error for combo without steps")
+	}
+}
+
+func TestMatcher_Listen(t *testing.T) {
+	h := event.NewBus(event.NewCallerMap())
+	m := NewMatcher()
+	m.Add(Combo{Name: "dash", Steps: []Step{
+		Inputs(action.JoystickButton(joystick.InputRight)),
+		Inputs(action.JoystickButton(joystick.InputRight)),
+	}})
+	matched := make(chan Match, 1)
+	<-event.GlobalBind(h, Matched, func(mt Match) event.Response {
+		matched <- mt
+		return 0
+	}).Bound
+	cancel := m.Listen(h)
+	defer cancel()
+	time.Sleep(200 * time.Millisecond)
+	for _, right := range []bool{true, false, true} {
+		<-event.TriggerOn(h, joystick.Change, &joystick.State{Buttons: map[string]bool{"Right": right}})
+	}
+	select {
+	case mt := <-matched:
+		if mt.Name != "dash" {
+			t.Fatalf("unexpected match %v", mt.Name)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected dash to match")
+	}
+}
+
+func TestMatcher_Stick(t *testing.T) {
+	h := event.NewBus(event.NewCallerMap())
+	m := NewMatcher()
+	jdown := action.JoystickButton(joystick.InputDown)
+	jforward := action.JoystickButton(joystick.InputRight)
+	jpunch := action.JoystickButton(joystick.InputA)
+	m.Add(Combo{Name: "fireball", Steps: []Step{
+		Inputs(jdown),
+		Inputs(jdown, jforward),
+		Inputs(jforward, jpunch),
+	}})
+	matched := make(chan Match, 2)
+	<-event.GlobalBind(h, Matched, func(mt Match) event.Response {
+		matched <- mt
+		return 0
+	}).Bound
+	cancel := m.Listen(h)
+	defer cancel()
+	time.Sleep(200 * time.Millisecond)
+	expect := func(how string) {
+		t.Helper()
+		select {
+		case mt := <-matched:
+			if mt.Name != "fireball" {
+				t.Fatalf("unexpected match %v", mt.Name)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected fireball to match %v", how)
+		}
+	}
+	states := []*joystick.State{
+		{StickLY: -30000},
+		// Diagonals hold both directions
+		{StickLX: 24000, StickLY: -24000},
+		// Within the threshold nothing is held
+		{StickLX: 10000, StickLY: -10000},
+		{StickLX: 30000, Buttons: map[string]bool{"A": true}},
+	}
+	for _, st := range states {
+		<-event.TriggerOn(h, joystick.Change, st)
+	}
+	expect("with the stick")
+	// The stick and d-pad can be mixed
+	states = []*joystick.State{
+		{Buttons: map[string]bool{"A": false}},
+		{StickLY: -30000},
+		{Buttons: map[string]bool{"Down": true, "Right": true}},
+		{StickLX: 30000, Buttons: map[string]bool{"A": true}},
+	}
+	for _, st := range states {
+		<-event.TriggerOn(h, joystick.Change, st)
+	}
+	expect("with the stick and d-pad")
+}
@@ -0,0 +1,100 @@
+package oak
+
+import (
+	"image/draw"
+	"sync"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/alg/intgeom"
+	"github.com/diakovliev/oak/v4/dlog"
+	"github.com/diakovliev/oak/v4/mouse"
+	"github.com/diakovliev/oak/v4/render"
+)
+
+// A CursorImage is a renderable drawn in place of the OS cursor. The hotspot
+// is the point within the renderable, relative to its own position, which
+// lies under the mouse.
+type CursorImage struct {
+	render.Renderable
+	Hotspot intgeom.Point2
+}
+
+// A Cursor is drawn by the engine in place of the OS cursor. Hover is drawn
+// while the mouse is over an entity in the mouse collision tree, and Press
+// while a mouse button is held. Either may be left empty to fall back to
+// Default.
+type Cursor struct {
+	Default CursorImage
+	Hover   CursorImage
+	Press   CursorImage
+}
+
+// cursorState tracks the custom cursor and where to draw it. It is written to
+// by input and read while drawing.
+type cursorState struct {
+	sync.Mutex
+	cursor  *Cursor
+	pos     floatgeom.Point2
+	seen    bool
+	pressed bool
+	hovered bool
+}
+
+// SetCustomCursor makes the window hide the OS cursor, if the platform supports
+// doing so, and draw c above the draw stack at the mouse's position. Passing
+// nil stops the custom cursor from being drawn; it does not restore the OS
+// cursor.
+func (w *Window) SetCustomCursor(c *Cursor) {
+	w.cursor.Lock()
+	w.cursor.cursor = c
+	w.cursor.Unlock()
+	// If the OS window has not been created, its cursor will be hidden
+	// when it is.
+	if c != nil && w.Window != nil {
+		w.hideOSCursor()
+	}
+}
+
+func (w *Window) hideOSCursor() {
+	if hc, ok := interface{}(w.Window).(interface{ HideCursor() error }); ok {
+		dlog.ErrorCheck(hc.HideCursor())
+	}
+}
+
+func (w *Window) trackCursor(me mouse.Event) {
+	w.cursor.Lock()
+	w.cursor.pos = me.Point2
+	w.cursor.seen = true
+	w.hoverMutex.Lock()
+	w.cursor.hovered = len(w.hovered) != 0
+	w.hoverMutex.Unlock()
+	switch me.EventType {
+	case mouse.Press:
+		w.cursor.pressed = true
+	case mouse.Release:
+		w.cursor.pressed = false
+	}
+	w.cursor.Unlock()
+}
+
+func (w *Window) drawCursor(buff draw.Image) {
+	w.cursor.Lock()
+	defer w.cursor.Unlock()
+	c := w.cursor.cursor
+	if c == nil || !w.cursor.seen {
+		return
+	}
+	img := c.Default
+	if w.cursor.pressed && c.Press.Renderable != nil {
+		img = c.Press
+	} else if !w.cursor.pressed && w.cursor.hovered && c.Hover.Renderable != nil {
+		img = c.Hover
+	}
+	if img.Renderable == nil {
+		return
+	}
+	img.Draw(buff,
+		w.cursor.pos.X()-float64(img.Hotspot.X()),
+		w.cursor.pos.Y()-float64(img.Hotspot.Y()),
+	)
+}
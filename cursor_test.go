@@ -0,0 +1,55 @@
+package oak
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/alg/intgeom"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/mouse"
+	"github.com/diakovliev/oak/v4/render"
+)
+
+func TestWindow_CustomCursor(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	green := color.RGBA{0, 255, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	c1 := NewWindow()
+	c1.SetCustomCursor(&Cursor{
+		Default: CursorImage{Renderable: render.NewColorBox(2, 2, red), Hotspot: intgeom.Point2{1, 1}},
+		Hover:   CursorImage{Renderable: render.NewColorBox(2, 2, green)},
+		Press:   CursorImage{Renderable: render.NewColorBox(2, 2, blue)},
+	})
+	draw := func() *image.RGBA {
+		buff := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		c1.drawCursor(buff)
+		return buff
+	}
+	if got := draw().RGBAAt(0, 0); got != (color.RGBA{}) {
+		t.Fatalf("expected no cursor before mouse input, got %v", got)
+	}
+	c1.trackCursor(mouse.NewEvent(5, 5, mouse.ButtonNone, mouse.Drag))
+	buff := draw()
+	if buff.RGBAAt(4, 4) != red || buff.RGBAAt(6, 6) == red {
+		t.Fatalf("expected default cursor drawn around its hotspot")
+	}
+	c1.hovered = []event.CallerID{1}
+	c1.trackCursor(mouse.NewEvent(5, 5, mouse.ButtonNone, mouse.Drag))
+	if got := draw().RGBAAt(5, 5); got != green {
+		t.Fatalf("expected hover cursor, got %v", got)
+	}
+	c1.trackCursor(mouse.NewEvent(5, 5, mouse.ButtonLeft, mouse.Press))
+	if got := draw().RGBAAt(5, 5); got != blue {
+		t.Fatalf("expected press cursor, got %v", got)
+	}
+	c1.hovered = nil
+	c1.trackCursor(mouse.NewEvent(5, 5, mouse.ButtonLeft, mouse.Release))
+	if got := draw().RGBAAt(4, 4); got != red {
+		t.Fatalf("expected default cursor after release, got %v", got)
+	}
+	c1.SetCustomCursor(nil)
+	if got := draw().RGBAAt(4, 4); got != (color.RGBA{}) {
+		t.Fatalf("expected no cursor after unsetting it, got %v", got)
+	}
+}
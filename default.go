@@ -97,6 +97,12 @@ func SetColorBackground(img image.Image) {
 	defaultWindow.SetColorBackground(img)
 }
 
+// SetCustomCursor calls SetCustomCursor on the default window.
+func SetCustomCursor(c *Cursor) {
+	initDefaultWindow()
+	defaultWindow.SetCustomCursor(c)
+}
+
 // Bounds returns the default window's boundary.
 func Bounds() intgeom.Point2 {
 	initDefaultWindow()
@@ -32,6 +32,7 @@ func (w *Window) drawLoop() {
 			w.DrawStack.PreDraw()
 			p := w.viewPos
 			w.DrawStack.DrawToScreen(buff.RGBA(), &p, w.ScreenWidth, w.ScreenHeight)
+			w.drawCursor(buff.RGBA())
 		}
 	}
 
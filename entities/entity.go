@@ -195,6 +195,20 @@ func (e *Entity) ShiftPos(x, y float64) {
 	e.Shift(floatgeom.Point2{x, y})
 }
 
+// SetDims sets the width and height of this entity and its collision space.
+// If the entity's renderable is render.Resizable, it is resized to match.
+func (e *Entity) SetDims(w, h float64) {
+	e.Rect = floatgeom.NewRect2WH(e.X(), e.Y(), w, h)
+	if rs, ok := e.Renderable.(render.Resizable); ok {
+		rs.Resize(int(w), int(h))
+	}
+	if e.Tree != nil {
+		e.Tree.UpdateSpace(
+			e.X(), e.Y(), e.W(), e.H(), e.Space,
+		)
+	}
+}
+
 func (e *Entity) HitLabel(label collision.Label) *collision.Space {
 	return e.Tree.HitLabel(e.Space, label)
 }
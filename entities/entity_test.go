@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/collision"
+	"github.com/diakovliev/oak/v4/internal/scenetest"
+	"github.com/diakovliev/oak/v4/render"
+)
+
+func TestEntity_SetDims(t *testing.T) {
+	ctx := scenetest.NewContext()
+	src := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	for x := 0; x < 3; x++ {
+		for y := 0; y < 3; y++ {
+			src.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+		}
+	}
+	ns := render.NewNineSlice(src, render.Insets{Left: 1, Top: 1, Right: 1, Bottom: 1}, 10, 10)
+	e := New(ctx,
+		WithRect(floatgeom.NewRect2WH(5, 5, 10, 10)),
+		WithRenderable(ns),
+		WithDrawLayers(nil),
+	)
+	e.SetDims(30, 20)
+	if e.W() != 30 || e.H() != 20 {
+		t.Fatalf("expected dims 30,20, got %v,%v", e.W(), e.H())
+	}
+	if e.X() != 5 || e.Y() != 5 {
+		t.Fatalf("expected position to be kept, got %v,%v", e.X(), e.Y())
+	}
+	if w, h := ns.GetDims(); w != 30 || h != 20 {
+		t.Fatalf("expected resizable renderable to be resized to 30,20, got %v,%v", w, h)
+	}
+	if hits := ctx.CollisionTree.Hits(collision.NewUnassignedSpace(30, 20, 1, 1)); len(hits) != 1 || hits[0] != e.Space {
+		t.Fatalf("expected collision space to be resized, got hits %v", hits)
+	}
+
+	// Renderables which cannot be resized are left alone
+	box := render.NewColorBox(10, 10, color.RGBA{0, 0, 255, 255})
+	e2 := New(ctx,
+		WithRect(floatgeom.NewRect2WH(0, 0, 10, 10)),
+		WithRenderable(box),
+		WithDrawLayers(nil),
+	)
+	e2.SetDims(20, 20)
+	if w, h := box.GetDims(); w != 10 || h != 10 {
+		t.Fatalf("expected color box to keep its dims, got %v,%v", w, h)
+	}
+	if e2.W() != 20 || e2.H() != 20 {
+		t.Fatalf("expected dims 20,20, got %v,%v", e2.W(), e2.H())
+	}
+}
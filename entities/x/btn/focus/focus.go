@@ -0,0 +1,468 @@
+// Package focus provides keyboard and gamepad navigation between buttons,
+// entities, and other focusable elements such as ui widgets.
+package focus
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"sync"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/entities"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/joystick"
+	"github.com/diakovliev/oak/v4/key"
+	"github.com/diakovliev/oak/v4/mouse"
+	"github.com/diakovliev/oak/v4/render"
+	"github.com/diakovliev/oak/v4/scene"
+)
+
+// Events triggered on focusable entities
+var (
+	// Gain is triggered when an entity becomes focused.
+	Gain = event.RegisterEvent[struct{}]()
+	// Loss is triggered when an entity stops being focused.
+	Loss = event.RegisterEvent[struct{}]()
+	// Activate is triggered when the focused entity is activated. A
+	// mouse.ClickOn event at the entity's center is triggered alongside it,
+	// so buttons with btn.Click bindings respond as if clicked. The two
+	// events are not ordered with respect to each other.
+	Activate = event.RegisterEvent[struct{}]()
+)
+
+// A Focusable is something focus can move to.
+type Focusable interface {
+	// FocusRect returns the focusable's area, used to find its neighbors
+	// in Spatial mode.
+	FocusRect() floatgeom.Rect2
+}
+
+// An Activator is a Focusable which responds to being activated.
+type Activator interface {
+	Activate()
+}
+
+// An Adjuster is a Focusable which uses horizontal input while focused,
+// such as a slider.
+type Adjuster interface {
+	// Adjust is called with -1 or 1 when left or right is pressed while
+	// focused. It returns false if the focusable does not use horizontal
+	// input, in which case focus moves instead.
+	Adjust(dir int) bool
+}
+
+// An Entity is a focusable entity. Focused entities are highlighted, and
+// Gain, Loss and Activate are triggered on them.
+type Entity struct {
+	*entities.Entity
+}
+
+// FocusRect returns the entity's rectangle.
+func (e Entity) FocusRect() floatgeom.Rect2 {
+	return e.Rect
+}
+
+// A Direction is a way focus can move.
+type Direction uint8
+
+// Direction values
+const (
+	Up Direction = iota
+	Down
+	Left
+	Right
+	Next
+	Prev
+)
+
+func (d Direction) vector() floatgeom.Point2 {
+	switch d {
+	case Up:
+		return floatgeom.Point2{0, -1}
+	case Down:
+		return floatgeom.Point2{0, 1}
+	case Left:
+		return floatgeom.Point2{-1, 0}
+	case Right:
+		return floatgeom.Point2{1, 0}
+	}
+	return floatgeom.Point2{}
+}
+
+// A Mode determines how directional input chooses the next focused focusable.
+type Mode uint8
+
+// Mode values
+const (
+	// Spatial moves focus to the nearest focusable in the direction pressed.
+	Spatial Mode = iota
+	// Ordered moves focus through focusables in the order they were added.
+	// Up and left move to the previous focusable, down and right to the next.
+	Ordered
+)
+
+// A Manager tracks which of a set of focusables is focused, moving focus and
+// activating the focused focusable in response to key and joystick input.
+type Manager struct {
+	ctx *scene.Context
+
+	mutex      sync.Mutex
+	focusables []Focusable
+	focused    int
+	// restore holds the focused entity's image from before it was
+	// highlighted.
+	restore  *image.RGBA
+	gen      Generator
+	bindings []event.Binding
+	// lastButtons holds each joystick's buttons from its last state, so
+	// held buttons are not treated as new presses.
+	lastButtons map[uint32]map[string]bool
+}
+
+// Generate creates a Manager from a generator, binding it to input on ctx.
+func (g Generator) Generate(ctx *scene.Context) *Manager {
+	m := &Manager{
+		ctx:         ctx,
+		focused:     -1,
+		gen:         g,
+		lastButtons: make(map[uint32]map[string]bool),
+	}
+	m.focusables = append(m.focusables, g.Focusables...)
+	m.bindings = append(m.bindings,
+		event.GlobalBind(ctx, key.AnyDown, func(ev key.Event) event.Response {
+			m.keyInput(ev)
+			return 0
+		}),
+		event.GlobalBind(ctx, key.AnyHeld, func(ev key.Event) event.Response {
+			// Holding a direction repeats it; holding activation does not
+			if d, ok := m.keyDirection(ev); ok {
+				m.navigate(d)
+			}
+			return 0
+		}),
+		event.GlobalBind(ctx, joystick.ButtonDown, func(st *joystick.State) event.Response {
+			m.joystickInput(st)
+			return 0
+		}),
+		event.GlobalBind(ctx, joystick.ButtonUp, func(st *joystick.State) event.Response {
+			m.joystickInput(st)
+			return 0
+		}),
+	)
+	if g.Start != nil {
+		m.Focus(g.Start)
+	}
+	return m
+}
+
+// An Option is used to populate generator fields prior to generation of a
+// manager
+type Option func(Generator) Generator
+
+// New creates a focus manager with the given options and defaults for all
+// variables not set.
+func New(ctx *scene.Context, opts ...Option) *Manager {
+	g := defGenerator()
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		g = opt(g)
+	}
+	return g.Generate(ctx)
+}
+
+// Add adds focusables to those focus can move between.
+func (m *Manager) Add(fs ...Focusable) {
+	m.mutex.Lock()
+	m.focusables = append(m.focusables, fs...)
+	m.mutex.Unlock()
+}
+
+// Remove removes a focusable from those focus can move between. If it was
+// focused, nothing will be focused.
+func (m *Manager) Remove(f Focusable) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	i := m.index(f)
+	if i < 0 {
+		return
+	}
+	if i == m.focused {
+		m.setFocus(-1)
+	} else if i < m.focused {
+		m.focused--
+	}
+	m.focusables = append(m.focusables[:i], m.focusables[i+1:]...)
+}
+
+// Focused returns the focused focusable, or nil if nothing is focused.
+func (m *Manager) Focused() Focusable {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.focused < 0 {
+		return nil
+	}
+	return m.focusables[m.focused]
+}
+
+// Focus focuses a focusable of this manager. Passing a focusable the
+// manager does not track removes focus.
+func (m *Manager) Focus(f Focusable) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.setFocus(m.index(f))
+}
+
+// Blur removes focus from the focused focusable.
+func (m *Manager) Blur() {
+	m.mutex.Lock()
+	m.setFocus(-1)
+	m.mutex.Unlock()
+}
+
+func (m *Manager) index(f Focusable) int {
+	for i, f2 := range m.focusables {
+		if f2 == f {
+			return i
+		}
+	}
+	return -1
+}
+
+// Move moves focus in a direction. If nothing is focused, the first
+// focusable is focused, or the last if moving backwards in Ordered mode.
+func (m *Manager) Move(d Direction) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	n := len(m.focusables)
+	if n == 0 {
+		return
+	}
+	ordered := m.gen.Mode == Ordered || d == Next || d == Prev
+	backwards := d == Up || d == Left || d == Prev
+	if m.focused < 0 {
+		if ordered && backwards {
+			m.setFocus(n - 1)
+		} else {
+			m.setFocus(0)
+		}
+		return
+	}
+	if ordered {
+		step := 1
+		if backwards {
+			step = -1
+		}
+		next := m.focused + step
+		if m.gen.Wrap {
+			next = (next + n) % n
+		}
+		if next >= 0 && next < n {
+			m.setFocus(next)
+		}
+		return
+	}
+	if next := m.nearest(d.vector()); next >= 0 {
+		m.setFocus(next)
+	}
+}
+
+// Adjust passes horizontal input, -1 or 1, to the focused focusable if it
+// is an Adjuster which uses it. Otherwise focus moves left or right.
+func (m *Manager) Adjust(dir int) {
+	if a, ok := m.Focused().(Adjuster); ok && a.Adjust(dir) {
+		return
+	}
+	if dir < 0 {
+		m.Move(Left)
+	} else {
+		m.Move(Right)
+	}
+}
+
+// nearest returns the index of the focusable closest to the focused one in
+// direction dir, weighting distance across dir more heavily than distance
+// along it, or -1 if no focusable lies in that direction.
+func (m *Manager) nearest(dir floatgeom.Point2) int {
+	from := m.focusables[m.focused].FocusRect().Center()
+	best, bestScore := -1, math.Inf(1)
+	for i, f := range m.focusables {
+		if i == m.focused {
+			continue
+		}
+		delta := f.FocusRect().Center().Sub(from)
+		along := delta.Dot(dir)
+		if along <= 0 {
+			continue
+		}
+		across := math.Abs(delta.Dot(floatgeom.Point2{dir.Y(), dir.X()}))
+		if score := along + 2*across; score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// setFocus must be called with the manager's mutex held.
+func (m *Manager) setFocus(i int) {
+	if i == m.focused {
+		return
+	}
+	if m.focused >= 0 {
+		if prev, ok := m.focusables[m.focused].(Entity); ok {
+			m.unhighlight(prev.Entity)
+			event.TriggerForCallerOn(m.ctx, prev.CID(), Loss, struct{}{})
+		}
+	}
+	m.focused = i
+	var f Focusable
+	if i >= 0 {
+		f = m.focusables[i]
+		if e, ok := f.(Entity); ok {
+			m.highlight(e.Entity)
+			event.TriggerForCallerOn(m.ctx, e.CID(), Gain, struct{}{})
+		}
+	}
+	if m.gen.OnChange != nil {
+		m.gen.OnChange(f)
+	}
+}
+
+func (m *Manager) highlight(e *entities.Entity) {
+	if m.gen.Highlight == nil {
+		return
+	}
+	switch r := e.Renderable.(type) {
+	case *render.Reverting:
+		r.Filter(m.gen.Highlight)
+	case render.Modifiable:
+		rgba := r.GetRGBA()
+		if rgba == nil {
+			return
+		}
+		m.restore = image.NewRGBA(rgba.Bounds())
+		copy(m.restore.Pix, rgba.Pix)
+		r.Filter(m.gen.Highlight)
+	}
+}
+
+func (m *Manager) unhighlight(e *entities.Entity) {
+	if m.gen.Highlight == nil {
+		return
+	}
+	switch r := e.Renderable.(type) {
+	case *render.Reverting:
+		r.Revert(1)
+	case render.Modifiable:
+		if m.restore == nil {
+			return
+		}
+		if rgba := r.GetRGBA(); rgba != nil && rgba.Bounds() == m.restore.Bounds() {
+			draw.Draw(rgba, rgba.Bounds(), m.restore, m.restore.Bounds().Min, draw.Src)
+		}
+		m.restore = nil
+	}
+}
+
+// Activate activates the focused focusable. Activators have their Activate
+// method called, and entities have Activate and mouse.ClickOn triggered on
+// them.
+func (m *Manager) Activate() {
+	f := m.Focused()
+	if a, ok := f.(Activator); ok {
+		a.Activate()
+	}
+	e, ok := f.(Entity)
+	if !ok {
+		return
+	}
+	event.TriggerForCallerOn(m.ctx, e.CID(), Activate, struct{}{})
+	c := e.Rect.Center()
+	me := mouse.NewEvent(c.X(), c.Y(), mouse.ButtonLeft, mouse.ClickOn)
+	event.TriggerForCallerOn(m.ctx, e.CID(), mouse.ClickOn, &me)
+}
+
+// Destroy removes focus and stops responding to input.
+func (m *Manager) Destroy() {
+	m.Blur()
+	m.mutex.Lock()
+	bindings := m.bindings
+	m.bindings = nil
+	m.mutex.Unlock()
+	for _, b := range bindings {
+		b.Unbind()
+	}
+}
+
+func (m *Manager) keyDirection(ev key.Event) (Direction, bool) {
+	if ev.Code == key.Tab {
+		if ev.Modifiers&key.ModShift != 0 {
+			return Prev, true
+		}
+		return Next, true
+	}
+	for d, codes := range m.gen.Keys {
+		for _, c := range codes {
+			if c == ev.Code {
+				return d, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// navigate responds to directional input.
+func (m *Manager) navigate(d Direction) {
+	switch d {
+	case Left:
+		m.Adjust(-1)
+	case Right:
+		m.Adjust(1)
+	default:
+		m.Move(d)
+	}
+	if m.gen.OnNavigate != nil {
+		m.gen.OnNavigate()
+	}
+}
+
+func (m *Manager) keyInput(ev key.Event) {
+	if d, ok := m.keyDirection(ev); ok {
+		m.navigate(d)
+		return
+	}
+	for _, c := range m.gen.ActivateKeys {
+		if c == ev.Code {
+			m.Activate()
+			return
+		}
+	}
+}
+
+// joystickInput responds to buttons newly pressed since the joystick's last
+// state.
+func (m *Manager) joystickInput(st *joystick.State) {
+	m.mutex.Lock()
+	last := m.lastButtons[st.ID]
+	m.lastButtons[st.ID] = st.Buttons
+	m.mutex.Unlock()
+	for b, down := range st.Buttons {
+		if !down || last[b] {
+			continue
+		}
+		for d, inputs := range m.gen.Buttons {
+			for _, in := range inputs {
+				if string(in) == b {
+					m.navigate(d)
+				}
+			}
+		}
+		for _, in := range m.gen.ActivateButtons {
+			if string(in) == b {
+				m.Activate()
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package focus
+
+import (
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/entities"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/internal/scenetest"
+	"github.com/diakovliev/oak/v4/key"
+	"github.com/diakovliev/oak/v4/mouse"
+	"github.com/diakovliev/oak/v4/render"
+	"github.com/diakovliev/oak/v4/scene"
+)
+
+// grid creates a 2x2 grid of entities, in reading order.
+func grid(ctx *scene.Context) []*entities.Entity {
+	es := make([]*entities.Entity, 4)
+	for i := range es {
+		x, y := float64(i%2)*20, float64(i/2)*20
+		es[i] = entities.New(ctx,
+			entities.WithRect(floatgeom.NewRect2WH(x, y, 10, 10)),
+			entities.WithRenderable(render.NewColorBox(10, 10, color.RGBA{100, 100, 100, 255})),
+			entities.WithDrawLayers(nil),
+		)
+	}
+	return es
+}
+
+func TestManager_Spatial(t *testing.T) {
+	ctx := scenetest.NewContext()
+	es := grid(ctx)
+	m := New(ctx, Entities(es...), Start(Entity{es[0]}))
+	defer m.Destroy()
+	moves := []struct {
+		d        Direction
+		expected int
+	}{
+		{Right, 1},
+		{Right, 1},
+		{Down, 3},
+		{Left, 2},
+		{Up, 0},
+		{Next, 1},
+		{Prev, 0},
+	}
+	for _, mv := range moves {
+		m.Move(mv.d)
+		if m.Focused() != (Entity{es[mv.expected]}) {
+			t.Fatalf("moving %v: expected entity %v to be focused", mv.d, mv.expected)
+		}
+	}
+}
+
+func TestManager_Ordered(t *testing.T) {
+	ctx := scenetest.NewContext()
+	es := grid(ctx)
+	m := New(ctx, Entities(es...), WithMode(Ordered), Wrap(true))
+	defer m.Destroy()
+	m.Move(Down)
+	if m.Focused() != (Entity{es[0]}) {
+		t.Fatalf("expected first move to focus the first entity")
+	}
+	m.Move(Left)
+	if m.Focused() != (Entity{es[3]}) {
+		t.Fatalf("expected wrapping to the last entity")
+	}
+	m.Move(Right)
+	if m.Focused() != (Entity{es[0]}) {
+		t.Fatalf("expected wrapping to the first entity")
+	}
+	m.Remove(Entity{es[0]})
+	if m.Focused() != nil {
+		t.Fatalf("expected removing the focused entity to clear focus")
+	}
+}
+
+func TestManager_Highlight(t *testing.T) {
+	ctx := scenetest.NewContext()
+	es := grid(ctx)
+	m := New(ctx, Entities(es...))
+	defer m.Destroy()
+	sp := es[0].Renderable.(*render.Sprite)
+	before := sp.GetRGBA().RGBAAt(0, 0)
+	m.Focus(Entity{es[0]})
+	if sp.GetRGBA().RGBAAt(0, 0) == before {
+		t.Fatalf("expected focused entity to be highlighted")
+	}
+	m.Focus(Entity{es[1]})
+	if got := sp.GetRGBA().RGBAAt(0, 0); got != before {
+		t.Fatalf("expected highlight to be removed, got %v", got)
+	}
+	rv := render.NewReverting(render.NewColorBox(10, 10, color.RGBA{100, 100, 100, 255}))
+	es[2].Renderable = rv
+	m.Focus(Entity{es[2]})
+	m.Blur()
+	if got := rv.GetRGBA().RGBAAt(0, 0); got != before {
+		t.Fatalf("expected reverting highlight to be reverted, got %v", got)
+	}
+}
+
+func TestManager_Events(t *testing.T) {
+	ctx := scenetest.NewContext()
+	es := grid(ctx)
+	gained, lost, activated, clicked := make(chan struct{}, 1), make(chan struct{}, 1), make(chan struct{}, 1), make(chan struct{}, 1)
+	<-event.Bind(ctx, Gain, es[0], func(*entities.Entity, struct{}) event.Response {
+		gained <- struct{}{}
+		return 0
+	}).Bound
+	<-event.Bind(ctx, Loss, es[0], func(*entities.Entity, struct{}) event.Response {
+		lost <- struct{}{}
+		return 0
+	}).Bound
+	<-event.Bind(ctx, Activate, es[1], func(*entities.Entity, struct{}) event.Response {
+		activated <- struct{}{}
+		return 0
+	}).Bound
+	<-event.Bind(ctx, mouse.ClickOn, es[1], func(*entities.Entity, *mouse.Event) event.Response {
+		clicked <- struct{}{}
+		return 0
+	}).Bound
+	m := New(ctx, Entities(es...))
+	defer m.Destroy()
+	time.Sleep(200 * time.Millisecond)
+
+	expect := func(ch chan struct{}, name string) {
+		t.Helper()
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			t.Fatalf("expected %v event", name)
+		}
+	}
+	m.Focus(Entity{es[0]})
+	expect(gained, "gain")
+	event.TriggerOn(ctx, key.AnyDown, key.Event{Code: key.RightArrow})
+	expect(lost, "loss")
+	event.TriggerOn(ctx, key.AnyDown, key.Event{Code: key.ReturnEnter})
+	expect(activated, "activate")
+	expect(clicked, "click")
+}
+
+type testFocusable struct {
+	rect      floatgeom.Rect2
+	activated int
+	adjusted  int
+	adjusts   bool
+}
+
+func (tf *testFocusable) FocusRect() floatgeom.Rect2 { return tf.rect }
+func (tf *testFocusable) Activate()                  { tf.activated++ }
+
+func (tf *testFocusable) Adjust(dir int) bool {
+	if tf.adjusts {
+		tf.adjusted += dir
+	}
+	return tf.adjusts
+}
+
+func TestManager_Focusables(t *testing.T) {
+	ctx := scenetest.NewContext()
+	slider := &testFocusable{rect: floatgeom.NewRect2WH(0, 0, 10, 10), adjusts: true}
+	button := &testFocusable{rect: floatgeom.NewRect2WH(0, 20, 10, 10)}
+	var changes []Focusable
+	navigated := make(chan struct{}, 10)
+	m := New(ctx, Focusables(slider, button), WithMode(Ordered),
+		OnChange(func(f Focusable) { changes = append(changes, f) }),
+		OnNavigate(func() { navigated <- struct{}{} }),
+	)
+	defer m.Destroy()
+	m.Move(Up)
+	if m.Focused() != button {
+		t.Fatalf("expected moving backwards from nothing to focus the last focusable")
+	}
+	m.Activate()
+	if button.activated != 1 {
+		t.Fatalf("expected activation to activate focused focusable")
+	}
+	m.Adjust(-1)
+	if m.Focused() != slider {
+		t.Fatalf("expected unused adjustment to move focus")
+	}
+	m.Adjust(1)
+	if m.Focused() != slider || slider.adjusted != 1 {
+		t.Fatalf("expected adjustment to be used by slider, got %v", slider.adjusted)
+	}
+	if len(changes) != 2 || changes[0] != button || changes[1] != slider {
+		t.Fatalf("expected focus changes to be reported, got %v", changes)
+	}
+	time.Sleep(200 * time.Millisecond)
+	event.TriggerOn(ctx, key.AnyDown, key.Event{Code: key.DownArrow})
+	select {
+	case <-navigated:
+	case <-time.After(time.Second):
+		t.Fatalf("expected navigation to be reported")
+	}
+	if m.Focused() != button {
+		t.Fatalf("expected down to focus the next focusable")
+	}
+}
@@ -0,0 +1,178 @@
+package focus
+
+import (
+	"github.com/diakovliev/oak/v4/entities"
+	"github.com/diakovliev/oak/v4/joystick"
+	"github.com/diakovliev/oak/v4/key"
+	"github.com/diakovliev/oak/v4/render/mod"
+)
+
+// A Generator defines the variables used to create a focus manager.
+type Generator struct {
+	Focusables []Focusable
+	// Start, if set, is focused when the manager is generated.
+	Start Focusable
+	Mode  Mode
+	// Wrap causes ordered navigation to wrap from the last focusable to the
+	// first, and vice versa.
+	Wrap bool
+	// Highlight is applied to the focused entity's renderable, and undone
+	// when it loses focus. It has no effect on renderables that are not
+	// render.Modifiable.
+	Highlight       mod.Filter
+	Keys            map[Direction][]key.Code
+	ActivateKeys    []key.Code
+	Buttons         map[Direction][]joystick.Input
+	ActivateButtons []joystick.Input
+	// OnChange, if set, is called with the newly focused focusable, or nil,
+	// whenever focus changes. It is called with the manager locked, so it
+	// must not call the manager's methods.
+	OnChange func(Focusable)
+	// OnNavigate, if set, is called after key or joystick input moves focus
+	// or adjusts the focused focusable.
+	OnNavigate func()
+}
+
+func defGenerator() Generator {
+	return Generator{
+		Mode:      Spatial,
+		Highlight: mod.Brighten(30),
+		Keys: map[Direction][]key.Code{
+			Up:    {key.UpArrow},
+			Down:  {key.DownArrow},
+			Left:  {key.LeftArrow},
+			Right: {key.RightArrow},
+		},
+		ActivateKeys: []key.Code{key.ReturnEnter, key.KeypadEnter, key.Spacebar},
+		Buttons: map[Direction][]joystick.Input{
+			Up:    {joystick.InputUp},
+			Down:  {joystick.InputDown},
+			Left:  {joystick.InputLeft},
+			Right: {joystick.InputRight},
+		},
+		ActivateButtons: []joystick.Input{joystick.InputA},
+	}
+}
+
+// And combines a variadic number of options
+func And(opts ...Option) Option {
+	return func(g Generator) Generator {
+		for _, opt := range opts {
+			g = opt(g)
+		}
+		return g
+	}
+}
+
+// Entities adds entities for the manager to move focus between.
+func Entities(es ...*entities.Entity) Option {
+	return func(g Generator) Generator {
+		for _, e := range es {
+			g.Focusables = append(g.Focusables, Entity{e})
+		}
+		return g
+	}
+}
+
+// Focusables adds focusables for the manager to move focus between.
+func Focusables(fs ...Focusable) Option {
+	return func(g Generator) Generator {
+		g.Focusables = append(g.Focusables, fs...)
+		return g
+	}
+}
+
+// Start sets the focusable focused when the manager is created.
+func Start(f Focusable) Option {
+	return func(g Generator) Generator {
+		g.Start = f
+		return g
+	}
+}
+
+// WithMode sets how directional input moves focus.
+func WithMode(m Mode) Option {
+	return func(g Generator) Generator {
+		g.Mode = m
+		return g
+	}
+}
+
+// Wrap sets whether ordered navigation wraps around at either end.
+func Wrap(wrap bool) Option {
+	return func(g Generator) Generator {
+		g.Wrap = wrap
+		return g
+	}
+}
+
+// Highlight sets the filter applied to the focused entity. A nil filter
+// disables highlighting.
+func Highlight(f mod.Filter) Option {
+	return func(g Generator) Generator {
+		g.Highlight = f
+		return g
+	}
+}
+
+// Keys sets the keys which move focus in a direction, replacing the
+// defaults for that direction.
+func Keys(d Direction, codes ...key.Code) Option {
+	return func(g Generator) Generator {
+		keys := make(map[Direction][]key.Code, len(g.Keys)+1)
+		for d2, cs := range g.Keys {
+			keys[d2] = cs
+		}
+		keys[d] = codes
+		g.Keys = keys
+		return g
+	}
+}
+
+// ActivateKeys sets the keys which activate the focused focusable.
+func ActivateKeys(codes ...key.Code) Option {
+	return func(g Generator) Generator {
+		g.ActivateKeys = codes
+		return g
+	}
+}
+
+// Buttons sets the joystick buttons which move focus in a direction,
+// replacing the defaults for that direction.
+func Buttons(d Direction, inputs ...joystick.Input) Option {
+	return func(g Generator) Generator {
+		buttons := make(map[Direction][]joystick.Input, len(g.Buttons)+1)
+		for d2, in := range g.Buttons {
+			buttons[d2] = in
+		}
+		buttons[d] = inputs
+		g.Buttons = buttons
+		return g
+	}
+}
+
+// ActivateButtons sets the joystick buttons which activate the focused
+// focusable.
+func ActivateButtons(inputs ...joystick.Input) Option {
+	return func(g Generator) Generator {
+		g.ActivateButtons = inputs
+		return g
+	}
+}
+
+// OnChange sets a function called whenever focus changes.
+func OnChange(fn func(Focusable)) Option {
+	return func(g Generator) Generator {
+		g.OnChange = fn
+		return g
+	}
+}
+
+// OnNavigate sets a function called whenever input moves focus or adjusts
+// the focused focusable.
+func OnNavigate(fn func()) Option {
+	return func(g Generator) Generator {
+		g.OnNavigate = fn
+		return g
+	}
+}
@@ -0,0 +1,190 @@
+package textfield
+
+import (
+	"strings"
+	"unicode"
+)
+
+// editor holds the text, caret and selection of a field, independent of how
+// the field is drawn or receives input.
+type editor struct {
+	text []rune
+	// caret is the index of the rune the caret is before.
+	caret int
+	// anchor is the other end of the selection from caret. There is no
+	// selection when anchor == caret.
+	anchor int
+
+	maxLength int
+	validate  func(string) bool
+}
+
+func (ed *editor) String() string {
+	return string(ed.text)
+}
+
+// selection returns the start and end of the selected runes.
+func (ed *editor) selection() (start, end int) {
+	if ed.anchor < ed.caret {
+		return ed.anchor, ed.caret
+	}
+	return ed.caret, ed.anchor
+}
+
+func (ed *editor) hasSelection() bool {
+	return ed.anchor != ed.caret
+}
+
+func (ed *editor) selected() string {
+	start, end := ed.selection()
+	return string(ed.text[start:end])
+}
+
+// setText replaces all text, ignoring validation, and moves the caret to the end.
+func (ed *editor) setText(s string) {
+	ed.text = []rune(sanitize(s))
+	if ed.maxLength > 0 && len(ed.text) > ed.maxLength {
+		ed.text = ed.text[:ed.maxLength]
+	}
+	ed.caret = len(ed.text)
+	ed.anchor = ed.caret
+}
+
+// moveTo moves the caret to i, extending the selection if extend is set and
+// clearing it otherwise.
+func (ed *editor) moveTo(i int, extend bool) {
+	if i < 0 {
+		i = 0
+	}
+	if i > len(ed.text) {
+		i = len(ed.text)
+	}
+	ed.caret = i
+	if !extend {
+		ed.anchor = i
+	}
+}
+
+// left moves the caret one rune left, or to the start of the selection.
+func (ed *editor) left(extend bool) {
+	if ed.hasSelection() && !extend {
+		start, _ := ed.selection()
+		ed.moveTo(start, false)
+		return
+	}
+	ed.moveTo(ed.caret-1, extend)
+}
+
+// right moves the caret one rune right, or to the end of the selection.
+func (ed *editor) right(extend bool) {
+	if ed.hasSelection() && !extend {
+		_, end := ed.selection()
+		ed.moveTo(end, false)
+		return
+	}
+	ed.moveTo(ed.caret+1, extend)
+}
+
+// wordLeft returns the index of the start of the word before the caret.
+func (ed *editor) wordLeft() int {
+	i := ed.caret
+	for i > 0 && unicode.IsSpace(ed.text[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(ed.text[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordRight returns the index of the end of the word after the caret.
+func (ed *editor) wordRight() int {
+	i := ed.caret
+	for i < len(ed.text) && unicode.IsSpace(ed.text[i]) {
+		i++
+	}
+	for i < len(ed.text) && !unicode.IsSpace(ed.text[i]) {
+		i++
+	}
+	return i
+}
+
+func (ed *editor) selectAll() {
+	ed.anchor = 0
+	ed.caret = len(ed.text)
+}
+
+// insert replaces the selection with s. Characters past the max length are
+// dropped. It returns false, leaving the text unchanged, if the result fails
+// validation or nothing would change.
+func (ed *editor) insert(s string) bool {
+	ins := []rune(sanitize(s))
+	start, end := ed.selection()
+	if ed.maxLength > 0 {
+		room := ed.maxLength - (len(ed.text) - (end - start))
+		if room < 0 {
+			room = 0
+		}
+		if len(ins) > room {
+			ins = ins[:room]
+		}
+	}
+	if len(ins) == 0 && start == end {
+		return false
+	}
+	next := make([]rune, 0, len(ed.text)-(end-start)+len(ins))
+	next = append(next, ed.text[:start]...)
+	next = append(next, ins...)
+	next = append(next, ed.text[end:]...)
+	if ed.validate != nil && !ed.validate(string(next)) {
+		return false
+	}
+	ed.text = next
+	ed.moveTo(start+len(ins), false)
+	return true
+}
+
+// deleteBackward deletes the selection, or the rune before the caret.
+func (ed *editor) deleteBackward() bool {
+	if ed.hasSelection() {
+		return ed.insert("")
+	}
+	if ed.caret == 0 {
+		return false
+	}
+	ed.anchor = ed.caret - 1
+	if !ed.insert("") {
+		ed.anchor = ed.caret
+		return false
+	}
+	return true
+}
+
+// deleteForward deletes the selection, or the rune after the caret.
+func (ed *editor) deleteForward() bool {
+	if ed.hasSelection() {
+		return ed.insert("")
+	}
+	if ed.caret == len(ed.text) {
+		return false
+	}
+	ed.anchor = ed.caret + 1
+	if !ed.insert("") {
+		ed.anchor = ed.caret
+		return false
+	}
+	return true
+}
+
+// sanitize removes characters a single line field cannot hold.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return ' '
+		}
+		if !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,76 @@
+package textfield
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEditor_Editing(t *testing.T) {
+	ed := editor{}
+	ed.setText("hello world")
+	ed.moveTo(5, false)
+	ed.insert(",")
+	if ed.String() != "hello, world" || ed.caret != 6 {
+		t.Fatalf("unexpected insert result %q at %v", ed.String(), ed.caret)
+	}
+	ed.deleteBackward()
+	ed.deleteForward()
+	if ed.String() != "helloworld" {
+		t.Fatalf("unexpected delete result %q", ed.String())
+	}
+	ed.moveTo(0, false)
+	ed.moveTo(ed.wordRight(), true)
+	if ed.selected() != "helloworld" {
+		t.Fatalf("expected word to be selected, got %q", ed.selected())
+	}
+	ed.insert("hi\nthere")
+	if ed.String() != "hi there" {
+		t.Fatalf("expected selection to be replaced without newline, got %q", ed.String())
+	}
+	if ed.wordLeft() != 3 {
+		t.Fatalf("expected word to start at 3, got %v", ed.wordLeft())
+	}
+	ed.left(true)
+	ed.left(true)
+	if ed.selected() != "re" {
+		t.Fatalf("expected shift-left selection, got %q", ed.selected())
+	}
+	ed.left(false)
+	if ed.hasSelection() || ed.caret != 6 {
+		t.Fatalf("expected left to collapse selection to its start, got caret %v", ed.caret)
+	}
+	ed.deleteBackward()
+	if ed.String() != "hi thre" {
+		t.Fatalf("unexpected backspace result %q", ed.String())
+	}
+}
+
+func TestEditor_Limits(t *testing.T) {
+	digits := func(s string) bool {
+		return strings.Trim(s, "0123456789") == ""
+	}
+	ed := editor{maxLength: 4, validate: digits}
+	if !ed.insert("123456") || ed.String() != "1234" {
+		t.Fatalf("expected insert to be truncated, got %q", ed.String())
+	}
+	if ed.insert("5") {
+		t.Fatalf("expected insert past max length to fail")
+	}
+	ed.selectAll()
+	if ed.insert("12a") {
+		t.Fatalf("expected invalid insert to fail")
+	}
+	if ed.String() != "1234" || ed.selected() != "1234" {
+		t.Fatalf("expected failed insert to leave text unchanged, got %q", ed.String())
+	}
+
+	ed = editor{validate: func(s string) bool { return len(s) >= 2 }}
+	ed.setText("ab")
+	if ed.deleteBackward() || ed.hasSelection() {
+		t.Fatalf("expected invalid delete to fail without selecting")
+	}
+	ed.moveTo(0, false)
+	if ed.deleteForward() || ed.hasSelection() {
+		t.Fatalf("expected invalid delete to fail without selecting")
+	}
+}
@@ -0,0 +1,149 @@
+package textfield
+
+import (
+	"image/color"
+
+	"github.com/diakovliev/oak/v4/collision"
+	"github.com/diakovliev/oak/v4/render"
+)
+
+// And combines a variadic number of options
+func And(opts ...Option) Option {
+	return func(g Generator) Generator {
+		for _, opt := range opts {
+			g = opt(g)
+		}
+		return g
+	}
+}
+
+// Pos sets the position of the field to be generated
+func Pos(x, y float64) Option {
+	return func(g Generator) Generator {
+		g.X = x
+		g.Y = y
+		return g
+	}
+}
+
+// Width sets the width of the field to be generated
+func Width(w float64) Option {
+	return func(g Generator) Generator {
+		g.W = w
+		return g
+	}
+}
+
+// Height sets the height of the field to be generated
+func Height(h float64) Option {
+	return func(g Generator) Generator {
+		g.H = h
+		return g
+	}
+}
+
+// Padding sets the space between the field's left and right edges and its text
+func Padding(p float64) Option {
+	return func(g Generator) Generator {
+		g.Padding = p
+		return g
+	}
+}
+
+// Font sets the font text in the field is drawn with
+func Font(f *render.Font) Option {
+	return func(g Generator) Generator {
+		g.Font = f
+		return g
+	}
+}
+
+// Text sets the initial text of the field to be generated
+func Text(s string) Option {
+	return func(g Generator) Generator {
+		g.Text = s
+		return g
+	}
+}
+
+// Placeholder sets text drawn while the field is empty and not focused
+func Placeholder(s string) Option {
+	return func(g Generator) Generator {
+		g.Placeholder = s
+		return g
+	}
+}
+
+// MaxLength limits how many characters can be entered into the field. Zero
+// means no limit.
+func MaxLength(n int) Option {
+	return func(g Generator) Generator {
+		g.MaxLength = n
+		return g
+	}
+}
+
+// Validate sets a function each edit of the field is checked with. Edits
+// which would produce text the function returns false for are rejected.
+func Validate(fn func(string) bool) Option {
+	return func(g Generator) Generator {
+		g.Validate = fn
+		return g
+	}
+}
+
+// Color sets the background color of the field while it is not focused
+func Color(c color.Color) Option {
+	return func(g Generator) Generator {
+		g.Color = c
+		return g
+	}
+}
+
+// FocusColor sets the background color of the field while it is focused
+func FocusColor(c color.Color) Option {
+	return func(g Generator) Generator {
+		g.FocusColor = c
+		return g
+	}
+}
+
+// SelectionColor sets the color drawn behind selected text
+func SelectionColor(c color.Color) Option {
+	return func(g Generator) Generator {
+		g.SelectionColor = c
+		return g
+	}
+}
+
+// CaretColor sets the color of the caret
+func CaretColor(c color.Color) Option {
+	return func(g Generator) Generator {
+		g.CaretColor = c
+		return g
+	}
+}
+
+// Layers sets the draw layers of the field to be generated
+func Layers(ls ...int) Option {
+	return func(g Generator) Generator {
+		g.Layers = ls
+		return g
+	}
+}
+
+// Label sets the collision label of the field to be generated
+func Label(l collision.Label) Option {
+	return func(g Generator) Generator {
+		g.Label = l
+		return g
+	}
+}
+
+// Focused causes the field to be generated with focus
+func Focused() Option {
+	return func(g Generator) Generator {
+		g.Focused = true
+		return g
+	}
+}
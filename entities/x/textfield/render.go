@@ -0,0 +1,72 @@
+package textfield
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"github.com/diakovliev/oak/v4/render"
+)
+
+// caretBlink is how long the caret is shown, then hidden, while blinking.
+const caretBlink = 500 * time.Millisecond
+
+// fieldRenderable draws a field's background, text, selection and caret,
+// clipped to the field's bounds.
+type fieldRenderable struct {
+	render.LayeredPoint
+	f    *Field
+	buff *image.RGBA
+}
+
+func (fr *fieldRenderable) GetDims() (int, int) {
+	return int(fr.f.W()), int(fr.f.H())
+}
+
+func (fr *fieldRenderable) Draw(buff draw.Image, xOff, yOff float64) {
+	w, h := fr.GetDims()
+	if w <= 0 || h <= 0 {
+		return
+	}
+	if fr.buff == nil || fr.buff.Bounds().Dx() != w || fr.buff.Bounds().Dy() != h {
+		fr.buff = image.NewRGBA(image.Rect(0, 0, w, h))
+	}
+	f := fr.f
+	f.mutex.Lock()
+	bg := f.colors[0]
+	if f.focused && f.colors[1] != nil {
+		bg = f.colors[1]
+	}
+	if bg == nil {
+		bg = color.RGBA{}
+	}
+	fill(fr.buff, fr.buff.Bounds(), bg, draw.Src)
+
+	textH := f.font.Height()
+	textY := (float64(h) - textH) / 2
+	x0 := f.padding - f.scroll
+	if f.focused && f.ed.hasSelection() && f.colors[2] != nil {
+		start, end := f.ed.selection()
+		fill(fr.buff, image.Rect(
+			int(x0+f.measure(start)), int(textY),
+			int(x0+f.measure(end)), int(textY+textH),
+		), f.colors[2], draw.Over)
+	}
+	if len(f.ed.text) == 0 && !f.focused {
+		f.placeholder.Draw(fr.buff, f.padding, textY)
+	} else {
+		f.txt.SetString(f.ed.String())
+		f.txt.Draw(fr.buff, x0, textY)
+	}
+	if f.focused && f.colors[3] != nil && (time.Since(f.lastInput)/caretBlink)%2 == 0 {
+		cx := int(x0 + f.measure(f.ed.caret))
+		fill(fr.buff, image.Rect(cx, int(textY), cx+1, int(textY+textH)), f.colors[3], draw.Over)
+	}
+	f.mutex.Unlock()
+	render.DrawImage(buff, fr.buff, int(fr.X()+xOff), int(fr.Y()+yOff))
+}
+
+func fill(buff draw.Image, r image.Rectangle, c color.Color, op draw.Op) {
+	draw.Draw(buff, r, image.NewUniform(c), image.Point{}, op)
+}
@@ -0,0 +1,392 @@
+// Package textfield provides single line text entry entities.
+package textfield
+
+import (
+	"image/color"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/collision"
+	"github.com/diakovliev/oak/v4/entities"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/key"
+	"github.com/diakovliev/oak/v4/mouse"
+	"github.com/diakovliev/oak/v4/render"
+	"github.com/diakovliev/oak/v4/scene"
+)
+
+// Events triggered on a field's entity
+var (
+	// Change is triggered when a field's text is edited, with the new text.
+	Change = event.RegisterEvent[string]()
+	// Submit is triggered when return is pressed in a focused field, with the
+	// field's text.
+	Submit = event.RegisterEvent[string]()
+	// FocusGain is triggered when a field starts receiving key input.
+	FocusGain = event.RegisterEvent[struct{}]()
+	// FocusLoss is triggered when a field stops receiving key input.
+	FocusLoss = event.RegisterEvent[struct{}]()
+)
+
+// A Generator defines the variables used to create text fields from optional
+// arguments
+type Generator struct {
+	X, Y, W, H     float64
+	Padding        float64
+	Font           *render.Font
+	Text           string
+	Placeholder    string
+	MaxLength      int
+	Validate       func(string) bool
+	Color          color.Color
+	FocusColor     color.Color
+	SelectionColor color.Color
+	CaretColor     color.Color
+	Layers         []int
+	Label          collision.Label
+	Focused        bool
+}
+
+func defGenerator() Generator {
+	return Generator{
+		W:              100,
+		H:              20,
+		Padding:        3,
+		Color:          color.RGBA{40, 40, 40, 255},
+		FocusColor:     color.RGBA{60, 60, 60, 255},
+		SelectionColor: color.RGBA{60, 100, 180, 255},
+		CaretColor:     color.RGBA{255, 255, 255, 255},
+		Layers:         []int{0},
+	}
+}
+
+// A Field is an entity that accepts typed text while focused. Clicking on a
+// field focuses it and places its caret; clicking elsewhere removes focus.
+//
+// While focused, a field supports:
+//
+//	left, right, home, end   move the caret, selecting with shift, by word with control
+//	backspace, delete        delete the selection or one character
+//	control+a                select all text
+//	control+c, x, v          copy, cut, and paste through Clipboard
+//	return                   trigger Submit
+type Field struct {
+	*entities.Entity
+
+	ctx *scene.Context
+
+	mutex       sync.Mutex
+	ed          editor
+	font        *render.Font
+	txt         *render.Text
+	placeholder *render.Text
+	padding     float64
+	colors      [4]color.Color
+	focused     bool
+	dragging    bool
+	scroll      float64
+	lastInput   time.Time
+}
+
+// Generate creates a Field from a generator.
+func (g Generator) Generate(ctx *scene.Context) *Field {
+	font := g.Font
+	if font == nil {
+		font = render.DefaultFont()
+	}
+	f := &Field{
+		ctx: ctx,
+		ed: editor{
+			maxLength: g.MaxLength,
+			validate:  g.Validate,
+		},
+		font:      font.Copy(),
+		padding:   g.Padding,
+		colors:    [4]color.Color{g.Color, g.FocusColor, g.SelectionColor, g.CaretColor},
+		lastInput: time.Now(),
+	}
+	f.txt = f.font.NewText("", 0, 0)
+	f.placeholder = f.font.NewText(g.Placeholder, 0, 0)
+	f.ed.setText(g.Text)
+
+	r := &fieldRenderable{
+		LayeredPoint: render.NewLayeredPoint(g.X, g.Y, 0),
+		f:            f,
+	}
+	f.Entity = entities.New(ctx,
+		entities.WithRenderable(r),
+		entities.WithRect(floatgeom.NewRect2WH(g.X, g.Y, g.W, g.H)),
+		entities.WithLabel(g.Label),
+		entities.WithDrawLayers(g.Layers),
+		entities.WithUseMouseTree(true),
+	)
+
+	event.Bind(ctx, mouse.PressOn, f.Entity, func(_ *entities.Entity, me *mouse.Event) event.Response {
+		f.press(me)
+		return 0
+	})
+	event.Bind(ctx, mouse.Press, f.Entity, func(_ *entities.Entity, me *mouse.Event) event.Response {
+		if !f.Rect.Contains(me.Point2) {
+			f.Blur()
+		}
+		return 0
+	})
+	event.Bind(ctx, mouse.Drag, f.Entity, func(_ *entities.Entity, me *mouse.Event) event.Response {
+		f.drag(me)
+		return 0
+	})
+	event.Bind(ctx, mouse.Release, f.Entity, func(_ *entities.Entity, me *mouse.Event) event.Response {
+		f.mutex.Lock()
+		f.dragging = false
+		f.mutex.Unlock()
+		return 0
+	})
+	keyFn := func(_ *entities.Entity, ev key.Event) event.Response {
+		f.handleKey(ev)
+		return 0
+	}
+	event.Bind(ctx, key.AnyDown, f.Entity, keyFn)
+	event.Bind(ctx, key.AnyHeld, f.Entity, keyFn)
+
+	mouse.PhaseCollision(f.Space, ctx.Handler)
+
+	if g.Focused {
+		f.Focus()
+	}
+	return f
+}
+
+// An Option is used to populate generator fields prior to generation of a field
+type Option func(Generator) Generator
+
+// New creates a text field with the given options and defaults for all
+// variables not set.
+func New(ctx *scene.Context, opts ...Option) *Field {
+	g := defGenerator()
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		g = opt(g)
+	}
+	return g.Generate(ctx)
+}
+
+// Text returns the current text of the field.
+func (f *Field) Text() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.ed.String()
+}
+
+// SetText replaces the text of the field, without validating it, and places
+// the caret at its end. Text beyond the field's max length is dropped.
+func (f *Field) SetText(s string) {
+	f.mutex.Lock()
+	f.ed.setText(s)
+	f.scrollToCaret()
+	txt := f.ed.String()
+	f.mutex.Unlock()
+	event.TriggerForCallerOn(f.ctx, f.CID(), Change, txt)
+}
+
+// Selected returns the currently selected text.
+func (f *Field) Selected() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.ed.selected()
+}
+
+// Select selects the runes of the field's text from start to end.
+func (f *Field) Select(start, end int) {
+	f.mutex.Lock()
+	f.ed.moveTo(start, false)
+	f.ed.moveTo(end, true)
+	f.scrollToCaret()
+	f.mutex.Unlock()
+}
+
+// Focused returns whether the field is receiving key input.
+func (f *Field) Focused() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.focused
+}
+
+// Focus causes the field to receive key input.
+func (f *Field) Focus() {
+	f.mutex.Lock()
+	wasFocused := f.focused
+	f.focused = true
+	f.lastInput = time.Now()
+	f.mutex.Unlock()
+	if !wasFocused {
+		event.TriggerForCallerOn(f.ctx, f.CID(), FocusGain, struct{}{})
+	}
+}
+
+// Blur causes the field to stop receiving key input.
+func (f *Field) Blur() {
+	f.mutex.Lock()
+	wasFocused := f.focused
+	f.focused = false
+	f.dragging = false
+	f.mutex.Unlock()
+	if wasFocused {
+		event.TriggerForCallerOn(f.ctx, f.CID(), FocusLoss, struct{}{})
+	}
+}
+
+func (f *Field) press(me *mouse.Event) {
+	if me.Button != mouse.ButtonLeft {
+		return
+	}
+	f.Focus()
+	f.mutex.Lock()
+	f.ed.moveTo(f.indexAt(me.X()), f.ctx.IsDown(key.LeftShift) || f.ctx.IsDown(key.RightShift))
+	f.dragging = true
+	f.lastInput = time.Now()
+	f.scrollToCaret()
+	f.mutex.Unlock()
+}
+
+func (f *Field) drag(me *mouse.Event) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.dragging {
+		return
+	}
+	f.ed.moveTo(f.indexAt(me.X()), true)
+	f.scrollToCaret()
+}
+
+func (f *Field) handleKey(ev key.Event) {
+	f.mutex.Lock()
+	if !f.focused {
+		f.mutex.Unlock()
+		return
+	}
+	shift := ev.Modifiers&key.ModShift != 0
+	ctrl := ev.Modifiers&(key.ModControl|key.ModMeta) != 0
+	ed := &f.ed
+	changed, submitted := false, false
+	switch ev.Code {
+	case key.LeftArrow:
+		if ctrl {
+			ed.moveTo(ed.wordLeft(), shift)
+		} else {
+			ed.left(shift)
+		}
+	case key.RightArrow:
+		if ctrl {
+			ed.moveTo(ed.wordRight(), shift)
+		} else {
+			ed.right(shift)
+		}
+	case key.Home:
+		ed.moveTo(0, shift)
+	case key.End:
+		ed.moveTo(len(ed.text), shift)
+	case key.DeleteBackspace:
+		changed = ed.deleteBackward()
+	case key.DeleteForward:
+		changed = ed.deleteForward()
+	case key.ReturnEnter, key.KeypadEnter:
+		submitted = true
+	default:
+		if ctrl {
+			switch ev.Code {
+			case key.A:
+				ed.selectAll()
+			case key.C:
+				if ed.hasSelection() {
+					SetClipboard(ed.selected())
+				}
+			case key.X:
+				if ed.hasSelection() {
+					SetClipboard(ed.selected())
+					changed = ed.insert("")
+				}
+			case key.V:
+				changed = ed.insert(Clipboard())
+			}
+		} else if ev.Rune > 0 && unicode.IsPrint(ev.Rune) {
+			changed = ed.insert(string(ev.Rune))
+		}
+	}
+	f.lastInput = time.Now()
+	f.scrollToCaret()
+	txt := ed.String()
+	f.mutex.Unlock()
+	if changed {
+		event.TriggerForCallerOn(f.ctx, f.CID(), Change, txt)
+	}
+	if submitted {
+		event.TriggerForCallerOn(f.ctx, f.CID(), Submit, txt)
+	}
+}
+
+// measure returns the width of the first n runes of the field's text.
+func (f *Field) measure(n int) float64 {
+	return float64(f.font.MeasureString(string(f.ed.text[:n])).Round())
+}
+
+// indexAt returns the caret index closest to the horizontal position x.
+func (f *Field) indexAt(x float64) int {
+	rel := x - f.X() - f.padding + f.scroll
+	best, bestDist := 0, rel
+	if bestDist < 0 {
+		bestDist = -bestDist
+	}
+	for i := 1; i <= len(f.ed.text); i++ {
+		d := f.measure(i) - rel
+		if d < 0 {
+			d = -d
+		}
+		if d > bestDist {
+			break
+		}
+		best, bestDist = i, d
+	}
+	return best
+}
+
+// scrollToCaret scrolls the field's text so the caret is visible.
+func (f *Field) scrollToCaret() {
+	inner := f.W() - 2*f.padding
+	cx := f.measure(f.ed.caret)
+	if cx-f.scroll > inner {
+		f.scroll = cx - inner
+	}
+	if cx < f.scroll {
+		f.scroll = cx
+	}
+	// Don't leave empty space after the text when it could be filled
+	if total := f.measure(len(f.ed.text)); total-f.scroll < inner {
+		f.scroll = total - inner
+	}
+	if f.scroll < 0 {
+		f.scroll = 0
+	}
+}
+
+var (
+	clipboardLock sync.Mutex
+	clipboard     string
+)
+
+// Clipboard returns the text most recently copied or cut from any field.
+func Clipboard() string {
+	clipboardLock.Lock()
+	defer clipboardLock.Unlock()
+	return clipboard
+}
+
+// SetClipboard sets the text that will be pasted into fields.
+func SetClipboard(s string) {
+	clipboardLock.Lock()
+	clipboard = s
+	clipboardLock.Unlock()
+}
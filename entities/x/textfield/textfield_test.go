@@ -0,0 +1,188 @@
+package textfield
+
+import (
+	"testing"
+	"time"
+
+	"github.com/diakovliev/oak/v4/entities"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/internal/scenetest"
+	"github.com/diakovliev/oak/v4/key"
+	"github.com/diakovliev/oak/v4/mouse"
+	"github.com/diakovliev/oak/v4/scene"
+)
+
+// newField creates a field and waits for its bindings to be bound.
+func newField(ctx *scene.Context, opts ...Option) *Field {
+	f := New(ctx, opts...)
+	time.Sleep(200 * time.Millisecond)
+	return f
+}
+
+func press(ctx *scene.Context, code key.Code, mods key.Modifiers) {
+	<-event.TriggerOn(ctx, key.AnyDown, key.Event{Code: code, Modifiers: mods})
+}
+
+func typeText(ctx *scene.Context, s string) {
+	for _, r := range s {
+		<-event.TriggerOn(ctx, key.AnyDown, key.Event{Rune: r})
+	}
+}
+
+func TestField_Keys(t *testing.T) {
+	ctx := scenetest.NewContext()
+	f := newField(ctx)
+	changes := make(chan string, 100)
+	submits := make(chan string, 1)
+	<-event.Bind(ctx, Change, f.Entity, func(_ *entities.Entity, s string) event.Response {
+		changes <- s
+		return 0
+	}).Bound
+	<-event.Bind(ctx, Submit, f.Entity, func(_ *entities.Entity, s string) event.Response {
+		submits <- s
+		return 0
+	}).Bound
+
+	typeText(ctx, "a")
+	if f.Text() != "" {
+		t.Fatalf("expected unfocused field to ignore keys, got %q", f.Text())
+	}
+	f.Focus()
+	typeText(ctx, "hello")
+	if f.Text() != "hello" {
+		t.Fatalf("expected typed text, got %q", f.Text())
+	}
+	press(ctx, key.LeftArrow, key.ModShift)
+	press(ctx, key.LeftArrow, key.ModShift)
+	if f.Selected() != "lo" {
+		t.Fatalf("expected shift+left to select, got %q", f.Selected())
+	}
+	press(ctx, key.C, key.ModControl)
+	if Clipboard() != "lo" {
+		t.Fatalf("expected copy to set clipboard, got %q", Clipboard())
+	}
+	press(ctx, key.X, key.ModControl)
+	if f.Text() != "hel" {
+		t.Fatalf("expected cut to remove selection, got %q", f.Text())
+	}
+	press(ctx, key.Home, 0)
+	press(ctx, key.V, key.ModControl)
+	if f.Text() != "lohel" {
+		t.Fatalf("expected paste at caret, got %q", f.Text())
+	}
+	press(ctx, key.End, 0)
+	press(ctx, key.DeleteBackspace, 0)
+	press(ctx, key.Home, 0)
+	press(ctx, key.DeleteForward, 0)
+	if f.Text() != "ohe" {
+		t.Fatalf("expected backspace and delete to remove characters, got %q", f.Text())
+	}
+	typeText(ctx, " wor")
+	press(ctx, key.LeftArrow, key.ModControl|key.ModShift)
+	if f.Selected() != "wor" {
+		t.Fatalf("expected control+shift+left to select a word, got %q", f.Selected())
+	}
+	press(ctx, key.A, key.ModControl)
+	typeText(ctx, "z")
+	if f.Text() != "z" {
+		t.Fatalf("expected typing over select all to replace text, got %q", f.Text())
+	}
+	press(ctx, key.ReturnEnter, 0)
+	select {
+	case s := <-submits:
+		if s != "z" {
+			t.Fatalf("expected submit with text, got %q", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected submit event")
+	}
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case s := <-changes:
+			if s == "z" {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("expected change events ending with the final text")
+		}
+	}
+}
+
+func TestField_Mouse(t *testing.T) {
+	ctx := scenetest.NewContext()
+	f := newField(ctx, Pos(10, 10), Width(200), Padding(3), Text("hello world"))
+	x := func(n int) float64 {
+		return 10 + 3 + f.measure(n)
+	}
+	me := mouse.NewEvent(x(3), 15, mouse.ButtonLeft, mouse.PressOn)
+	<-event.TriggerForCallerOn(ctx, f.CID(), mouse.PressOn, &me)
+	if !f.Focused() {
+		t.Fatalf("expected press to focus field")
+	}
+	typeText(ctx, "_")
+	if f.Text() != "hel_lo world" {
+		t.Fatalf("expected press to place caret, got %q", f.Text())
+	}
+	press(ctx, key.DeleteBackspace, 0)
+
+	drag := mouse.NewEvent(x(5), 15, mouse.ButtonNone, mouse.Drag)
+	<-event.TriggerOn(ctx, mouse.Drag, &drag)
+	if f.Selected() != "lo" {
+		t.Fatalf("expected drag to select, got %q", f.Selected())
+	}
+	release := mouse.NewEvent(x(5), 15, mouse.ButtonLeft, mouse.Release)
+	<-event.TriggerOn(ctx, mouse.Release, &release)
+	drag = mouse.NewEvent(x(8), 15, mouse.ButtonNone, mouse.Drag)
+	<-event.TriggerOn(ctx, mouse.Drag, &drag)
+	if f.Selected() != "lo" {
+		t.Fatalf("expected drag after release to be ignored, got %q", f.Selected())
+	}
+
+	right := mouse.NewEvent(x(0), 15, mouse.ButtonRight, mouse.PressOn)
+	<-event.TriggerForCallerOn(ctx, f.CID(), mouse.PressOn, &right)
+	if f.Selected() != "lo" {
+		t.Fatalf("expected right press to be ignored, got %q", f.Selected())
+	}
+	outside := mouse.NewEvent(500, 500, mouse.ButtonLeft, mouse.Press)
+	<-event.TriggerOn(ctx, mouse.Press, &outside)
+	if f.Focused() {
+		t.Fatalf("expected press outside the field to blur it")
+	}
+}
+
+func TestField_ScrollToCaret(t *testing.T) {
+	ctx := scenetest.NewContext()
+	f := newField(ctx, Width(30), Padding(0), Focused())
+	typeText(ctx, "abcdefghijklmnopqrstuvwxyz")
+	f.mutex.Lock()
+	scroll, end := f.scroll, f.measure(len(f.ed.text))
+	f.mutex.Unlock()
+	if scroll != end-30 {
+		t.Fatalf("expected end of text to be scrolled into view, scroll %v for text width %v", scroll, end)
+	}
+	press(ctx, key.Home, 0)
+	f.mutex.Lock()
+	scroll = f.scroll
+	f.mutex.Unlock()
+	if scroll != 0 {
+		t.Fatalf("expected start of text to be scrolled into view, got scroll %v", scroll)
+	}
+	for i := 0; i < 20; i++ {
+		press(ctx, key.RightArrow, 0)
+	}
+	f.mutex.Lock()
+	caret := f.measure(f.ed.caret)
+	scroll = f.scroll
+	f.mutex.Unlock()
+	if caret-scroll > 30 || caret < scroll {
+		t.Fatalf("expected caret at %v to be visible with scroll %v", caret, scroll)
+	}
+	f.SetText("ab")
+	f.mutex.Lock()
+	scroll = f.scroll
+	f.mutex.Unlock()
+	if scroll != 0 {
+		t.Fatalf("expected short text not to scroll, got %v", scroll)
+	}
+}
@@ -0,0 +1,179 @@
+package ui
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/entities"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/mouse"
+	"github.com/diakovliev/oak/v4/render"
+)
+
+// A DropdownNode chooses one of a list of options, shown in a list below it
+// while it is open.
+type DropdownNode struct {
+	options  []string
+	selected int
+	onChange func(int)
+
+	u    *UI
+	ent  *entities.Entity
+	txt  *render.Text
+	list []*entities.Entity
+}
+
+// Dropdown creates a dropdown calling onChange with the index of the option
+// chosen.
+func Dropdown(options []string, selected int, onChange func(int)) *DropdownNode {
+	return &DropdownNode{options: options, selected: selected, onChange: onChange}
+}
+
+// Selected returns the index of the chosen option.
+func (d *DropdownNode) Selected() int {
+	return d.selected
+}
+
+// SetSelected chooses an option without calling the dropdown's change
+// function.
+func (d *DropdownNode) SetSelected(i int) {
+	if i < 0 || i >= len(d.options) {
+		return
+	}
+	d.selected = i
+	if d.txt != nil {
+		d.txt.SetString(d.options[i])
+	}
+}
+
+// IsOpen returns whether the dropdown's list is shown.
+func (d *DropdownNode) IsOpen() bool {
+	return d.list != nil
+}
+
+func (d *DropdownNode) arrowSize(th *Theme) float64 {
+	return th.WidgetHeight / 2
+}
+
+// MinSize returns the size of the widest option with padding and an arrow.
+func (d *DropdownNode) MinSize(th *Theme) floatgeom.Point2 {
+	w := 0.0
+	for _, o := range d.options {
+		if ow := th.textWidth(o); ow > w {
+			w = ow
+		}
+	}
+	return floatgeom.Point2{w + 3*th.Padding + d.arrowSize(th), widgetHeight(th)}
+}
+
+// Build creates an entity for the closed dropdown.
+func (d *DropdownNode) Build(u *UI, r floatgeom.Rect2) {
+	d.u = u
+	d.list = nil
+	th := &u.Theme
+	bg, accent := th.Button, th.Accent
+	arrow := int(d.arrowSize(th))
+	pad := int(th.Padding)
+	rend := newShape(0, 0, int(r.W()), int(r.H()), func(buff draw.Image, r image.Rectangle) {
+		fill(buff, r, bg)
+		y := r.Min.Y + (r.Dy()-arrow/2)/2
+		// A downward triangle, drawn row by row
+		for i := 0; i < arrow/2; i++ {
+			fill(buff, image.Rect(r.Max.X-pad-arrow+i, y+i, r.Max.X-pad-i, y+i+1), accent)
+		}
+	})
+	label := ""
+	if d.selected >= 0 && d.selected < len(d.options) {
+		label = d.options[d.selected]
+	}
+	d.txt = th.font().NewText(label, 0, 0)
+	d.ent = u.NewEntity(r, rend, childText(d.txt, th.Padding, (r.H()-th.font().Height())/2))
+	event.Bind(u.ctx, mouse.ClickOn, d.ent, func(_ *entities.Entity, me *mouse.Event) event.Response {
+		u.Focus(d)
+		d.Activate()
+		me.StopPropagation = true
+		return 0
+	})
+	mouse.PhaseCollision(d.ent.Space, u.ctx.Handler)
+	u.AddFocusable(d)
+	u.dropdowns = append(u.dropdowns, d)
+}
+
+// FocusRect returns the dropdown's area.
+func (d *DropdownNode) FocusRect() floatgeom.Rect2 {
+	return d.ent.Rect
+}
+
+// Activate opens or closes the dropdown's list.
+func (d *DropdownNode) Activate() {
+	if d.IsOpen() {
+		d.Close()
+	} else {
+		d.Open()
+	}
+}
+
+// Adjust chooses the previous or next option.
+func (d *DropdownNode) Adjust(dir int) bool {
+	d.choose(d.selected + dir)
+	return true
+}
+
+func (d *DropdownNode) choose(i int) {
+	if i < 0 || i >= len(d.options) || i == d.selected {
+		return
+	}
+	d.SetSelected(i)
+	if d.onChange != nil {
+		d.onChange(i)
+	}
+}
+
+// Open shows the dropdown's list of options below it, over the rest of its UI.
+func (d *DropdownNode) Open() {
+	if d.IsOpen() || d.ent == nil {
+		return
+	}
+	u := d.u
+	th := &u.Theme
+	r := d.ent.Rect
+	h := r.H()
+	for i, o := range d.options {
+		i := i
+		bg := th.Track
+		if i == d.selected {
+			bg = th.Button
+		}
+		or := floatgeom.NewRect2WH(r.Min.X(), r.Max.Y()+float64(i)*h, r.W(), h)
+		rend := newShape(0, 0, int(r.W()), int(h), func(buff draw.Image, r image.Rectangle) {
+			fill(buff, r, bg)
+		})
+		txt := th.font().NewText(o, 0, 0)
+		e := entities.New(u.ctx,
+			entities.WithRect(or),
+			entities.WithRenderable(rend),
+			entities.WithUseMouseTree(true),
+			entities.WithDrawLayers(th.layers(popupDepth)),
+			childText(txt, th.Padding, (h-th.font().Height())/2),
+		)
+		u.ctx.Draw(txt, th.layers(popupDepth+1)...)
+		// Options are checked for clicks before anything they cover
+		e.Tree.UpdateSpaceRect(floatgeom.NewRect3(or.Min.X(), or.Min.Y(), popupDepth, or.Max.X(), or.Max.Y(), popupDepth+1), e.Space)
+		event.Bind(u.ctx, mouse.ClickOn, e, func(_ *entities.Entity, me *mouse.Event) event.Response {
+			me.StopPropagation = true
+			d.choose(i)
+			d.Close()
+			return 0
+		})
+		d.list = append(d.list, e)
+	}
+}
+
+// Close hides the dropdown's list.
+func (d *DropdownNode) Close() {
+	for _, e := range d.list {
+		d.u.destroy(e)
+	}
+	d.list = nil
+}
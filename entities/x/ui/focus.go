@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/diakovliev/oak/v4/entities/x/btn/focus"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/mouse"
+)
+
+// A Focusable is a widget that keyboard and gamepad navigation can move to.
+type Focusable interface {
+	// FocusRect, from focus.Focusable, returns the area the focus outline is
+	// drawn around.
+	focus.Focusable
+	// Activate is called when return, space, or the gamepad A button is
+	// pressed while the widget is focused.
+	Activate()
+	// Adjust is called with -1 or 1 when left or right is pressed while the
+	// widget is focused. It returns false if the widget does not use
+	// horizontal input, in which case focus moves instead.
+	Adjust(dir int) bool
+}
+
+// AddFocusable adds a widget being built to this UI's navigation order.
+// Widgets are navigated in the order they are added.
+func (u *UI) AddFocusable(f Focusable) {
+	u.focusLock.Lock()
+	u.panels[f] = u.panel
+	u.focusLock.Unlock()
+	u.focus.Add(f)
+}
+
+// Focused returns the focused widget, or nil if no widget is focused.
+func (u *UI) Focused() Focusable {
+	f, _ := u.focus.Focused().(Focusable)
+	return f
+}
+
+// Focus moves focus to a widget of this UI. Focus set by mouse input is
+// tracked, but the focus outline is only shown after keyboard or gamepad
+// navigation.
+func (u *UI) Focus(f Focusable) {
+	u.focus.Focus(f)
+}
+
+// FocusNext moves focus to the next widget, wrapping around to the first.
+func (u *UI) FocusNext() {
+	u.focus.Move(focus.Next)
+	u.setShowFocus(true)
+}
+
+// FocusPrev moves focus to the previous widget, wrapping around to the last.
+func (u *UI) FocusPrev() {
+	u.focus.Move(focus.Prev)
+	u.setShowFocus(true)
+}
+
+// Activate activates the focused widget, if any.
+func (u *UI) Activate() {
+	u.focus.Activate()
+}
+
+func (u *UI) adjust(dir int) {
+	u.focus.Adjust(dir)
+	u.setShowFocus(true)
+}
+
+func (u *UI) setShowFocus(show bool) {
+	u.focusLock.Lock()
+	u.showFocus = show
+	u.focusLock.Unlock()
+}
+
+// scrollToFocus scrolls the panel containing a newly focused widget to show it.
+func (u *UI) scrollToFocus(f focus.Focusable) {
+	if f == nil {
+		return
+	}
+	u.focusLock.Lock()
+	panel := u.panels[f]
+	u.focusLock.Unlock()
+	if panel != nil {
+		panel.ScrollTo(f.FocusRect())
+	}
+}
+
+// bindNavigation moves focus between widgets in the order they were added
+// in response to key and joystick input.
+func (u *UI) bindNavigation() {
+	u.focus = focus.New(u.ctx,
+		focus.WithMode(focus.Ordered),
+		focus.Wrap(true),
+		focus.Highlight(nil),
+		focus.OnChange(u.scrollToFocus),
+		focus.OnNavigate(func() { u.setShowFocus(true) }),
+	)
+	u.bindings = append(u.bindings,
+		event.GlobalBind(u.ctx, mouse.Press, func(*mouse.Event) event.Response {
+			u.setShowFocus(false)
+			return 0
+		}),
+	)
+}
+
+// clearFocus removes all widgets from navigation.
+func (u *UI) clearFocus() {
+	u.focusLock.Lock()
+	panels := u.panels
+	u.panels = make(map[focus.Focusable]*ScrollPanelNode)
+	u.focusLock.Unlock()
+	for f := range panels {
+		u.focus.Remove(f)
+	}
+}
+
+// focusOutline returns a renderable which draws an outline around the focused
+// widget, wherever it is.
+func (u *UI) focusOutline() *shape {
+	return newShape(0, 0, 0, 0, func(buff draw.Image, r image.Rectangle) {
+		u.focusLock.Lock()
+		show := u.showFocus
+		u.focusLock.Unlock()
+		f := u.focus.Focused()
+		if f == nil || !show {
+			return
+		}
+		fr := f.FocusRect()
+		// r.Min is the draw offset, as this shape is positioned at 0,0
+		outline(buff, image.Rect(
+			int(fr.Min.X())+r.Min.X-1, int(fr.Min.Y())+r.Min.Y-1,
+			int(fr.Max.X())+r.Min.X+1, int(fr.Max.Y())+r.Min.Y+1,
+		), u.Theme.Focus)
+	})
+}
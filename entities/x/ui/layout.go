@@ -0,0 +1,276 @@
+package ui
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+)
+
+// An Align determines where a node is placed across a stack when it is
+// smaller than the stack.
+type Align uint8
+
+// Align values
+const (
+	AlignStretch Align = iota
+	AlignStart
+	AlignCenter
+	AlignEnd
+)
+
+// A Stack lays out its children one after another, vertically or
+// horizontally. Space beyond the children's minimum sizes is shared between
+// children wrapped with Grow, or left at the end if there are none.
+type Stack struct {
+	axis     int
+	children []Node
+	gap      float64
+	gapSet   bool
+	align    Align
+}
+
+// VStack creates a stack laying out its children top to bottom.
+func VStack(children ...Node) *Stack {
+	return &Stack{axis: 1, children: children}
+}
+
+// HStack creates a stack laying out its children left to right.
+func HStack(children ...Node) *Stack {
+	return &Stack{axis: 0, children: children}
+}
+
+// WithGap sets the space between the stack's children, overriding the
+// theme's Gap.
+func (s *Stack) WithGap(gap float64) *Stack {
+	s.gap = gap
+	s.gapSet = true
+	return s
+}
+
+// WithAlign sets how children are placed across the stack. The default,
+// AlignStretch, sizes each child to the width of a vertical stack or the
+// height of a horizontal stack.
+func (s *Stack) WithAlign(a Align) *Stack {
+	s.align = a
+	return s
+}
+
+func (s *Stack) gapFor(th *Theme) float64 {
+	if s.gapSet {
+		return s.gap
+	}
+	return th.Gap
+}
+
+// MinSize returns the sum of the children's sizes along the stack, and the
+// largest of their sizes across it.
+func (s *Stack) MinSize(th *Theme) floatgeom.Point2 {
+	var size floatgeom.Point2
+	for i, c := range s.children {
+		cs := c.MinSize(th)
+		size[s.axis] += cs[s.axis]
+		if i != 0 {
+			size[s.axis] += s.gapFor(th)
+		}
+		if cs[1-s.axis] > size[1-s.axis] {
+			size[1-s.axis] = cs[1-s.axis]
+		}
+	}
+	return size
+}
+
+// Build lays out and builds each child.
+func (s *Stack) Build(u *UI, r floatgeom.Rect2) {
+	for i, cr := range s.layout(&u.Theme, r) {
+		s.children[i].Build(u, cr)
+	}
+}
+
+func (s *Stack) layout(th *Theme, r floatgeom.Rect2) []floatgeom.Rect2 {
+	main, cross := s.axis, 1-s.axis
+	extra := span(r, main) - s.MinSize(th)[main]
+	if extra < 0 {
+		extra = 0
+	}
+	totalWeight := 0.0
+	for _, c := range s.children {
+		if g, ok := c.(*growNode); ok {
+			totalWeight += g.weight
+		}
+	}
+	rects := make([]floatgeom.Rect2, len(s.children))
+	pos := r.Min[main]
+	for i, c := range s.children {
+		cs := c.MinSize(th)
+		length := cs[main]
+		if g, ok := c.(*growNode); ok && totalWeight > 0 {
+			length += extra * g.weight / totalWeight
+		}
+		var cr floatgeom.Rect2
+		cr.Min[main] = pos
+		cr.Max[main] = pos + length
+		cr.Min[cross], cr.Max[cross] = align(s.align, r.Min[cross], r.Max[cross], cs[cross])
+		rects[i] = cr
+		pos += length + s.gapFor(th)
+	}
+	return rects
+}
+
+func span(r floatgeom.Rect2, axis int) float64 {
+	return r.Max[axis] - r.Min[axis]
+}
+
+// align places a length within min and max.
+func align(a Align, min, max, length float64) (float64, float64) {
+	if length > max-min {
+		length = max - min
+	}
+	switch a {
+	case AlignStart:
+		return min, min + length
+	case AlignCenter:
+		start := min + (max-min-length)/2
+		return start, start + length
+	case AlignEnd:
+		return max - length, max
+	}
+	return min, max
+}
+
+type growNode struct {
+	Node
+	weight float64
+}
+
+// Grow causes a node in a stack to take a share of any extra space along the
+// stack, proportional to weight.
+func Grow(weight float64, n Node) Node {
+	return &growNode{Node: n, weight: weight}
+}
+
+type emptyNode floatgeom.Point2
+
+func (e emptyNode) MinSize(*Theme) floatgeom.Point2 { return floatgeom.Point2(e) }
+func (e emptyNode) Build(*UI, floatgeom.Rect2)      {}
+
+// Fixed creates empty space of a fixed size.
+func Fixed(w, h float64) Node {
+	return emptyNode{w, h}
+}
+
+// Spacer creates empty space that grows to fill a stack, pushing the nodes
+// after it to the end of the stack.
+func Spacer() Node {
+	return Grow(1, Fixed(0, 0))
+}
+
+// Insets are distances from each edge of a rectangle.
+type Insets struct {
+	Top, Right, Bottom, Left float64
+}
+
+type padNode struct {
+	Node
+	in Insets
+}
+
+// Pad surrounds a node with empty space.
+func Pad(in Insets, n Node) Node {
+	return &padNode{Node: n, in: in}
+}
+
+// PadAll surrounds a node with the same amount of empty space on each side.
+func PadAll(p float64, n Node) Node {
+	return Pad(Insets{p, p, p, p}, n)
+}
+
+func (p *padNode) MinSize(th *Theme) floatgeom.Point2 {
+	return p.Node.MinSize(th).Add(floatgeom.Point2{p.in.Left + p.in.Right, p.in.Top + p.in.Bottom})
+}
+
+func (p *padNode) Build(u *UI, r floatgeom.Rect2) {
+	p.Node.Build(u, floatgeom.NewRect2(
+		r.Min.X()+p.in.Left, r.Min.Y()+p.in.Top,
+		r.Max.X()-p.in.Right, r.Max.Y()-p.in.Bottom,
+	))
+}
+
+// An Anchor is a position within a rectangle a node can be placed at.
+type Anchor uint8
+
+// Anchor values
+const (
+	TopLeft Anchor = iota
+	Top
+	TopRight
+	Left
+	Center
+	Right
+	BottomLeft
+	Bottom
+	BottomRight
+)
+
+type anchorNode struct {
+	Node
+	anchor Anchor
+}
+
+// At places a node, at its minimum size, at an anchor within its space.
+func At(a Anchor, n Node) Node {
+	return &anchorNode{Node: n, anchor: a}
+}
+
+func (a *anchorNode) Build(u *UI, r floatgeom.Rect2) {
+	size := a.Node.MinSize(&u.Theme)
+	aligns := [3]Align{AlignStart, AlignCenter, AlignEnd}
+	var nr floatgeom.Rect2
+	nr.Min[0], nr.Max[0] = align(aligns[a.anchor%3], r.Min[0], r.Max[0], size[0])
+	nr.Min[1], nr.Max[1] = align(aligns[a.anchor/3], r.Min[1], r.Max[1], size[1])
+	a.Node.Build(u, nr)
+}
+
+type overlayNode []Node
+
+// Overlay lays out each node in the same space, drawing later nodes over
+// earlier ones. Combined with At, this can position elements around the
+// edges of a screen.
+func Overlay(children ...Node) Node {
+	return overlayNode(children)
+}
+
+func (o overlayNode) MinSize(th *Theme) floatgeom.Point2 {
+	var size floatgeom.Point2
+	for _, c := range o {
+		size = size.GreaterOf(c.MinSize(th))
+	}
+	return size
+}
+
+func (o overlayNode) Build(u *UI, r floatgeom.Rect2) {
+	for i, c := range o {
+		u.depth += i
+		c.Build(u, r)
+		u.depth -= i
+	}
+}
+
+type panelNode struct {
+	Node
+}
+
+// Panel draws the theme's background behind a node.
+func Panel(n Node) Node {
+	return &panelNode{Node: n}
+}
+
+func (p *panelNode) Build(u *UI, r floatgeom.Rect2) {
+	bg := u.Theme.Background
+	u.NewEntity(r, newShape(0, 0, int(r.W()), int(r.H()), func(buff draw.Image, r image.Rectangle) {
+		fill(buff, r, bg)
+	}))
+	u.depth++
+	p.Node.Build(u, r)
+	u.depth--
+}
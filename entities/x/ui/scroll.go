@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/collision"
+	"github.com/diakovliev/oak/v4/entities"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/mouse"
+	"github.com/diakovliev/oak/v4/render"
+)
+
+// A ScrollPanelNode shows part of content taller than itself, scrolled with
+// the mouse wheel or by moving focus to widgets outside of its view.
+type ScrollPanelNode struct {
+	size    floatgeom.Point2
+	content Node
+
+	ent         *entities.Entity
+	entities    []*entities.Entity
+	renderables []panelRenderable
+	hidden      map[*entities.Entity]bool
+	offset      float64
+	maxOffset   float64
+}
+
+type panelRenderable struct {
+	r     render.Renderable
+	depth int
+}
+
+// ScrollPanel creates a panel of the given size showing content.
+func ScrollPanel(w, h float64, content Node) *ScrollPanelNode {
+	return &ScrollPanelNode{size: floatgeom.Point2{w, h}, content: content}
+}
+
+// MinSize returns the size of the panel.
+func (p *ScrollPanelNode) MinSize(th *Theme) floatgeom.Point2 {
+	return p.size
+}
+
+// Build builds the panel's content at its full height, drawing it clipped to
+// the panel.
+func (p *ScrollPanelNode) Build(u *UI, r floatgeom.Rect2) {
+	p.entities = nil
+	p.renderables = nil
+	p.hidden = make(map[*entities.Entity]bool)
+	p.offset = 0
+
+	p.ent = u.NewEntity(r, &panelView{
+		LayeredPoint: render.NewLayeredPoint(r.Min.X(), r.Min.Y(), 0),
+		p:            p,
+		bg:           u.Theme.Background,
+	})
+	step := u.Theme.WidgetHeight
+	event.Bind(u.ctx, mouse.ScrollDownOn, p.ent, func(*entities.Entity, *mouse.Event) event.Response {
+		p.ScrollBy(step)
+		return 0
+	})
+	event.Bind(u.ctx, mouse.ScrollUpOn, p.ent, func(*entities.Entity, *mouse.Event) event.Response {
+		p.ScrollBy(-step)
+		return 0
+	})
+
+	contentH := math.Max(p.content.MinSize(&u.Theme).Y(), r.H())
+	p.maxOffset = contentH - r.H()
+
+	drawer, panel := u.drawer, u.panel
+	u.drawer = func(r render.Renderable, depth int) {
+		p.renderables = append(p.renderables, panelRenderable{r: r, depth: depth})
+	}
+	u.panel = p
+	u.depth++
+	p.content.Build(u, floatgeom.NewRect2WH(r.Min.X(), r.Min.Y(), r.W(), contentH))
+	u.depth--
+	u.drawer, u.panel = drawer, panel
+
+	sort.SliceStable(p.renderables, func(i, j int) bool {
+		return p.renderables[i].depth < p.renderables[j].depth
+	})
+	p.updateVisibility()
+}
+
+// Offset returns how far the panel's content is scrolled.
+func (p *ScrollPanelNode) Offset() float64 {
+	return p.offset
+}
+
+// ScrollBy scrolls the panel's content by dy, within the content's bounds.
+func (p *ScrollPanelNode) ScrollBy(dy float64) {
+	next := math.Max(0, math.Min(p.maxOffset, p.offset+dy))
+	delta := next - p.offset
+	if delta == 0 {
+		return
+	}
+	p.offset = next
+	for _, e := range p.entities {
+		e.ShiftY(-delta)
+	}
+	p.updateVisibility()
+}
+
+// ScrollTo scrolls the panel's content the least distance that shows r.
+func (p *ScrollPanelNode) ScrollTo(r floatgeom.Rect2) {
+	view := p.ent.Rect
+	if r.Min.Y() < view.Min.Y() {
+		p.ScrollBy(r.Min.Y() - view.Min.Y())
+	} else if r.Max.Y() > view.Max.Y() {
+		p.ScrollBy(r.Max.Y() - view.Max.Y())
+	}
+}
+
+// updateVisibility removes the collision spaces of entities scrolled out of
+// view, so they cannot be clicked, and restores those scrolled into view.
+func (p *ScrollPanelNode) updateVisibility() {
+	var update func(e *entities.Entity)
+	update = func(e *entities.Entity) {
+		for _, c := range e.Children {
+			update(c)
+		}
+		if e.Tree == nil || e.Space == nil {
+			return
+		}
+		view := p.ent.Rect
+		visible := e.Rect.Max.Y() > view.Min.Y() && e.Rect.Min.Y() < view.Max.Y()
+		if !visible && !p.hidden[e] {
+			e.Tree.Remove(e.Space)
+			p.hidden[e] = true
+		} else if visible && p.hidden[e] {
+			e.Space.Location = collision.NewRect(e.X(), e.Y(), e.W(), e.H())
+			e.Tree.Add(e.Space)
+			delete(p.hidden, e)
+		}
+	}
+	for _, e := range p.entities {
+		update(e)
+	}
+}
+
+// panelView draws a scroll panel's background and content, clipped to the
+// panel.
+type panelView struct {
+	render.LayeredPoint
+	p    *ScrollPanelNode
+	bg   color.Color
+	buff *image.RGBA
+}
+
+func (pv *panelView) GetDims() (int, int) {
+	return int(pv.p.ent.W()), int(pv.p.ent.H())
+}
+
+func (pv *panelView) Draw(buff draw.Image, xOff, yOff float64) {
+	w, h := pv.GetDims()
+	if w <= 0 || h <= 0 {
+		return
+	}
+	if pv.buff == nil || pv.buff.Bounds().Dx() != w || pv.buff.Bounds().Dy() != h {
+		pv.buff = image.NewRGBA(image.Rect(0, 0, w, h))
+	}
+	draw.Draw(pv.buff, pv.buff.Bounds(), image.Transparent, image.Point{}, draw.Src)
+	fill(pv.buff, pv.buff.Bounds(), pv.bg)
+	for _, pr := range pv.p.renderables {
+		if pr.r.GetLayer() == render.Undraw {
+			continue
+		}
+		pr.r.Draw(pv.buff, -pv.X(), -pv.Y())
+	}
+	render.DrawImage(buff, pv.buff, int(pv.X()+xOff), int(pv.Y()+yOff))
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/diakovliev/oak/v4/render"
+)
+
+// shape is a renderable drawn each frame by a function, so that widgets can
+// reflect their current state without rebuilding sprites.
+type shape struct {
+	render.LayeredPoint
+	w, h int
+	fn   func(buff draw.Image, r image.Rectangle)
+}
+
+func newShape(x, y float64, w, h int, fn func(buff draw.Image, r image.Rectangle)) *shape {
+	return &shape{
+		LayeredPoint: render.NewLayeredPoint(x, y, 0),
+		w:            w,
+		h:            h,
+		fn:           fn,
+	}
+}
+
+func (s *shape) GetDims() (int, int) {
+	return s.w, s.h
+}
+
+func (s *shape) Resize(w, h int) {
+	s.w, s.h = w, h
+}
+
+func (s *shape) Draw(buff draw.Image, xOff, yOff float64) {
+	x, y := int(s.X()+xOff), int(s.Y()+yOff)
+	s.fn(buff, image.Rect(x, y, x+s.w, y+s.h))
+}
+
+func fill(buff draw.Image, r image.Rectangle, c color.Color) {
+	if c == nil {
+		return
+	}
+	draw.Draw(buff, r, image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+// outline draws a one pixel border just inside r.
+func outline(buff draw.Image, r image.Rectangle, c color.Color) {
+	fill(buff, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), c)
+	fill(buff, image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), c)
+	fill(buff, image.Rect(r.Min.X, r.Min.Y+1, r.Min.X+1, r.Max.Y-1), c)
+	fill(buff, image.Rect(r.Max.X-1, r.Min.Y+1, r.Max.X, r.Max.Y-1), c)
+}
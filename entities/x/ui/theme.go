@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"image/color"
+
+	"github.com/diakovliev/oak/v4/render"
+)
+
+// A Theme defines the appearance shared by all widgets of a UI.
+type Theme struct {
+	// Font is used for all text. If nil, render.DefaultFont is used.
+	Font *render.Font
+	// Background is drawn behind Panels and ScrollPanels.
+	Background color.Color
+	// Button is the color of buttons and dropdowns.
+	Button color.Color
+	// Track is the color of the unfilled parts of checkboxes, sliders and
+	// progress bars.
+	Track color.Color
+	// Accent is the color of the filled parts of checkboxes, sliders and
+	// progress bars.
+	Accent color.Color
+	// Focus is the color of the outline drawn around the focused widget.
+	Focus color.Color
+	// Padding is the space between a widget's edge and its text.
+	Padding float64
+	// Gap is the default space between elements of a stack.
+	Gap float64
+	// WidgetHeight is the height of buttons, checkboxes, sliders and
+	// dropdowns.
+	WidgetHeight float64
+	// Layers are the draw layers of the UI. Elements drawn over others are
+	// drawn with the last layer increased.
+	Layers []int
+}
+
+// DefaultTheme is a dark theme for UIs which do not need to match a
+// particular appearance.
+var DefaultTheme = Theme{
+	Background:   color.RGBA{30, 30, 36, 230},
+	Button:       color.RGBA{70, 70, 90, 255},
+	Track:        color.RGBA{50, 50, 60, 255},
+	Accent:       color.RGBA{90, 150, 230, 255},
+	Focus:        color.RGBA{255, 220, 100, 255},
+	Padding:      6,
+	Gap:          4,
+	WidgetHeight: 24,
+	Layers:       []int{0},
+}
+
+func (th *Theme) font() *render.Font {
+	if th.Font == nil {
+		th.Font = render.DefaultFont()
+	}
+	return th.Font
+}
+
+// layers returns the theme's layers, raised by depth.
+func (th *Theme) layers(depth int) []int {
+	ls := make([]int, len(th.Layers))
+	copy(ls, th.Layers)
+	if len(ls) != 0 {
+		ls[len(ls)-1] += depth
+	}
+	return ls
+}
+
+func (th *Theme) textWidth(s string) float64 {
+	return float64(th.font().MeasureString(s).Ceil())
+}
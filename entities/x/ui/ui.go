@@ -0,0 +1,194 @@
+// Package ui builds menus and other interfaces from a tree of layout
+// containers and widgets.
+//
+// A UI is described by Nodes: containers such as VStack, HStack, Pad and At
+// arrange their children, and widgets such as Button, Checkbox and Slider
+// create entities. Building a UI creates ordinary entities in the scene's
+// mouse tree, drawn according to a Theme, and lets the keyboard or a gamepad
+// move focus between widgets.
+package ui
+
+import (
+	"sync"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/entities"
+	"github.com/diakovliev/oak/v4/entities/x/btn/focus"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/render"
+	"github.com/diakovliev/oak/v4/scene"
+)
+
+// A Node is an element of a UI layout.
+type Node interface {
+	// MinSize returns the smallest size the node can be laid out in.
+	MinSize(th *Theme) floatgeom.Point2
+	// Build creates the node's entities, laid out within r.
+	Build(u *UI, r floatgeom.Rect2)
+}
+
+// Depths at which elements drawn over the rest of a UI are drawn.
+const (
+	popupDepth = 50
+	focusDepth = 100
+)
+
+// A UI is a built tree of nodes.
+type UI struct {
+	Theme Theme
+
+	ctx  *scene.Context
+	root Node
+	rect floatgeom.Rect2
+
+	entities  []*entities.Entity
+	bindings  []event.Binding
+	dropdowns []*DropdownNode
+
+	focus     *focus.Manager
+	focusLock sync.Mutex
+	// panels holds the scroll panel, if any, containing each focusable.
+	panels    map[focus.Focusable]*ScrollPanelNode
+	showFocus bool
+	focusR    render.Renderable
+
+	// While building, depth is how far above the theme's layers entities are
+	// drawn, and drawer draws them. Scroll panels replace drawer to draw
+	// their content themselves.
+	depth  int
+	drawer func(r render.Renderable, depth int)
+	panel  *ScrollPanelNode
+}
+
+// New builds root within r.
+func New(ctx *scene.Context, th Theme, r floatgeom.Rect2, root Node) *UI {
+	u := &UI{
+		Theme:  th,
+		ctx:    ctx,
+		root:   root,
+		rect:   r,
+		panels: make(map[focus.Focusable]*ScrollPanelNode),
+	}
+	u.bindNavigation()
+	u.build()
+	return u
+}
+
+func (u *UI) build() {
+	u.drawer = func(r render.Renderable, depth int) {
+		u.ctx.Draw(r, u.Theme.layers(depth)...)
+	}
+	u.root.Build(u, u.rect)
+	u.focusR = u.focusOutline()
+	u.ctx.Draw(u.focusR, u.Theme.layers(focusDepth)...)
+}
+
+// Context returns the scene context this UI was built in.
+func (u *UI) Context() *scene.Context {
+	return u.ctx
+}
+
+// Rect returns the area this UI is laid out in.
+func (u *UI) Rect() floatgeom.Rect2 {
+	return u.rect
+}
+
+// SetRect lays this UI out again within r. Widgets keep their state, but
+// their entities are recreated.
+func (u *UI) SetRect(r floatgeom.Rect2) {
+	u.destroyEntities()
+	u.rect = r
+	u.build()
+}
+
+// Entities returns the top level entities created by this UI's nodes.
+func (u *UI) Entities() []*entities.Entity {
+	return u.entities
+}
+
+// NewEntity creates an entity in the mouse tree for a node being built. The
+// entity is drawn, with its children drawn above it, and destroyed with the
+// UI.
+func (u *UI) NewEntity(r floatgeom.Rect2, rend render.Renderable, opts ...entities.Option) *entities.Entity {
+	opts = append([]entities.Option{
+		entities.WithRect(r),
+		entities.WithRenderable(rend),
+		entities.WithUseMouseTree(true),
+		entities.WithDrawLayers(nil),
+	}, opts...)
+	e := entities.New(u.ctx, opts...)
+	u.Add(e)
+	return e
+}
+
+// Add draws an entity created outside of NewEntity, which should not already
+// be drawn, as part of this UI.
+func (u *UI) Add(e *entities.Entity) {
+	u.entities = append(u.entities, e)
+	u.draw(e, u.depth)
+	if u.panel != nil {
+		u.panel.entities = append(u.panel.entities, e)
+	}
+}
+
+func (u *UI) draw(e *entities.Entity, depth int) {
+	if e.Renderable != nil {
+		u.drawer(e.Renderable, depth)
+	}
+	for _, c := range e.Children {
+		u.draw(c, depth+1)
+	}
+}
+
+// Destroy destroys all entities of this UI and stops responding to
+// navigation input.
+func (u *UI) Destroy() {
+	for _, b := range u.bindings {
+		b.Unbind()
+	}
+	u.bindings = nil
+	u.destroyEntities()
+	u.focus.Destroy()
+}
+
+func (u *UI) destroyEntities() {
+	for _, d := range u.dropdowns {
+		d.Close()
+	}
+	u.dropdowns = nil
+	for _, e := range u.entities {
+		u.destroy(e)
+	}
+	u.entities = nil
+	if u.focusR != nil {
+		u.focusR.Undraw()
+		u.focusR = nil
+	}
+	u.clearFocus()
+}
+
+// destroy acts like Entity.Destroy, but also destroys children and accepts
+// entities without renderables or collision spaces.
+func (u *UI) destroy(e *entities.Entity) {
+	for _, c := range e.Children {
+		u.destroy(c)
+	}
+	if e.Renderable != nil {
+		e.Renderable.Undraw()
+	}
+	if e.Tree != nil {
+		e.Tree.Remove(e.Space)
+	}
+	u.ctx.UnbindAllFrom(e.CallerID)
+}
+
+// childText returns options for a text child of a widget's entity, offset
+// from the entity's position.
+func childText(txt *render.Text, x, y float64) entities.Option {
+	return entities.WithChild(
+		entities.WithRenderable(txt),
+		entities.WithPosition(floatgeom.Point2{x, y}),
+		entities.WithDrawLayers(nil),
+		entities.WithWithoutCollision(true),
+	)
+}
@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/internal/scenetest"
+	"github.com/diakovliev/oak/v4/render"
+)
+
+func TestStack_Layout(t *testing.T) {
+	th := DefaultTheme
+	th.Gap = 10
+	s := VStack(Fixed(20, 10), Grow(1, Fixed(30, 10)), Grow(3, Fixed(10, 10))).WithAlign(AlignCenter)
+	if min := s.MinSize(&th); min != (floatgeom.Point2{30, 50}) {
+		t.Fatalf("unexpected min size %v", min)
+	}
+	rects := s.layout(&th, floatgeom.NewRect2(0, 0, 40, 90))
+	expected := []floatgeom.Rect2{
+		floatgeom.NewRect2(10, 0, 30, 10),
+		floatgeom.NewRect2(5, 20, 35, 40),
+		floatgeom.NewRect2(15, 50, 25, 90),
+	}
+	for i, r := range rects {
+		if r != expected[i] {
+			t.Fatalf("child %v: expected %v, got %v", i, expected[i], r)
+		}
+	}
+	rects = HStack(Fixed(10, 5), Spacer(), Fixed(10, 5)).WithGap(0).layout(&th, floatgeom.NewRect2(0, 0, 100, 20))
+	if rects[2] != floatgeom.NewRect2(90, 0, 100, 20) {
+		t.Fatalf("expected spacer to push last child to the end, got %v", rects[2])
+	}
+}
+
+func TestUI_Build(t *testing.T) {
+	ctx := scenetest.NewContext()
+	clicks := 0
+	button := Button("Play", func() { clicks++ })
+	check := Checkbox("Sound", true, nil)
+	slider := Slider(0, 10, 5, nil).WithStep(1)
+	drop := Dropdown([]string{"Low", "High"}, 0, nil)
+	root := At(Center, PadAll(8, VStack(
+		Label("Options"),
+		button,
+		check,
+		slider,
+		drop,
+		ProgressBar(func() float64 { return .5 }),
+	)))
+	u := New(ctx, DefaultTheme, floatgeom.NewRect2(0, 0, 640, 480), root)
+	if len(u.Entities()) != 6 {
+		t.Fatalf("expected 6 entities, got %v", len(u.Entities()))
+	}
+	br := button.Entity().Rect
+	if br.Min.X() <= 0 || br.Max.X() >= 640 || br.W() != u.Entities()[2].W() {
+		t.Fatalf("expected centered, stretched button, got %v", br)
+	}
+	if len(ctx.MouseTree.SearchIntersect(floatgeom.NewRect3WH(br.Min.X()+1, br.Min.Y()+1, 0, 1, 1, 1))) == 0 {
+		t.Fatalf("expected button to be in mouse tree")
+	}
+
+	u.FocusNext()
+	if u.Focused() != button {
+		t.Fatalf("expected button to be focused first")
+	}
+	u.Activate()
+	if clicks != 1 {
+		t.Fatalf("expected activation to click button")
+	}
+	u.FocusNext()
+	u.Activate()
+	if check.Checked() {
+		t.Fatalf("expected activation to toggle checkbox")
+	}
+	u.FocusNext()
+	u.adjust(1)
+	if slider.Value() != 6 {
+		t.Fatalf("expected adjusting to step slider, got %v", slider.Value())
+	}
+	u.FocusNext()
+	u.adjust(1)
+	if drop.Selected() != 1 {
+		t.Fatalf("expected adjusting to change dropdown option")
+	}
+	u.Activate()
+	if !drop.IsOpen() {
+		t.Fatalf("expected activation to open dropdown")
+	}
+	u.FocusNext()
+	if u.Focused() != button {
+		t.Fatalf("expected focus to wrap around")
+	}
+
+	u.Destroy()
+	if drop.IsOpen() {
+		t.Fatalf("expected destroy to close dropdown")
+	}
+	if len(ctx.MouseTree.SearchIntersect(floatgeom.NewRect3WH(0, 0, 0, 640, 480, 1))) != 0 {
+		t.Fatalf("expected destroy to remove all mouse spaces")
+	}
+}
+
+func TestScrollPanel(t *testing.T) {
+	ctx := scenetest.NewContext()
+	buttons := make([]Node, 10)
+	for i := range buttons {
+		buttons[i] = Button("Item", nil)
+	}
+	th := DefaultTheme
+	th.Gap = 0
+	th.WidgetHeight = 20
+	th.Padding = 0
+	th.Font = render.DefaultFont()
+	panel := ScrollPanel(100, 50, VStack(buttons...))
+	u := New(ctx, th, floatgeom.NewRect2(0, 0, 100, 50), panel)
+	visible := func() int {
+		return len(ctx.MouseTree.SearchIntersect(floatgeom.NewRect3WH(0, 0, 0, 100, 50, 1)))
+	}
+	// The panel and the three buttons intersecting it
+	if visible() != 4 {
+		t.Fatalf("expected 4 visible spaces, got %v", visible())
+	}
+	for i := 0; i < 5; i++ {
+		u.FocusNext()
+	}
+	last := buttons[4].(*ButtonNode).Entity().Rect
+	if last.Max.Y() > 50 || panel.Offset() != 50 {
+		t.Fatalf("expected focus to scroll button into view, got %v at offset %v", last, panel.Offset())
+	}
+	panel.ScrollBy(1000)
+	if panel.Offset() != 150 {
+		t.Fatalf("expected scrolling to stop at end of content, got %v", panel.Offset())
+	}
+}
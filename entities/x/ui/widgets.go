@@ -0,0 +1,339 @@
+package ui
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/entities"
+	"github.com/diakovliev/oak/v4/entities/x/btn"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/mouse"
+	"github.com/diakovliev/oak/v4/render"
+)
+
+// A LabelNode is a line of text.
+type LabelNode struct {
+	text string
+	txt  *render.Text
+}
+
+// Label creates a line of text.
+func Label(text string) *LabelNode {
+	return &LabelNode{text: text}
+}
+
+// SetText changes the label's text. The UI is not laid out again, so the
+// text should fit in the label's space.
+func (l *LabelNode) SetText(s string) {
+	l.text = s
+	if l.txt != nil {
+		l.txt.SetString(s)
+	}
+}
+
+// MinSize returns the size of the label's text.
+func (l *LabelNode) MinSize(th *Theme) floatgeom.Point2 {
+	return floatgeom.Point2{th.textWidth(l.text), th.font().Height()}
+}
+
+// Build creates an entity for the text, vertically centered in r.
+func (l *LabelNode) Build(u *UI, r floatgeom.Rect2) {
+	l.txt = u.Theme.font().NewText(l.text, 0, 0)
+	h := u.Theme.font().Height()
+	u.NewEntity(floatgeom.NewRect2WH(r.Min.X(), r.Min.Y()+(r.H()-h)/2, r.W(), h), l.txt)
+}
+
+// widgetHeight returns the height of a widget with text.
+func widgetHeight(th *Theme) float64 {
+	return math.Max(th.WidgetHeight, th.font().Height()+2*th.Padding)
+}
+
+// A ButtonNode is a clickable button with text.
+type ButtonNode struct {
+	text    string
+	onClick func()
+	ent     *entities.Entity
+}
+
+// Button creates a button calling onClick when it is clicked or activated.
+func Button(text string, onClick func()) *ButtonNode {
+	return &ButtonNode{text: text, onClick: onClick}
+}
+
+// MinSize returns the size of the button's text with padding.
+func (b *ButtonNode) MinSize(th *Theme) floatgeom.Point2 {
+	return floatgeom.Point2{th.textWidth(b.text) + 2*th.Padding, widgetHeight(th)}
+}
+
+// Build creates the button with btn.New.
+func (b *ButtonNode) Build(u *UI, r floatgeom.Rect2) {
+	th := &u.Theme
+	b.ent = btn.New(u.ctx,
+		btn.Pos(r.Min.X(), r.Min.Y()),
+		btn.Width(r.W()),
+		btn.Height(r.H()),
+		btn.Color(th.Button),
+		btn.Font(th.font()),
+		btn.Text(b.text),
+		btn.TxtOff((r.W()-th.textWidth(b.text))/2, (r.H()-th.font().Height())/2),
+		btn.Layers(),
+		btn.Click(func(*entities.Entity, *mouse.Event) event.Response {
+			u.Focus(b)
+			b.Activate()
+			return 0
+		}),
+	)
+	u.Add(b.ent)
+	u.AddFocusable(b)
+}
+
+// Entity returns the button's entity, once built.
+func (b *ButtonNode) Entity() *entities.Entity {
+	return b.ent
+}
+
+// FocusRect returns the button's area.
+func (b *ButtonNode) FocusRect() floatgeom.Rect2 {
+	return b.ent.Rect
+}
+
+// Activate calls the button's click function.
+func (b *ButtonNode) Activate() {
+	if b.onClick != nil {
+		b.onClick()
+	}
+}
+
+// Adjust does nothing.
+func (b *ButtonNode) Adjust(int) bool {
+	return false
+}
+
+// A CheckboxNode is a box which can be checked or unchecked, with a label.
+type CheckboxNode struct {
+	text     string
+	checked  bool
+	onChange func(bool)
+	ent      *entities.Entity
+}
+
+// Checkbox creates a checkbox calling onChange when it is toggled.
+func Checkbox(text string, checked bool, onChange func(bool)) *CheckboxNode {
+	return &CheckboxNode{text: text, checked: checked, onChange: onChange}
+}
+
+// Checked returns whether the box is checked.
+func (c *CheckboxNode) Checked() bool {
+	return c.checked
+}
+
+// SetChecked sets whether the box is checked, without calling the
+// checkbox's change function.
+func (c *CheckboxNode) SetChecked(checked bool) {
+	c.checked = checked
+}
+
+func (c *CheckboxNode) boxSize(th *Theme) float64 {
+	return math.Round(th.WidgetHeight * 2 / 3)
+}
+
+// MinSize returns the size of the box and its label.
+func (c *CheckboxNode) MinSize(th *Theme) floatgeom.Point2 {
+	return floatgeom.Point2{c.boxSize(th) + th.Padding + th.textWidth(c.text), widgetHeight(th)}
+}
+
+// Build creates an entity drawing the box, with the label as a child.
+func (c *CheckboxNode) Build(u *UI, r floatgeom.Rect2) {
+	th := &u.Theme
+	box := int(c.boxSize(th))
+	track, accent := th.Track, th.Accent
+	h := r.H()
+	rend := newShape(0, 0, int(r.W()), int(h), func(buff draw.Image, r image.Rectangle) {
+		y := r.Min.Y + (r.Dy()-box)/2
+		br := image.Rect(r.Min.X, y, r.Min.X+box, y+box)
+		fill(buff, br, track)
+		if c.checked {
+			fill(buff, br.Inset(box/4), accent)
+		}
+	})
+	txt := th.font().NewText(c.text, 0, 0)
+	c.ent = u.NewEntity(r, rend, childText(txt, float64(box)+th.Padding, (h-th.font().Height())/2))
+	event.Bind(u.ctx, mouse.ClickOn, c.ent, func(*entities.Entity, *mouse.Event) event.Response {
+		u.Focus(c)
+		c.Activate()
+		return 0
+	})
+	mouse.PhaseCollision(c.ent.Space, u.ctx.Handler)
+	u.AddFocusable(c)
+}
+
+// FocusRect returns the checkbox's area.
+func (c *CheckboxNode) FocusRect() floatgeom.Rect2 {
+	return c.ent.Rect
+}
+
+// Activate toggles the checkbox.
+func (c *CheckboxNode) Activate() {
+	c.checked = !c.checked
+	if c.onChange != nil {
+		c.onChange(c.checked)
+	}
+}
+
+// Adjust does nothing.
+func (c *CheckboxNode) Adjust(int) bool {
+	return false
+}
+
+// A SliderNode chooses a value in a range by dragging a knob along a track.
+type SliderNode struct {
+	min, max, value, step float64
+	onChange              func(float64)
+	ent                   *entities.Entity
+	dragging              bool
+}
+
+// Slider creates a slider from min to max calling onChange when its value is
+// changed.
+func Slider(min, max, value float64, onChange func(float64)) *SliderNode {
+	s := &SliderNode{min: min, max: max, onChange: onChange}
+	s.value = s.clamp(value)
+	return s
+}
+
+// WithStep causes the slider's value to snap to multiples of step from its
+// minimum, and sets how far adjusting the slider with the keyboard or a
+// gamepad moves it. Without a step, adjusting moves by a twentieth of its
+// range.
+func (s *SliderNode) WithStep(step float64) *SliderNode {
+	s.step = step
+	return s
+}
+
+// Value returns the slider's value.
+func (s *SliderNode) Value() float64 {
+	return s.value
+}
+
+// SetValue sets the slider's value, without calling its change function.
+func (s *SliderNode) SetValue(v float64) {
+	s.value = s.clamp(v)
+}
+
+func (s *SliderNode) clamp(v float64) float64 {
+	if s.step > 0 {
+		v = s.min + math.Round((v-s.min)/s.step)*s.step
+	}
+	return math.Max(s.min, math.Min(s.max, v))
+}
+
+func (s *SliderNode) set(v float64) {
+	v = s.clamp(v)
+	if v == s.value {
+		return
+	}
+	s.value = v
+	if s.onChange != nil {
+		s.onChange(v)
+	}
+}
+
+func (s *SliderNode) fraction() float64 {
+	if s.max == s.min {
+		return 0
+	}
+	return (s.value - s.min) / (s.max - s.min)
+}
+
+func (s *SliderNode) setFromX(x float64) {
+	r := s.ent.Rect
+	frac := 0.0
+	if r.W() > 0 {
+		frac = (x - r.Min.X()) / r.W()
+	}
+	s.set(s.min + frac*(s.max-s.min))
+}
+
+// MinSize returns a default slider size.
+func (s *SliderNode) MinSize(th *Theme) floatgeom.Point2 {
+	return floatgeom.Point2{100, th.WidgetHeight}
+}
+
+// Build creates an entity drawing the slider's track and knob.
+func (s *SliderNode) Build(u *UI, r floatgeom.Rect2) {
+	track, accent := u.Theme.Track, u.Theme.Accent
+	rend := newShape(0, 0, int(r.W()), int(r.H()), func(buff draw.Image, r image.Rectangle) {
+		th := r.Dy() / 3
+		y := r.Min.Y + (r.Dy()-th)/2
+		knobX := r.Min.X + int(s.fraction()*float64(r.Dx()))
+		fill(buff, image.Rect(r.Min.X, y, r.Max.X, y+th), track)
+		fill(buff, image.Rect(r.Min.X, y, knobX, y+th), accent)
+		knobW := r.Dy() / 3
+		fill(buff, image.Rect(knobX-knobW/2, r.Min.Y+r.Dy()/6, knobX+knobW-knobW/2, r.Max.Y-r.Dy()/6), accent)
+	})
+	s.ent = u.NewEntity(r, rend)
+	event.Bind(u.ctx, mouse.PressOn, s.ent, func(_ *entities.Entity, me *mouse.Event) event.Response {
+		u.Focus(s)
+		s.dragging = true
+		s.setFromX(me.X())
+		return 0
+	})
+	event.Bind(u.ctx, mouse.Drag, s.ent, func(_ *entities.Entity, me *mouse.Event) event.Response {
+		if s.dragging {
+			s.setFromX(me.X())
+		}
+		return 0
+	})
+	event.Bind(u.ctx, mouse.Release, s.ent, func(*entities.Entity, *mouse.Event) event.Response {
+		s.dragging = false
+		return 0
+	})
+	mouse.PhaseCollision(s.ent.Space, u.ctx.Handler)
+	u.AddFocusable(s)
+}
+
+// FocusRect returns the slider's area.
+func (s *SliderNode) FocusRect() floatgeom.Rect2 {
+	return s.ent.Rect
+}
+
+// Activate does nothing.
+func (s *SliderNode) Activate() {}
+
+// Adjust moves the slider one step.
+func (s *SliderNode) Adjust(dir int) bool {
+	step := s.step
+	if step <= 0 {
+		step = (s.max - s.min) / 20
+	}
+	s.set(s.value + float64(dir)*step)
+	return true
+}
+
+// A ProgressBarNode shows how complete something is.
+type ProgressBarNode struct {
+	progress func() float64
+}
+
+// ProgressBar creates a bar filled to the fraction, from 0 to 1, returned by
+// progress. Progress is called each time the bar is drawn.
+func ProgressBar(progress func() float64) *ProgressBarNode {
+	return &ProgressBarNode{progress: progress}
+}
+
+// MinSize returns a default progress bar size.
+func (p *ProgressBarNode) MinSize(th *Theme) floatgeom.Point2 {
+	return floatgeom.Point2{100, math.Round(th.WidgetHeight / 2)}
+}
+
+// Build creates an entity drawing the bar.
+func (p *ProgressBarNode) Build(u *UI, r floatgeom.Rect2) {
+	track, accent := u.Theme.Track, u.Theme.Accent
+	u.NewEntity(r, newShape(0, 0, int(r.W()), int(r.H()), func(buff draw.Image, r image.Rectangle) {
+		frac := math.Max(0, math.Min(1, p.progress()))
+		fill(buff, r, track)
+		fill(buff, image.Rect(r.Min.X, r.Min.Y, r.Min.X+int(frac*float64(r.Dx())), r.Max.Y), accent)
+	}))
+}
@@ -0,0 +1,5 @@
+// Package gesture recognizes taps, long presses, swipes, pinches and rotations
+// from pointer input. A window with Config.GestureSupport set feeds its mouse
+// and touch input to a Recognizer; mouse input can only produce single pointer
+// gestures.
+package gesture
@@ -0,0 +1,89 @@
+package gesture
+
+import (
+	"time"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/event"
+)
+
+var (
+	// Tap is triggered when a pointer is pressed and released in place.
+	Tap = event.RegisterEvent[Event]()
+	// DoubleTap is triggered when a tap follows another tap nearby, soon after.
+	// The second tap still triggers Tap.
+	DoubleTap = event.RegisterEvent[Event]()
+	// LongPress is triggered when a pointer is held in place.
+	LongPress = event.RegisterEvent[Event]()
+	// Swipe is triggered when a pointer is quickly dragged and released.
+	Swipe = event.RegisterEvent[SwipeEvent]()
+	// Pinch is triggered when two pointers move towards or away from each other.
+	Pinch = event.RegisterEvent[PinchEvent]()
+	// Rotate is triggered when two pointers rotate around each other.
+	Rotate = event.RegisterEvent[RotateEvent]()
+)
+
+// An Event is passed to tap and long press bindings.
+type Event struct {
+	floatgeom.Point2
+	// Pointer identifies the finger, or mouse, which made the gesture.
+	Pointer int
+}
+
+// A Direction is the direction a swipe was made in.
+type Direction uint8
+
+// Direction values
+const (
+	Up Direction = iota
+	Down
+	Left
+	Right
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Up:
+		return "Up"
+	case Down:
+		return "Down"
+	case Left:
+		return "Left"
+	case Right:
+		return "Right"
+	}
+	return ""
+}
+
+// A SwipeEvent is passed to Swipe bindings.
+type SwipeEvent struct {
+	Start, End floatgeom.Point2
+	Pointer    int
+	// Direction is the axis aligned direction the swipe mostly travelled in.
+	Direction Direction
+	// Velocity is the swipe's average velocity in pixels per second.
+	Velocity floatgeom.Point2
+	Duration time.Duration
+}
+
+// A PinchEvent is passed to Pinch bindings.
+type PinchEvent struct {
+	// Center is the midpoint between the two pointers.
+	Center floatgeom.Point2
+	// Scale is the distance between the pointers relative to their
+	// distance when the pinch began.
+	Scale float64
+	// Delta is the change in Scale since the last Pinch event.
+	Delta float64
+}
+
+// A RotateEvent is passed to Rotate bindings.
+type RotateEvent struct {
+	// Center is the midpoint between the two pointers.
+	Center floatgeom.Point2
+	// Angle is the rotation in radians, clockwise on screen, of the pointers
+	// since the rotation began.
+	Angle float64
+	// Delta is the change in Angle since the last Rotate event.
+	Delta float64
+}
@@ -0,0 +1,283 @@
+package gesture
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/event"
+)
+
+// MousePointer is the pointer ID used for mouse input.
+const MousePointer = -1
+
+// A Recognizer converts pointer presses, moves and releases into gesture
+// events. Its thresholds may be changed before it receives input.
+type Recognizer struct {
+	// TapDistance is how far, in pixels, a pointer can move and still tap or
+	// long press.
+	TapDistance float64
+	// TapDuration is how long a pointer can be held and still tap.
+	TapDuration time.Duration
+	// DoubleTapInterval is the most time between two taps of a double tap.
+	DoubleTapInterval time.Duration
+	// LongPressDuration is how long a pointer must be held to long press.
+	LongPressDuration time.Duration
+	// SwipeDistance is how far, in pixels, a pointer must move to swipe.
+	SwipeDistance float64
+	// SwipeVelocity is how fast, in pixels per second, a pointer must move to
+	// swipe.
+	SwipeVelocity float64
+
+	handler event.Handler
+
+	mutex    sync.Mutex
+	pointers map[int]*pointer
+	// order holds the IDs of pressed pointers, in the order they were
+	// pressed. The first two make up pinches and rotations.
+	order   []int
+	lastTap *tap
+	multi   *multiTouch
+}
+
+type pointer struct {
+	start, pos floatgeom.Point2
+	startTime  time.Time
+	// cancelled pointers will not tap, long press or swipe, because they have
+	// moved too far or taken part in a multi touch gesture.
+	cancelled bool
+	// moved pointers have left their tap distance at some point, so they will
+	// not tap or long press even if they return, but may still swipe.
+	moved     bool
+	longPress *time.Timer
+}
+
+type tap struct {
+	pos floatgeom.Point2
+	at  time.Time
+}
+
+type multiTouch struct {
+	startDist, startAngle float64
+	scale, angle          float64
+}
+
+// NewRecognizer creates a recognizer which triggers events on h, with default
+// thresholds.
+func NewRecognizer(h event.Handler) *Recognizer {
+	return &Recognizer{
+		TapDistance:       10,
+		TapDuration:       300 * time.Millisecond,
+		DoubleTapInterval: 300 * time.Millisecond,
+		LongPressDuration: 500 * time.Millisecond,
+		SwipeDistance:     50,
+		SwipeVelocity:     200,
+		handler:           h,
+		pointers:          make(map[int]*pointer),
+	}
+}
+
+// Press starts tracking a pointer pressed at p.
+func (r *Recognizer) Press(id int, p floatgeom.Point2, at time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if old, ok := r.pointers[id]; ok {
+		old.stop()
+		r.remove(id)
+	}
+	ptr := &pointer{start: p, pos: p, startTime: at}
+	r.pointers[id] = ptr
+	r.order = append(r.order, id)
+	if len(r.order) == 2 {
+		for _, id := range r.order {
+			r.pointers[id].cancel()
+		}
+		r.startMulti()
+		return
+	}
+	if len(r.order) > 2 {
+		ptr.cancelled = true
+		return
+	}
+	ptr.longPress = time.AfterFunc(r.LongPressDuration, func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		if r.pointers[id] != ptr || ptr.cancelled || ptr.moved {
+			return
+		}
+		ptr.cancelled = true
+		event.TriggerOn(r.handler, LongPress, Event{Point2: ptr.pos, Pointer: id})
+	})
+}
+
+// Move updates the position of a pressed pointer. Moves of pointers which are
+// not pressed are ignored.
+func (r *Recognizer) Move(id int, p floatgeom.Point2, at time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	ptr, ok := r.pointers[id]
+	if !ok {
+		return
+	}
+	ptr.pos = p
+	if p.Distance(ptr.start) > r.TapDistance {
+		ptr.moved = true
+		ptr.stop()
+	}
+	if r.multi != nil && r.isMulti(id) {
+		r.moveMulti()
+	}
+}
+
+// Release stops tracking a pointer, triggering any gesture it completed.
+func (r *Recognizer) Release(id int, p floatgeom.Point2, at time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	ptr, ok := r.pointers[id]
+	if !ok {
+		return
+	}
+	ptr.pos = p
+	ptr.stop()
+	if r.multi != nil && r.isMulti(id) {
+		r.moveMulti()
+		r.multi = nil
+	}
+	r.remove(id)
+	if ptr.cancelled {
+		return
+	}
+	elapsed := at.Sub(ptr.startTime)
+	dist := p.Distance(ptr.start)
+	if dist <= r.TapDistance {
+		if ptr.moved || elapsed > r.TapDuration {
+			return
+		}
+		ev := Event{Point2: p, Pointer: id}
+		event.TriggerOn(r.handler, Tap, ev)
+		if r.lastTap != nil && at.Sub(r.lastTap.at) <= r.DoubleTapInterval &&
+			p.Distance(r.lastTap.pos) <= r.TapDistance {
+			r.lastTap = nil
+			event.TriggerOn(r.handler, DoubleTap, ev)
+			return
+		}
+		r.lastTap = &tap{pos: p, at: at}
+		return
+	}
+	if dist < r.SwipeDistance || elapsed <= 0 {
+		return
+	}
+	delta := p.Sub(ptr.start)
+	velocity := delta.DivConst(elapsed.Seconds())
+	if velocity.Magnitude() < r.SwipeVelocity {
+		return
+	}
+	event.TriggerOn(r.handler, Swipe, SwipeEvent{
+		Start:     ptr.start,
+		End:       p,
+		Pointer:   id,
+		Direction: direction(delta),
+		Velocity:  velocity,
+		Duration:  elapsed,
+	})
+}
+
+// Reset forgets all pressed pointers, as if they were released without
+// completing any gesture.
+func (r *Recognizer) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, ptr := range r.pointers {
+		ptr.stop()
+	}
+	r.pointers = make(map[int]*pointer)
+	r.order = nil
+	r.multi = nil
+	r.lastTap = nil
+}
+
+func (p *pointer) stop() {
+	if p.longPress != nil {
+		p.longPress.Stop()
+	}
+}
+
+func (p *pointer) cancel() {
+	p.cancelled = true
+	p.stop()
+}
+
+func (r *Recognizer) remove(id int) {
+	delete(r.pointers, id)
+	for i, id2 := range r.order {
+		if id2 == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *Recognizer) isMulti(id int) bool {
+	return len(r.order) >= 2 && (r.order[0] == id || r.order[1] == id)
+}
+
+func (r *Recognizer) pair() (floatgeom.Point2, floatgeom.Point2) {
+	return r.pointers[r.order[0]].pos, r.pointers[r.order[1]].pos
+}
+
+func (r *Recognizer) startMulti() {
+	a, b := r.pair()
+	r.multi = &multiTouch{
+		startDist:  a.Distance(b),
+		startAngle: angle(a, b),
+		scale:      1,
+	}
+}
+
+func (r *Recognizer) moveMulti() {
+	a, b := r.pair()
+	center := a.Add(b).DivConst(2)
+	if r.multi.startDist > 0 {
+		scale := a.Distance(b) / r.multi.startDist
+		if scale != r.multi.scale {
+			delta := scale - r.multi.scale
+			r.multi.scale = scale
+			event.TriggerOn(r.handler, Pinch, PinchEvent{Center: center, Scale: scale, Delta: delta})
+		}
+	}
+	ang := normalizeAngle(angle(a, b) - r.multi.startAngle)
+	if ang != r.multi.angle {
+		delta := normalizeAngle(ang - r.multi.angle)
+		r.multi.angle = ang
+		event.TriggerOn(r.handler, Rotate, RotateEvent{Center: center, Angle: ang, Delta: delta})
+	}
+}
+
+func angle(a, b floatgeom.Point2) float64 {
+	return math.Atan2(b.Y()-a.Y(), b.X()-a.X())
+}
+
+// normalizeAngle wraps a into (-pi, pi].
+func normalizeAngle(a float64) float64 {
+	for a > math.Pi {
+		a -= 2 * math.Pi
+	}
+	for a <= -math.Pi {
+		a += 2 * math.Pi
+	}
+	return a
+}
+
+func direction(delta floatgeom.Point2) Direction {
+	if math.Abs(delta.X()) >= math.Abs(delta.Y()) {
+		if delta.X() < 0 {
+			return Left
+		}
+		return Right
+	}
+	if delta.Y() < 0 {
+		return Up
+	}
+	return Down
+}
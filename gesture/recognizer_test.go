@@ -0,0 +1,140 @@
+package gesture
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/event"
+)
+
+func listen[T any](t *testing.T, h event.Handler, ev event.EventID[T]) chan T {
+	t.Helper()
+	ch := make(chan T, 10)
+	<-event.GlobalBind(h, ev, func(v T) event.Response {
+		ch <- v
+		return 0
+	}).Bound
+	return ch
+}
+
+func expect[T any](t *testing.T, ch chan T, name string) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatalf("expected %v event", name)
+	}
+	var v T
+	return v
+}
+
+func expectNone[T any](t *testing.T, ch chan T, name string) {
+	t.Helper()
+	select {
+	case <-ch:
+		t.Fatalf("unexpected %v event", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRecognizer_Tap(t *testing.T) {
+	h := event.NewBus(event.NewCallerMap())
+	taps := listen(t, h, Tap)
+	doubles := listen(t, h, DoubleTap)
+	r := NewRecognizer(h)
+	now := time.Now()
+	p := floatgeom.Point2{10, 10}
+	r.Press(MousePointer, p, now)
+	r.Release(MousePointer, p.Add(floatgeom.Point2{2, 0}), now.Add(50*time.Millisecond))
+	if ev := expect(t, taps, "tap"); ev.Pointer != MousePointer {
+		t.Fatalf("expected mouse pointer, got %v", ev.Pointer)
+	}
+	expectNone(t, doubles, "double tap")
+	r.Press(MousePointer, p, now.Add(100*time.Millisecond))
+	r.Release(MousePointer, p, now.Add(150*time.Millisecond))
+	expect(t, taps, "tap")
+	expect(t, doubles, "double tap")
+
+	// Holding too long is not a tap
+	r.Press(MousePointer, p, now.Add(time.Second))
+	r.Release(MousePointer, p, now.Add(2*time.Second))
+	expectNone(t, taps, "tap")
+
+	// Moving away and back is not a tap
+	r.Press(MousePointer, p, now.Add(3*time.Second))
+	r.Move(MousePointer, p.Add(floatgeom.Point2{30, 0}), now.Add(3*time.Second+20*time.Millisecond))
+	r.Move(MousePointer, p, now.Add(3*time.Second+40*time.Millisecond))
+	r.Release(MousePointer, p, now.Add(3*time.Second+60*time.Millisecond))
+	expectNone(t, taps, "tap")
+}
+
+func TestRecognizer_LongPress(t *testing.T) {
+	h := event.NewBus(event.NewCallerMap())
+	presses := listen(t, h, LongPress)
+	taps := listen(t, h, Tap)
+	r := NewRecognizer(h)
+	r.LongPressDuration = 20 * time.Millisecond
+	now := time.Now()
+	r.Press(0, floatgeom.Point2{5, 5}, now)
+	if ev := expect(t, presses, "long press"); ev.Point2 != (floatgeom.Point2{5, 5}) {
+		t.Fatalf("unexpected long press position %v", ev.Point2)
+	}
+	r.Release(0, floatgeom.Point2{5, 5}, now.Add(10*time.Millisecond))
+	expectNone(t, taps, "tap")
+
+	// Moving cancels a long press
+	r.Press(0, floatgeom.Point2{5, 5}, now)
+	r.Move(0, floatgeom.Point2{50, 5}, now)
+	expectNone(t, presses, "long press")
+	r.Reset()
+}
+
+func TestRecognizer_Swipe(t *testing.T) {
+	h := event.NewBus(event.NewCallerMap())
+	swipes := listen(t, h, Swipe)
+	r := NewRecognizer(h)
+	now := time.Now()
+	r.Press(1, floatgeom.Point2{100, 100}, now)
+	r.Move(1, floatgeom.Point2{100, 50}, now.Add(50*time.Millisecond))
+	r.Release(1, floatgeom.Point2{110, 0}, now.Add(100*time.Millisecond))
+	ev := expect(t, swipes, "swipe")
+	if ev.Direction != Up {
+		t.Fatalf("expected swipe up, got %v", ev.Direction)
+	}
+	if ev.Velocity != (floatgeom.Point2{100, -1000}) {
+		t.Fatalf("unexpected velocity %v", ev.Velocity)
+	}
+
+	// Slow drags are not swipes
+	r.Press(1, floatgeom.Point2{100, 100}, now)
+	r.Release(1, floatgeom.Point2{200, 100}, now.Add(time.Second))
+	expectNone(t, swipes, "swipe")
+}
+
+func TestRecognizer_PinchRotate(t *testing.T) {
+	h := event.NewBus(event.NewCallerMap())
+	pinches := listen(t, h, Pinch)
+	rotates := listen(t, h, Rotate)
+	taps := listen(t, h, Tap)
+	r := NewRecognizer(h)
+	now := time.Now()
+	r.Press(0, floatgeom.Point2{0, 0}, now)
+	r.Press(1, floatgeom.Point2{10, 0}, now)
+	r.Move(1, floatgeom.Point2{20, 0}, now)
+	ev := expect(t, pinches, "pinch")
+	if ev.Scale != 2 || ev.Delta != 1 || ev.Center != (floatgeom.Point2{10, 0}) {
+		t.Fatalf("unexpected pinch %+v", ev)
+	}
+	expectNone(t, rotates, "rotate")
+	r.Move(1, floatgeom.Point2{0, 20}, now)
+	rot := expect(t, rotates, "rotate")
+	if math.Abs(rot.Angle-math.Pi/2) > 1e-9 {
+		t.Fatalf("expected quarter turn, got %v", rot.Angle)
+	}
+	r.Release(1, floatgeom.Point2{0, 20}, now)
+	r.Release(0, floatgeom.Point2{0, 0}, now)
+	expectNone(t, taps, "tap")
+}
@@ -11,6 +11,7 @@ require (
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20231124074035-2de0cf0c80af // osx, shiny
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/hajimehoshi/go-mp3 v0.3.4
+	github.com/jfreymuth/oggvorbis v1.0.5 // audio
 	github.com/jfreymuth/pulse v0.1.0 // linux, audio
 	github.com/oakmound/alsa v0.0.2 // linux, audio
 	github.com/oakmound/libudev v0.2.1 // linux, joystick
@@ -25,5 +26,6 @@ require (
 
 require (
 	github.com/eaburns/bit v0.0.0-20131029213740-7bd5cd37375d // indirect
+	github.com/jfreymuth/vorbis v1.0.2 // indirect
 	golang.org/x/exp/shiny v0.0.0-20231219180239-dc181d75b848 // indirect
 )
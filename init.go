@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/diakovliev/oak/v4/dlog"
+	"github.com/diakovliev/oak/v4/gesture"
 	"github.com/diakovliev/oak/v4/oakerr"
 	"github.com/diakovliev/oak/v4/scene"
 	"github.com/diakovliev/oak/v4/timing"
@@ -61,6 +62,10 @@ func (w *Window) Init(firstScene string, configOptions ...ConfigOption) error {
 		trackJoystickChanges(w.eventHandler)
 	}
 
+	if w.config.GestureSupport {
+		w.gestures = gesture.NewRecognizer(w.eventHandler)
+	}
+
 	if !w.config.SkipRNGSeed {
 		// seed math/rand with time.Now, useful for minimal examples
 		//that would tend to forget to do this.
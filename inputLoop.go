@@ -1,8 +1,12 @@
 package oak
 
 import (
+	"time"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
 	"github.com/diakovliev/oak/v4/alg/intgeom"
 	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/gesture"
 	"github.com/diakovliev/oak/v4/timing"
 
 	"github.com/diakovliev/oak/v4/dlog"
@@ -12,6 +16,7 @@ import (
 	"golang.org/x/mobile/event/lifecycle"
 	"golang.org/x/mobile/event/mouse"
 	"golang.org/x/mobile/event/size"
+	"golang.org/x/mobile/event/touch"
 )
 
 // The following block defines events generated by oak during scene execution
@@ -96,13 +101,38 @@ func (w *Window) inputLoop() {
 			// render and collision space. I.e. if the viewport is at 0, the mouse's
 			// position is exactly the same as the position of a visible entity
 			// on screen. When not at zero, the offset will be exactly the viewport.
-			mevent := omouse.NewEvent(
-				float64((((e.X - float32(w.windowRect.Min.X)) / float32(w.windowRect.Max.X-w.windowRect.Min.X)) * float32(w.ScreenWidth))),
-				float64((((e.Y - float32(w.windowRect.Min.Y)) / float32(w.windowRect.Max.Y-w.windowRect.Min.Y)) * float32(w.ScreenHeight))),
-				button,
-				ev,
-			)
+			pt := w.screenPoint(e.X, e.Y)
+			mevent := omouse.NewEvent(pt.X(), pt.Y(), button, ev)
 			w.TriggerMouseEvent(mevent)
+			if w.gestures != nil && !w.touchInput {
+				switch {
+				case e.Direction == mouse.DirNone:
+					w.gestures.Move(gesture.MousePointer, pt, time.Now())
+				case e.Button != mouse.ButtonLeft:
+				case e.Direction == mouse.DirPress:
+					w.gestures.Press(gesture.MousePointer, pt, time.Now())
+				case e.Direction == mouse.DirRelease:
+					w.gestures.Release(gesture.MousePointer, pt, time.Now())
+				}
+			}
+
+		// Touch events are only used to recognize gestures; drivers which
+		// produce them also emulate mouse events for each touch.
+		case touch.Event:
+			if w.gestures == nil {
+				continue
+			}
+			w.touchInput = true
+			pt := w.screenPoint(e.X, e.Y)
+			id := int(e.Sequence)
+			switch e.Type {
+			case touch.TypeBegin:
+				w.gestures.Press(id, pt, time.Now())
+			case touch.TypeMove:
+				w.gestures.Move(id, pt, time.Now())
+			case touch.TypeEnd:
+				w.gestures.Release(id, pt, time.Now())
+			}
 
 		// Size events update what we scale the screen to
 		case size.Event:
@@ -112,6 +142,15 @@ func (w *Window) inputLoop() {
 	}
 }
 
+// screenPoint converts a position on the OS window to a position on the
+// screen buffer.
+func (w *Window) screenPoint(x, y float32) floatgeom.Point2 {
+	return floatgeom.Point2{
+		float64((((x - float32(w.windowRect.Min.X)) / float32(w.windowRect.Max.X-w.windowRect.Min.X)) * float32(w.ScreenWidth))),
+		float64((((y - float32(w.windowRect.Min.Y)) / float32(w.windowRect.Max.Y-w.windowRect.Min.Y)) * float32(w.ScreenHeight))),
+	}
+}
+
 // TriggerKeyDown triggers a software-emulated keypress.
 // This should be used cautiously when the keyboard is in use.
 // From the perspective of the event handler this is indistinguishable
@@ -153,6 +192,7 @@ func (w *Window) TriggerMouseEvent(mevent omouse.Event) {
 		w.Propagate(on, mevent)
 	}
 	event.TriggerOn(w.eventHandler, mevent.EventType, &mevent)
+	w.trackCursor(mevent)
 
 	if onOk {
 		rel, ok := omouse.EventRelative(on)
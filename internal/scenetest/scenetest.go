@@ -0,0 +1,28 @@
+// Package scenetest provides scene contexts for tests that run without a window.
+package scenetest
+
+import (
+	"context"
+
+	"github.com/diakovliev/oak/v4/collision"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/key"
+	"github.com/diakovliev/oak/v4/render"
+	"github.com/diakovliev/oak/v4/scene"
+)
+
+// NewContext returns a scene context with its own event bus, draw stack, keyboard state and
+// collision trees, and no window.
+func NewContext() *scene.Context {
+	cm := event.NewCallerMap()
+	ks := key.NewState()
+	return &scene.Context{
+		Context:       context.Background(),
+		CallerMap:     cm,
+		Handler:       event.NewBus(cm),
+		DrawStack:     render.NewDrawStack(render.NewDynamicHeap()),
+		State:         &ks,
+		MouseTree:     collision.NewTree(),
+		CollisionTree: collision.NewTree(),
+	}
+}
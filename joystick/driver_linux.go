@@ -2,10 +2,13 @@ package joystick
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
+	"unsafe"
 
 	"github.com/diakovliev/oak/v4/dlog"
 	"github.com/diakovliev/oak/v4/event"
@@ -18,10 +21,11 @@ import (
 
 	"github.com/oakmound/libudev"
 	"github.com/oakmound/libudev/types"
+	"golang.org/x/sys/unix"
 )
 
-// This has all been tested with wired xbox 360 controllers.
-// Todo: get more controllers, test with more controllers.
+// This has all been tested with wired xbox 360 controllers. Other controllers
+// are normalized if DefaultMappings has a Mapping for their GUID.
 
 func newJoystick(devName string, id uint32) *Joystick {
 	return &Joystick{
@@ -45,6 +49,19 @@ type osJoystick struct {
 	sync.Mutex
 	quit         chan struct{}
 	disconnected bool
+
+	// raw and axisMap are used to apply a Mapping to the joystick's input.
+	raw     RawState
+	axisMap []rawAxis
+}
+
+// A rawAxis describes how an axis reported by the joystick API is reported in
+// a RawState. Like SDL, hats are split out from other axes.
+type rawAxis struct {
+	// hat is the hat this axis is half of, or -1 if it is not a hat.
+	hat   int
+	hatY  bool
+	index int
 }
 
 func osinit() error {
@@ -61,6 +78,20 @@ type jevent struct {
 const (
 	axisType   = 2
 	buttonType = 1
+	// initType is set on events describing the joystick's initial state.
+	initType = 0x80
+)
+
+// joystick API ioctls, from linux/joystick.h
+const (
+	jsiocgaxes  = 0x80016a11
+	jsiocgaxmap = 0x80406a32
+)
+
+// Hat axis codes, from linux/input-event-codes.h
+const (
+	absHat0X = 0x10
+	absHat3Y = 0x17
 )
 
 var (
@@ -83,6 +114,7 @@ func (j *Joystick) prepare() error {
 	var err error
 	j.fh, err = os.Open(j.devName)
 	if err == nil {
+		j.axisMap = readAxisMap(j.fh)
 		go func(j *Joystick) {
 			// Read events continually
 			e := &jevent{}
@@ -98,6 +130,14 @@ func (j *Joystick) prepare() error {
 					return
 				}
 				j.Lock()
+				typ := e.Type &^ initType
+				if m, ok := j.mapping(); ok {
+					j.updateRaw(typ, e)
+					m.Apply(j.raw, &j.cache)
+					j.cache.Frame = e.Time
+					j.Unlock()
+					continue
+				}
 				switch e.Type {
 				case axisType:
 					switch e.Number {
@@ -151,6 +191,92 @@ func (j *Joystick) prepare() error {
 	return err
 }
 
+func (j *Joystick) mapping() (*Mapping, bool) {
+	if j.guid == "" {
+		return nil, false
+	}
+	return DefaultMappings.Get(j.guid)
+}
+
+// readAxisMap asks the joystick API which axes of a joystick are hats.
+func readAxisMap(fh *os.File) []rawAxis {
+	var count uint8
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, fh.Fd(), jsiocgaxes, uintptr(unsafe.Pointer(&count))); errno != 0 {
+		return nil
+	}
+	var codes [64]uint8
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, fh.Fd(), jsiocgaxmap, uintptr(unsafe.Pointer(&codes[0]))); errno != 0 {
+		return nil
+	}
+	axes := make([]rawAxis, count)
+	var next int
+	for i := range axes {
+		c := codes[i]
+		if c >= absHat0X && c <= absHat3Y {
+			axes[i] = rawAxis{hat: int(c-absHat0X) / 2, hatY: (c-absHat0X)%2 == 1}
+			continue
+		}
+		axes[i] = rawAxis{hat: -1, index: next}
+		next++
+	}
+	return axes
+}
+
+func (j *Joystick) updateRaw(typ uint8, e *jevent) {
+	n := int(e.Number)
+	switch typ {
+	case buttonType:
+		for len(j.raw.Buttons) <= n {
+			j.raw.Buttons = append(j.raw.Buttons, false)
+		}
+		j.raw.Buttons[n] = e.Value != 0
+	case axisType:
+		ax := rawAxis{hat: -1, index: n}
+		if n < len(j.axisMap) {
+			ax = j.axisMap[n]
+		}
+		if ax.hat < 0 {
+			for len(j.raw.Axes) <= ax.index {
+				j.raw.Axes = append(j.raw.Axes, 0)
+			}
+			j.raw.Axes[ax.index] = e.Value
+			return
+		}
+		for len(j.raw.Hats) <= ax.hat {
+			j.raw.Hats = append(j.raw.Hats, 0)
+		}
+		neg, pos := HatLeft, HatRight
+		if ax.hatY {
+			neg, pos = HatUp, HatDown
+		}
+		hat := j.raw.Hats[ax.hat] &^ (neg | pos)
+		if e.Value < 0 {
+			hat |= neg
+		} else if e.Value > 0 {
+			hat |= pos
+		}
+		j.raw.Hats[ax.hat] = hat
+	}
+}
+
+// sysfsGUID builds the SDL GUID of a joystick from the ids its input device
+// reports in sysfs.
+func sysfsGUID(devpath string) string {
+	var guid strings.Builder
+	for _, field := range []string{"bustype", "vendor", "product", "version"} {
+		data, err := os.ReadFile(path.Join("/sys", devpath, "device", "id", field))
+		if err != nil {
+			return ""
+		}
+		v, err := strconv.ParseUint(strings.TrimSpace(string(data)), 16, 16)
+		if err != nil {
+			return ""
+		}
+		fmt.Fprintf(&guid, "%02x%02x0000", v&0xff, v>>8)
+	}
+	return guid.String()
+}
+
 func (j *Joystick) getState() (*State, error) {
 	if j.disconnected {
 		return nil, errors.New("Joystick disconnected")
@@ -213,6 +339,7 @@ func getJoysticks() []*Joystick {
 			dlog.ErrorCheck(err)
 		}
 		joys[i] = newJoystick(path.Join("/", "dev", f.Env["DEVNAME"]), id)
+		joys[i].guid = sysfsGUID(f.Devpath)
 	}
 	return joys
 }
@@ -62,6 +62,7 @@ type Joystick struct {
 	Handler  Triggerer
 	PollRate time.Duration
 	id       uint32
+	guid     string
 	osJoystick
 }
 
@@ -346,6 +347,8 @@ func (j *Joystick) Listen(opts *ListenOptions) (cancel func()) {
 			if lastState.Frame == state.Frame {
 				continue
 			}
+			// Not all drivers set the ID of their states
+			state.ID = j.id
 			sendFn(j.Handler, state, lastState)
 			lastState = state
 		}
@@ -361,6 +364,12 @@ func (j *Joystick) ID() uint32 {
 	return j.id
 }
 
+// GUID returns the SDL compatible GUID of this joystick, used to look up its
+// Mapping. It is empty on platforms where joysticks are not mapped.
+func (j *Joystick) GUID() string {
+	return j.guid
+}
+
 // Vibrate triggers vibration on a joystick (if it is supported).
 func (j *Joystick) Vibrate(left, right uint16) error {
 	return j.vibrate(left, right)
@@ -0,0 +1,480 @@
+package joystick
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/diakovliev/oak/v4/oakerr"
+)
+
+// InputGuide is the button in the center of many controllers, e.g. the XBox
+// button. It is only reported by joysticks with a Mapping.
+const InputGuide Input = "Guide"
+
+// sdlButtons maps SDL game controller button names to the names used in
+// State.Buttons.
+var sdlButtons = map[string]Input{
+	"a":             InputA,
+	"b":             InputB,
+	"x":             InputX,
+	"y":             InputY,
+	"back":          InputBack,
+	"start":         InputStart,
+	"guide":         InputGuide,
+	"leftshoulder":  InputLeftShoulder,
+	"rightshoulder": InputRightShoulder,
+	"leftstick":     InputLeftStick,
+	"rightstick":    InputRightStick,
+	"dpup":          InputUp,
+	"dpdown":        InputDown,
+	"dpleft":        InputLeft,
+	"dpright":       InputRight,
+}
+
+// SDL game controller axis names
+const (
+	sdlLeftX        = "leftx"
+	sdlLeftY        = "lefty"
+	sdlRightX       = "rightx"
+	sdlRightY       = "righty"
+	sdlLeftTrigger  = "lefttrigger"
+	sdlRightTrigger = "righttrigger"
+)
+
+// Hat directions, as bits of a RawState hat
+const (
+	HatUp    uint8 = 1
+	HatRight uint8 = 2
+	HatDown  uint8 = 4
+	HatLeft  uint8 = 8
+)
+
+// A RawState holds a joystick's inputs in the order its driver reports them.
+// Axes range over int16, and hats are bitmasks of Hat directions.
+type RawState struct {
+	Buttons []bool
+	Axes    []int16
+	Hats    []uint8
+}
+
+type sourceKind uint8
+
+const (
+	sourceButton sourceKind = iota
+	sourceAxis
+	sourceHat
+)
+
+type mappingSource struct {
+	kind  sourceKind
+	index int
+	// hatMask is the direction of a hat source
+	hatMask uint8
+	// half is 1 or -1 to use only the positive or negative half of an
+	// axis, or 0 to use all of it.
+	half   int8
+	invert bool
+}
+
+type mappingElement struct {
+	from mappingSource
+	// Exactly one of button and axis is set
+	button Input
+	axis   string
+	half   int8
+}
+
+// A Mapping converts the raw inputs of a kind of joystick into the standard
+// names and axes of a State. Mappings use the format of SDL's
+// gamecontrollerdb.txt:
+//
+//	GUID,Name,a:b0,b:b1,leftx:a0,lefty:a1,dpup:h0.1,lefttrigger:+a2,platform:Linux,
+//
+// Inputs are bN for buttons, aN for axes and hN.M for hat N in direction M.
+// Axes may be prefixed with + or - to use half of the axis, and suffixed with
+// ~ to invert it.
+type Mapping struct {
+	GUID     string
+	Name     string
+	Platform string
+	elements []mappingElement
+}
+
+// ParseMapping parses a single SDL game controller mapping.
+func ParseMapping(s string) (*Mapping, error) {
+	fields := strings.Split(strings.TrimSpace(s), ",")
+	if len(fields) < 2 || fields[0] == "" {
+		return nil, oakerr.InvalidInput{InputName: "mapping"}
+	}
+	m := &Mapping{
+		GUID: strings.ToLower(fields[0]),
+		Name: fields[1],
+	}
+	for _, f := range fields[2:] {
+		if f == "" {
+			continue
+		}
+		colon := strings.IndexByte(f, ':')
+		if colon == -1 {
+			return nil, oakerr.InvalidInput{InputName: f}
+		}
+		target, source := f[:colon], f[colon+1:]
+		if target == "platform" {
+			m.Platform = source
+			continue
+		}
+		var el mappingElement
+		switch {
+		case strings.HasPrefix(target, "+"):
+			el.half, target = 1, target[1:]
+		case strings.HasPrefix(target, "-"):
+			el.half, target = -1, target[1:]
+		}
+		if in, ok := sdlButtons[target]; ok {
+			el.button = in
+		} else {
+			switch target {
+			case sdlLeftX, sdlLeftY, sdlRightX, sdlRightY, sdlLeftTrigger, sdlRightTrigger:
+				el.axis = target
+			default:
+				// Other fields, like misc1 and paddle1, or hints like
+				// crc and hint, are not represented in State.
+				continue
+			}
+		}
+		from, err := parseSource(source)
+		if err != nil {
+			return nil, oakerr.InvalidInput{InputName: f}
+		}
+		el.from = from
+		m.elements = append(m.elements, el)
+	}
+	return m, nil
+}
+
+func parseSource(s string) (mappingSource, error) {
+	var src mappingSource
+	switch {
+	case strings.HasPrefix(s, "+"):
+		src.half, s = 1, s[1:]
+	case strings.HasPrefix(s, "-"):
+		src.half, s = -1, s[1:]
+	}
+	if strings.HasSuffix(s, "~") {
+		src.invert, s = true, s[:len(s)-1]
+	}
+	if len(s) < 2 {
+		return src, oakerr.InvalidInput{InputName: s}
+	}
+	switch s[0] {
+	case 'b':
+		src.kind = sourceButton
+	case 'a':
+		src.kind = sourceAxis
+	case 'h':
+		src.kind = sourceHat
+		dot := strings.IndexByte(s, '.')
+		if dot == -1 {
+			return src, oakerr.InvalidInput{InputName: s}
+		}
+		mask, err := strconv.ParseUint(s[dot+1:], 10, 8)
+		if err != nil {
+			return src, err
+		}
+		src.hatMask = uint8(mask)
+		s = s[:dot]
+	default:
+		return src, oakerr.InvalidInput{InputName: s}
+	}
+	idx, err := strconv.Atoi(s[1:])
+	if err != nil || idx < 0 {
+		return src, oakerr.InvalidInput{InputName: s}
+	}
+	src.index = idx
+	return src, nil
+}
+
+// value returns the source's value from 0 to 1, or -1 to 1 for full axes.
+func (src mappingSource) value(raw RawState) float64 {
+	var v float64
+	switch src.kind {
+	case sourceButton:
+		if src.index < len(raw.Buttons) && raw.Buttons[src.index] {
+			v = 1
+		}
+	case sourceHat:
+		if src.index < len(raw.Hats) && raw.Hats[src.index]&src.hatMask != 0 {
+			v = 1
+		}
+	case sourceAxis:
+		if src.index < len(raw.Axes) {
+			v = float64(raw.Axes[src.index]) / math.MaxInt16
+			if v < -1 {
+				v = -1
+			}
+		}
+		if src.invert {
+			v = -v
+		}
+		switch {
+		case src.half > 0 && v < 0, src.half < 0 && v > 0:
+			v = 0
+		case src.half < 0:
+			v = -v
+		}
+	}
+	return v
+}
+
+// Apply sets the buttons, sticks and triggers of st from raw. Buttons the
+// mapping defines are always present in st.Buttons.
+func (m *Mapping) Apply(raw RawState, st *State) {
+	if st.Buttons == nil {
+		st.Buttons = make(map[string]bool)
+	}
+	for _, el := range m.elements {
+		if el.button != "" {
+			st.Buttons[string(el.button)] = false
+		}
+	}
+	var axes [6]float64
+	var set [6]bool
+	for _, el := range m.elements {
+		v := el.from.value(raw)
+		if el.button != "" {
+			if v > 0.5 {
+				st.Buttons[string(el.button)] = true
+			}
+			continue
+		}
+		i := axisIndex(el.axis)
+		set[i] = true
+		isTrigger := el.axis == sdlLeftTrigger || el.axis == sdlRightTrigger
+		switch {
+		case el.half > 0:
+			axes[i] += absF(v)
+		case el.half < 0:
+			axes[i] -= absF(v)
+		case isTrigger && el.from.kind == sourceAxis && el.from.half == 0:
+			// Full axes used as triggers rest at their minimum
+			axes[i] += (v + 1) / 2
+		default:
+			axes[i] += v
+		}
+	}
+	stick := func(v float64) int16 {
+		if v > 1 {
+			v = 1
+		} else if v < -1 {
+			v = -1
+		}
+		return int16(v * math.MaxInt16)
+	}
+	trigger := func(v float64) uint8 {
+		if v > 1 {
+			v = 1
+		} else if v < 0 {
+			v = 0
+		}
+		return uint8(v * math.MaxUint8)
+	}
+	// SDL's y axes are positive downwards, State's upwards.
+	if set[0] {
+		st.StickLX = stick(axes[0])
+	}
+	if set[1] {
+		st.StickLY = stick(-axes[1])
+	}
+	if set[2] {
+		st.StickRX = stick(axes[2])
+	}
+	if set[3] {
+		st.StickRY = stick(-axes[3])
+	}
+	if set[4] {
+		st.TriggerL = trigger(axes[4])
+	}
+	if set[5] {
+		st.TriggerR = trigger(axes[5])
+	}
+}
+
+func axisIndex(axis string) int {
+	switch axis {
+	case sdlLeftX:
+		return 0
+	case sdlLeftY:
+		return 1
+	case sdlRightX:
+		return 2
+	case sdlRightY:
+		return 3
+	case sdlLeftTrigger:
+		return 4
+	}
+	return 5
+}
+
+func absF(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+// A MappingDatabase holds Mappings by GUID.
+type MappingDatabase struct {
+	mutex    sync.RWMutex
+	mappings map[string]*Mapping
+}
+
+// NewMappingDatabase creates an empty MappingDatabase.
+func NewMappingDatabase() *MappingDatabase {
+	return &MappingDatabase{
+		mappings: make(map[string]*Mapping),
+	}
+}
+
+// DefaultMappings is the database joysticks look up their mapping in.
+var DefaultMappings = NewMappingDatabase()
+
+// A MappingError describes a line of a mapping database which could not be parsed.
+type MappingError struct {
+	// Line is the line number, starting from 1.
+	Line int
+	Err  error
+}
+
+func (me MappingError) Error() string {
+	return "line " + strconv.Itoa(me.Line) + ": " + me.Err.Error()
+}
+
+func (me MappingError) Unwrap() error {
+	return me.Err
+}
+
+// MappingErrors are returned by Load for the lines it skipped.
+type MappingErrors []MappingError
+
+func (me MappingErrors) Error() string {
+	msgs := make([]string, len(me))
+	for i, e := range me {
+		msgs[i] = e.Error()
+	}
+	return strconv.Itoa(len(me)) + " mappings skipped: " + strings.Join(msgs, "; ")
+}
+
+// Load adds each mapping in a gamecontrollerdb.txt formatted reader. Blank
+// lines and comments beginning with # are skipped, as are mappings for other
+// platforms. Mappings replace those already loaded with the same GUID.
+// Lines which cannot be parsed are skipped and returned together as
+// MappingErrors, after the rest of the mappings have been added.
+func (db *MappingDatabase) Load(r io.Reader) error {
+	var mappings []*Mapping
+	var skipped MappingErrors
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		m, err := ParseMapping(text)
+		if err != nil {
+			skipped = append(skipped, MappingError{Line: line, Err: err})
+			continue
+		}
+		if m.Platform != "" && m.Platform != sdlPlatform() {
+			continue
+		}
+		mappings = append(mappings, m)
+	}
+	db.mutex.Lock()
+	for _, m := range mappings {
+		db.mappings[m.GUID] = m
+	}
+	db.mutex.Unlock()
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if len(skipped) != 0 {
+		return skipped
+	}
+	return nil
+}
+
+// Add parses and adds a single mapping, replacing any with the same GUID.
+// Unlike Load, it adds mappings regardless of their platform.
+func (db *MappingDatabase) Add(mapping string) error {
+	m, err := ParseMapping(mapping)
+	if err != nil {
+		return err
+	}
+	db.mutex.Lock()
+	db.mappings[m.GUID] = m
+	db.mutex.Unlock()
+	return nil
+}
+
+// Remove removes the mapping for a GUID.
+func (db *MappingDatabase) Remove(guid string) {
+	db.mutex.Lock()
+	delete(db.mappings, strings.ToLower(guid))
+	db.mutex.Unlock()
+}
+
+// Get returns the mapping for a GUID. Like SDL, if no mapping matches
+// exactly, it tries again ignoring the GUID's name checksum and then its
+// version.
+func (db *MappingDatabase) Get(guid string) (*Mapping, bool) {
+	guid = strings.ToLower(guid)
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+	if m, ok := db.mappings[guid]; ok {
+		return m, true
+	}
+	if len(guid) != 32 {
+		return nil, false
+	}
+	// Bytes 2 and 3 hold a checksum of the device name
+	guid = guid[:4] + "0000" + guid[8:]
+	if m, ok := db.mappings[guid]; ok {
+		return m, true
+	}
+	// Bytes 12 and 13 hold the device version
+	guid = guid[:24] + "0000" + guid[28:]
+	m, ok := db.mappings[guid]
+	return m, ok
+}
+
+// LoadMappings calls Load on DefaultMappings.
+func LoadMappings(r io.Reader) error {
+	return DefaultMappings.Load(r)
+}
+
+// AddMapping calls Add on DefaultMappings. Mappings added while a joystick
+// is connected apply to its next input.
+func AddMapping(mapping string) error {
+	return DefaultMappings.Add(mapping)
+}
+
+// sdlPlatform returns the name SDL uses for the current platform.
+func sdlPlatform() string {
+	switch runtime.GOOS {
+	case "linux":
+		return "Linux"
+	case "windows":
+		return "Windows"
+	case "darwin":
+		return "Mac OS X"
+	case "android":
+		return "Android"
+	case "ios":
+		return "iOS"
+	}
+	return runtime.GOOS
+}
@@ -0,0 +1,121 @@
+package joystick
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/oakerr"
+)
+
+const testMappingGUID = "030000005e0400008e02000014010000"
+
+const testMappings = `# Test mappings
+030000005e0400008e02000014010000,X360 Controller,a:b0,b:b1,x:b2,y:b3,back:b6,guide:b8,start:b7,leftstick:b9,rightstick:b10,leftshoulder:b4,rightshoulder:b5,dpup:h0.1,dpdown:h0.4,dpleft:h0.8,dpright:h0.2,leftx:a0,lefty:a1,rightx:a3,righty:a4,lefttrigger:a2,righttrigger:a5,platform:` + "PLATFORM" + `,
+
+03000000ffff0000ffff000000000000,Other Platform,a:b1,platform:Nowhere,
+`
+
+func TestMappingDatabase_Load(t *testing.T) {
+	db := NewMappingDatabase()
+	if err := db.Load(strings.NewReader(strings.Replace(testMappings, "PLATFORM", sdlPlatform(), 1))); err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	m, ok := db.Get(strings.ToUpper(testMappingGUID))
+	if !ok {
+		t.Fatalf("expected mapping to be found")
+	}
+	if m.Name != "X360 Controller" {
+		t.Fatalf("unexpected name %q", m.Name)
+	}
+	if _, ok := db.Get("03000000ffff0000ffff000000000000"); ok {
+		t.Fatalf("expected mapping for another platform to be skipped")
+	}
+	// Name checksums and versions are ignored when there is no exact match
+	if _, ok := db.Get("0300abcd5e0400008e02000014010000"); !ok {
+		t.Fatalf("expected lookup ignoring checksum to find mapping")
+	}
+	if err := db.Add("03000000010000000200000000000000,Any Version,a:b0"); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	if _, ok := db.Get("03000000010000000200000003000000"); !ok {
+		t.Fatalf("expected lookup ignoring version to find mapping")
+	}
+	err := db.Load(strings.NewReader("guid,name,a:q0\n03000000020000000300000000000000,Good,a:b0\n\nbad"))
+	var skipped MappingErrors
+	if !errors.As(err, &skipped) {
+		t.Fatalf("expected skipped lines to be returned, got %v", err)
+	}
+	if len(skipped) != 2 || skipped[0].Line != 1 || skipped[1].Line != 4 {
+		t.Fatalf("unexpected skipped lines: %v", skipped)
+	}
+	var invalid oakerr.InvalidInput
+	if !errors.As(skipped[0], &invalid) {
+		t.Fatalf("expected skipped line to wrap its parse error, got %v", skipped[0].Err)
+	}
+	if _, ok := db.Get("03000000020000000300000000000000"); !ok {
+		t.Fatalf("expected valid lines to be loaded alongside invalid ones")
+	}
+	db.Remove(testMappingGUID)
+	if _, ok := db.Get(testMappingGUID); ok {
+		t.Fatalf("expected mapping to be removed")
+	}
+}
+
+func TestMapping_Apply(t *testing.T) {
+	m, err := ParseMapping(strings.Replace(strings.Split(testMappings, "\n")[1], "PLATFORM", "Linux", 1))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	raw := RawState{
+		Buttons: make([]bool, 11),
+		Axes:    []int16{math.MaxInt16, math.MinInt16, math.MinInt16, 0, 100, math.MaxInt16},
+		Hats:    []uint8{HatUp | HatRight},
+	}
+	raw.Buttons[1] = true
+	st := &State{}
+	m.Apply(raw, st)
+	if !st.Buttons["B"] || st.Buttons["A"] {
+		t.Fatalf("unexpected buttons %v", st.Buttons)
+	}
+	if _, ok := st.Buttons["Guide"]; !ok {
+		t.Fatalf("expected all mapped buttons to be present")
+	}
+	if !st.Buttons["Up"] || !st.Buttons["Right"] || st.Buttons["Down"] {
+		t.Fatalf("unexpected dpad %v", st.Buttons)
+	}
+	if st.StickLX != math.MaxInt16 || st.StickLY != math.MaxInt16 {
+		t.Fatalf("unexpected left stick %v,%v", st.StickLX, st.StickLY)
+	}
+	if st.StickRY >= 0 {
+		t.Fatalf("expected right stick y to be flipped, got %v", st.StickRY)
+	}
+	if st.TriggerL != 0 || st.TriggerR != math.MaxUint8 {
+		t.Fatalf("unexpected triggers %v,%v", st.TriggerL, st.TriggerR)
+	}
+}
+
+func TestMapping_HalfAxes(t *testing.T) {
+	m, err := ParseMapping("guid,Half,dpleft:-a0,dpright:+a0,lefttrigger:+a1,+leftx:b0,-leftx:b1,righty:a2~")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	st := &State{}
+	m.Apply(RawState{
+		Buttons: []bool{false, true},
+		Axes:    []int16{-30000, -30000, 1000},
+	}, st)
+	if !st.Buttons["Left"] || st.Buttons["Right"] {
+		t.Fatalf("unexpected dpad %v", st.Buttons)
+	}
+	if st.TriggerL != 0 {
+		t.Fatalf("expected negative half of trigger axis to be ignored, got %v", st.TriggerL)
+	}
+	if st.StickLX != -math.MaxInt16 {
+		t.Fatalf("expected button to push stick left, got %v", st.StickLX)
+	}
+	if st.StickRY != 1000 {
+		t.Fatalf("expected inverted axis, got %v", st.StickRY)
+	}
+}
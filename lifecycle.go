@@ -63,6 +63,11 @@ func (w *Window) newWindow(x, y, width, height int) error {
 		return err
 	}
 	w.Window = wC
+	w.cursor.Lock()
+	if w.cursor.cursor != nil {
+		w.hideOSCursor()
+	}
+	w.cursor.Unlock()
 	return w.ChangeWindow(width, height)
 }
 
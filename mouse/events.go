@@ -26,6 +26,16 @@ var (
 	ClickOn      = event.RegisterEvent[*Event]()
 	DragOn       = event.RegisterEvent[*Event]()
 
+	// HoverEnter is triggered on an entity in a mouse collision tree when the
+	// mouse moves onto it. Entities beneath one which stops the propagation of
+	// DragOn are not considered hovered. Hover only changes as the mouse moves,
+	// so an entity created beneath a still mouse, such as when a scene starts,
+	// is not entered until the mouse next moves.
+	HoverEnter = event.RegisterEvent[*Event]()
+	// HoverExit is triggered on an entity which was hovered when the mouse
+	// moves off of it, or onto an entity above it which stops propagation.
+	HoverExit = event.RegisterEvent[*Event]()
+
 	// Relative variants are like 'On' variants, but their mouse position data is relative to
 	// the window's current viewport. E.g. if the viewport is at 100,100 and a click happens at
 	// 100,100 on the window-- Relative will report 100,100, and non-relative will report 200,200.
@@ -0,0 +1,61 @@
+package player
+
+import (
+	"sync"
+
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/joystick"
+	"github.com/diakovliev/oak/v4/key"
+	"github.com/diakovliev/oak/v4/mouse"
+)
+
+// playerEvents lazily registers one event per player.
+type playerEvents[T any] struct {
+	sync.Mutex
+	events map[int]event.EventID[T]
+}
+
+func (pe *playerEvents[T]) get(player int) event.EventID[T] {
+	pe.Lock()
+	defer pe.Unlock()
+	if ev, ok := pe.events[player]; ok {
+		return ev
+	}
+	if pe.events == nil {
+		pe.events = make(map[int]event.EventID[T])
+	}
+	ev := event.RegisterEvent[T]()
+	pe.events[player] = ev
+	return ev
+}
+
+var (
+	keyDownEvents  playerEvents[key.Event]
+	keyUpEvents    playerEvents[key.Event]
+	mouseEvents    playerEvents[*mouse.Event]
+	joystickEvents playerEvents[*joystick.State]
+)
+
+// KeyDown returns the event triggered when a key is pressed on a player's
+// keyboard.
+func KeyDown(player int) event.EventID[key.Event] {
+	return keyDownEvents.get(player)
+}
+
+// KeyUp returns the event triggered when a key is released on a player's
+// keyboard.
+func KeyUp(player int) event.EventID[key.Event] {
+	return keyUpEvents.get(player)
+}
+
+// Mouse returns the event triggered for presses, releases, drags and scrolls
+// of a player's mouse. The event's EventType tells which occurred.
+func Mouse(player int) event.EventID[*mouse.Event] {
+	return mouseEvents.get(player)
+}
+
+// JoystickChange returns the event triggered when a player's joystick
+// changes state.
+func JoystickChange(player int) event.EventID[*joystick.State] {
+	return joystickEvents.get(player)
+}
@@ -0,0 +1,430 @@
+// Package player assigns input devices to numbered local players and routes
+// each device's input to events for its player.
+package player
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/joystick"
+	"github.com/diakovliev/oak/v4/key"
+	"github.com/diakovliev/oak/v4/mouse"
+	"github.com/diakovliev/oak/v4/oakerr"
+)
+
+// A DeviceKind is a kind of input device.
+type DeviceKind uint8
+
+// DeviceKind values
+const (
+	// KeyboardMouse is the keyboard and mouse, treated as one device.
+	KeyboardMouse DeviceKind = iota
+	Joystick
+)
+
+// A Device is an input device a player can own.
+type Device struct {
+	Kind DeviceKind
+	// ID is the joystick's ID. It is zero for KeyboardMouse.
+	ID uint32
+}
+
+// Keyboard is the keyboard and mouse device.
+var Keyboard = Device{Kind: KeyboardMouse}
+
+// JoystickDevice returns the device for a joystick ID.
+func JoystickDevice(id uint32) Device {
+	return Device{Kind: Joystick, ID: id}
+}
+
+func (d Device) String() string {
+	if d.Kind == KeyboardMouse {
+		return "keyboard"
+	}
+	return fmt.Sprintf("joystick %d", d.ID)
+}
+
+// Events triggered globally as players and devices come and go
+var (
+	// Connected is triggered when a device not owned by a player is first
+	// seen, e.g. to prompt it to join. Player is zero.
+	Connected = event.RegisterEvent[Event]()
+	// Joined is triggered when a device is assigned to a player.
+	Joined = event.RegisterEvent[Event]()
+	// Left is triggered when a player loses their device, because it
+	// disconnected or they chose to leave.
+	Left = event.RegisterEvent[Event]()
+)
+
+// An Event is passed to Connected, Joined and Left bindings.
+type Event struct {
+	Player int
+	Device Device
+}
+
+// Options configure a Manager. Zero values are replaced with defaults.
+type Options struct {
+	// MaxPlayers is the number of player slots, defaulting to 4.
+	MaxPlayers int
+	// JoinKeys join the keyboard to the game, defaulting to enter.
+	JoinKeys []key.Code
+	// JoinButtons join a joystick to the game, defaulting to Start and A.
+	JoinButtons []joystick.Input
+	// LeaveKeys and LeaveButtons remove a player from the game. By default,
+	// players cannot leave except by disconnecting.
+	LeaveKeys    []key.Code
+	LeaveButtons []joystick.Input
+	// AutoJoin assigns devices to players as soon as they are seen, instead
+	// of waiting for a join input.
+	AutoJoin bool
+}
+
+// A Manager assigns devices to numbered player slots, starting at 1.
+type Manager struct {
+	opts Options
+
+	mutex sync.Mutex
+	// slots holds the device of each player, indexed by player - 1.
+	slots   []*Device
+	seen    map[Device]bool
+	buttons map[uint32]map[string]bool
+	handler event.Handler
+}
+
+// NewManager creates a Manager with no players.
+func NewManager(opts Options) *Manager {
+	if opts.MaxPlayers <= 0 {
+		opts.MaxPlayers = 4
+	}
+	if opts.JoinKeys == nil {
+		opts.JoinKeys = []key.Code{key.ReturnEnter, key.KeypadEnter}
+	}
+	if opts.JoinButtons == nil {
+		opts.JoinButtons = []joystick.Input{joystick.InputStart, joystick.InputA}
+	}
+	return &Manager{
+		opts:    opts,
+		slots:   make([]*Device, opts.MaxPlayers),
+		seen:    make(map[Device]bool),
+		buttons: make(map[uint32]map[string]bool),
+	}
+}
+
+// Join assigns a device to the lowest free player slot, returning the
+// player. If the device already belongs to a player, that player is returned.
+func (m *Manager) Join(d Device) (int, error) {
+	m.mutex.Lock()
+	if p, ok := m.player(d); ok {
+		m.mutex.Unlock()
+		return p, nil
+	}
+	for i, slot := range m.slots {
+		if slot == nil {
+			events := m.assign(i+1, d)
+			h := m.handler
+			m.mutex.Unlock()
+			events.trigger(h)
+			return i + 1, nil
+		}
+	}
+	m.mutex.Unlock()
+	return 0, oakerr.NotFound{InputName: "free player slot"}
+}
+
+// Assign gives a device to a player, replacing the player's previous device
+// and removing the device from any other player.
+func (m *Manager) Assign(player int, d Device) error {
+	if player < 1 || player > len(m.slots) {
+		return oakerr.InvalidInput{InputName: "player"}
+	}
+	m.mutex.Lock()
+	events := m.assign(player, d)
+	h := m.handler
+	m.mutex.Unlock()
+	events.trigger(h)
+	return nil
+}
+
+// pendingEvents are events decided on while the manager is locked, to be
+// triggered once it is unlocked.
+type pendingEvents struct {
+	events   []event.EventID[Event]
+	payloads []Event
+}
+
+func (pe *pendingEvents) add(ev event.EventID[Event], payload Event) {
+	pe.events = append(pe.events, ev)
+	pe.payloads = append(pe.payloads, payload)
+}
+
+func (pe pendingEvents) trigger(h event.Handler) {
+	if h == nil {
+		return
+	}
+	for i, ev := range pe.events {
+		event.TriggerOn(h, ev, pe.payloads[i])
+	}
+}
+
+// assign gives a device to a valid player. The manager must be locked.
+func (m *Manager) assign(player int, d Device) pendingEvents {
+	var events pendingEvents
+	if old, ok := m.player(d); ok && old != player {
+		m.slots[old-1] = nil
+		events.add(Left, Event{Player: old, Device: d})
+	}
+	if prev := m.slots[player-1]; prev != nil && *prev != d {
+		events.add(Left, Event{Player: player, Device: *prev})
+	}
+	if prev := m.slots[player-1]; prev == nil || *prev != d {
+		dev := d
+		m.slots[player-1] = &dev
+		events.add(Joined, Event{Player: player, Device: d})
+	}
+	m.seen[d] = true
+	return events
+}
+
+// Leave frees a player's slot.
+func (m *Manager) Leave(player int) {
+	if player < 1 || player > len(m.slots) {
+		return
+	}
+	m.mutex.Lock()
+	d := m.slots[player-1]
+	m.slots[player-1] = nil
+	h := m.handler
+	m.mutex.Unlock()
+	if d != nil && h != nil {
+		event.TriggerOn(h, Left, Event{Player: player, Device: *d})
+	}
+}
+
+// Player returns the player a device belongs to.
+func (m *Manager) Player(d Device) (int, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.player(d)
+}
+
+func (m *Manager) player(d Device) (int, bool) {
+	for i, slot := range m.slots {
+		if slot != nil && *slot == d {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// Device returns the device a player owns.
+func (m *Manager) Device(player int) (Device, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if player < 1 || player > len(m.slots) || m.slots[player-1] == nil {
+		return Device{}, false
+	}
+	return *m.slots[player-1], true
+}
+
+// Players returns the players with devices, in order.
+func (m *Manager) Players() []int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var players []int
+	for i, slot := range m.slots {
+		if slot != nil {
+			players = append(players, i+1)
+		}
+	}
+	return players
+}
+
+// Listen starts routing key, mouse and joystick events on h to player
+// events, handling join and leave inputs, and triggering Connected, Joined
+// and Left on h. The returned function stops listening.
+func (m *Manager) Listen(h event.Handler) (cancel func()) {
+	m.mutex.Lock()
+	m.handler = h
+	m.mutex.Unlock()
+	bindings := []event.Binding{
+		event.GlobalBind(h, key.AnyDown, func(ev key.Event) event.Response {
+			m.keyInput(h, ev, true)
+			return 0
+		}),
+		event.GlobalBind(h, key.AnyUp, func(ev key.Event) event.Response {
+			m.keyInput(h, ev, false)
+			return 0
+		}),
+		event.GlobalBind(h, joystick.Change, func(st *joystick.State) event.Response {
+			m.joystickInput(h, st)
+			return 0
+		}),
+		event.GlobalBind(h, joystick.Disconnected, func(id uint32) event.Response {
+			m.disconnect(JoystickDevice(id))
+			return 0
+		}),
+	}
+	for _, ev := range []event.EventID[*mouse.Event]{mouse.Press, mouse.Release, mouse.Drag, mouse.ScrollUp, mouse.ScrollDown} {
+		bindings = append(bindings, event.GlobalBind(h, ev, func(me *mouse.Event) event.Response {
+			if p, ok := m.Player(Keyboard); ok {
+				event.TriggerOn(h, Mouse(p), me)
+			}
+			return 0
+		}))
+	}
+	return func() {
+		for _, b := range bindings {
+			b.Unbind()
+		}
+		m.mutex.Lock()
+		if m.handler == h {
+			m.handler = nil
+		}
+		m.mutex.Unlock()
+	}
+}
+
+// WatchJoysticks polls for newly connected joysticks, sending their input to
+// h and treating them as seen. It should be used alongside Listen.
+func (m *Manager) WatchJoysticks(h event.Handler, pollRate time.Duration) (cancel func()) {
+	joyCh, cancelWait := joystick.WaitForJoysticks(pollRate)
+	done := make(chan struct{})
+	go func() {
+		var cancels []func()
+		defer func() {
+			for _, c := range cancels {
+				c()
+			}
+		}()
+		for {
+			select {
+			case <-done:
+				return
+			case joy, ok := <-joyCh:
+				if !ok {
+					return
+				}
+				joy.Handler = h
+				cancels = append(cancels, joy.Listen(nil))
+				m.see(JoystickDevice(joy.ID()))
+			}
+		}
+	}()
+	return func() {
+		cancelWait()
+		close(done)
+	}
+}
+
+// see records a device as seen, triggering Connected or joining it.
+func (m *Manager) see(d Device) {
+	m.mutex.Lock()
+	if m.seen[d] {
+		m.mutex.Unlock()
+		return
+	}
+	m.seen[d] = true
+	_, owned := m.player(d)
+	h := m.handler
+	m.mutex.Unlock()
+	if owned {
+		return
+	}
+	if m.opts.AutoJoin {
+		m.Join(d)
+		return
+	}
+	if h != nil {
+		event.TriggerOn(h, Connected, Event{Device: d})
+	}
+}
+
+func (m *Manager) disconnect(d Device) {
+	m.mutex.Lock()
+	delete(m.seen, d)
+	if d.Kind == Joystick {
+		delete(m.buttons, d.ID)
+	}
+	p, ok := m.player(d)
+	m.mutex.Unlock()
+	if ok {
+		m.Leave(p)
+	}
+}
+
+func (m *Manager) keyInput(h event.Handler, ev key.Event, down bool) {
+	m.see(Keyboard)
+	p, ok := m.Player(Keyboard)
+	if !ok {
+		if down && containsKey(m.opts.JoinKeys, ev.Code) {
+			m.Join(Keyboard)
+		}
+		return
+	}
+	if down && containsKey(m.opts.LeaveKeys, ev.Code) {
+		m.Leave(p)
+		return
+	}
+	if down {
+		event.TriggerOn(h, KeyDown(p), ev)
+	} else {
+		event.TriggerOn(h, KeyUp(p), ev)
+	}
+}
+
+func (m *Manager) joystickInput(h event.Handler, st *joystick.State) {
+	d := JoystickDevice(st.ID)
+	m.see(d)
+	m.mutex.Lock()
+	last := m.buttons[st.ID]
+	cp := make(map[string]bool, len(st.Buttons))
+	var pressed []string
+	for b, down := range st.Buttons {
+		cp[b] = down
+		if down && !last[b] {
+			pressed = append(pressed, b)
+		}
+	}
+	sort.Strings(pressed)
+	m.buttons[st.ID] = cp
+	m.mutex.Unlock()
+
+	p, ok := m.Player(d)
+	if !ok {
+		for _, b := range pressed {
+			if containsInput(m.opts.JoinButtons, b) {
+				m.Join(d)
+				return
+			}
+		}
+		return
+	}
+	for _, b := range pressed {
+		if containsInput(m.opts.LeaveButtons, b) {
+			m.Leave(p)
+			return
+		}
+	}
+	event.TriggerOn(h, JoystickChange(p), st)
+}
+
+func containsKey(codes []key.Code, c key.Code) bool {
+	for _, c2 := range codes {
+		if c2 == c {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInput(inputs []joystick.Input, b string) bool {
+	for _, in := range inputs {
+		if string(in) == b {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package player
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/joystick"
+	"github.com/diakovliev/oak/v4/key"
+	"github.com/diakovliev/oak/v4/mouse"
+)
+
+func TestManager_Slots(t *testing.T) {
+	m := NewManager(Options{MaxPlayers: 2})
+	if p, err := m.Join(JoystickDevice(3)); err != nil || p != 1 {
+		t.Fatalf("expected player 1, got %v (%v)", p, err)
+	}
+	if p, _ := m.Join(JoystickDevice(3)); p != 1 {
+		t.Fatalf("expected rejoining to keep player 1, got %v", p)
+	}
+	if p, _ := m.Join(Keyboard); p != 2 {
+		t.Fatalf("expected player 2, got %v", p)
+	}
+	if _, err := m.Join(JoystickDevice(4)); err == nil {
+		t.Fatalf("expected error with no free slots")
+	}
+	m.Leave(1)
+	if p, _ := m.Join(JoystickDevice(4)); p != 1 {
+		t.Fatalf("expected freed slot to be reused, got %v", p)
+	}
+	if err := m.Assign(2, JoystickDevice(4)); err != nil {
+		t.Fatalf("failed to assign: %v", err)
+	}
+	if _, ok := m.Player(Keyboard); ok {
+		t.Fatalf("expected keyboard to be replaced")
+	}
+	if players := m.Players(); len(players) != 1 || players[0] != 2 {
+		t.Fatalf("expected device to move to player 2, got players %v", players)
+	}
+	if err := m.Assign(3, Keyboard); err == nil {
+		t.Fatalf("expected error assigning invalid player")
+	}
+}
+
+func TestManager_JoinConcurrent(t *testing.T) {
+	m := NewManager(Options{MaxPlayers: 8})
+	var wg sync.WaitGroup
+	joined := make([]int, 8)
+	for i := range joined {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			joined[i], _ = m.Join(JoystickDevice(uint32(i)))
+		}(i)
+	}
+	wg.Wait()
+	seen := make(map[int]bool)
+	for i, p := range joined {
+		if p == 0 || seen[p] {
+			t.Fatalf("expected each device its own player, got %v", joined)
+		}
+		seen[p] = true
+		if owner, ok := m.Device(p); !ok || owner != JoystickDevice(uint32(i)) {
+			t.Fatalf("expected player %v to own joystick %v, got %v", p, i, owner)
+		}
+	}
+}
+
+func TestManager_Listen(t *testing.T) {
+	h := event.NewBus(event.NewCallerMap())
+	m := NewManager(Options{LeaveButtons: []joystick.Input{joystick.InputBack}})
+	connected, joined, left := make(chan Event, 4), make(chan Event, 4), make(chan Event, 4)
+	for ev, ch := range map[event.EventID[Event]]chan Event{Connected: connected, Joined: joined, Left: left} {
+		ch := ch
+		<-event.GlobalBind(h, ev, func(e Event) event.Response {
+			ch <- e
+			return 0
+		}).Bound
+	}
+	keys, states, mice := make(chan key.Event, 4), make(chan *joystick.State, 4), make(chan *mouse.Event, 4)
+	<-event.GlobalBind(h, KeyDown(1), func(ev key.Event) event.Response {
+		keys <- ev
+		return 0
+	}).Bound
+	<-event.GlobalBind(h, Mouse(1), func(ev *mouse.Event) event.Response {
+		mice <- ev
+		return 0
+	}).Bound
+	<-event.GlobalBind(h, JoystickChange(2), func(st *joystick.State) event.Response {
+		states <- st
+		return 0
+	}).Bound
+	cancel := m.Listen(h)
+	defer cancel()
+	time.Sleep(200 * time.Millisecond)
+
+	expect := func(ch chan Event, name string, player int, d Device) {
+		t.Helper()
+		select {
+		case ev := <-ch:
+			if ev.Player != player || ev.Device != d {
+				t.Fatalf("unexpected %v event %+v", name, ev)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %v event", name)
+		}
+	}
+	<-event.TriggerOn(h, key.AnyDown, key.Event{Code: key.A})
+	expect(connected, "connected", 0, Keyboard)
+	<-event.TriggerOn(h, key.AnyDown, key.Event{Code: key.ReturnEnter})
+	expect(joined, "joined", 1, Keyboard)
+	<-event.TriggerOn(h, key.AnyDown, key.Event{Code: key.A})
+	select {
+	case ev := <-keys:
+		if ev.Code != key.A {
+			t.Fatalf("unexpected key %v", ev.Code)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected key to be routed to player 1")
+	}
+	<-event.TriggerOn(h, mouse.Press, &mouse.Event{Button: mouse.ButtonLeft})
+	select {
+	case <-mice:
+	case <-time.After(time.Second):
+		t.Fatalf("expected mouse to be routed to player 1")
+	}
+
+	pad := JoystickDevice(7)
+	<-event.TriggerOn(h, joystick.Change, &joystick.State{ID: 7, Buttons: map[string]bool{"Start": true}})
+	expect(connected, "connected", 0, pad)
+	expect(joined, "joined", 2, pad)
+	<-event.TriggerOn(h, joystick.Change, &joystick.State{ID: 7, Buttons: map[string]bool{"Start": false}, Frame: 1})
+	select {
+	case st := <-states:
+		if st.Frame != 1 {
+			t.Fatalf("unexpected state %+v", st)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected joystick to be routed to player 2")
+	}
+	<-event.TriggerOn(h, joystick.Change, &joystick.State{ID: 7, Buttons: map[string]bool{"Back": true}})
+	expect(left, "left", 2, pad)
+
+	m.Join(pad)
+	expect(joined, "joined", 2, pad)
+	<-event.TriggerOn(h, joystick.Disconnected, uint32(7))
+	expect(left, "left", 2, pad)
+}
@@ -0,0 +1,449 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"image"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/diakovliev/oak/v4/alg/intgeom"
+	"github.com/diakovliev/oak/v4/fileutil"
+	"github.com/diakovliev/oak/v4/oakerr"
+)
+
+// A BitmapFont is a font made of glyphs cut from pre-rendered page images,
+// such as those exported by AngelCode's BMFont tool. Bitmap fonts are drawn
+// without any antialiasing or hinting, making them suited to pixel art.
+//
+// A BitmapFont can be used to generate a Font via FontGenerator.Bitmap.
+type BitmapFont struct {
+	// LineHeight is the distance between lines of text.
+	LineHeight int
+	// Base is the distance from the top of a line to the baseline of its glyphs.
+	Base    int
+	Pages   []*image.RGBA
+	Glyphs  map[rune]BitmapGlyph
+	Kerning map[[2]rune]int
+}
+
+// A BitmapGlyph is the location and spacing of a single character of a
+// BitmapFont.
+type BitmapGlyph struct {
+	Page int
+	// Bounds is the location of this glyph within its page.
+	Bounds image.Rectangle
+	// Offset is the distance from the pen position, at the top of the line,
+	// to the top left corner of where this glyph is drawn.
+	Offset image.Point
+	// Advance is how far the pen moves after drawing this glyph.
+	Advance int
+}
+
+// NewGridBitmapFont creates a BitmapFont from a sheet of equally sized cells,
+// where chars lists the characters of each cell in order, left to right and
+// then top to bottom.
+func NewGridBitmapFont(sheet *image.RGBA, cellSize intgeom.Point2, chars string) (*BitmapFont, error) {
+	if cellSize.X() <= 0 || cellSize.Y() <= 0 {
+		return nil, oakerr.InvalidInput{InputName: "cellSize"}
+	}
+	bds := sheet.Bounds()
+	cols := bds.Dx() / cellSize.X()
+	rows := bds.Dy() / cellSize.Y()
+	if cols == 0 || len([]rune(chars)) > cols*rows {
+		return nil, oakerr.InvalidInput{InputName: "chars"}
+	}
+	bf := &BitmapFont{
+		LineHeight: cellSize.Y(),
+		Base:       cellSize.Y(),
+		Pages:      []*image.RGBA{sheet},
+		Glyphs:     make(map[rune]BitmapGlyph),
+		Kerning:    make(map[[2]rune]int),
+	}
+	i := 0
+	for _, c := range chars {
+		min := bds.Min.Add(image.Point{(i % cols) * cellSize.X(), (i / cols) * cellSize.Y()})
+		bf.Glyphs[c] = BitmapGlyph{
+			Bounds:  image.Rectangle{Min: min, Max: min.Add(image.Point{cellSize.X(), cellSize.Y()})},
+			Advance: cellSize.X(),
+		}
+		i++
+	}
+	return bf, nil
+}
+
+// LoadBitmapFont calls LoadBitmapFont on the Default Cache.
+func LoadBitmapFont(file string) (*BitmapFont, error) {
+	return DefaultCache.LoadBitmapFont(file)
+}
+
+// LoadBitmapFont loads a BMFont descriptor in text, XML, or binary format,
+// and the page images it references, relative to the descriptor's directory.
+// Page images are stored in this cache.
+func (c *Cache) LoadBitmapFont(file string) (*BitmapFont, error) {
+	data, err := fileutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(file)
+	return ParseBitmapFont(data, func(page string) (*image.RGBA, error) {
+		return c.loadSprite(filepath.Join(dir, page), 0)
+	})
+}
+
+// ParseBitmapFont parses a BMFont descriptor in text, XML, or binary format.
+// loadPage is called with each page file name the descriptor references.
+func ParseBitmapFont(data []byte, loadPage func(file string) (*image.RGBA, error)) (*BitmapFont, error) {
+	var desc *bmDescriptor
+	var err error
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	switch {
+	case bytes.HasPrefix(data, []byte("BMF")):
+		desc, err = parseBMFontBinary(data)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		desc, err = parseBMFontXML(trimmed)
+	default:
+		desc, err = parseBMFontText(trimmed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	bf := &BitmapFont{
+		LineHeight: desc.lineHeight,
+		Base:       desc.base,
+		Pages:      make([]*image.RGBA, len(desc.pages)),
+		Glyphs:     make(map[rune]BitmapGlyph, len(desc.chars)),
+		Kerning:    make(map[[2]rune]int, len(desc.kernings)),
+	}
+	for i, page := range desc.pages {
+		bf.Pages[i], err = loadPage(page)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, ch := range desc.chars {
+		if ch.page < 0 || ch.page >= len(bf.Pages) {
+			return nil, oakerr.InvalidInput{InputName: "page"}
+		}
+		bf.Glyphs[rune(ch.id)] = BitmapGlyph{
+			Page:    ch.page,
+			Bounds:  image.Rect(ch.x, ch.y, ch.x+ch.w, ch.y+ch.h),
+			Offset:  image.Point{ch.xOffset, ch.yOffset},
+			Advance: ch.xAdvance,
+		}
+	}
+	for _, k := range desc.kernings {
+		bf.Kerning[[2]rune{rune(k.first), rune(k.second)}] = k.amount
+	}
+	return bf, nil
+}
+
+// bmDescriptor is the format independent content of a BMFont descriptor.
+type bmDescriptor struct {
+	lineHeight int
+	base       int
+	pages      []string
+	chars      []bmChar
+	kernings   []bmKerning
+}
+
+// maxBitmapFontPages bounds the page ids a descriptor may use. The binary
+// format stores page ids in a byte, so no real font needs more.
+const maxBitmapFontPages = 256
+
+// setPage records the file of a page, growing pages to fit its id.
+func (desc *bmDescriptor) setPage(id int, file string) error {
+	if id < 0 || id >= maxBitmapFontPages {
+		return oakerr.InvalidInput{InputName: "page id"}
+	}
+	for len(desc.pages) <= id {
+		desc.pages = append(desc.pages, "")
+	}
+	desc.pages[id] = file
+	return nil
+}
+
+type bmChar struct {
+	id               int
+	x, y, w, h       int
+	xOffset, yOffset int
+	xAdvance, page   int
+}
+
+type bmKerning struct {
+	first, second, amount int
+}
+
+func parseBMFontText(data []byte) (*bmDescriptor, error) {
+	desc := &bmDescriptor{}
+	hasCommon := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		tag, attrs := splitBMFontLine(scanner.Text())
+		atoi := func(key string) int {
+			v, _ := strconv.Atoi(attrs[key])
+			return v
+		}
+		switch tag {
+		case "common":
+			hasCommon = true
+			desc.lineHeight = atoi("lineHeight")
+			desc.base = atoi("base")
+		case "page":
+			if err := desc.setPage(atoi("id"), attrs["file"]); err != nil {
+				return nil, err
+			}
+		case "char":
+			desc.chars = append(desc.chars, bmChar{
+				id: atoi("id"),
+				x:  atoi("x"), y: atoi("y"),
+				w: atoi("width"), h: atoi("height"),
+				xOffset: atoi("xoffset"), yOffset: atoi("yoffset"),
+				xAdvance: atoi("xadvance"), page: atoi("page"),
+			})
+		case "kerning":
+			desc.kernings = append(desc.kernings, bmKerning{
+				first:  atoi("first"),
+				second: atoi("second"),
+				amount: atoi("amount"),
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !hasCommon {
+		return nil, oakerr.NotFound{InputName: "common"}
+	}
+	return desc, nil
+}
+
+// splitBMFontLine splits a line of a text BMFont descriptor into its tag
+// and key=value attributes. Values may be quoted to contain spaces.
+func splitBMFontLine(line string) (string, map[string]string) {
+	line = strings.TrimSpace(line)
+	tagEnd := strings.IndexAny(line, " \t")
+	if tagEnd == -1 {
+		return line, nil
+	}
+	tag := line[:tagEnd]
+	rest := line[tagEnd:]
+	attrs := make(map[string]string)
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		eq := strings.IndexByte(rest, '=')
+		if eq == -1 {
+			break
+		}
+		key := rest[:eq]
+		rest = rest[eq+1:]
+		var val string
+		if strings.HasPrefix(rest, "\"") {
+			end := strings.IndexByte(rest[1:], '"')
+			if end == -1 {
+				val, rest = rest[1:], ""
+			} else {
+				val, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t")
+			if end == -1 {
+				val, rest = rest, ""
+			} else {
+				val, rest = rest[:end], rest[end:]
+			}
+		}
+		attrs[key] = val
+	}
+	return tag, attrs
+}
+
+type bmFontXML struct {
+	Common struct {
+		LineHeight int `xml:"lineHeight,attr"`
+		Base       int `xml:"base,attr"`
+	} `xml:"common"`
+	Pages []struct {
+		ID   int    `xml:"id,attr"`
+		File string `xml:"file,attr"`
+	} `xml:"pages>page"`
+	Chars []struct {
+		ID       int `xml:"id,attr"`
+		X        int `xml:"x,attr"`
+		Y        int `xml:"y,attr"`
+		Width    int `xml:"width,attr"`
+		Height   int `xml:"height,attr"`
+		XOffset  int `xml:"xoffset,attr"`
+		YOffset  int `xml:"yoffset,attr"`
+		XAdvance int `xml:"xadvance,attr"`
+		Page     int `xml:"page,attr"`
+	} `xml:"chars>char"`
+	Kernings []struct {
+		First  int `xml:"first,attr"`
+		Second int `xml:"second,attr"`
+		Amount int `xml:"amount,attr"`
+	} `xml:"kernings>kerning"`
+}
+
+func parseBMFontXML(data []byte) (*bmDescriptor, error) {
+	var fx bmFontXML
+	if err := xml.Unmarshal(data, &fx); err != nil {
+		return nil, err
+	}
+	desc := &bmDescriptor{
+		lineHeight: fx.Common.LineHeight,
+		base:       fx.Common.Base,
+	}
+	for _, p := range fx.Pages {
+		if err := desc.setPage(p.ID, p.File); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range fx.Chars {
+		desc.chars = append(desc.chars, bmChar{
+			id: c.ID,
+			x:  c.X, y: c.Y,
+			w: c.Width, h: c.Height,
+			xOffset: c.XOffset, yOffset: c.YOffset,
+			xAdvance: c.XAdvance, page: c.Page,
+		})
+	}
+	for _, k := range fx.Kernings {
+		desc.kernings = append(desc.kernings, bmKerning{
+			first:  k.First,
+			second: k.Second,
+			amount: k.Amount,
+		})
+	}
+	return desc, nil
+}
+
+const (
+	bmBlockInfo    = 1
+	bmBlockCommon  = 2
+	bmBlockPages   = 3
+	bmBlockChars   = 4
+	bmBlockKerning = 5
+
+	bmCharSize    = 20
+	bmKerningSize = 10
+)
+
+func parseBMFontBinary(data []byte) (*bmDescriptor, error) {
+	if len(data) < 4 {
+		return nil, oakerr.InvalidInput{InputName: "data"}
+	}
+	if data[3] != 3 {
+		return nil, oakerr.UnsupportedFormat{Format: "BMF version " + strconv.Itoa(int(data[3]))}
+	}
+	desc := &bmDescriptor{}
+	hasCommon := false
+	le := binary.LittleEndian
+	data = data[4:]
+	for len(data) >= 5 {
+		blockType := data[0]
+		size := int(le.Uint32(data[1:5]))
+		data = data[5:]
+		if size > len(data) {
+			return nil, oakerr.InvalidInput{InputName: "data"}
+		}
+		block := data[:size]
+		data = data[size:]
+		switch blockType {
+		case bmBlockCommon:
+			if len(block) < 4 {
+				return nil, oakerr.InvalidInput{InputName: "data"}
+			}
+			hasCommon = true
+			desc.lineHeight = int(le.Uint16(block[0:2]))
+			desc.base = int(le.Uint16(block[2:4]))
+		case bmBlockPages:
+			for _, name := range bytes.Split(block, []byte{0}) {
+				if len(name) != 0 {
+					desc.pages = append(desc.pages, string(name))
+				}
+			}
+		case bmBlockChars:
+			for i := 0; i+bmCharSize <= len(block); i += bmCharSize {
+				c := block[i : i+bmCharSize]
+				desc.chars = append(desc.chars, bmChar{
+					id:       int(le.Uint32(c[0:4])),
+					x:        int(le.Uint16(c[4:6])),
+					y:        int(le.Uint16(c[6:8])),
+					w:        int(le.Uint16(c[8:10])),
+					h:        int(le.Uint16(c[10:12])),
+					xOffset:  int(int16(le.Uint16(c[12:14]))),
+					yOffset:  int(int16(le.Uint16(c[14:16]))),
+					xAdvance: int(int16(le.Uint16(c[16:18]))),
+					page:     int(c[18]),
+				})
+			}
+		case bmBlockKerning:
+			for i := 0; i+bmKerningSize <= len(block); i += bmKerningSize {
+				k := block[i : i+bmKerningSize]
+				desc.kernings = append(desc.kernings, bmKerning{
+					first:  int(le.Uint32(k[0:4])),
+					second: int(le.Uint32(k[4:8])),
+					amount: int(int16(le.Uint16(k[8:10]))),
+				})
+			}
+		}
+	}
+	if !hasCommon {
+		return nil, oakerr.NotFound{InputName: "common"}
+	}
+	return desc, nil
+}
+
+// bitmapFace implements font.Face for a BitmapFont. Pen positions given to
+// a bitmapFace are on the baseline, as with other faces.
+type bitmapFace struct {
+	*BitmapFont
+}
+
+func (bf bitmapFace) Close() error { return nil }
+
+func (bf bitmapFace) Glyph(dot fixed.Point26_6, r rune) (dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	g, ok := bf.Glyphs[r]
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	min := image.Point{dot.X.Round(), dot.Y.Round() - bf.Base}.Add(g.Offset)
+	dr = image.Rectangle{Min: min, Max: min.Add(g.Bounds.Size())}
+	return dr, bf.Pages[g.Page], g.Bounds.Min, fixed.I(g.Advance), true
+}
+
+func (bf bitmapFace) GlyphBounds(r rune) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	g, ok := bf.Glyphs[r]
+	if !ok {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	min := fixed.P(g.Offset.X, g.Offset.Y-bf.Base)
+	bounds = fixed.Rectangle26_6{Min: min, Max: min.Add(fixed.P(g.Bounds.Dx(), g.Bounds.Dy()))}
+	return bounds, fixed.I(g.Advance), true
+}
+
+func (bf bitmapFace) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
+	g, ok := bf.Glyphs[r]
+	return fixed.I(g.Advance), ok
+}
+
+func (bf bitmapFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	return fixed.I(bf.Kerning[[2]rune{r0, r1}])
+}
+
+func (bf bitmapFace) Metrics() font.Metrics {
+	return font.Metrics{
+		Height:    fixed.I(bf.LineHeight),
+		Ascent:    fixed.I(bf.Base),
+		Descent:   fixed.I(bf.LineHeight - bf.Base),
+		CapHeight: fixed.I(bf.Base),
+		XHeight:   fixed.I(bf.Base),
+	}
+}
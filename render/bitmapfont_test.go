@@ -0,0 +1,248 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/alg/intgeom"
+	"github.com/diakovliev/oak/v4/oakerr"
+)
+
+// bitmapFontPage returns a page with two 2x3 glyphs: a solid red 'A' and a
+// solid blue 'B'.
+func bitmapFontPage() *image.RGBA {
+	page := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for y := 0; y < 3; y++ {
+		page.SetRGBA(0, y, color.RGBA{255, 0, 0, 255})
+		page.SetRGBA(1, y, color.RGBA{255, 0, 0, 255})
+		page.SetRGBA(2, y, color.RGBA{0, 0, 255, 255})
+		page.SetRGBA(3, y, color.RGBA{0, 0, 255, 255})
+	}
+	return page
+}
+
+const bitmapFontText = `info face="Test Font" size=3 bold=0 italic=0
+common lineHeight=5 base=4 scaleW=4 scaleH=3 pages=1 packed=0
+page id=0 file="page 0.png"
+chars count=2
+char id=65 x=0 y=0 width=2 height=3 xoffset=0 yoffset=1 xadvance=3 page=0 chnl=15
+char id=66 x=2 y=0 width=2 height=3 xoffset=0 yoffset=1 xadvance=3 page=0 chnl=15
+kernings count=1
+kerning first=65 second=66 amount=-1
+`
+
+const bitmapFontXML = `<?xml version="1.0"?>
+<font>
+  <info face="Test Font" size="3"/>
+  <common lineHeight="5" base="4" scaleW="4" scaleH="3" pages="1"/>
+  <pages>
+    <page id="0" file="page 0.png"/>
+  </pages>
+  <chars count="2">
+    <char id="65" x="0" y="0" width="2" height="3" xoffset="0" yoffset="1" xadvance="3" page="0" chnl="15"/>
+    <char id="66" x="2" y="0" width="2" height="3" xoffset="0" yoffset="1" xadvance="3" page="0" chnl="15"/>
+  </chars>
+  <kernings count="1">
+    <kerning first="65" second="66" amount="-1"/>
+  </kernings>
+</font>`
+
+func bitmapFontBinary() []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("BMF")
+	buf.WriteByte(3)
+	le := binary.LittleEndian
+	block := func(typ byte, data []byte) {
+		buf.WriteByte(typ)
+		binary.Write(buf, le, uint32(len(data)))
+		buf.Write(data)
+	}
+	common := &bytes.Buffer{}
+	binary.Write(common, le, []uint16{5, 4, 4, 3, 1})
+	common.Write([]byte{0, 0, 0, 0, 0})
+	block(bmBlockCommon, common.Bytes())
+	block(bmBlockPages, []byte("page 0.png\x00"))
+	chars := &bytes.Buffer{}
+	for i, id := range []uint32{65, 66} {
+		binary.Write(chars, le, id)
+		binary.Write(chars, le, []uint16{uint16(i * 2), 0, 2, 3})
+		binary.Write(chars, le, []int16{0, 1, 3})
+		chars.Write([]byte{0, 15})
+	}
+	block(bmBlockChars, chars.Bytes())
+	kerning := &bytes.Buffer{}
+	binary.Write(kerning, le, []uint32{65, 66})
+	binary.Write(kerning, le, int16(-1))
+	block(bmBlockKerning, kerning.Bytes())
+	return buf.Bytes()
+}
+
+func TestParseBitmapFont(t *testing.T) {
+	formats := map[string][]byte{
+		"text":   []byte(bitmapFontText),
+		"xml":    []byte(bitmapFontXML),
+		"binary": bitmapFontBinary(),
+	}
+	for name, data := range formats {
+		data := data
+		t.Run(name, func(t *testing.T) {
+			var loaded string
+			bf, err := ParseBitmapFont(data, func(file string) (*image.RGBA, error) {
+				loaded = file
+				return bitmapFontPage(), nil
+			})
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if loaded != "page 0.png" {
+				t.Fatalf("expected page 'page 0.png' to be loaded, got %q", loaded)
+			}
+			if bf.LineHeight != 5 || bf.Base != 4 {
+				t.Fatalf("expected line height 5 and base 4, got %v and %v", bf.LineHeight, bf.Base)
+			}
+			expectedB := BitmapGlyph{
+				Bounds:  image.Rect(2, 0, 4, 3),
+				Offset:  image.Point{0, 1},
+				Advance: 3,
+			}
+			if bf.Glyphs['B'] != expectedB {
+				t.Fatalf("expected glyph %v, got %v", expectedB, bf.Glyphs['B'])
+			}
+			if bf.Kerning[[2]rune{'A', 'B'}] != -1 {
+				t.Fatalf("expected kerning pair to be parsed")
+			}
+		})
+	}
+}
+
+func TestParseBitmapFont_Errors(t *testing.T) {
+	noPage := func(string) (*image.RGBA, error) { return bitmapFontPage(), nil }
+	if _, err := ParseBitmapFont([]byte("info face=x"), noPage); err == nil {
+		t.Fatalf("expected error for descriptor without common block")
+	}
+	if _, err := ParseBitmapFont([]byte("BMF\x02"), noPage); err == nil {
+		t.Fatalf("expected error for unsupported binary version")
+	}
+	if _, err := ParseBitmapFont([]byte("<font><common"), noPage); err == nil {
+		t.Fatalf("expected error for invalid xml")
+	}
+	for _, id := range []string{"-1", "1000000000"} {
+		text := "common lineHeight=5 base=4\npage id=" + id + " file=\"a.png\"\n"
+		if _, err := ParseBitmapFont([]byte(text), noPage); !errors.As(err, &oakerr.InvalidInput{}) {
+			t.Fatalf("expected invalid input for text page id %v, got %v", id, err)
+		}
+		xml := `<font><common lineHeight="5"/><pages><page id="` + id + `" file="a.png"/></pages></font>`
+		if _, err := ParseBitmapFont([]byte(xml), noPage); !errors.As(err, &oakerr.InvalidInput{}) {
+			t.Fatalf("expected invalid input for xml page id %v, got %v", id, err)
+		}
+	}
+}
+
+func TestLoadBitmapFont(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "page 0.png"))
+	if err != nil {
+		t.Fatalf("failed to create page: %v", err)
+	}
+	png.Encode(f, bitmapFontPage())
+	f.Close()
+	fntFile := filepath.Join(dir, "test.fnt")
+	if err := os.WriteFile(fntFile, []byte(bitmapFontText), 0644); err != nil {
+		t.Fatalf("failed to write descriptor: %v", err)
+	}
+	bf, err := NewCache().LoadBitmapFont(fntFile)
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if bf.Pages[0].RGBAAt(3, 2) != (color.RGBA{0, 0, 255, 255}) {
+		t.Fatalf("page was not loaded")
+	}
+	if _, err := NewCache().LoadBitmapFont(filepath.Join(dir, "missing.fnt")); err == nil {
+		t.Fatalf("expected error loading missing file")
+	}
+}
+
+func TestBitmapFont_Text(t *testing.T) {
+	bf, err := ParseBitmapFont([]byte(bitmapFontText), func(string) (*image.RGBA, error) {
+		return bitmapFontPage(), nil
+	})
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	fg := FontGenerator{Bitmap: bf}
+	fnt, err := fg.Generate()
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	// A (3) + kerning (-1) + B (3); C is not in the font.
+	if w := fnt.MeasureString("ABC").Round(); w != 5 {
+		t.Fatalf("expected width 5, got %v", w)
+	}
+	if fnt.Height() != 5 {
+		t.Fatalf("expected height of line height 5, got %v", fnt.Height())
+	}
+
+	txt := fnt.NewText("AB", 1, 0)
+	if w, h := txt.GetDims(); w != 5 || h != 5 {
+		t.Fatalf("expected dims 5,5, got %v,%v", w, h)
+	}
+	buff := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	txt.Draw(buff, 0, 0)
+	if got := buff.RGBAAt(1, 1); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Fatalf("expected A to be drawn at 1,1 in its page color, got %v", got)
+	}
+	if got := buff.RGBAAt(1, 0); got != (color.RGBA{}) {
+		t.Fatalf("expected glyph offset to leave first row empty, got %v", got)
+	}
+	if got := buff.RGBAAt(3, 3); got != (color.RGBA{0, 0, 255, 255}) {
+		t.Fatalf("expected kerned B to be drawn at 3,3, got %v", got)
+	}
+
+	tinted, err := fnt.RegenerateWith(func(fg FontGenerator) FontGenerator {
+		fg.Color = image.NewUniform(color.RGBA{0, 255, 0, 255})
+		return fg
+	})
+	if err != nil {
+		t.Fatalf("failed to regenerate: %v", err)
+	}
+	buff = image.NewRGBA(image.Rect(0, 0, 10, 10))
+	tinted.Copy().NewText("B", 0, 0).Draw(buff, 0, 0)
+	if got := buff.RGBAAt(0, 1); got != (color.RGBA{0, 255, 0, 255}) {
+		t.Fatalf("expected tinted glyph, got %v", got)
+	}
+	sp := tinted.NewText("AB", 0, 0).ToSprite()
+	if w, _ := sp.GetDims(); w != 5 {
+		t.Fatalf("expected sprite width 5, got %v", w)
+	}
+}
+
+func TestNewGridBitmapFont(t *testing.T) {
+	bf, err := NewGridBitmapFont(bitmapFontPage(), intgeom.Point2{2, 3}, "AB")
+	if err != nil {
+		t.Fatalf("failed to create grid font: %v", err)
+	}
+	if bf.Glyphs['B'].Bounds != image.Rect(2, 0, 4, 3) {
+		t.Fatalf("unexpected glyph bounds %v", bf.Glyphs['B'].Bounds)
+	}
+	fnt, err := (&FontGenerator{Bitmap: bf}).Generate()
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	wrapped := fnt.NewText("ABAB", 0, 0).Wrap(2, float64(bf.LineHeight))
+	if len(wrapped) != 2 {
+		t.Fatalf("expected two wrapped texts, got %v", len(wrapped))
+	}
+	if _, err := NewGridBitmapFont(bitmapFontPage(), intgeom.Point2{2, 3}, "ABC"); err == nil {
+		t.Fatalf("expected error for more characters than cells")
+	}
+	if _, err := NewGridBitmapFont(bitmapFontPage(), intgeom.Point2{0, 3}, "A"); err == nil {
+		t.Fatalf("expected error for empty cells")
+	}
+}
@@ -0,0 +1,218 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// A BlendMode determines how the pixels of a drawn renderable are combined
+// with the pixels already present in the buffer it is drawn to.
+type BlendMode uint8
+
+// BlendMode values
+const (
+	// BlendOver merges pixels based on the source's alpha channel, as draw.Over.
+	// This is the default blend mode.
+	BlendOver BlendMode = iota
+	// BlendAdd adds source colors to destination colors, brightening them.
+	BlendAdd
+	// BlendMultiply multiplies source colors with destination colors, darkening them.
+	BlendMultiply
+	// BlendScreen inverts, multiplies, and inverts colors again, brightening them
+	// without the saturation of BlendAdd.
+	BlendScreen
+	// BlendSubtract subtracts source colors from destination colors.
+	BlendSubtract
+	// BlendReplace overwrites destination pixels, including their alpha, as draw.Src.
+	BlendReplace
+)
+
+// String returns a human readable name for this blend mode.
+func (bm BlendMode) String() string {
+	switch bm {
+	case BlendOver:
+		return "Over"
+	case BlendAdd:
+		return "Add"
+	case BlendMultiply:
+		return "Multiply"
+	case BlendScreen:
+		return "Screen"
+	case BlendSubtract:
+		return "Subtract"
+	case BlendReplace:
+		return "Replace"
+	}
+	return "Unknown"
+}
+
+// Blendable types can be drawn with a BlendMode other than BlendOver.
+//
+// Basic Implementing struct: Blend
+type Blendable interface {
+	GetBlendMode() BlendMode
+	SetBlendMode(BlendMode)
+}
+
+// A Blend object has a blend mode. Its zero value is BlendOver.
+type Blend struct {
+	mode BlendMode
+}
+
+// GetBlendMode returns the blend mode of this object.
+func (bl *Blend) GetBlendMode() BlendMode {
+	return bl.mode
+}
+
+// SetBlendMode sets the blend mode of this object.
+func (bl *Blend) SetBlendMode(mode BlendMode) {
+	bl.mode = mode
+}
+
+// DrawImageBlend acts as DrawImage, combining img with buff according to mode.
+// BlendOver and BlendReplace are delegated to image/draw.
+func DrawImageBlend(buff draw.Image, img image.Image, x, y int, mode BlendMode) {
+	switch mode {
+	case BlendOver:
+		DrawImage(buff, img, x, y)
+		return
+	case BlendReplace:
+		OverwriteImage(buff, img, x, y)
+		return
+	}
+	srcBds := img.Bounds()
+	dstRect := srcBds.Add(image.Point{x, y}).Intersect(buff.Bounds())
+	if dstRect.Empty() {
+		return
+	}
+	if dst, ok := buff.(*image.RGBA); ok {
+		if src, ok := img.(*image.RGBA); ok {
+			blendRGBA(dst, src, dstRect, image.Point{x, y}, mode)
+			return
+		}
+	}
+	for dy := dstRect.Min.Y; dy < dstRect.Max.Y; dy++ {
+		for dx := dstRect.Min.X; dx < dstRect.Max.X; dx++ {
+			sr, sg, sb, sa := img.At(dx-x, dy-y).RGBA()
+			if sa == 0 {
+				continue
+			}
+			dr, dg, db, da := buff.At(dx, dy).RGBA()
+			r, g, b, a := blendPixel(sr, sg, sb, sa, dr, dg, db, da, mode)
+			buff.Set(dx, dy, color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)})
+		}
+	}
+}
+
+func blendRGBA(dst, src *image.RGBA, dstRect image.Rectangle, off image.Point, mode BlendMode) {
+	w := dstRect.Dx()
+	for dy := dstRect.Min.Y; dy < dstRect.Max.Y; dy++ {
+		di := dst.PixOffset(dstRect.Min.X, dy)
+		si := src.PixOffset(dstRect.Min.X-off.X, dy-off.Y)
+		for i := 0; i < w; i, di, si = i+1, di+4, si+4 {
+			sp := src.Pix[si : si+4 : si+4]
+			if sp[3] == 0 {
+				continue
+			}
+			dp := dst.Pix[di : di+4 : di+4]
+			r, g, b, a := blendPixel(
+				uint32(sp[0])*0x101, uint32(sp[1])*0x101, uint32(sp[2])*0x101, uint32(sp[3])*0x101,
+				uint32(dp[0])*0x101, uint32(dp[1])*0x101, uint32(dp[2])*0x101, uint32(dp[3])*0x101,
+				mode)
+			dp[0] = uint8(r >> 8)
+			dp[1] = uint8(g >> 8)
+			dp[2] = uint8(b >> 8)
+			dp[3] = uint8(a >> 8)
+		}
+	}
+}
+
+// BlendColor returns the result of drawing src over dst with the given mode.
+func BlendColor(dst, src color.Color, mode BlendMode) color.RGBA64 {
+	sr, sg, sb, sa := src.RGBA()
+	dr, dg, db, da := dst.RGBA()
+	r, g, b, a := blendPixel(sr, sg, sb, sa, dr, dg, db, da, mode)
+	return color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}
+}
+
+// SetBlended sets the pixel at x, y of buff to c, blended onto the existing
+// pixel with the given mode.
+func SetBlended(buff draw.Image, x, y int, c color.Color, mode BlendMode) {
+	if mode == BlendReplace {
+		buff.Set(x, y, c)
+		return
+	}
+	if mode == BlendOver {
+		if _, _, _, a := c.RGBA(); a == 0xffff {
+			buff.Set(x, y, c)
+			return
+		}
+	}
+	buff.Set(x, y, BlendColor(buff.At(x, y), c, mode))
+}
+
+// blendPixel combines alpha-premultiplied 16 bit source and destination
+// channels.
+func blendPixel(sr, sg, sb, sa, dr, dg, db, da uint32, mode BlendMode) (r, g, b, a uint32) {
+	const m = 0xffff
+	switch mode {
+	case BlendReplace:
+		return sr, sg, sb, sa
+	case BlendAdd:
+		return addClamp(dr, sr), addClamp(dg, sg), addClamp(db, sb), addClamp(da, sa)
+	case BlendSubtract:
+		return subClamp(dr, sr), subClamp(dg, sg), subClamp(db, sb), da
+	case BlendMultiply:
+		// s*d + s*(1-da) + d*(1-sa)
+		mul := func(s, d uint32) uint32 {
+			return uint32((uint64(s)*uint64(d) + uint64(s)*uint64(m-da) + uint64(d)*uint64(m-sa)) / m)
+		}
+		return min16(mul(sr, dr)), min16(mul(sg, dg)), min16(mul(sb, db)), sa + da - sa*da/m
+	case BlendScreen:
+		scr := func(s, d uint32) uint32 {
+			return s + d - s*d/m
+		}
+		return scr(sr, dr), scr(sg, dg), scr(sb, db), scr(sa, da)
+	}
+	// BlendOver
+	inv := m - sa
+	return sr + dr*inv/m, sg + dg*inv/m, sb + db*inv/m, sa + da*inv/m
+}
+
+func addClamp(a, b uint32) uint32 {
+	return min16(a + b)
+}
+
+func subClamp(a, b uint32) uint32 {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
+
+func min16(a uint32) uint32 {
+	if a > 0xffff {
+		return 0xffff
+	}
+	return a
+}
+
+// drawBlended draws r to buff at the given offset, combining it with the
+// buffer using mode. Renderables other than Sprites are first drawn to a
+// temporary image of their dimensions.
+func drawBlended(buff draw.Image, r Renderable, xOff, yOff float64, mode BlendMode) {
+	if mode == BlendOver {
+		r.Draw(buff, xOff, yOff)
+		return
+	}
+	x, y := int(r.X()+xOff), int(r.Y()+yOff)
+	if sp, ok := r.(*Sprite); ok {
+		DrawImageBlend(buff, sp.r, x, y, mode)
+		return
+	}
+	w, h := r.GetDims()
+	tmp := image.NewRGBA(image.Rect(0, 0, w, h))
+	r.Draw(tmp, -r.X(), -r.Y())
+	DrawImageBlend(buff, tmp, x, y, mode)
+}
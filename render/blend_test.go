@@ -0,0 +1,97 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+)
+
+func TestBlendModes(t *testing.T) {
+	type testCase struct {
+		mode     BlendMode
+		dst, src color.RGBA
+		expected color.RGBA
+	}
+	tcs := []testCase{
+		{BlendOver, color.RGBA{100, 100, 100, 255}, color.RGBA{50, 60, 70, 255}, color.RGBA{50, 60, 70, 255}},
+		{BlendAdd, color.RGBA{100, 100, 100, 255}, color.RGBA{50, 60, 200, 255}, color.RGBA{150, 160, 255, 255}},
+		{BlendSubtract, color.RGBA{100, 100, 100, 255}, color.RGBA{50, 160, 70, 255}, color.RGBA{50, 0, 30, 255}},
+		{BlendMultiply, color.RGBA{255, 128, 0, 255}, color.RGBA{128, 128, 128, 255}, color.RGBA{128, 64, 0, 255}},
+		{BlendScreen, color.RGBA{0, 255, 128, 255}, color.RGBA{128, 0, 128, 255}, color.RGBA{128, 255, 192, 255}},
+		{BlendReplace, color.RGBA{100, 100, 100, 255}, color.RGBA{0, 0, 0, 0}, color.RGBA{0, 0, 0, 0}},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.mode.String(), func(t *testing.T) {
+			dst := NewColorBoxM(4, 4, tc.dst)
+			src := NewColorBoxM(2, 2, tc.src)
+			src.SetBlendMode(tc.mode)
+			src.SetPos(1, 1)
+			src.Draw(dst, 0, 0)
+			got := dst.GetRGBA().RGBAAt(1, 1)
+			if !colorsClose(got, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, got)
+			}
+			untouched := dst.GetRGBA().RGBAAt(0, 0)
+			if untouched != tc.dst {
+				t.Fatalf("expected pixel outside of source to be %v, got %v", tc.dst, untouched)
+			}
+		})
+	}
+}
+
+func colorsClose(a, b color.RGBA) bool {
+	near := func(x, y uint8) bool {
+		d := int(x) - int(y)
+		return d >= -1 && d <= 1
+	}
+	return near(a.R, b.R) && near(a.G, b.G) && near(a.B, b.B) && near(a.A, b.A)
+}
+
+func TestDrawImageBlend_NonRGBA(t *testing.T) {
+	dst := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for x := 0; x < 2; x++ {
+		for y := 0; y < 2; y++ {
+			dst.Set(x, y, color.RGBA{100, 100, 100, 255})
+		}
+	}
+	src := image.NewUniform(color.RGBA{50, 50, 50, 255})
+	DrawImageBlend(dst, image.NewRGBA(image.Rect(0, 0, 0, 0)), 0, 0, BlendAdd)
+	SetBlended(dst, 1, 1, src.C, BlendAdd)
+	got := color.RGBAModel.Convert(dst.At(1, 1)).(color.RGBA)
+	if got != (color.RGBA{150, 150, 150, 255}) {
+		t.Fatalf("expected added color, got %v", got)
+	}
+}
+
+func TestCompositeM_Blend(t *testing.T) {
+	cmp := NewCompositeM(
+		NewColorBoxM(1, 1, color.RGBA{10, 10, 10, 255}),
+		NewColorBoxM(1, 1, color.RGBA{20, 20, 20, 255}),
+	)
+	cmp.SetOffsets(floatgeom.Point2{0, 0}, floatgeom.Point2{1, 0})
+	cmp.SetBlendMode(BlendAdd)
+	dst := NewColorBoxM(2, 1, color.RGBA{100, 100, 100, 255})
+	cmp.Draw(dst, 0, 0)
+	if got := dst.GetRGBA().RGBAAt(0, 0); got != (color.RGBA{110, 110, 110, 255}) {
+		t.Fatalf("expected added first part, got %v", got)
+	}
+	if got := dst.GetRGBA().RGBAAt(1, 0); got != (color.RGBA{120, 120, 120, 255}) {
+		t.Fatalf("expected added second part, got %v", got)
+	}
+	if cmp.Copy().(*CompositeM).GetBlendMode() != BlendAdd {
+		t.Fatalf("copy did not retain blend mode")
+	}
+}
+
+func TestSequence_Blend(t *testing.T) {
+	sq := NewSequence(1, NewColorBoxM(1, 1, color.RGBA{255, 255, 255, 255}))
+	sq.SetBlendMode(BlendSubtract)
+	dst := NewColorBoxM(1, 1, color.RGBA{100, 100, 100, 255})
+	sq.Draw(dst, 0, 0)
+	if got := dst.GetRGBA().RGBAAt(0, 0); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Fatalf("expected subtracted color, got %v", got)
+	}
+}
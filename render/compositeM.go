@@ -13,6 +13,7 @@ import (
 // position of the composite itself
 type CompositeM struct {
 	LayeredPoint
+	Blend
 	rs []Modifiable
 }
 
@@ -57,6 +58,7 @@ func (cs *CompositeM) Slice(start, end int) *CompositeM {
 	newRs := cs.rs[start:end]
 	return &CompositeM{
 		LayeredPoint: cs.LayeredPoint.Copy(),
+		Blend:        cs.Blend,
 		rs:           newRs,
 	}
 }
@@ -88,10 +90,11 @@ func (cs *CompositeM) Get(i int) Modifiable {
 }
 
 // Draw draws the CompositeM with some offset from its logical position
-// (and therefore sub renderables logical positions).
+// (and therefore sub renderables logical positions). If the CompositeM has a
+// blend mode other than BlendOver, each part is drawn with that mode.
 func (cs *CompositeM) Draw(buff draw.Image, xOff, yOff float64) {
 	for _, c := range cs.rs {
-		c.Draw(buff, cs.X()+xOff, cs.Y()+yOff)
+		drawBlended(buff, c, cs.X()+xOff, cs.Y()+yOff, cs.mode)
 	}
 }
 
@@ -148,6 +151,7 @@ func (cs *CompositeM) ToSprite() *Sprite {
 func (cs *CompositeM) Copy() Modifiable {
 	cs2 := new(CompositeM)
 	cs2.layer = cs.layer
+	cs2.mode = cs.mode
 	cs2.Vector = cs.Vector.Copy()
 	cs2.rs = make([]Modifiable, len(cs.rs))
 	for i, v := range cs.rs {
@@ -39,6 +39,7 @@ type Font struct {
 	gen FontGenerator
 	font.Drawer
 	ttfnt  *truetype.Font
+	bitmap *BitmapFont
 	bounds intgeom.Rect2
 	Unsafe bool
 	mutex  sync.Mutex
@@ -51,7 +52,12 @@ type FontGenerator struct {
 	Cache   *Cache
 	File    string
 	RawFile []byte
-	Color   image.Image
+	// Bitmap, if provided, is used in place of File or RawFile to generate
+	// a font from pre-rendered glyphs. FontOptions are ignored for bitmap
+	// fonts. If Color is not provided, bitmap glyphs are drawn in the colors
+	// of their page images.
+	Bitmap *BitmapFont
+	Color  image.Image
 	// FontOptions holds all optional font components. Reasonable defaults
 	// will be used if these are not provided.
 	FontOptions
@@ -67,16 +73,17 @@ func DefaultFont() *Font {
 }
 
 func (fg FontGenerator) validate() error {
-	if len(fg.File) == 0 && len(fg.RawFile) == 0 {
+	if len(fg.File) == 0 && len(fg.RawFile) == 0 && fg.Bitmap == nil {
 		return oakerr.InvalidInput{InputName: "File"}
 	}
-	if fg.Color == nil {
+	if fg.Color == nil && fg.Bitmap == nil {
 		return oakerr.InvalidInput{InputName: "Color"}
 	}
 	return nil
 }
 
-// Generate generates a font. File or RawFile and Color must be provided.
+// Generate generates a font. File, RawFile, or Bitmap must be provided. Color
+// must be provided unless Bitmap is.
 // If Cache and File are provided, the generated font will be stored in the provided cache.
 // If Cache is not provided, it will default to DefaultCache.
 func (fg *FontGenerator) Generate() (*Font, error) {
@@ -86,6 +93,9 @@ func (fg *FontGenerator) Generate() (*Font, error) {
 	if fg.Cache == nil {
 		fg.Cache = DefaultCache
 	}
+	if fg.Bitmap != nil {
+		return fg.generateBitmap(), nil
+	}
 
 	var fnt *truetype.Font
 	var err error
@@ -130,6 +140,28 @@ func (fg *FontGenerator) Generate() (*Font, error) {
 	}, nil
 }
 
+func (fg *FontGenerator) generateBitmap() *Font {
+	bm := fg.Bitmap
+	maxW := 0
+	for _, g := range bm.Glyphs {
+		if w := g.Offset.X + g.Bounds.Dx(); w > maxW {
+			maxW = w
+		}
+	}
+	gen := *fg
+	// Text layout relies on Size as the font's height
+	gen.FontOptions.Size = float64(bm.LineHeight)
+	return &Font{
+		gen: gen,
+		Drawer: font.Drawer{
+			Src:  fg.Color,
+			Face: bitmapFace{bm},
+		},
+		bitmap: bm,
+		bounds: intgeom.NewRect2(0, -bm.Base, maxW, bm.LineHeight-bm.Base),
+	}
+}
+
 // RegenerateWith creates a new font off of this generator after changing its generation settings.
 func (fg FontGenerator) RegenerateWith(fgFunc func(FontGenerator) FontGenerator) (*Font, error) {
 	g := fgFunc(fg)
@@ -150,11 +182,14 @@ func (f *Font) Copy() *Font {
 		gen:       f.gen,
 		Drawer:    f.Drawer,
 		ttfnt:     f.ttfnt,
+		bitmap:    f.bitmap,
 		bounds:    f.bounds,
 		Unsafe:    f.Unsafe,
 		Fallbacks: f.Fallbacks,
 	}
-	f2.Drawer.Face = truetype.NewFace(f.ttfnt, &f.gen.FontOptions)
+	if f.bitmap == nil {
+		f2.Drawer.Face = truetype.NewFace(f.ttfnt, &f.gen.FontOptions)
+	}
 	return f2
 }
 
@@ -167,14 +202,14 @@ func (f *Font) MeasureString(s string) fixed.Int26_6 {
 	var width fixed.Int26_6
 	for _, c := range s {
 		if prevC >= 0 {
-			f.Drawer.Dot.X += f.Drawer.Face.Kern(prevC, c)
+			width += f.Drawer.Face.Kern(prevC, c)
 		}
 		_, _, _, advance, ok := f.Drawer.Face.Glyph(f.Drawer.Dot, c)
-		if idx := f.ttfnt.Index(c); !ok || idx == 0 {
+		if !ok || !f.hasGlyph(c) {
 			found := false
 			for _, fallback := range f.Fallbacks {
 				_, _, _, advance, ok = fallback.Drawer.Face.Glyph(f.Drawer.Dot, c)
-				if idx := fallback.ttfnt.Index(c); ok && idx != 0 {
+				if ok && fallback.hasGlyph(c) {
 					found = true
 					break
 				}
@@ -198,11 +233,11 @@ func (f *Font) drawString(s string) {
 			f.Drawer.Dot.X += f.Drawer.Face.Kern(prevC, c)
 		}
 		dr, mask, maskp, advance, ok := f.Drawer.Face.Glyph(f.Drawer.Dot, c)
-		if idx := f.ttfnt.Index(c); !ok || idx == 0 {
+		if !ok || !f.hasGlyph(c) {
 			found := false
 			for _, fallback := range f.Fallbacks {
 				dr, mask, maskp, advance, ok = fallback.Drawer.Face.Glyph(f.Drawer.Dot, c)
-				if idx := fallback.ttfnt.Index(c); ok && idx != 0 {
+				if ok && fallback.hasGlyph(c) {
 					found = true
 					break
 				}
@@ -211,12 +246,38 @@ func (f *Font) drawString(s string) {
 				continue
 			}
 		}
-		draw.DrawMask(f.Drawer.Dst, dr, f.Drawer.Src, image.Point{}, mask, maskp, draw.Over)
+		if f.Drawer.Src == nil {
+			// Bitmap fonts without a color draw their glyphs as they are
+			draw.Draw(f.Drawer.Dst, dr, mask, maskp, draw.Over)
+		} else {
+			draw.DrawMask(f.Drawer.Dst, dr, f.Drawer.Src, image.Point{}, mask, maskp, draw.Over)
+		}
 		f.Drawer.Dot.X += advance
 		prevC = c
 	}
 }
 
+// hasGlyph reports whether this font, ignoring fallbacks, can draw c.
+func (f *Font) hasGlyph(c rune) bool {
+	if f.bitmap != nil {
+		_, ok := f.bitmap.Glyphs[c]
+		return ok
+	}
+	return f.ttfnt.Index(c) != 0
+}
+
+// baseline returns the distance from the top of a line of text to where
+// glyphs are drawn from.
+func (f *Font) baseline() float64 {
+	if f.bitmap != nil {
+		return float64(f.bitmap.Base)
+	}
+	if f.gen.Size == 0 {
+		return defFontSize
+	}
+	return f.gen.Size
+}
+
 // Height returns the height or size of the font
 func (f *Font) Height() float64 {
 	if f.gen.Size == 0 {
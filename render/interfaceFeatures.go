@@ -17,3 +17,8 @@ type Triggerable interface {
 type updates interface {
 	update()
 }
+
+// Resizable types can be redrawn at new dimensions.
+type Resizable interface {
+	Resize(w, h int)
+}
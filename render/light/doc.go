@@ -0,0 +1,3 @@
+// Package light provides a render.Stackable for darkening a scene and lighting
+// it with point and spot lights that cast shadows from collision spaces.
+package light
@@ -0,0 +1,160 @@
+package light
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/alg/intgeom"
+	"github.com/diakovliev/oak/v4/collision"
+	"github.com/diakovliev/oak/v4/render"
+)
+
+// A Layer is a render.Stackable which builds a light map from an ambient
+// color and a set of lights, then multiplies that light map over everything
+// drawn by the stackables beneath it. Lights are positioned in world space,
+// like renderables on a dynamic heap.
+//
+// Renderables added to a Layer which are not Lights are drawn directly onto
+// the light map, so sprites may be used as custom light shapes.
+type Layer struct {
+	// Ambient is the light level of pixels no light reaches. Black makes unlit
+	// areas completely dark, white leaves them unchanged.
+	Ambient color.Color
+	// Tree is the collision tree occluders are found in. If Tree is nil,
+	// lights do not cast shadows.
+	Tree *collision.Tree
+	// OccluderLabel is the label of spaces in Tree which block light.
+	OccluderLabel collision.Label
+
+	rs       []render.Renderable
+	toPush   []render.Renderable
+	toUndraw []render.Renderable
+	addLock  sync.Mutex
+	lightMap *image.RGBA
+}
+
+// NewLayer creates a lighting layer with the given ambient light level, casting
+// shadows from spaces in tree labeled with occluder.
+func NewLayer(ambient color.Color, tree *collision.Tree, occluder collision.Label) *Layer {
+	return &Layer{
+		Ambient:       ambient,
+		Tree:          tree,
+		OccluderLabel: occluder,
+	}
+}
+
+// Add stages a light or other renderable to be added to the layer.
+func (ly *Layer) Add(r render.Renderable, layers ...int) render.Renderable {
+	if len(layers) > 0 {
+		r.SetLayer(layers[0])
+	}
+	ly.addLock.Lock()
+	ly.toPush = append(ly.toPush, r)
+	ly.addLock.Unlock()
+	return r
+}
+
+// Replace adds a renderable and removes an old one.
+func (ly *Layer) Replace(old, new render.Renderable, layer int) {
+	new.SetLayer(layer)
+	ly.addLock.Lock()
+	ly.toPush = append(ly.toPush, new)
+	ly.toUndraw = append(ly.toUndraw, old)
+	ly.addLock.Unlock()
+}
+
+// PreDraw adds staged renderables to the layer and removes undrawn ones.
+func (ly *Layer) PreDraw() {
+	ly.addLock.Lock()
+	for _, r := range ly.toUndraw {
+		if r != nil {
+			r.Undraw()
+		}
+	}
+	ly.toUndraw = ly.toUndraw[:0]
+	for _, r := range ly.toPush {
+		if r != nil {
+			ly.rs = append(ly.rs, r)
+		}
+	}
+	ly.toPush = ly.toPush[:0]
+	ly.addLock.Unlock()
+	kept := ly.rs[:0]
+	for _, r := range ly.rs {
+		if r.GetLayer() != render.Undraw {
+			kept = append(kept, r)
+		}
+	}
+	for i := len(kept); i < len(ly.rs); i++ {
+		ly.rs[i] = nil
+	}
+	ly.rs = kept
+}
+
+// Copy returns a layer with the same ambient light and occluders, but none
+// of this layer's lights.
+func (ly *Layer) Copy() render.Stackable {
+	return NewLayer(ly.Ambient, ly.Tree, ly.OccluderLabel)
+}
+
+// Clear removes all lights from the layer.
+func (ly *Layer) Clear() {
+	ly.addLock.Lock()
+	ly.rs = nil
+	ly.toPush = nil
+	ly.toUndraw = nil
+	ly.addLock.Unlock()
+}
+
+// DrawToScreen builds this layer's light map for the screen area at viewPos
+// and multiplies it onto world.
+func (ly *Layer) DrawToScreen(world draw.Image, viewPos *intgeom.Point2, screenW, screenH int) {
+	ly.drawLightMap(viewPos, screenW, screenH)
+	render.DrawImageBlend(world, ly.lightMap, 0, 0, render.BlendMultiply)
+}
+
+func (ly *Layer) drawLightMap(viewPos *intgeom.Point2, screenW, screenH int) {
+	if ly.lightMap == nil || ly.lightMap.Bounds().Dx() != screenW || ly.lightMap.Bounds().Dy() != screenH {
+		ly.lightMap = image.NewRGBA(image.Rect(0, 0, screenW, screenH))
+	}
+	ambient := ly.Ambient
+	if ambient == nil {
+		ambient = color.Black
+	}
+	r, g, b, _ := ambient.RGBA()
+	draw.Draw(ly.lightMap, ly.lightMap.Bounds(),
+		image.NewUniform(color.RGBA64{uint16(r), uint16(g), uint16(b), 0xffff}),
+		image.Point{}, draw.Src)
+
+	vx, vy := float64(-viewPos[0]), float64(-viewPos[1])
+	for _, rend := range ly.rs {
+		if l, ok := rend.(*Light); ok {
+			l.draw(ly.lightMap, vx, vy, ly.occluders(l.bounds()))
+			continue
+		}
+		rend.Draw(ly.lightMap, vx, vy)
+	}
+}
+
+// occluders returns the bounds of all occluding spaces intersecting area.
+func (ly *Layer) occluders(area floatgeom.Rect2) []floatgeom.Rect2 {
+	if ly.Tree == nil {
+		return nil
+	}
+	search := floatgeom.NewRect3(area.Min.X(), area.Min.Y(), -math.MaxFloat64,
+		area.Max.X(), area.Max.Y(), math.MaxFloat64)
+	ly.Tree.Lock()
+	spaces := ly.Tree.SearchIntersect(search)
+	ly.Tree.Unlock()
+	rects := make([]floatgeom.Rect2, 0, len(spaces))
+	for _, s := range spaces {
+		if s.Label == ly.OccluderLabel {
+			rects = append(rects, s.Location.ProjectZ())
+		}
+	}
+	return rects
+}
@@ -0,0 +1,124 @@
+package light
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/alg/intgeom"
+	"github.com/diakovliev/oak/v4/collision"
+	"github.com/diakovliev/oak/v4/render"
+)
+
+func whiteWorld(w, h int) *image.RGBA {
+	world := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(world, world.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	return world
+}
+
+func TestLayer_AmbientAndPointLight(t *testing.T) {
+	ly := NewLayer(color.RGBA{20, 20, 20, 255}, nil, 0)
+	l := NewPointLight(10, 10, 5, color.RGBA{255, 0, 0, 255})
+	ly.Add(l)
+	ly.PreDraw()
+
+	world := whiteWorld(40, 40)
+	ly.DrawToScreen(world, &intgeom.Point2{0, 0}, 40, 40)
+
+	if got := world.RGBAAt(30, 30); got != (color.RGBA{20, 20, 20, 255}) {
+		t.Fatalf("expected ambient color away from light, got %v", got)
+	}
+	got := world.RGBAAt(10, 10)
+	if got.R < 200 || got.G != 20 || got.B != 20 {
+		t.Fatalf("expected red light at center, got %v", got)
+	}
+	edge := world.RGBAAt(13, 10)
+	if edge.R >= got.R || edge.R <= 20 {
+		t.Fatalf("expected light to fall off toward its radius, center %v edge %v", got, edge)
+	}
+}
+
+func TestLayer_Viewport(t *testing.T) {
+	ly := NewLayer(color.Black, nil, 0)
+	ly.Add(NewPointLight(110, 110, 3, color.White))
+	ly.PreDraw()
+
+	world := whiteWorld(20, 20)
+	ly.DrawToScreen(world, &intgeom.Point2{100, 100}, 20, 20)
+	if got := world.RGBAAt(10, 10); got.R == 0 {
+		t.Fatalf("expected light to be offset by the viewport, got %v", got)
+	}
+}
+
+func TestLayer_Shadows(t *testing.T) {
+	const wallLabel collision.Label = 3
+	tree := collision.NewTree()
+	tree.Add(collision.NewLabeledSpace(15, 5, 2, 10, wallLabel))
+	// Non-occluding spaces do not cast shadows
+	tree.Add(collision.NewLabeledSpace(5, 5, 2, 10, wallLabel+1))
+
+	ly := NewLayer(color.Black, tree, wallLabel)
+	ly.Add(NewPointLight(10, 10, 15, color.White))
+	ly.PreDraw()
+
+	world := whiteWorld(30, 30)
+	ly.DrawToScreen(world, &intgeom.Point2{0, 0}, 30, 30)
+	if got := world.RGBAAt(20, 10); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Fatalf("expected shadow behind occluder, got %v", got)
+	}
+	if got := world.RGBAAt(3, 10); got.R == 0 {
+		t.Fatalf("expected light behind unlabeled space, got %v", got)
+	}
+	if got := world.RGBAAt(15, 10); got.R == 0 {
+		t.Fatalf("expected occluder to be lit, got %v", got)
+	}
+}
+
+func TestLayer_SpotLight(t *testing.T) {
+	ly := NewLayer(color.Black, nil, 0)
+	ly.Add(NewSpotLight(10, 10, 8, color.White, 0, math.Pi/2))
+	ly.PreDraw()
+
+	world := whiteWorld(20, 20)
+	ly.DrawToScreen(world, &intgeom.Point2{0, 0}, 20, 20)
+	if got := world.RGBAAt(13, 10); got.R == 0 {
+		t.Fatalf("expected light in spot light direction, got %v", got)
+	}
+	if got := world.RGBAAt(7, 10); got.R != 0 {
+		t.Fatalf("expected no light behind spot light, got %v", got)
+	}
+}
+
+func TestLayer_UndrawAndClear(t *testing.T) {
+	ly := NewLayer(color.Black, nil, 0)
+	l := NewPointLight(10, 10, 5, color.White)
+	ly.Add(l)
+	ly.Add(render.NewColorBoxR(2, 2, color.White))
+	ly.PreDraw()
+	if len(ly.rs) != 2 {
+		t.Fatalf("expected 2 renderables, got %v", len(ly.rs))
+	}
+	l.Undraw()
+	ly.PreDraw()
+	if len(ly.rs) != 1 {
+		t.Fatalf("expected undrawn light to be removed, got %v renderables", len(ly.rs))
+	}
+
+	world := whiteWorld(20, 20)
+	ly.DrawToScreen(world, &intgeom.Point2{0, 0}, 20, 20)
+	if got := world.RGBAAt(1, 1); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Fatalf("expected renderable drawn to the light map, got %v", got)
+	}
+
+	ly.Clear()
+	ly.PreDraw()
+	if len(ly.rs) != 0 {
+		t.Fatalf("expected clear to remove lights")
+	}
+	cp := ly.Copy().(*Layer)
+	if cp.OccluderLabel != ly.OccluderLabel || cp.Tree != ly.Tree {
+		t.Fatalf("copy did not retain occluder settings")
+	}
+}
@@ -0,0 +1,177 @@
+package light
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/diakovliev/oak/v4/alg/floatgeom"
+	"github.com/diakovliev/oak/v4/render"
+)
+
+// A Light is a source of light with a color and a radius. Its position is
+// the center of the light. A Light with a Spread between 0 and 2π is a spot
+// light, emitting only within Spread radians centered on Direction.
+type Light struct {
+	render.LayeredPoint
+	Color  color.Color
+	Radius float64
+	// Falloff is the exponent applied to the light's attenuation over its
+	// radius. 1 is linear, 2 is quadratic, and 0 is a light of constant
+	// intensity.
+	Falloff float64
+	// Direction is the angle, in radians, a spot light points toward.
+	// 0 points right and angles increase clockwise on screen.
+	Direction float64
+	// Spread is the angular width of a spot light, in radians.
+	Spread float64
+}
+
+// NewPointLight creates a light emitting in all directions from x, y.
+func NewPointLight(x, y, radius float64, c color.Color) *Light {
+	return &Light{
+		LayeredPoint: render.NewLayeredPoint(x, y, 0),
+		Color:        c,
+		Radius:       radius,
+		Falloff:      1,
+	}
+}
+
+// NewSpotLight creates a light emitting from x, y within a cone of spread
+// radians centered on direction.
+func NewSpotLight(x, y, radius float64, c color.Color, direction, spread float64) *Light {
+	l := NewPointLight(x, y, radius, c)
+	l.Direction = direction
+	l.Spread = spread
+	return l
+}
+
+// GetDims returns the diameter of the light in both dimensions.
+func (l *Light) GetDims() (int, int) {
+	d := int(math.Ceil(l.Radius * 2))
+	return d, d
+}
+
+// Draw adds this light's contribution to buff, without casting shadows.
+func (l *Light) Draw(buff draw.Image, xOff, yOff float64) {
+	l.draw(buff, xOff, yOff, nil)
+}
+
+// isSpot reports whether this light is limited to a cone.
+func (l *Light) isSpot() bool {
+	return l.Spread > 0 && l.Spread < 2*math.Pi
+}
+
+// bounds returns the world space rectangle this light can illuminate.
+func (l *Light) bounds() floatgeom.Rect2 {
+	return floatgeom.NewRect2(l.X()-l.Radius, l.Y()-l.Radius, l.X()+l.Radius, l.Y()+l.Radius)
+}
+
+// draw adds this light to buff, which represents world space offset by
+// xOff, yOff. Pixels hidden from the light by an occluder are not lit.
+func (l *Light) draw(buff draw.Image, xOff, yOff float64, occluders []floatgeom.Rect2) {
+	if l.Radius <= 0 || l.Color == nil {
+		return
+	}
+	cr, cg, cb, _ := l.Color.RGBA()
+	lx, ly := l.X(), l.Y()
+	bds := image.Rect(
+		int(math.Floor(lx-l.Radius+xOff)), int(math.Floor(ly-l.Radius+yOff)),
+		int(math.Ceil(lx+l.Radius+xOff)), int(math.Ceil(ly+l.Radius+yOff)),
+	).Intersect(buff.Bounds())
+	rgba, isRGBA := buff.(*image.RGBA)
+	for py := bds.Min.Y; py < bds.Max.Y; py++ {
+		for px := bds.Min.X; px < bds.Max.X; px++ {
+			// Sample from pixel centers, in world space
+			wx := float64(px) - xOff + .5
+			wy := float64(py) - yOff + .5
+			dx, dy := wx-lx, wy-ly
+			dist := math.Hypot(dx, dy)
+			if dist >= l.Radius {
+				continue
+			}
+			if l.isSpot() && math.Abs(angleDiff(math.Atan2(dy, dx), l.Direction)) > l.Spread/2 {
+				continue
+			}
+			if shadowed(lx, ly, wx, wy, occluders) {
+				continue
+			}
+			intensity := math.Pow(1-dist/l.Radius, l.Falloff)
+			r := uint32(float64(cr) * intensity)
+			g := uint32(float64(cg) * intensity)
+			b := uint32(float64(cb) * intensity)
+			if isRGBA {
+				i := rgba.PixOffset(px, py)
+				p := rgba.Pix[i : i+4 : i+4]
+				p[0] = addChannel(p[0], r)
+				p[1] = addChannel(p[1], g)
+				p[2] = addChannel(p[2], b)
+				p[3] = 255
+				continue
+			}
+			render.SetBlended(buff, px, py, color.RGBA64{uint16(r), uint16(g), uint16(b), 0xffff}, render.BlendAdd)
+		}
+	}
+}
+
+func addChannel(c uint8, v uint32) uint8 {
+	sum := uint32(c) + v>>8
+	if sum > 255 {
+		return 255
+	}
+	return uint8(sum)
+}
+
+// angleDiff returns a-b normalized to [-π, π].
+func angleDiff(a, b float64) float64 {
+	d := math.Mod(a-b, 2*math.Pi)
+	if d > math.Pi {
+		d -= 2 * math.Pi
+	} else if d < -math.Pi {
+		d += 2 * math.Pi
+	}
+	return d
+}
+
+// shadowed reports whether the segment from (x1, y1) to (x2, y2) passes
+// through any of the given occluders. Occluders containing either end of the
+// segment do not block it, so lights inside occluders still shine and
+// occluders themselves are lit.
+func shadowed(x1, y1, x2, y2 float64, occluders []floatgeom.Rect2) bool {
+	for _, o := range occluders {
+		if o.Contains(floatgeom.Point2{x2, y2}) || o.Contains(floatgeom.Point2{x1, y1}) {
+			continue
+		}
+		if segmentHitsRect(x1, y1, x2, y2, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentHitsRect performs a slab test of a segment against a rectangle.
+func segmentHitsRect(x1, y1, x2, y2 float64, r floatgeom.Rect2) bool {
+	tMin, tMax := 0.0, 1.0
+	d := [2]float64{x2 - x1, y2 - y1}
+	o := [2]float64{x1, y1}
+	for i := 0; i < 2; i++ {
+		if d[i] == 0 {
+			if o[i] < r.Min[i] || o[i] > r.Max[i] {
+				return false
+			}
+			continue
+		}
+		t1 := (r.Min[i] - o[i]) / d[i]
+		t2 := (r.Max[i] - o[i]) / d[i]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		tMin = math.Max(tMin, t1)
+		tMax = math.Min(tMax, t2)
+		if tMin > tMax {
+			return false
+		}
+	}
+	return true
+}
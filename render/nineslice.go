@@ -0,0 +1,178 @@
+package render
+
+import (
+	"image"
+
+	"github.com/diakovliev/oak/v4/render/mod"
+)
+
+// Insets describe the widths of the borders of a nine-slice source image.
+type Insets struct {
+	Left, Top, Right, Bottom int
+}
+
+// A NineSliceMode determines how the edges and center of a NineSlice fill
+// space beyond their size in the source image.
+type NineSliceMode uint8
+
+// NineSliceMode values
+const (
+	// NineSliceStretch scales edges and the center to fill their area.
+	NineSliceStretch NineSliceMode = iota
+	// NineSliceTile repeats edges and the center to fill their area.
+	NineSliceTile
+)
+
+// A NineSlice is a Sprite built from a source image split into nine regions
+// by a set of insets. When resized, its corners keep their size while its
+// edges and center are stretched or tiled to fill the requested dimensions.
+type NineSlice struct {
+	*Sprite
+	src     *image.RGBA
+	insets  Insets
+	mode    NineSliceMode
+	w, h    int
+	mods    []mod.Mod
+	filters []mod.Filter
+}
+
+// NewNineSlice creates a NineSlice of dimensions w, h from src, with the given
+// border insets. Insets are clamped to fit within src: negative insets become
+// zero, and opposing insets which leave no center in src are shrunk in
+// proportion until they leave a center one pixel wide and tall.
+func NewNineSlice(src *image.RGBA, insets Insets, w, h int) *NineSlice {
+	sb := src.Bounds()
+	insets.Left, insets.Right = clampInsets(insets.Left, insets.Right, sb.Dx())
+	insets.Top, insets.Bottom = clampInsets(insets.Top, insets.Bottom, sb.Dy())
+	ns := &NineSlice{
+		Sprite: NewSprite(0, 0, nil),
+		src:    src,
+		insets: insets,
+		w:      w,
+		h:      h,
+	}
+	ns.rebuild()
+	return ns
+}
+
+// clampInsets fits a pair of opposing insets within size, leaving at least one
+// pixel between them.
+func clampInsets(a, b, size int) (int, int) {
+	if a < 0 {
+		a = 0
+	}
+	if b < 0 {
+		b = 0
+	}
+	if size == 0 {
+		return 0, 0
+	}
+	return fitBorders(a, b, size-1)
+}
+
+// SetMode sets how this NineSlice's edges and center fill their area.
+func (ns *NineSlice) SetMode(mode NineSliceMode) {
+	ns.mode = mode
+	ns.rebuild()
+}
+
+// Resize rebuilds this NineSlice at the new dimensions.
+func (ns *NineSlice) Resize(w, h int) {
+	if w == ns.w && h == ns.h {
+		return
+	}
+	ns.w = w
+	ns.h = h
+	ns.rebuild()
+}
+
+// Modify applies mods to this NineSlice. Mods are reapplied
+// whenever the NineSlice is resized.
+func (ns *NineSlice) Modify(ms ...mod.Mod) Modifiable {
+	ns.mods = append(ns.mods, ms...)
+	ns.Sprite.Modify(ms...)
+	return ns
+}
+
+// Filter applies filters to this NineSlice. Filters are reapplied
+// whenever the NineSlice is resized.
+func (ns *NineSlice) Filter(fs ...mod.Filter) {
+	ns.filters = append(ns.filters, fs...)
+	ns.Sprite.Filter(fs...)
+}
+
+// Copy returns a copy of this NineSlice
+func (ns *NineSlice) Copy() Modifiable {
+	ns2 := new(NineSlice)
+	*ns2 = *ns
+	ns2.Sprite = ns.Sprite.Copy().(*Sprite)
+	ns2.src = rgbaCopy(ns.src)
+	ns2.mods = append([]mod.Mod{}, ns.mods...)
+	ns2.filters = append([]mod.Filter{}, ns.filters...)
+	return ns2
+}
+
+func (ns *NineSlice) rebuild() {
+	w, h := ns.w, ns.h
+	if w < 0 {
+		w = 0
+	}
+	if h < 0 {
+		h = 0
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	sb := ns.src.Bounds()
+	in := ns.insets
+	srcXs := [4]int{sb.Min.X, sb.Min.X + in.Left, sb.Max.X - in.Right, sb.Max.X}
+	srcYs := [4]int{sb.Min.Y, sb.Min.Y + in.Top, sb.Max.Y - in.Bottom, sb.Max.Y}
+	left, right := fitBorders(in.Left, in.Right, w)
+	top, bottom := fitBorders(in.Top, in.Bottom, h)
+	dstXs := [4]int{0, left, w - right, w}
+	dstYs := [4]int{0, top, h - bottom, h}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			srcRect := image.Rect(srcXs[i], srcYs[j], srcXs[i+1], srcYs[j+1])
+			dstRect := image.Rect(dstXs[i], dstYs[j], dstXs[i+1], dstYs[j+1])
+			tileX := i == 1 && ns.mode == NineSliceTile
+			tileY := j == 1 && ns.mode == NineSliceTile
+			fillRegion(dst, dstRect, ns.src, srcRect, tileX, tileY)
+		}
+	}
+	ns.Sprite.SetRGBA(dst)
+	ns.Sprite.Modify(ns.mods...)
+	ns.Sprite.Filter(ns.filters...)
+}
+
+// fitBorders shrinks two border sizes proportionally if they exceed size.
+func fitBorders(a, b, size int) (int, int) {
+	if a+b <= size || a+b == 0 {
+		return a, b
+	}
+	a2 := a * size / (a + b)
+	return a2, size - a2
+}
+
+// fillRegion fills dstRect of dst with srcRect of src, scaling with nearest
+// neighbor sampling or tiling independently in each dimension.
+func fillRegion(dst *image.RGBA, dstRect image.Rectangle, src *image.RGBA, srcRect image.Rectangle, tileX, tileY bool) {
+	sw, sh := srcRect.Dx(), srcRect.Dy()
+	dw, dh := dstRect.Dx(), dstRect.Dy()
+	if sw <= 0 || sh <= 0 || dw <= 0 || dh <= 0 {
+		return
+	}
+	for y := 0; y < dh; y++ {
+		sy := y * sh / dh
+		if tileY {
+			sy = y % sh
+		}
+		for x := 0; x < dw; x++ {
+			sx := x * sw / dw
+			if tileX {
+				sx = x % sw
+			}
+			si := src.PixOffset(srcRect.Min.X+sx, srcRect.Min.Y+sy)
+			di := dst.PixOffset(dstRect.Min.X+x, dstRect.Min.Y+y)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/diakovliev/oak/v4/render/mod"
+)
+
+// nineSliceSource returns a 3x3 source where each pixel's red channel marks its
+// region.
+func nineSliceSource() *image.RGBA {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	for x := 0; x < 3; x++ {
+		for y := 0; y < 3; y++ {
+			src.SetRGBA(x, y, color.RGBA{uint8(x*3 + y), 0, 0, 255})
+		}
+	}
+	return src
+}
+
+func TestNineSlice_Stretch(t *testing.T) {
+	ns := NewNineSlice(nineSliceSource(), Insets{1, 1, 1, 1}, 5, 4)
+	w, h := ns.GetDims()
+	if w != 5 || h != 4 {
+		t.Fatalf("expected dims 5,4, got %v,%v", w, h)
+	}
+	rgba := ns.GetRGBA()
+	expected := [][]uint8{
+		{0, 3, 3, 3, 6},
+		{1, 4, 4, 4, 7},
+		{1, 4, 4, 4, 7},
+		{2, 5, 5, 5, 8},
+	}
+	for y, row := range expected {
+		for x, r := range row {
+			if got := rgba.RGBAAt(x, y).R; got != r {
+				t.Fatalf("at %v,%v expected region %v, got %v", x, y, r, got)
+			}
+		}
+	}
+}
+
+func TestNineSlice_Tile(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for x := 0; x < 4; x++ {
+		src.SetRGBA(x, 1, color.RGBA{uint8(x), 0, 0, 255})
+	}
+	ns := NewNineSlice(src, Insets{1, 1, 1, 1}, 7, 3)
+	ns.SetMode(NineSliceTile)
+	rgba := ns.GetRGBA()
+	expected := []uint8{0, 1, 2, 1, 2, 1, 3}
+	for x, r := range expected {
+		if got := rgba.RGBAAt(x, 1).R; got != r {
+			t.Fatalf("at %v expected %v, got %v", x, r, got)
+		}
+	}
+}
+
+func TestNineSlice_ResizeKeepsMods(t *testing.T) {
+	ns := NewNineSlice(nineSliceSource(), Insets{1, 1, 1, 1}, 3, 3)
+	var green mod.Filter = func(rgba *image.RGBA) {
+		for i := 0; i < len(rgba.Pix); i += 4 {
+			rgba.Pix[i+1] = 255
+		}
+	}
+	ns.Filter(green)
+	ns.SetPos(10, 10)
+	ns.Resize(8, 2)
+	w, h := ns.GetDims()
+	if w != 8 || h != 2 {
+		t.Fatalf("expected dims 8,2, got %v,%v", w, h)
+	}
+	if got := ns.GetRGBA().RGBAAt(7, 1); got.G != 255 {
+		t.Fatalf("expected filter to be reapplied after resize, got %v", got)
+	}
+	if ns.X() != 10 || ns.Y() != 10 {
+		t.Fatalf("resize changed position")
+	}
+	cp := ns.Copy().(*NineSlice)
+	cp.Resize(2, 2)
+	if w, _ := ns.GetDims(); w != 8 {
+		t.Fatalf("resizing copy modified original")
+	}
+	var _ Resizable = cp
+}
+
+func TestNineSlice_Small(t *testing.T) {
+	ns := NewNineSlice(nineSliceSource(), Insets{1, 1, 1, 1}, 1, 0)
+	w, h := ns.GetDims()
+	if w != 1 || h != 0 {
+		t.Fatalf("expected dims 1,0, got %v,%v", w, h)
+	}
+}
+
+func TestNineSlice_OversizedInsets(t *testing.T) {
+	ns := NewNineSlice(nineSliceSource(), Insets{4, -1, 2, 5}, 6, 6)
+	if ns.insets != (Insets{1, 0, 1, 2}) {
+		t.Fatalf("expected insets clamped to the source, got %+v", ns.insets)
+	}
+	rgba := ns.GetRGBA()
+	for x := 0; x < 6; x++ {
+		for y := 0; y < 6; y++ {
+			if rgba.RGBAAt(x, y).A == 0 {
+				t.Fatalf("expected every pixel to be drawn, %v,%v was empty", x, y)
+			}
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"image/color"
 
 	"github.com/diakovliev/oak/v4/alg"
+	"github.com/diakovliev/oak/v4/render"
 	"github.com/diakovliev/oak/v4/shape"
 
 	"github.com/diakovliev/oak/v4/alg/span"
@@ -43,6 +44,7 @@ func (cg *ColorGenerator) setDefaults() {
 	cg.Size = span.NewConstant(1)
 	cg.EndSize = span.NewConstant(1)
 	cg.Shape = shape.Square
+	cg.BlendMode = render.BlendReplace
 }
 
 // Generate creates a source using this generator
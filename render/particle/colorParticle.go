@@ -62,7 +62,7 @@ func (cp *ColorParticle) DrawOffsetGen(generator Generator, buff draw.Image, xOf
 	for i := 0; i < size; i++ {
 		for j := 0; j < size; j++ {
 			if gen.Shape.In(i, j, size) {
-				buff.Set(xOffi+i, yOffi+j, c)
+				render.SetBlended(buff, xOffi+i, yOffi+j, c, gen.BlendMode)
 			}
 		}
 	}
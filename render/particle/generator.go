@@ -60,6 +60,11 @@ type BaseGenerator struct {
 	EndFunc       func(Particle)
 	LayerFunc     func(physics.Vector) int
 	ParticleLimit int
+	// BlendMode determines how particles are combined with
+	// what has already been drawn beneath them. Color and gradient
+	// generators default to BlendReplace, setting pixels to the particle's
+	// color as they always have; sprite generators default to BlendOver.
+	BlendMode render.BlendMode
 }
 
 // GetBaseGenerator returns this
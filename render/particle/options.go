@@ -124,3 +124,10 @@ func DrawStack(drawStack *render.DrawStack) func(Generator) {
 		g.GetBaseGenerator().DrawStack = drawStack
 	}
 }
+
+// Blend sets how particles should be blended with what is drawn beneath them
+func Blend(mode render.BlendMode) func(Generator) {
+	return func(g Generator) {
+		g.GetBaseGenerator().BlendMode = mode
+	}
+}
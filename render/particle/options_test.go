@@ -3,6 +3,8 @@ package particle
 import (
 	"math"
 	"testing"
+
+	"github.com/diakovliev/oak/v4/render"
 )
 
 func TestInfiniteLifeSpan(t *testing.T) {
@@ -12,3 +14,23 @@ func TestInfiniteLifeSpan(t *testing.T) {
 		t.Fatalf("Infinite Life Span did not poll math.MaxFloat64")
 	}
 }
+
+func TestBlend(t *testing.T) {
+	g := &ColorGenerator{}
+	Blend(render.BlendAdd)(g)
+	if g.BlendMode != render.BlendAdd {
+		t.Fatalf("Blend did not set generator blend mode")
+	}
+}
+
+func TestBlend_Defaults(t *testing.T) {
+	if g := NewColorGenerator().GetBaseGenerator(); g.BlendMode != render.BlendReplace {
+		t.Fatalf("expected color generators to replace pixels, got %v", g.BlendMode)
+	}
+	if g := NewGradientGenerator().GetBaseGenerator(); g.BlendMode != render.BlendReplace {
+		t.Fatalf("expected gradient generators to replace pixels, got %v", g.BlendMode)
+	}
+	if g := NewSpriteGenerator().GetBaseGenerator(); g.BlendMode != render.BlendOver {
+		t.Fatalf("expected sprite generators to draw over pixels, got %v", g.BlendMode)
+	}
+}
@@ -23,5 +23,5 @@ func (sp *SpriteParticle) DrawOffsetGen(generator Generator, buff draw.Image, xO
 	sp.rotation += sp.rotation
 	gen := generator.(*SpriteGenerator)
 	rgba := gen.Base.Copy().Modify(mod.Rotate(sp.rotation)).GetRGBA()
-	render.DrawImage(buff, rgba, int(sp.X()+xOff), int(sp.Y()+yOff))
+	render.DrawImageBlend(buff, rgba, int(sp.X()+xOff), int(sp.Y()+yOff), gen.BlendMode)
 }
@@ -0,0 +1,472 @@
+package render
+
+import (
+	"image"
+	"image/draw"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/image/math/fixed"
+
+	"github.com/diakovliev/oak/v4/alg"
+	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/oakerr"
+)
+
+// A TextAlign determines how lines of text are positioned horizontally.
+type TextAlign uint8
+
+// TextAlign values
+const (
+	AlignLeft TextAlign = iota
+	AlignCenter
+	AlignRight
+)
+
+// RichTextOptions are optional settings for laying out a RichText.
+type RichTextOptions struct {
+	// Width is the width text will be wrapped to. If zero, lines only break
+	// at explicit line breaks.
+	Width int
+	Align TextAlign
+	// Fonts are the fonts available to [font=name] tags. The font named
+	// BoldFont is used for [b] tags.
+	Fonts map[string]*Font
+	// Images are the renderables available to [img=name] tags.
+	Images map[string]Renderable
+}
+
+// RevealEnd is triggered on a RichText's trigger ID when a typewriter effect
+// started by Typewriter has revealed all of its text.
+var RevealEnd = event.RegisterEvent[struct{}]()
+
+// A RichText is a renderable that draws text with inline styles, images, and
+// line breaks described in a small markup language, wrapped to a width.
+// See NewRichText for the supported markup.
+type RichText struct {
+	LayeredPoint
+	event.CallerID
+
+	opts      RichTextOptions
+	base      *Font
+	fontCache map[richStyle]*Font
+	spans     []richSpan
+
+	layoutLock sync.RWMutex
+	lines      []richLine
+	w, h       int
+	total      int
+
+	// revealed is the number of characters drawn, or -1 for all of them
+	revealed int64
+}
+
+type richLine struct {
+	items  []richItem
+	width  int
+	ascent int
+	height int
+	xOff   int
+}
+
+type richItem struct {
+	x     int
+	w     int
+	text  string
+	font  *Font
+	img   Renderable
+	space bool
+}
+
+// NewRichText creates a RichText from markup, drawn by default in this font.
+//
+// Markup is made of tags in square brackets:
+//
+//	[color=red]...[/color]   changes text color, by SVG 1.1 name or #rgb, #rrggbb, #rrggbbaa
+//	[b]...[/b]               switches to the font named BoldFont
+//	[font=name]...[/font]    switches to a named font from opts.Fonts
+//	[size=18]...[/size]      regenerates the current font at a new size
+//	[img=name]               draws a named renderable from opts.Images inline
+//	[br]                     breaks the line, as does a newline
+//
+// "[[" is drawn as a literal "[". An error is returned if the markup is
+// malformed or refers to unknown fonts or images.
+func (f *Font) NewRichText(markup string, x, y float64, opts RichTextOptions) (*RichText, error) {
+	rt := &RichText{
+		LayeredPoint: NewLayeredPoint(x, y, 0),
+		opts:         opts,
+		base:         f.Copy(),
+		fontCache:    make(map[richStyle]*Font),
+		revealed:     -1,
+	}
+	if err := rt.SetMarkup(markup); err != nil {
+		return nil, err
+	}
+	return rt, nil
+}
+
+// SetMarkup replaces the content of this RichText and lays it out again.
+func (rt *RichText) SetMarkup(markup string) error {
+	spans, err := parseRichText(markup)
+	if err != nil {
+		return err
+	}
+	rt.layoutLock.Lock()
+	defer rt.layoutLock.Unlock()
+	if err := rt.layout(spans); err != nil {
+		return err
+	}
+	rt.spans = spans
+	return nil
+}
+
+// SetWidth changes the width this RichText is wrapped to and lays it out again.
+// If the text cannot be laid out, the width is left unchanged.
+func (rt *RichText) SetWidth(w int) error {
+	rt.layoutLock.Lock()
+	defer rt.layoutLock.Unlock()
+	old := rt.opts.Width
+	rt.opts.Width = w
+	if err := rt.layout(rt.spans); err != nil {
+		rt.opts.Width = old
+		return err
+	}
+	return nil
+}
+
+// SetAlign changes the alignment of this RichText's lines. If the text cannot
+// be laid out, the alignment is left unchanged.
+func (rt *RichText) SetAlign(align TextAlign) error {
+	rt.layoutLock.Lock()
+	defer rt.layoutLock.Unlock()
+	old := rt.opts.Align
+	rt.opts.Align = align
+	if err := rt.layout(rt.spans); err != nil {
+		rt.opts.Align = old
+		return err
+	}
+	return nil
+}
+
+// GetDims returns the width and height of the laid out text. If the text has
+// a wrap width, that is its width.
+func (rt *RichText) GetDims() (int, int) {
+	rt.layoutLock.RLock()
+	defer rt.layoutLock.RUnlock()
+	return rt.w, rt.h
+}
+
+// Len returns the number of characters, counting each inline image as one,
+// that can be revealed.
+func (rt *RichText) Len() int {
+	rt.layoutLock.RLock()
+	defer rt.layoutLock.RUnlock()
+	return rt.total
+}
+
+// SetRevealed limits drawing to the first n characters of this text.
+func (rt *RichText) SetRevealed(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt64(&rt.revealed, int64(n))
+}
+
+// RevealAll removes any limit on how many characters of this text are drawn.
+func (rt *RichText) RevealAll() {
+	atomic.StoreInt64(&rt.revealed, -1)
+}
+
+// Revealed returns how many characters of this text will be drawn.
+func (rt *RichText) Revealed() int {
+	n := atomic.LoadInt64(&rt.revealed)
+	if n < 0 {
+		return rt.Len()
+	}
+	return int(n)
+}
+
+// SetTriggerID sets the ID RevealEnd will be triggered on.
+func (rt *RichText) SetTriggerID(id event.CallerID) {
+	rt.CallerID = id
+}
+
+// Typewriter hides this text, then reveals charsPerSecond characters each
+// second on each event.Enter of the given handler. Once all text is revealed
+// the binding unbinds itself and RevealEnd is triggered on this text's
+// trigger ID, if set. If charsPerSecond is not positive, all text is revealed
+// at once and the binding ends on the next event.Enter.
+func (rt *RichText) Typewriter(h event.Handler, charsPerSecond float64) event.Binding {
+	if charsPerSecond > 0 {
+		rt.SetRevealed(0)
+	} else {
+		rt.RevealAll()
+	}
+	progress := 0.0
+	return event.GlobalBind(h, event.Enter, func(ep event.EnterPayload) event.Response {
+		progress += ep.SinceLastFrame.Seconds() * charsPerSecond
+		if charsPerSecond > 0 && int(progress) < rt.Len() {
+			rt.SetRevealed(int(progress))
+			return event.ResponseNone
+		}
+		rt.RevealAll()
+		if rt.CallerID != 0 {
+			event.TriggerForCallerOn(h, rt.CallerID, RevealEnd, struct{}{})
+		}
+		return event.ResponseUnbindThisBinding
+	})
+}
+
+// Draw draws the revealed portion of this text at +xOff, +yOff
+func (rt *RichText) Draw(buff draw.Image, xOff, yOff float64) {
+	rt.layoutLock.RLock()
+	defer rt.layoutLock.RUnlock()
+	limit := int(atomic.LoadInt64(&rt.revealed))
+	x0 := int(rt.X() + xOff)
+	y := int(rt.Y() + yOff)
+	count := 0
+	for _, ln := range rt.lines {
+		for _, it := range ln.items {
+			if limit >= 0 && count >= limit {
+				return
+			}
+			x := x0 + ln.xOff + it.x
+			if it.img != nil {
+				_, h := it.img.GetDims()
+				it.img.Draw(buff, float64(x)-it.img.X(), float64(y+ln.ascent-h)-it.img.Y())
+				count++
+				continue
+			}
+			txt := it.text
+			n := utf8.RuneCountInString(txt)
+			if limit >= 0 && count+n > limit {
+				txt = string([]rune(txt)[:limit-count])
+			}
+			count += n
+			if it.space {
+				continue
+			}
+			it.font.Drawer.Dst = buff
+			it.font.Drawer.Dot = fixed.P(x, y+ln.ascent)
+			it.font.drawString(txt)
+		}
+		y += ln.height
+	}
+}
+
+// fontFor returns the font a style is drawn with.
+func (rt *RichText) fontFor(st richStyle) (*Font, error) {
+	if f, ok := rt.fontCache[st]; ok {
+		return f, nil
+	}
+	f := rt.base
+	if st.font != "" {
+		var ok bool
+		f, ok = rt.opts.Fonts[st.font]
+		if !ok {
+			return nil, oakerr.NotFound{InputName: st.font}
+		}
+	}
+	var err error
+	if st.size != 0 && st.size != f.Height() && f.bitmap == nil {
+		f, err = f.RegenerateWith(func(fg FontGenerator) FontGenerator {
+			fg.Size = st.size
+			return fg
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if st.color != nil {
+		colored := f.Copy()
+		if colored == f {
+			// Unsafe fonts do not copy, so must be regenerated
+			colored, err = f.RegenerateWith(func(fg FontGenerator) FontGenerator {
+				fg.Color = image.NewUniform(st.color)
+				return fg
+			})
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			colored.Drawer.Src = image.NewUniform(st.color)
+		}
+		f = colored
+	} else if f != rt.base {
+		f = f.Copy()
+	}
+	rt.fontCache[st] = f
+	return f, nil
+}
+
+// splitWords splits s into alternating runs of whitespace and non-whitespace.
+func splitWords(s string) []string {
+	var words []string
+	start := 0
+	prevSpace := false
+	for i, r := range s {
+		space := unicode.IsSpace(r)
+		if i != 0 && space != prevSpace {
+			words = append(words, s[start:i])
+			start = i
+		}
+		prevSpace = space
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// layout lays out spans with the current options. The layout lock must be held.
+func (rt *RichText) layout(spans []richSpan) error {
+	width := rt.opts.Width
+	var lines []richLine
+	cur := richLine{}
+	var descent int
+	wrapped := false
+	endLine := func(wrap bool) {
+		// Trailing whitespace does not count toward alignment
+		for len(cur.items) > 0 && cur.items[len(cur.items)-1].space {
+			last := cur.items[len(cur.items)-1]
+			cur.width -= last.w
+			cur.items = cur.items[:len(cur.items)-1]
+		}
+		if cur.ascent == 0 && descent == 0 {
+			cur.ascent = alg.RoundF64(rt.base.baseline())
+			descent = alg.RoundF64(rt.base.Height()) - cur.ascent
+		}
+		cur.height = cur.ascent + descent
+		lines = append(lines, cur)
+		cur = richLine{}
+		descent = 0
+		wrapped = wrap
+	}
+	place := func(it richItem, ascent, height int) {
+		it.x = cur.width
+		cur.width += it.w
+		cur.items = append(cur.items, it)
+		if ascent > cur.ascent {
+			cur.ascent = ascent
+		}
+		if height-ascent > descent {
+			descent = height - ascent
+		}
+	}
+	for _, sp := range spans {
+		if sp.lineBreak {
+			endLine(false)
+			continue
+		}
+		if sp.img != "" {
+			img, ok := rt.opts.Images[sp.img]
+			if !ok {
+				return oakerr.NotFound{InputName: sp.img}
+			}
+			w, h := img.GetDims()
+			if width > 0 && len(cur.items) > 0 && cur.width+w > width {
+				endLine(true)
+			}
+			place(richItem{img: img, w: w}, h, h)
+			continue
+		}
+		f, err := rt.fontFor(sp.style)
+		if err != nil {
+			return err
+		}
+		ascent := alg.RoundF64(f.baseline())
+		height := alg.RoundF64(f.Height())
+		if height < ascent {
+			height = ascent
+		}
+		measure := func(s string) int {
+			return f.MeasureString(s).Round()
+		}
+		for _, word := range splitWords(sp.text) {
+			r, _ := utf8.DecodeRuneInString(word)
+			space := unicode.IsSpace(r)
+			if space && wrapped && len(cur.items) == 0 {
+				// Whitespace a line was wrapped at is not drawn
+				continue
+			}
+			w := measure(word)
+			if width > 0 && cur.width+w > width {
+				if space {
+					endLine(true)
+					continue
+				}
+				if len(cur.items) > 0 {
+					endLine(true)
+				}
+				// Words longer than a line are broken between characters
+				for w > width && utf8.RuneCountInString(word) > 1 {
+					runes := []rune(word)
+					n := 1
+					for n < len(runes)-1 && measure(string(runes[:n+1])) <= width {
+						n++
+					}
+					part := string(runes[:n])
+					place(richItem{text: part, font: f, w: measure(part)}, ascent, height)
+					endLine(true)
+					word = string(runes[n:])
+					w = measure(word)
+				}
+			}
+			place(richItem{text: word, font: f, w: w, space: space}, ascent, height)
+		}
+	}
+	endLine(false)
+
+	maxW, total, h := 0, 0, 0
+	for _, ln := range lines {
+		if ln.width > maxW {
+			maxW = ln.width
+		}
+	}
+	boxW := width
+	if boxW <= 0 {
+		boxW = maxW
+	}
+	for i := range lines {
+		switch rt.opts.Align {
+		case AlignCenter:
+			lines[i].xOff = (boxW - lines[i].width) / 2
+		case AlignRight:
+			lines[i].xOff = boxW - lines[i].width
+		}
+		for _, it := range lines[i].items {
+			if it.img != nil {
+				total++
+			} else {
+				total += utf8.RuneCountInString(it.text)
+			}
+		}
+		h += lines[i].height
+	}
+
+	rt.lines = lines
+	rt.w = boxW
+	rt.h = h
+	rt.total = total
+	return nil
+}
+
+// PlainText returns the text of this RichText, without markup, images, or
+// whitespace removed by wrapping. Lines are separated by newlines.
+func (rt *RichText) PlainText() string {
+	rt.layoutLock.RLock()
+	defer rt.layoutLock.RUnlock()
+	sb := strings.Builder{}
+	for i, ln := range rt.lines {
+		if i != 0 {
+			sb.WriteByte('\n')
+		}
+		for _, it := range ln.items {
+			sb.WriteString(it.text)
+		}
+	}
+	return sb.String()
+}
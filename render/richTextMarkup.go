@@ -0,0 +1,142 @@
+package render
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/colornames"
+
+	"github.com/diakovliev/oak/v4/oakerr"
+)
+
+// BoldFont is the name of the font RichText uses for [b] tags.
+const BoldFont = "bold"
+
+type richStyle struct {
+	font  string
+	color color.Color
+	size  float64
+}
+
+type richSpan struct {
+	text      string
+	style     richStyle
+	img       string
+	lineBreak bool
+}
+
+type richTag struct {
+	name  string
+	style richStyle
+}
+
+// parseRichText splits markup into spans of consistently styled text,
+// inline images and line breaks. See NewRichText for the markup format.
+func parseRichText(markup string) ([]richSpan, error) {
+	var spans []richSpan
+	stack := []richTag{{}}
+	var text strings.Builder
+	flush := func() {
+		if text.Len() != 0 {
+			spans = append(spans, richSpan{text: text.String(), style: stack[len(stack)-1].style})
+			text.Reset()
+		}
+	}
+	for len(markup) > 0 {
+		switch {
+		case strings.HasPrefix(markup, "[["):
+			text.WriteByte('[')
+			markup = markup[2:]
+			continue
+		case markup[0] == '\n':
+			flush()
+			spans = append(spans, richSpan{lineBreak: true})
+			markup = markup[1:]
+			continue
+		case markup[0] != '[':
+			end := strings.IndexAny(markup, "[\n")
+			if end == -1 {
+				end = len(markup)
+			}
+			text.WriteString(markup[:end])
+			markup = markup[end:]
+			continue
+		}
+		end := strings.IndexByte(markup, ']')
+		if end == -1 {
+			return nil, oakerr.InvalidInput{InputName: "markup"}
+		}
+		tag := markup[1:end]
+		markup = markup[end+1:]
+		flush()
+		if strings.HasPrefix(tag, "/") {
+			name := tag[1:]
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].name == name {
+					stack = stack[:i]
+					break
+				}
+			}
+			continue
+		}
+		name, val := tag, ""
+		if eq := strings.IndexByte(tag, '='); eq != -1 {
+			name, val = tag[:eq], tag[eq+1:]
+		}
+		style := stack[len(stack)-1].style
+		switch name {
+		case "br":
+			spans = append(spans, richSpan{lineBreak: true})
+			continue
+		case "img":
+			spans = append(spans, richSpan{img: val, style: style})
+			continue
+		case "b":
+			style.font = BoldFont
+		case "font":
+			style.font = val
+		case "color":
+			c, err := parseRichColor(val)
+			if err != nil {
+				return nil, err
+			}
+			style.color = c
+		case "size":
+			sz, err := strconv.ParseFloat(val, 64)
+			if err != nil || sz <= 0 {
+				return nil, oakerr.InvalidInput{InputName: "size"}
+			}
+			style.size = sz
+		default:
+			return nil, oakerr.UnsupportedFormat{Format: "[" + tag + "]"}
+		}
+		stack = append(stack, richTag{name: name, style: style})
+	}
+	flush()
+	return spans, nil
+}
+
+func parseRichColor(s string) (color.Color, error) {
+	if c, ok := colornames.Map[strings.ToLower(s)]; ok {
+		return c, nil
+	}
+	if !strings.HasPrefix(s, "#") {
+		return nil, oakerr.InvalidInput{InputName: "color"}
+	}
+	hex := s[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return nil, oakerr.InvalidInput{InputName: "color"}
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, oakerr.InvalidInput{InputName: "color"}
+	}
+	return color.NRGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
@@ -0,0 +1,217 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diakovliev/oak/v4/event"
+)
+
+func TestRichText_Errors(t *testing.T) {
+	fnt := DefaultFont()
+	bad := []string{
+		"[unknown]text",
+		"[color=notacolor]text",
+		"[color=#12345]text",
+		"[size=-1]text",
+		"[font=missing]text",
+		"[img=missing]",
+		"[b]no bold font",
+		"[color=red",
+	}
+	for _, markup := range bad {
+		if _, err := fnt.NewRichText(markup, 0, 0, RichTextOptions{}); err == nil {
+			t.Fatalf("expected error for markup %q", markup)
+		}
+	}
+}
+
+func TestRichText_Markup(t *testing.T) {
+	fnt := DefaultFont()
+	img := NewColorBox(4, 4, color.RGBA{255, 0, 0, 255})
+	rt, err := fnt.NewRichText("a [[b] [color=#0f0]c[/color][img=box]\nd[br][size=20]e[/size]", 0, 0, RichTextOptions{
+		Fonts:  map[string]*Font{BoldFont: fnt},
+		Images: map[string]Renderable{"box": img},
+	})
+	if err != nil {
+		t.Fatalf("failed to create rich text: %v", err)
+	}
+	if txt := rt.PlainText(); txt != "a [b] c\nd\ne" {
+		t.Fatalf("unexpected plain text %q", txt)
+	}
+	// 7 characters, one image, and two more lines of one character
+	if rt.Len() != 10 {
+		t.Fatalf("expected 10 revealable characters, got %v", rt.Len())
+	}
+	_, h := rt.GetDims()
+	if h < int(fnt.Height())*2+20 {
+		t.Fatalf("expected larger text to increase height, got %v", h)
+	}
+}
+
+func TestRichText_Wrap(t *testing.T) {
+	fnt := DefaultFont()
+	width := fnt.MeasureString("hello world").Round()
+	rt, err := fnt.NewRichText("hello world hello world", 0, 0, RichTextOptions{Width: width})
+	if err != nil {
+		t.Fatalf("failed to create rich text: %v", err)
+	}
+	if txt := rt.PlainText(); txt != "hello world\nhello world" {
+		t.Fatalf("unexpected wrapped text %q", txt)
+	}
+	if w, _ := rt.GetDims(); w != width {
+		t.Fatalf("expected width %v, got %v", width, w)
+	}
+
+	if err := rt.SetWidth(fnt.MeasureString("hel").Round()); err != nil {
+		t.Fatalf("failed to set width: %v", err)
+	}
+	for _, ln := range strings.Split(rt.PlainText(), "\n") {
+		if fnt.MeasureString(ln).Round() > fnt.MeasureString("hel").Round() {
+			t.Fatalf("line %q exceeds wrap width", ln)
+		}
+	}
+}
+
+func TestRichText_Align(t *testing.T) {
+	fnt := DefaultFont()
+	rt, err := fnt.NewRichText("a\nwide line", 0, 0, RichTextOptions{Align: AlignRight})
+	if err != nil {
+		t.Fatalf("failed to create rich text: %v", err)
+	}
+	w, _ := rt.GetDims()
+	if w != fnt.MeasureString("wide line").Round() {
+		t.Fatalf("expected width of widest line, got %v", w)
+	}
+	if rt.lines[0].xOff != w-rt.lines[0].width {
+		t.Fatalf("expected right aligned line, got offset %v", rt.lines[0].xOff)
+	}
+	if err := rt.SetAlign(AlignCenter); err != nil {
+		t.Fatalf("failed to set align: %v", err)
+	}
+	if rt.lines[0].xOff != (w-rt.lines[0].width)/2 {
+		t.Fatalf("expected centered line, got offset %v", rt.lines[0].xOff)
+	}
+	if rt.lines[1].xOff != 0 {
+		t.Fatalf("expected widest line to have no offset, got %v", rt.lines[1].xOff)
+	}
+}
+
+func TestRichText_SetterErrors(t *testing.T) {
+	fnt := DefaultFont()
+	images := map[string]Renderable{"dot": NewColorBox(2, 2, color.RGBA{255, 0, 0, 255})}
+	rt, err := fnt.NewRichText("a[img=dot]", 0, 0, RichTextOptions{Images: images})
+	if err != nil {
+		t.Fatalf("failed to create rich text: %v", err)
+	}
+	delete(images, "dot")
+	if err := rt.SetWidth(1); err == nil {
+		t.Fatalf("expected missing image to fail layout")
+	}
+	if rt.opts.Width != 0 {
+		t.Fatalf("expected width to be left unchanged, got %v", rt.opts.Width)
+	}
+	if err := rt.SetAlign(AlignRight); err == nil {
+		t.Fatalf("expected missing image to fail layout")
+	}
+	if rt.opts.Align != AlignLeft {
+		t.Fatalf("expected alignment to be left unchanged, got %v", rt.opts.Align)
+	}
+}
+
+func TestRichText_ConcurrentSetters(t *testing.T) {
+	fnt := DefaultFont()
+	rt, err := fnt.NewRichText("hello world hello world", 0, 0, RichTextOptions{})
+	if err != nil {
+		t.Fatalf("failed to create rich text: %v", err)
+	}
+	buff := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			rt.SetWidth(20 + i)
+			rt.SetAlign(TextAlign(i % 3))
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		rt.Draw(buff, 0, 0)
+		rt.GetDims()
+	}
+	<-done
+}
+
+func TestRichText_Reveal(t *testing.T) {
+	fnt := DefaultFont()
+	img := NewColorBox(4, 4, color.RGBA{255, 0, 0, 255})
+	rt, err := fnt.NewRichText("ab[img=box]", 0, 0, RichTextOptions{
+		Images: map[string]Renderable{"box": img},
+	})
+	if err != nil {
+		t.Fatalf("failed to create rich text: %v", err)
+	}
+	w, h := rt.GetDims()
+	redAt := func(buff *image.RGBA) bool {
+		return buff.RGBAAt(w-1, h-1) == (color.RGBA{255, 0, 0, 255})
+	}
+	buff := image.NewRGBA(image.Rect(0, 0, w, h))
+	rt.SetRevealed(2)
+	rt.Draw(buff, 0, 0)
+	if redAt(buff) {
+		t.Fatalf("expected image to be hidden")
+	}
+	rt.RevealAll()
+	rt.Draw(buff, 0, 0)
+	if !redAt(buff) {
+		t.Fatalf("expected image to be drawn")
+	}
+	if rt.Revealed() != 3 {
+		t.Fatalf("expected all 3 characters revealed, got %v", rt.Revealed())
+	}
+}
+
+func TestRichText_Typewriter(t *testing.T) {
+	b := event.NewBus(event.NewCallerMap())
+	rt, err := DefaultFont().NewRichText("abcd", 0, 0, RichTextOptions{})
+	if err != nil {
+		t.Fatalf("failed to create rich text: %v", err)
+	}
+	rt.SetTriggerID(b.GetCallerMap().Register(rt))
+	ended := make(chan struct{}, 1)
+	<-event.Bind(b, RevealEnd, rt, func(*RichText, struct{}) event.Response {
+		ended <- struct{}{}
+		return 0
+	}).Bound
+	<-rt.Typewriter(b, 2).Bound
+	if rt.Revealed() != 0 {
+		t.Fatalf("expected text to start hidden")
+	}
+	<-event.TriggerOn(b, event.Enter, event.EnterPayload{SinceLastFrame: time.Second})
+	if rt.Revealed() != 2 {
+		t.Fatalf("expected 2 characters revealed, got %v", rt.Revealed())
+	}
+	<-event.TriggerOn(b, event.Enter, event.EnterPayload{SinceLastFrame: time.Second})
+	select {
+	case <-ended:
+	case <-time.After(time.Second):
+		t.Fatalf("expected reveal end to be triggered")
+	}
+	if rt.Revealed() != 4 {
+		t.Fatalf("expected all characters revealed, got %v", rt.Revealed())
+	}
+
+	// Without a positive speed, everything is revealed at once.
+	<-rt.Typewriter(b, 0).Bound
+	if rt.Revealed() != 4 {
+		t.Fatalf("expected all characters revealed at once, got %v", rt.Revealed())
+	}
+	<-event.TriggerOn(b, event.Enter, event.EnterPayload{SinceLastFrame: time.Second})
+	select {
+	case <-ended:
+	case <-time.After(time.Second):
+		t.Fatalf("expected reveal end to be triggered")
+	}
+}
@@ -14,6 +14,7 @@ import (
 // primitive than animation, but less efficient.
 type Sequence struct {
 	LayeredPoint
+	Blend
 	pauseBool
 	InterruptBool
 	rs         []Modifiable
@@ -96,10 +97,11 @@ func (sq *Sequence) Get(i int) Modifiable {
 	return sq.rs[i]
 }
 
-// Draw draws this sequence at +xOff, +yOff
+// Draw draws this sequence at +xOff, +yOff. If the sequence has a blend mode
+// other than BlendOver, its current frame is drawn with that mode.
 func (sq *Sequence) Draw(buff draw.Image, xOff, yOff float64) {
 	sq.update()
-	sq.rs[sq.sheetPos].Draw(buff, sq.X()+xOff, sq.Y()+yOff)
+	drawBlended(buff, sq.rs[sq.sheetPos], sq.X()+xOff, sq.Y()+yOff, sq.mode)
 }
 
 // GetRGBA returns the RGBA of the currently showing frame of this sequence
@@ -11,6 +11,7 @@ import (
 // A Sprite is a basic wrapper around image data and a point. The most basic Renderable.
 type Sprite struct {
 	LayeredPoint
+	Blend
 	r *image.RGBA
 }
 
@@ -70,9 +71,9 @@ func (s *Sprite) Set(x, y int, c color.Color) {
 	s.r.Set(x, y, c)
 }
 
-// Draw draws this sprite at +xOff, +yOff
+// Draw draws this sprite at +xOff, +yOff, with this sprite's blend mode
 func (s *Sprite) Draw(buff draw.Image, xOff, yOff float64) {
-	DrawImage(buff, s.r, int(s.X()+xOff), int(s.Y()+yOff))
+	DrawImageBlend(buff, s.r, int(s.X()+xOff), int(s.Y()+yOff), s.mode)
 }
 
 // Copy returns a copy of this Sprite
@@ -82,6 +83,7 @@ func (s *Sprite) Copy() Modifiable {
 		newS.r = rgbaCopy(s.r)
 	}
 	newS.LayeredPoint = s.LayeredPoint.Copy()
+	newS.Blend = s.Blend
 	return newS
 }
 
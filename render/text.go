@@ -69,11 +69,7 @@ func (f *Font) NewStrPtrText(str *string, x, y float64) *Text {
 
 func (t *Text) drawWithFont(buff draw.Image, xOff, yOff float64, fnt *Font) {
 	fnt.Drawer.Dst = buff
-	sz := 12.0
-	if t.d.gen.Size != 0 {
-		sz = t.d.gen.Size
-	}
-	fnt.Drawer.Dot = fixed.P(int(t.X()+xOff), int(t.Y()+yOff)+int(sz))
+	fnt.Drawer.Dot = fixed.P(int(t.X()+xOff), int(t.Y()+yOff)+int(t.d.baseline()))
 	fnt.drawString(t.text.String())
 }
 
@@ -110,6 +110,9 @@ func (w *Window) sceneLoop(first string, trackingInputs bool) {
 		// be triggered and attempt to access an entity
 		w.CollisionTree.Clear()
 		w.MouseTree.Clear()
+		w.hoverMutex.Lock()
+		w.hovered = nil
+		w.hoverMutex.Unlock()
 		w.CallerMap.Clear()
 		w.eventHandler.SetCallerMap(w.CallerMap)
 		w.DrawStack.Clear()
@@ -46,8 +46,9 @@ func Main(f func(screen.Screen)) {
 				s.lastSz = e
 				s.Deque.Send(e)
 			case touch.Event:
-				// TODO: expose touch events in a way an oak program can
-				// differentiate them from clicks
+				// Touch events are sent as they are for gesture recognition,
+				// and emulated as left mouse button events.
+				s.Deque.Send(e)
 				switch e.Type {
 				case touch.TypeBegin:
 					s.Deque.Send(mouse.Event{
@@ -12,6 +12,7 @@ import (
 	"github.com/diakovliev/oak/v4/shiny/screen"
 	"golang.org/x/mobile/event/key"
 	"golang.org/x/mobile/event/mouse"
+	"golang.org/x/mobile/event/touch"
 )
 
 func Main(f func(screen.Screen)) {
@@ -62,6 +63,20 @@ func (s *screenImpl) NewWindow(opts screen.WindowGenerator) (screen.Window, erro
 		w.sendMouseEvent(args[0], mouse.DirRelease)
 		return nil
 	}))
+	cvs.canvas.Call("addEventListener", "touchstart", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		w.sendTouchEvents(args[0], touch.TypeBegin)
+		return nil
+	}))
+	cvs.canvas.Call("addEventListener", "touchmove", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		w.sendTouchEvents(args[0], touch.TypeMove)
+		return nil
+	}))
+	for _, name := range []string{"touchend", "touchcancel"} {
+		cvs.canvas.Call("addEventListener", name, js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			w.sendTouchEvents(args[0], touch.TypeEnd)
+			return nil
+		}))
+	}
 	cvs.doc.Call("addEventListener", "keydown", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		w.sendKeyEvent(args[0], key.DirPress)
 		return nil
@@ -12,6 +12,7 @@ import (
 	"github.com/diakovliev/oak/v4/shiny/screen"
 	"golang.org/x/mobile/event/key"
 	"golang.org/x/mobile/event/mouse"
+	"golang.org/x/mobile/event/touch"
 )
 
 type Window struct {
@@ -31,6 +32,12 @@ func (w *Window) Upload(dp image.Point, src screen.Image, sr image.Rectangle) {}
 
 func (w *Window) Publish() {}
 
+// HideCursor hides the cursor while it is over the canvas.
+func (w *Window) HideCursor() error {
+	w.cvs.canvas.Get("style").Set("cursor", "none")
+	return nil
+}
+
 func (w *Window) sendMouseEvent(mouseEvent js.Value, dir mouse.Direction) {
 	x, y := mouseEvent.Get("offsetX"), mouseEvent.Get("offsetY")
 	button := mouseEvent.Get("button")
@@ -53,6 +60,21 @@ func (w *Window) sendMouseEvent(mouseEvent js.Value, dir mouse.Direction) {
 	})
 }
 
+func (w *Window) sendTouchEvents(touchEvent js.Value, typ touch.Type) {
+	rect := w.cvs.canvas.Call("getBoundingClientRect")
+	left, top := rect.Get("left").Float(), rect.Get("top").Float()
+	touches := touchEvent.Get("changedTouches")
+	for i := 0; i < touches.Length(); i++ {
+		t := touches.Index(i)
+		w.Send(touch.Event{
+			X:        float32(t.Get("clientX").Float() - left),
+			Y:        float32(t.Get("clientY").Float() - top),
+			Sequence: touch.Sequence(t.Get("identifier").Int()),
+			Type:     typ,
+		})
+	}
+}
+
 func (w *Window) sendKeyEvent(keyEvent js.Value, dir key.Direction) {
 	var mods key.Modifiers
 	if keyEvent.Get("shiftKey").Bool() {
@@ -7,6 +7,7 @@ package x11driver
 // TODO: implement a back buffer.
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
@@ -247,6 +248,58 @@ func (w *Window) HideCursor() error {
 	return nil
 }
 
+// SetCursor replaces the OS cursor over this window with an image. The hotspot
+// is the point within the image which lies under the mouse.
+func (w *Window) SetCursor(img image.Image, hotspot image.Point) error {
+	bds := img.Bounds()
+	wd, h := bds.Dx(), bds.Dy()
+	if wd <= 0 || h <= 0 {
+		return fmt.Errorf("x11driver: invalid cursor size %v", bds.Size())
+	}
+	// The render extension expects premultiplied b/g/r/a pixels
+	bgra := make([]byte, 0, wd*h*4)
+	for y := bds.Min.Y; y < bds.Max.Y; y++ {
+		for x := bds.Min.X; x < bds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			bgra = append(bgra, byte(b>>8), byte(g>>8), byte(r>>8), byte(a>>8))
+		}
+	}
+
+	px, err := xproto.NewPixmapId(w.s.xc)
+	if err != nil {
+		return err
+	}
+	xproto.CreatePixmap(w.s.xc, textureDepth, px, xproto.Drawable(w.s.window32), uint16(wd), uint16(h))
+	defer xproto.FreePixmap(w.s.xc, px)
+
+	gc, err := xproto.NewGcontextId(w.s.xc)
+	if err != nil {
+		return err
+	}
+	xproto.CreateGC(w.s.xc, gc, xproto.Drawable(px), 0, nil)
+	defer xproto.FreeGC(w.s.xc, gc)
+	xproto.PutImage(w.s.xc, xproto.ImageFormatZPixmap, xproto.Drawable(px), gc,
+		uint16(wd), uint16(h), 0, 0, 0, textureDepth, bgra)
+
+	pic, err := render.NewPictureId(w.s.xc)
+	if err != nil {
+		return err
+	}
+	render.CreatePicture(w.s.xc, pic, xproto.Drawable(px), w.s.pictformat32, 0, nil)
+	defer render.FreePicture(w.s.xc, pic)
+
+	cursorId, err := xproto.NewCursorId(w.s.xc)
+	if err != nil {
+		return err
+	}
+	render.CreateCursor(w.s.xc, cursorId, pic, uint16(hotspot.X-bds.Min.X), uint16(hotspot.Y-bds.Min.Y))
+	xproto.ChangeWindowAttributes(w.s.xc, w.xw, xproto.CwCursor, []uint32{uint32(cursorId)})
+	// As with HideCursor, the window keeps its cursor after we release our
+	// reference to it.
+	xproto.FreeCursor(w.s.xc, cursorId)
+	return nil
+}
+
 func (w *Window) SetIcon(icon image.Image) error {
 	bds := icon.Bounds()
 	wd := bds.Max.X
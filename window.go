@@ -17,6 +17,7 @@ import (
 	"image"
 	"io"
 	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -24,6 +25,7 @@ import (
 	"github.com/diakovliev/oak/v4/collision"
 	"github.com/diakovliev/oak/v4/debugstream"
 	"github.com/diakovliev/oak/v4/event"
+	"github.com/diakovliev/oak/v4/gesture"
 	"github.com/diakovliev/oak/v4/key"
 	"github.com/diakovliev/oak/v4/mouse"
 	"github.com/diakovliev/oak/v4/render"
@@ -158,6 +160,13 @@ type Window struct {
 	// where the mouse event was a press.
 	// If TrackMouseClicks is set to false then this will not be tracked
 	LastMousePress mouse.Event
+	// hovered holds the entities under the mouse as of its last movement,
+	// from the top layer down. It is guarded by hoverMutex, as scene changes
+	// clear it outside of the input loop.
+	hovered    []event.CallerID
+	hoverMutex sync.Mutex
+
+	cursor cursorState
 
 	FirstSceneInput interface{}
 
@@ -176,6 +185,13 @@ type Window struct {
 	UseAspectRatio bool
 
 	inFocus bool
+
+	// gestures recognizes gestures from pointer input when
+	// Config.GestureSupport is set.
+	gestures *gesture.Recognizer
+	// touchInput is set once touch input is received, after which mouse input
+	// is no longer used to recognize gestures.
+	touchInput bool
 }
 
 var (
@@ -213,14 +229,20 @@ func (w *Window) Propagate(ev event.EventID[*mouse.Event], me mouse.Event) {
 	sort.Slice(hits, func(i, j int) bool {
 		return hits[i].Location.Min.Z() > hits[j].Location.Max.Z()
 	})
-	for _, sp := range hits {
+	reached := hits
+	for i, sp := range hits {
 		<-event.TriggerForCallerOn(w.eventHandler, sp.CID, ev, &me)
 		if me.StopPropagation {
+			reached = hits[:i+1]
 			break
 		}
 	}
 	me.StopPropagation = false
 
+	if ev == mouse.DragOn {
+		w.updateHover(reached, me)
+	}
+
 	if ev == mouse.RelativePressOn {
 		w.lastRelativePress = me
 	} else if ev == mouse.PressOn {
@@ -264,6 +286,39 @@ func (w *Window) Propagate(ev event.EventID[*mouse.Event], me mouse.Event) {
 	}
 }
 
+// updateHover triggers HoverExit on entities no longer under the mouse and
+// HoverEnter on entities newly under it. spaces should be sorted from the top
+// layer down. Hover is only updated as the mouse moves, so entities created
+// beneath a still mouse are not hovered until it next moves.
+func (w *Window) updateHover(spaces []*collision.Space, me mouse.Event) {
+	hovered := make([]event.CallerID, 0, len(spaces))
+	isHovered := make(map[event.CallerID]bool, len(spaces))
+	for _, sp := range spaces {
+		if !isHovered[sp.CID] {
+			isHovered[sp.CID] = true
+			hovered = append(hovered, sp.CID)
+		}
+	}
+	w.hoverMutex.Lock()
+	prev := w.hovered
+	w.hovered = hovered
+	w.hoverMutex.Unlock()
+	wasHovered := make(map[event.CallerID]bool, len(prev))
+	for _, cid := range prev {
+		wasHovered[cid] = true
+		if !isHovered[cid] {
+			exit := me
+			<-event.TriggerForCallerOn(w.eventHandler, cid, mouse.HoverExit, &exit)
+		}
+	}
+	for _, cid := range hovered {
+		if !wasHovered[cid] {
+			enter := me
+			<-event.TriggerForCallerOn(w.eventHandler, cid, mouse.HoverEnter, &enter)
+		}
+	}
+}
+
 // Width returns the absolute bounds of a window in pixels. It does not include window elements outside
 // of the client area (OS provided title bars).
 func (w *Window) Bounds() intgeom.Point2 {
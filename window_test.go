@@ -183,3 +183,72 @@ func TestWindowGetters(t *testing.T) {
 		t.Errorf("background was not set to empty renderable")
 	}
 }
+
+func TestPropagate_Hover(t *testing.T) {
+	c1 := NewWindow()
+	c1.eventHandler = event.NewBus(event.NewCallerMap())
+	c1.MouseTree = collision.NewTree()
+
+	e1 := ent{}
+	e1.CallerID = c1.eventHandler.GetCallerMap().Register(e1)
+	e2 := ent{}
+	e2.CallerID = c1.eventHandler.GetCallerMap().Register(e2)
+
+	s1 := collision.NewSpace(10, 10, 10, 10, e1.CallerID)
+	s1.SetZLayer(10)
+	c1.MouseTree.Insert(s1)
+	s2 := collision.NewSpace(0, 0, 40, 40, e2.CallerID)
+	s2.SetZLayer(1)
+	c1.MouseTree.Insert(s2)
+
+	var entered, exited []event.CallerID
+	stop := false
+	for _, e := range []ent{e1, e2} {
+		cid := e.CallerID
+		<-event.Bind(c1.eventHandler, mouse.HoverEnter, e, func(ent, *mouse.Event) event.Response {
+			entered = append(entered, cid)
+			return 0
+		}).Bound
+		<-event.Bind(c1.eventHandler, mouse.HoverExit, e, func(ent, *mouse.Event) event.Response {
+			exited = append(exited, cid)
+			return 0
+		}).Bound
+	}
+	<-event.Bind(c1.eventHandler, mouse.DragOn, e1, func(_ ent, ev *mouse.Event) event.Response {
+		ev.StopPropagation = stop
+		return 0
+	}).Bound
+
+	move := func(x, y float64) {
+		c1.Propagate(mouse.DragOn, mouse.NewEvent(x, y, mouse.ButtonNone, mouse.Drag))
+	}
+	expect := func(step string, enter, exit []event.CallerID) {
+		t.Helper()
+		if len(entered) != len(enter) || len(exited) != len(exit) {
+			t.Fatalf("%v: expected enter %v and exit %v, got %v and %v", step, enter, exit, entered, exited)
+		}
+		for i := range enter {
+			if entered[i] != enter[i] {
+				t.Fatalf("%v: expected enter %v, got %v", step, enter, entered)
+			}
+		}
+		for i := range exit {
+			if exited[i] != exit[i] {
+				t.Fatalf("%v: expected exit %v, got %v", step, exit, exited)
+			}
+		}
+		entered, exited = nil, nil
+	}
+
+	move(5, 5)
+	expect("onto lower entity", []event.CallerID{e2.CallerID}, nil)
+	move(6, 6)
+	expect("within lower entity", nil, nil)
+	move(15, 15)
+	expect("onto upper entity", []event.CallerID{e1.CallerID}, nil)
+	stop = true
+	move(16, 16)
+	expect("stopped propagation", nil, []event.CallerID{e2.CallerID})
+	move(50, 50)
+	expect("off all entities", nil, []event.CallerID{e1.CallerID})
+}